@@ -0,0 +1,552 @@
+package aiassistant
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/willknow-ai/willknow-go/provider"
+)
+
+// Agent-to-agent (A2A) protocol support.
+//
+// The agent card is served publicly at /.well-known/agent-card.json (and the
+// older /.well-known/agent.json). JSON-RPC 2.0 requests are accepted at
+// POST /willknow/a2a with the same authentication as /willknow/chat:
+//   - message/send:   run a task; blocks until done unless configuration.blocking is false
+//   - message/stream: run a task and stream task, status-update and artifact-update events over SSE
+//   - tasks/get:      fetch a task by ID
+//   - tasks/cancel:   cancel a running task
+//
+// An A2A contextId is a /willknow/chat session ID, so both endpoints share conversations.
+
+const (
+	a2aProtocolVersion = "0.2.5"
+	a2aAgentCardPath   = "/.well-known/agent-card.json"
+
+	// maxStoredA2ATasks is how many recent tasks are kept for tasks/get
+	maxStoredA2ATasks = 500
+)
+
+// A2A task states
+const (
+	a2aStateSubmitted = "submitted"
+	a2aStateWorking   = "working"
+	a2aStateCompleted = "completed"
+	a2aStateCanceled  = "canceled"
+	a2aStateFailed    = "failed"
+)
+
+// JSON-RPC and A2A error codes
+const (
+	rpcParseError          = -32700
+	rpcInvalidRequest      = -32600
+	rpcMethodNotFound      = -32601
+	rpcInvalidParams       = -32602
+	rpcInternalError       = -32603
+	a2aTaskNotFound        = -32001
+	a2aTaskNotCancelable   = -32002
+	a2aUnsupportedFeature  = -32004
+	a2aContentTypeNotValid = -32005
+)
+
+// a2aPart is a message or artifact part. Only text parts are supported.
+type a2aPart struct {
+	Kind string `json:"kind"`
+	Text string `json:"text,omitempty"`
+}
+
+// a2aMessage is a single message between the client and the agent
+type a2aMessage struct {
+	Kind      string    `json:"kind"` // "message"
+	Role      string    `json:"role"` // "user" or "agent"
+	MessageID string    `json:"messageId"`
+	Parts     []a2aPart `json:"parts"`
+	ContextID string    `json:"contextId,omitempty"`
+	TaskID    string    `json:"taskId,omitempty"`
+}
+
+// a2aTaskStatus is the current state of a task
+type a2aTaskStatus struct {
+	State     string      `json:"state"`
+	Message   *a2aMessage `json:"message,omitempty"`
+	Timestamp string      `json:"timestamp"`
+}
+
+// a2aArtifact is an output of a task
+type a2aArtifact struct {
+	ArtifactID string    `json:"artifactId"`
+	Name       string    `json:"name,omitempty"`
+	Parts      []a2aPart `json:"parts"`
+}
+
+// a2aTask is the unit of work created for each incoming message
+type a2aTask struct {
+	Kind      string        `json:"kind"` // "task"
+	ID        string        `json:"id"`
+	ContextID string        `json:"contextId"`
+	Status    a2aTaskStatus `json:"status"`
+	Artifacts []a2aArtifact `json:"artifacts,omitempty"`
+	History   []a2aMessage  `json:"history,omitempty"`
+}
+
+// a2aStatusUpdate is streamed when a task's state changes
+type a2aStatusUpdate struct {
+	Kind      string        `json:"kind"` // "status-update"
+	TaskID    string        `json:"taskId"`
+	ContextID string        `json:"contextId"`
+	Status    a2aTaskStatus `json:"status"`
+	Final     bool          `json:"final"`
+}
+
+// a2aArtifactUpdate is streamed when a task produces an artifact
+type a2aArtifactUpdate struct {
+	Kind      string      `json:"kind"` // "artifact-update"
+	TaskID    string      `json:"taskId"`
+	ContextID string      `json:"contextId"`
+	Artifact  a2aArtifact `json:"artifact"`
+	LastChunk bool        `json:"lastChunk"`
+}
+
+// rpcRequest is a JSON-RPC 2.0 request
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      interface{}     `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+// rpcError is a JSON-RPC 2.0 error object
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// rpcResponse is a JSON-RPC 2.0 response
+type rpcResponse struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      interface{} `json:"id"`
+	Result  interface{} `json:"result,omitempty"`
+	Error   *rpcError   `json:"error,omitempty"`
+}
+
+// a2aServer implements the A2A JSON-RPC endpoint on top of the HTTP chat sessions
+type a2aServer struct {
+	a        *Assistant
+	sessions *httpSessionStore
+
+	mu      sync.Mutex
+	tasks   map[string]*a2aTask
+	order   []string          // task IDs, oldest first
+	running map[string]string // contextId -> running task ID
+	// canceled marks tasks whose result must be discarded when processing ends
+	canceled map[string]bool
+}
+
+// newA2AServer creates an A2A server sharing sessions with /willknow/chat
+func newA2AServer(a *Assistant, sessions *httpSessionStore) *a2aServer {
+	return &a2aServer{
+		a:        a,
+		sessions: sessions,
+		tasks:    make(map[string]*a2aTask),
+		running:  make(map[string]string),
+		canceled: make(map[string]bool),
+	}
+}
+
+// handleAgentCard serves the public A2A agent card
+func (s *a2aServer) handleAgentCard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name, desc := agentIdentity(s.a)
+	skills := []map[string]interface{}{}
+	for _, c := range agentCapabilities(s.a) {
+		skills = append(skills, map[string]interface{}{
+			"id":          c.Name,
+			"name":        c.Name,
+			"description": c.Description,
+			"tags":        []string{"api"},
+		})
+	}
+	if len(skills) == 0 {
+		skills = append(skills, map[string]interface{}{
+			"id":          "diagnose",
+			"name":        "Diagnose application issues",
+			"description": "Investigates errors using the application's logs and source code and explains the root cause with file and line references",
+			"tags":        []string{"debugging", "logs", "source-code"},
+			"examples":    []string{"Why did request abc123 fail?", "What causes the 500 errors on /checkout?"},
+		})
+	}
+
+	card := map[string]interface{}{
+		"protocolVersion":    a2aProtocolVersion,
+		"name":               name,
+		"description":        desc,
+		"url":                requestBaseURL(r) + "/willknow/a2a",
+		"preferredTransport": "JSONRPC",
+		"version":            "1.0.0",
+		"capabilities": map[string]interface{}{
+			"streaming":              true,
+			"pushNotifications":      false,
+			"stateTransitionHistory": false,
+		},
+		"defaultInputModes":  []string{"text/plain"},
+		"defaultOutputModes": []string{"text/plain"},
+		"skills":             skills,
+	}
+	if !s.a.authManager.isOpenMode() {
+		card["securitySchemes"] = map[string]interface{}{
+			"bearer": map[string]string{"type": "http", "scheme": "bearer"},
+		}
+		card["security"] = []map[string][]string{{"bearer": {}}}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(card)
+}
+
+// requestBaseURL reconstructs the externally visible base URL of a request
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = strings.TrimSpace(strings.Split(proto, ",")[0])
+	}
+	host := r.Host
+	if fwd := r.Header.Get("X-Forwarded-Host"); fwd != "" {
+		host = strings.TrimSpace(strings.Split(fwd, ",")[0])
+	}
+	return scheme + "://" + host
+}
+
+// handleRPC handles POST /willknow/a2a
+func (s *a2aServer) handleRPC(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req rpcRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeRPC(w, nil, nil, &rpcError{Code: rpcParseError, Message: "Invalid JSON"})
+		return
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		writeRPC(w, req.ID, nil, &rpcError{Code: rpcInvalidRequest, Message: "Invalid JSON-RPC request"})
+		return
+	}
+
+	switch req.Method {
+	case "message/send", "message/stream":
+		var params struct {
+			Message       a2aMessage `json:"message"`
+			Configuration struct {
+				Blocking *bool `json:"blocking"`
+			} `json:"configuration"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			writeRPC(w, req.ID, nil, &rpcError{Code: rpcInvalidParams, Message: "Invalid params: " + err.Error()})
+			return
+		}
+		text, rpcErr := messageText(params.Message)
+		if rpcErr != nil {
+			writeRPC(w, req.ID, nil, rpcErr)
+			return
+		}
+		user, _ := r.Context().Value(userContextKey).(*User)
+		session, task, rpcErr := s.startTask(params.Message, user, r.Header.Get("Authorization"))
+		if rpcErr != nil {
+			writeRPC(w, req.ID, nil, rpcErr)
+			return
+		}
+
+		if req.Method == "message/stream" {
+			s.stream(w, req.ID, session, task, text)
+			return
+		}
+		if params.Configuration.Blocking != nil && !*params.Configuration.Blocking {
+			go s.run(session, task, text, nil)
+			writeRPC(w, req.ID, s.snapshot(task.ID, 0), nil)
+			return
+		}
+		s.run(session, task, text, nil)
+		writeRPC(w, req.ID, s.snapshot(task.ID, 0), nil)
+
+	case "tasks/get":
+		var params struct {
+			ID            string `json:"id"`
+			HistoryLength int    `json:"historyLength"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil || params.ID == "" {
+			writeRPC(w, req.ID, nil, &rpcError{Code: rpcInvalidParams, Message: "id is required"})
+			return
+		}
+		task := s.snapshot(params.ID, params.HistoryLength)
+		if task == nil {
+			writeRPC(w, req.ID, nil, &rpcError{Code: a2aTaskNotFound, Message: "Task not found"})
+			return
+		}
+		writeRPC(w, req.ID, task, nil)
+
+	case "tasks/cancel":
+		var params struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil || params.ID == "" {
+			writeRPC(w, req.ID, nil, &rpcError{Code: rpcInvalidParams, Message: "id is required"})
+			return
+		}
+		task, rpcErr := s.cancel(params.ID)
+		if rpcErr != nil {
+			writeRPC(w, req.ID, nil, rpcErr)
+			return
+		}
+		writeRPC(w, req.ID, task, nil)
+
+	case "tasks/resubscribe", "tasks/pushNotificationConfig/set", "tasks/pushNotificationConfig/get":
+		writeRPC(w, req.ID, nil, &rpcError{Code: a2aUnsupportedFeature, Message: "This operation is not supported"})
+
+	default:
+		writeRPC(w, req.ID, nil, &rpcError{Code: rpcMethodNotFound, Message: "Method not found: " + req.Method})
+	}
+}
+
+// messageText joins the text parts of a user message
+func messageText(msg a2aMessage) (string, *rpcError) {
+	var texts []string
+	for _, part := range msg.Parts {
+		if part.Kind != "text" {
+			return "", &rpcError{Code: a2aContentTypeNotValid, Message: fmt.Sprintf("Unsupported part kind %q; only text parts are accepted", part.Kind)}
+		}
+		texts = append(texts, part.Text)
+	}
+	text := strings.TrimSpace(strings.Join(texts, "\n"))
+	if text == "" {
+		return "", &rpcError{Code: rpcInvalidParams, Message: "message must contain a text part"}
+	}
+	return text, nil
+}
+
+// startTask resolves the session for the message's context and registers a new task.
+// Only one task runs per context at a time, since they share the conversation.
+func (s *a2aServer) startTask(msg a2aMessage, user *User, authHeader string) (*Session, *a2aTask, *rpcError) {
+	var session *Session
+	if msg.ContextID != "" {
+		session = s.sessions.get(msg.ContextID)
+	}
+	if session == nil {
+		sessionID := generateSessionID()
+		logFile, _ := initSessionLog(sessionID, s.a.transcripts)
+		session = &Session{
+			ID:         sessionID,
+			User:       user,
+			messages:   []provider.Message{},
+			logFile:    logFile,
+			authHeader: authHeader,
+		}
+		s.sessions.set(sessionID, session)
+		session.logEvent("session_start", map[string]interface{}{"channel": "a2a"})
+		log.Printf("[A2A Session %s] Created", sessionID)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if running, ok := s.running[session.ID]; ok {
+		return nil, nil, &rpcError{Code: rpcInvalidRequest, Message: fmt.Sprintf("Task %s is still running in this context", running)}
+	}
+
+	msg.Kind = "message"
+	msg.Role = "user"
+	msg.ContextID = session.ID
+	task := &a2aTask{
+		Kind:      "task",
+		ID:        generateSessionID(),
+		ContextID: session.ID,
+		Status:    a2aTaskStatus{State: a2aStateSubmitted, Timestamp: time.Now().UTC().Format(time.RFC3339)},
+	}
+	msg.TaskID = task.ID
+	task.History = []a2aMessage{msg}
+
+	s.tasks[task.ID] = task
+	s.order = append(s.order, task.ID)
+	if len(s.order) > maxStoredA2ATasks {
+		delete(s.tasks, s.order[0])
+		delete(s.canceled, s.order[0])
+		s.order = s.order[1:]
+	}
+	s.running[session.ID] = task.ID
+	return session, task, nil
+}
+
+// run processes a task to completion. emit, if set, receives every event
+// (status and artifact updates) as it happens.
+func (s *a2aServer) run(session *Session, task *a2aTask, text string, emit func(event interface{})) {
+	if emit == nil {
+		emit = func(interface{}) {}
+	}
+	defer func() {
+		s.mu.Lock()
+		delete(s.running, session.ID)
+		s.mu.Unlock()
+	}()
+
+	emit(s.setStatus(task.ID, a2aStateWorking, "", false))
+	session.onToolUse = func(name string, input map[string]interface{}) {
+		emit(s.setStatus(task.ID, a2aStateWorking, toolStatus(s.a, session, name, input), false))
+	}
+	defer func() { session.onToolUse = nil }()
+
+	session.mu.Lock()
+	session.messages = append(session.messages, provider.Message{
+		Role:    "user",
+		Content: []provider.ContentBlock{{Type: "text", Text: text}},
+	})
+	session.mu.Unlock()
+	session.logEvent("user_message", map[string]interface{}{"content": text, "a2a_task_id": task.ID})
+
+	var answer string
+	err := processChatHTTP(s.a, session, &answer)
+
+	s.mu.Lock()
+	canceled := s.canceled[task.ID]
+	s.mu.Unlock()
+	if canceled {
+		log.Printf("[A2A Session %s] Task %s finished after cancellation; result discarded", session.ID, task.ID)
+		return
+	}
+	if err != nil {
+		log.Printf("[A2A Session %s] Task %s failed: %v", session.ID, task.ID, err)
+		emit(s.setStatus(task.ID, a2aStateFailed, fmt.Sprintf("Error: %v", err), true))
+		return
+	}
+
+	artifact := a2aArtifact{
+		ArtifactID: task.ID + "-answer",
+		Name:       "answer",
+		Parts:      []a2aPart{{Kind: "text", Text: answer}},
+	}
+	s.mu.Lock()
+	task.Artifacts = append(task.Artifacts, artifact)
+	s.mu.Unlock()
+	emit(a2aArtifactUpdate{Kind: "artifact-update", TaskID: task.ID, ContextID: task.ContextID, Artifact: artifact, LastChunk: true})
+	emit(s.setStatus(task.ID, a2aStateCompleted, answer, true))
+}
+
+// setStatus updates a task's status and returns the matching status-update event.
+// A non-empty text becomes the agent message attached to the status.
+func (s *a2aServer) setStatus(taskID, state, text string, final bool) a2aStatusUpdate {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	task := s.tasks[taskID]
+	if task != nil && isTerminalA2AState(task.Status.State) {
+		// A canceled task keeps its state while its processing winds down
+		return a2aStatusUpdate{Kind: "status-update", TaskID: taskID, ContextID: task.ContextID, Status: task.Status, Final: true}
+	}
+	status := a2aTaskStatus{State: state, Timestamp: time.Now().UTC().Format(time.RFC3339)}
+	if text != "" {
+		msg := a2aMessage{
+			Kind:      "message",
+			Role:      "agent",
+			MessageID: generateSessionID(),
+			Parts:     []a2aPart{{Kind: "text", Text: text}},
+			TaskID:    taskID,
+		}
+		if task != nil {
+			msg.ContextID = task.ContextID
+		}
+		status.Message = &msg
+		if task != nil && final {
+			task.History = append(task.History, msg)
+		}
+	}
+	update := a2aStatusUpdate{Kind: "status-update", TaskID: taskID, Status: status, Final: final}
+	if task != nil {
+		task.Status = status
+		update.ContextID = task.ContextID
+	}
+	return update
+}
+
+// stream runs a task and writes its events as Server-Sent Events
+func (s *a2aServer) stream(w http.ResponseWriter, id interface{}, session *Session, task *a2aTask, text string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		s.mu.Lock()
+		delete(s.running, session.ID)
+		s.mu.Unlock()
+		writeRPC(w, id, nil, &rpcError{Code: rpcInternalError, Message: "Streaming is not supported by the server"})
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	send := func(event interface{}) {
+		data, err := json.Marshal(rpcResponse{JSONRPC: "2.0", ID: id, Result: event})
+		if err != nil {
+			return
+		}
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		flusher.Flush()
+	}
+	send(s.snapshot(task.ID, 0))
+	s.run(session, task, text, send)
+}
+
+// snapshot returns a copy of a task, with at most historyLength messages of
+// history (0 means all), or nil if the task is unknown
+func (s *a2aServer) snapshot(taskID string, historyLength int) *a2aTask {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	task, ok := s.tasks[taskID]
+	if !ok {
+		return nil
+	}
+	copied := *task
+	copied.Artifacts = append([]a2aArtifact(nil), task.Artifacts...)
+	copied.History = append([]a2aMessage(nil), task.History...)
+	if historyLength > 0 && len(copied.History) > historyLength {
+		copied.History = copied.History[len(copied.History)-historyLength:]
+	}
+	return &copied
+}
+
+// cancel marks a running task as canceled. The model call in progress is not
+// interrupted, but its result is discarded.
+func (s *a2aServer) cancel(taskID string) (*a2aTask, *rpcError) {
+	s.mu.Lock()
+	task, ok := s.tasks[taskID]
+	if !ok {
+		s.mu.Unlock()
+		return nil, &rpcError{Code: a2aTaskNotFound, Message: "Task not found"}
+	}
+	if isTerminalA2AState(task.Status.State) {
+		s.mu.Unlock()
+		return nil, &rpcError{Code: a2aTaskNotCancelable, Message: "Task is already " + task.Status.State}
+	}
+	s.canceled[taskID] = true
+	s.mu.Unlock()
+
+	s.setStatus(taskID, a2aStateCanceled, "", true)
+	return s.snapshot(taskID, 0), nil
+}
+
+// isTerminalA2AState reports whether a task in this state can no longer change
+func isTerminalA2AState(state string) bool {
+	return state == a2aStateCompleted || state == a2aStateCanceled || state == a2aStateFailed
+}
+
+// writeRPC writes a JSON-RPC response with either a result or an error
+func writeRPC(w http.ResponseWriter, id interface{}, result interface{}, rpcErr *rpcError) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rpcResponse{JSONRPC: "2.0", ID: id, Result: result, Error: rpcErr})
+}
@@ -0,0 +1,220 @@
+package aiassistant
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// airGapChecker verifies network destinations against the air-gap allowlist
+type airGapChecker struct {
+	hosts    []string // exact host names, or ".suffix" / "*.suffix" entries
+	networks []*net.IPNet
+	problems []string
+}
+
+// checkAirGapped verifies that every network destination in config is an
+// allowlisted internal host and that no SaaS-only integration is enabled.
+// All violations are reported at once so they can be fixed in one pass.
+func checkAirGapped(config Config) error {
+	c, err := newAirGapChecker(config.AirGappedHosts)
+	if err != nil {
+		return err
+	}
+
+	// The model endpoint: provider defaults are all public
+	if config.BaseURL == "" {
+		c.problems = append(c.problems, "BaseURL must point at an internal model endpoint")
+	} else {
+		c.checkURL("BaseURL", config.BaseURL)
+	}
+
+	// Specs are read from local files only
+	if strings.HasPrefix(config.APISpec, "http://") || strings.HasPrefix(config.APISpec, "https://") {
+		c.problems = append(c.problems, "APISpec must be a local file, not a URL")
+	}
+	for _, t := range config.Targets {
+		if strings.HasPrefix(t.APISpec, "http://") || strings.HasPrefix(t.APISpec, "https://") {
+			c.problems = append(c.problems, fmt.Sprintf("Targets[%s].APISpec must be a local file, not a URL", t.Name))
+		}
+		c.checkURL(fmt.Sprintf("Targets[%s].HostBaseURL", t.Name), t.HostBaseURL)
+	}
+
+	// Source repository
+	if u := config.GitSource.URL; u != "" {
+		if strings.Contains(u, "://") {
+			c.checkURL("GitSource.URL", u)
+		} else if at, colon := strings.Index(u, "@"), strings.Index(u, ":"); at >= 0 && colon > at {
+			c.checkHost("GitSource.URL", u[at+1:colon]) // scp-like git@host:org/repo.git
+		}
+	}
+
+	// Peer agents
+	for _, peer := range config.Peers {
+		c.checkURL("Peers.InfoURL", peer.InfoURL)
+	}
+
+	// Host APIs and observability backends
+	c.checkURL("HostBaseURL", config.HostBaseURL)
+	c.checkURL("RequestCapture.ReplayBaseURL", config.RequestCapture.ReplayBaseURL)
+	c.checkAddr("GRPC.Target", config.GRPC.Target)
+	c.checkURL("GraphQL.Endpoint", config.GraphQL.Endpoint)
+	c.checkURL("Loki.URL", config.Loki.URL)
+	c.checkURL("Elasticsearch.URL", config.Elasticsearch.URL)
+	c.checkURL("Tracing.URL", config.Tracing.URL)
+	c.checkURL("Prometheus.URL", config.Prometheus.URL)
+	c.checkAddr("Redis.Addr", config.Redis.Addr)
+	for _, broker := range config.Kafka.Brokers {
+		c.checkAddr("Kafka.Brokers", broker)
+	}
+
+	// Integrations are allowed only when self-hosted
+	if config.GitHub.Token != "" {
+		c.checkRequiredURL("GitHub.APIURL (GitHub Enterprise)", config.GitHub.APIURL)
+	}
+	if config.GitLab.Token != "" {
+		c.checkRequiredURL("GitLab.APIURL (self-managed GitLab)", config.GitLab.APIURL)
+	}
+	if config.Sentry.AuthToken != "" {
+		c.checkRequiredURL("Sentry.DSN (self-hosted Sentry)", config.Sentry.DSN)
+	}
+	c.checkURL("KnowledgeBase.Confluence.URL", config.KnowledgeBase.Confluence.URL)
+	for _, u := range config.Runbooks.URLs {
+		c.checkURL("Runbooks.URLs", u)
+	}
+	for _, hook := range config.Webhooks {
+		c.checkURL("Webhooks", hook.URL)
+	}
+	for _, hook := range config.OwnerChannels {
+		c.checkURL("OwnerChannels", hook.URL)
+	}
+	c.checkAddr("Digest.SMTPHost", config.Digest.SMTPHost)
+
+	// SaaS-only integrations
+	if config.KnowledgeBase.Notion.Token != "" {
+		c.problems = append(c.problems, "KnowledgeBase.Notion is a SaaS integration")
+	}
+	if config.CloudLogging.Enabled {
+		c.problems = append(c.problems, "CloudLogging is a SaaS integration")
+	}
+	if config.Teams.AppID != "" {
+		c.problems = append(c.problems, "Teams is a SaaS integration")
+	}
+	if config.Alerts.PagerDutyToken != "" || config.Alerts.OpsgenieAPIKey != "" {
+		c.problems = append(c.problems, "Alerts cannot post notes back to PagerDuty/Opsgenie (leave PagerDutyToken and OpsgenieAPIKey empty)")
+	}
+
+	return c.err()
+}
+
+// checkAirGappedURL verifies a destination discovered after startup checks,
+// such as a host base URL taken from an OpenAPI spec's servers list
+func checkAirGappedURL(config Config, name, raw string) error {
+	c, err := newAirGapChecker(config.AirGappedHosts)
+	if err != nil {
+		return err
+	}
+	c.checkURL(name, raw)
+	return c.err()
+}
+
+// newAirGapChecker parses the allowlist
+func newAirGapChecker(allowlist []string) (*airGapChecker, error) {
+	c := &airGapChecker{}
+	for _, entry := range allowlist {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			c.networks = append(c.networks, network)
+			continue
+		}
+		if entry != "" {
+			c.hosts = append(c.hosts, strings.TrimPrefix(entry, "*"))
+		}
+	}
+	if len(c.hosts) == 0 && len(c.networks) == 0 {
+		return nil, fmt.Errorf("AirGapped requires AirGappedHosts (internal host names or CIDRs)")
+	}
+	return c, nil
+}
+
+// err reports every violation found so far
+func (c *airGapChecker) err() error {
+	if len(c.problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("AirGapped: refusing to start:\n  - %s", strings.Join(c.problems, "\n  - "))
+}
+
+// checkRequiredURL checks a URL that must be set because its default is a public service
+func (c *airGapChecker) checkRequiredURL(name, raw string) {
+	if raw == "" {
+		c.problems = append(c.problems, name+" must be set: the default is a public service")
+		return
+	}
+	c.checkURL(name, raw)
+}
+
+// checkURL checks the host of a URL, if set
+func (c *airGapChecker) checkURL(name, raw string) {
+	if raw == "" {
+		return
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Hostname() == "" {
+		c.problems = append(c.problems, fmt.Sprintf("%s: invalid URL %q", name, raw))
+		return
+	}
+	c.checkHost(name, u.Hostname())
+}
+
+// checkAddr checks the host of a host[:port] address, if set
+func (c *airGapChecker) checkAddr(name, addr string) {
+	if addr == "" {
+		return
+	}
+	host := addr
+	if h, _, err := net.SplitHostPort(addr); err == nil {
+		host = h
+	}
+	c.checkHost(name, host)
+}
+
+// checkHost requires the host to be allowlisted and to resolve only to
+// private, loopback or link-local addresses
+func (c *airGapChecker) checkHost(name, host string) {
+	host = strings.ToLower(host)
+
+	var ips []net.IP
+	if ip := net.ParseIP(host); ip != nil {
+		ips = []net.IP{ip}
+	} else {
+		resolved, err := net.LookupIP(host)
+		if err != nil {
+			c.problems = append(c.problems, fmt.Sprintf("%s: cannot resolve %s: %v", name, host, err))
+			return
+		}
+		ips = resolved
+	}
+
+	allowed := false
+	for _, h := range c.hosts {
+		if host == h || strings.HasPrefix(h, ".") && strings.HasSuffix(host, h) {
+			allowed = true
+		}
+	}
+	for _, ip := range ips {
+		if !ip.IsPrivate() && !ip.IsLoopback() && !ip.IsLinkLocalUnicast() {
+			c.problems = append(c.problems, fmt.Sprintf("%s: %s resolves to public address %s", name, host, ip))
+			return
+		}
+		for _, network := range c.networks {
+			if network.Contains(ip) {
+				allowed = true
+			}
+		}
+	}
+	if !allowed {
+		c.problems = append(c.problems, fmt.Sprintf("%s: %s is not in AirGappedHosts", name, host))
+	}
+}
@@ -0,0 +1,376 @@
+package aiassistant
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/willknow-ai/willknow-go/provider"
+)
+
+// maxStoredAlertAnalyses is how many recent alert analyses are kept for the chat UI
+const maxStoredAlertAnalyses = 50
+
+// AlertsConfig configures alert-triggered analysis.
+//
+// When Secret is set, the assistant accepts alert webhooks at:
+//   - POST /willknow/alerts/pagerduty (PagerDuty v3 webhook subscription)
+//   - POST /willknow/alerts/opsgenie  (Opsgenie outgoing webhook integration)
+//
+// Each new alert starts an automated analysis session; the result is posted
+// back to the incident as a note and shown in the chat UI.
+type AlertsConfig struct {
+	// Secret authenticates inbound alert webhooks.
+	// PagerDuty: the webhook subscription's signing secret (X-PagerDuty-Signature).
+	// Opsgenie: sent by the integration as the X-Willknow-Token header.
+	// Default: "" (alert endpoints disabled)
+	Secret string
+
+	// PagerDutyToken is a PagerDuty REST API token used to add notes to incidents.
+	// Optional: results are not posted back when empty.
+	PagerDutyToken string
+
+	// PagerDutyFrom is the email of a valid PagerDuty user, required by the API when adding notes
+	PagerDutyFrom string
+
+	// OpsgenieAPIKey is an Opsgenie API integration key used to add notes to alerts.
+	// Optional: results are not posted back when empty.
+	OpsgenieAPIKey string
+
+	// OpsgenieAPIURL is the Opsgenie API base URL (use https://api.eu.opsgenie.com for EU accounts).
+	// Default: https://api.opsgenie.com
+	OpsgenieAPIURL string
+
+	// LogWindow is how far around the alert time the assistant is asked to look in logs.
+	// Default: 15 minutes
+	LogWindow time.Duration
+}
+
+// alert is a normalized inbound alert
+type alert struct {
+	Source      string // "pagerduty" or "opsgenie"
+	ID          string // incident / alert ID in the source system
+	Title       string
+	Description string
+	Service     string
+	URL         string
+	Time        time.Time
+}
+
+// AlertAnalysis is the result of an automated alert analysis, as shown in the chat UI
+type AlertAnalysis struct {
+	Source    string    `json:"source"`
+	AlertID   string    `json:"alert_id"`
+	Title     string    `json:"title"`
+	URL       string    `json:"url,omitempty"`
+	SessionID string    `json:"session_id"`
+	Analysis  string    `json:"analysis"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// alertHandler receives alert webhooks and runs automated analyses
+type alertHandler struct {
+	a      *Assistant
+	config AlertsConfig
+	client *http.Client
+
+	mu       sync.Mutex
+	analyses []AlertAnalysis // most recent last
+}
+
+// newAlertHandler creates an alert handler with defaults applied
+func newAlertHandler(a *Assistant) *alertHandler {
+	config := a.config.Alerts
+	if config.OpsgenieAPIURL == "" {
+		config.OpsgenieAPIURL = "https://api.opsgenie.com"
+	}
+	if config.LogWindow == 0 {
+		config.LogWindow = 15 * time.Minute
+	}
+	return &alertHandler{
+		a:      a,
+		config: config,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// handlePagerDuty handles POST /willknow/alerts/pagerduty
+func (h *alertHandler) handlePagerDuty(w http.ResponseWriter, r *http.Request) {
+	body, ok := h.readBody(w, r)
+	if !ok {
+		return
+	}
+	if !h.verifyPagerDutySignature(r.Header.Get("X-PagerDuty-Signature"), body) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var payload struct {
+		Event struct {
+			EventType  string    `json:"event_type"`
+			OccurredAt time.Time `json:"occurred_at"`
+			Data       struct {
+				ID      string `json:"id"`
+				Title   string `json:"title"`
+				HTMLURL string `json:"html_url"`
+				Service struct {
+					Summary string `json:"summary"`
+				} `json:"service"`
+			} `json:"data"`
+		} `json:"event"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+
+	// Only new incidents trigger an analysis
+	if payload.Event.EventType != "incident.triggered" {
+		return
+	}
+	go h.analyze(alert{
+		Source:  "pagerduty",
+		ID:      payload.Event.Data.ID,
+		Title:   payload.Event.Data.Title,
+		Service: payload.Event.Data.Service.Summary,
+		URL:     payload.Event.Data.HTMLURL,
+		Time:    payload.Event.OccurredAt,
+	})
+}
+
+// handleOpsgenie handles POST /willknow/alerts/opsgenie
+func (h *alertHandler) handleOpsgenie(w http.ResponseWriter, r *http.Request) {
+	body, ok := h.readBody(w, r)
+	if !ok {
+		return
+	}
+	token := r.Header.Get("X-Willknow-Token")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(h.config.Secret)) != 1 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var payload struct {
+		Action string `json:"action"`
+		Alert  struct {
+			AlertID     string `json:"alertId"`
+			Message     string `json:"message"`
+			Description string `json:"description"`
+			Entity      string `json:"entity"`
+			CreatedAt   int64  `json:"createdAt"` // epoch milliseconds
+		} `json:"alert"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+
+	if payload.Action != "Create" {
+		return
+	}
+	alertTime := time.Now()
+	if payload.Alert.CreatedAt > 0 {
+		alertTime = time.UnixMilli(payload.Alert.CreatedAt)
+	}
+	go h.analyze(alert{
+		Source:      "opsgenie",
+		ID:          payload.Alert.AlertID,
+		Title:       payload.Alert.Message,
+		Description: payload.Alert.Description,
+		Service:     payload.Alert.Entity,
+		Time:        alertTime,
+	})
+}
+
+// handleList serves GET /api/alerts with the recent alert analyses for the chat UI
+func (h *alertHandler) handleList(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	analyses := make([]AlertAnalysis, len(h.analyses))
+	copy(analyses, h.analyses)
+	h.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(analyses)
+}
+
+// readBody reads a POST body, writing an error response on failure
+func (h *alertHandler) readBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return nil, false
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, "Failed to read body", http.StatusBadRequest)
+		return nil, false
+	}
+	return body, true
+}
+
+// verifyPagerDutySignature checks the v1 HMAC signatures PagerDuty sends
+// (the header may list several during secret rotation)
+func (h *alertHandler) verifyPagerDutySignature(header string, body []byte) bool {
+	mac := hmac.New(sha256.New, []byte(h.config.Secret))
+	mac.Write(body)
+	expected := "v1=" + hex.EncodeToString(mac.Sum(nil))
+	for _, sig := range strings.Split(header, ",") {
+		if hmac.Equal([]byte(strings.TrimSpace(sig)), []byte(expected)) {
+			return true
+		}
+	}
+	return false
+}
+
+// analyze runs an automated analysis session for an alert and publishes the result
+func (h *alertHandler) analyze(al alert) {
+	sessionID := generateSessionID()
+	logFile, _ := initSessionLog(sessionID, h.a.transcripts)
+	session := &Session{
+		ID:         sessionID,
+		User:       &User{ID: "alert:" + al.Source, Name: "Alert " + al.ID},
+		messages:   []provider.Message{},
+		logFile:    logFile,
+		unattended: true,
+	}
+	defer func() {
+		if logFile != nil {
+			logFile.Close()
+		}
+	}()
+
+	log.Printf("[Alert %s/%s] Starting analysis in session %s: %s", al.Source, al.ID, sessionID, al.Title)
+	h.a.digest.record(digestEntry{Time: al.Time, Kind: "alert", Title: al.Title})
+	session.logEvent("session_start", map[string]interface{}{
+		"channel":  "alert",
+		"source":   al.Source,
+		"alert_id": al.ID,
+	})
+
+	prompt := h.buildPrompt(al)
+	session.messages = append(session.messages, provider.Message{
+		Role: "user",
+		Content: []provider.ContentBlock{
+			{Type: "text", Text: prompt},
+		},
+	})
+	session.logEvent("user_message", map[string]interface{}{"content": prompt})
+
+	var analysis string
+	if err := processChatHTTP(h.a, session, &analysis); err != nil {
+		log.Printf("[Alert %s/%s] Analysis failed: %v", al.Source, al.ID, err)
+		analysis = fmt.Sprintf("Automated analysis failed: %v", err)
+	}
+
+	h.mu.Lock()
+	h.analyses = append(h.analyses, AlertAnalysis{
+		Source:    al.Source,
+		AlertID:   al.ID,
+		Title:     al.Title,
+		URL:       al.URL,
+		SessionID: sessionID,
+		Analysis:  analysis,
+		CreatedAt: time.Now(),
+	})
+	if len(h.analyses) > maxStoredAlertAnalyses {
+		h.analyses = h.analyses[len(h.analyses)-maxStoredAlertAnalyses:]
+	}
+	h.mu.Unlock()
+
+	var err error
+	switch al.Source {
+	case "pagerduty":
+		err = h.postPagerDutyNote(al.ID, analysis)
+	case "opsgenie":
+		err = h.postOpsgenieNote(al.ID, analysis)
+	}
+	if err != nil {
+		log.Printf("[Alert %s/%s] Failed to post analysis back: %v", al.Source, al.ID, err)
+	}
+	log.Printf("[Alert %s/%s] Analysis complete", al.Source, al.ID)
+}
+
+// buildPrompt creates the initial question of an alert analysis session
+func (h *alertHandler) buildPrompt(al alert) string {
+	from := al.Time.Add(-h.config.LogWindow).UTC().Format(time.RFC3339)
+	to := al.Time.Add(h.config.LogWindow).UTC().Format(time.RFC3339)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "An alert fired and needs an automated root cause analysis.\n\n")
+	fmt.Fprintf(&b, "Alert: %s\n", al.Title)
+	if al.Service != "" {
+		fmt.Fprintf(&b, "Service: %s\n", al.Service)
+	}
+	if al.Description != "" {
+		fmt.Fprintf(&b, "Details: %s\n", al.Description)
+	}
+	fmt.Fprintf(&b, "Fired at: %s\n\n", al.Time.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&b, "Investigate the logs between %s and %s for errors related to this alert, ", from, to)
+	b.WriteString("then find and read the relevant code. Reply with a short report: Summary, Likely Root Cause (with file and line references), Evidence, and Suggested Next Steps.")
+	return b.String()
+}
+
+// postPagerDutyNote adds the analysis as a note on a PagerDuty incident
+func (h *alertHandler) postPagerDutyNote(incidentID, analysis string) error {
+	if h.config.PagerDutyToken == "" {
+		return nil
+	}
+	body, _ := json.Marshal(map[string]interface{}{
+		"note": map[string]string{"content": truncate(analysis, 25000)},
+	})
+	req, err := http.NewRequest(http.MethodPost, "https://api.pagerduty.com/incidents/"+url.PathEscape(incidentID)+"/notes", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/vnd.pagerduty+json;version=2")
+	req.Header.Set("Authorization", "Token token="+h.config.PagerDutyToken)
+	req.Header.Set("From", h.config.PagerDutyFrom)
+	return h.send(req)
+}
+
+// postOpsgenieNote adds the analysis as a note on an Opsgenie alert
+func (h *alertHandler) postOpsgenieNote(alertID, analysis string) error {
+	if h.config.OpsgenieAPIKey == "" {
+		return nil
+	}
+	body, _ := json.Marshal(map[string]string{
+		"user":   "Willknow",
+		"source": "willknow",
+		"note":   truncate(analysis, 25000),
+	})
+	endpoint := strings.TrimRight(h.config.OpsgenieAPIURL, "/") + "/v2/alerts/" + url.PathEscape(alertID) + "/notes?identifierType=id"
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "GenieKey "+h.config.OpsgenieAPIKey)
+	return h.send(req)
+}
+
+// send executes a request and treats non-2xx responses as errors
+func (h *alertHandler) send(req *http.Request) error {
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("status %d: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
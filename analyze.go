@@ -0,0 +1,446 @@
+package aiassistant
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/willknow-ai/willknow-go/provider"
+	"github.com/willknow-ai/willknow-go/tools"
+)
+
+const (
+	// analyzePath is the endpoint of the headless analysis API
+	analyzePath = "/willknow/analyze"
+	// defaultAnalyzeLogWindow is the log window of jobs without start_time
+	defaultAnalyzeLogWindow = time.Hour
+	// defaultMaxConcurrentAnalyses is the number of jobs run at once
+	defaultMaxConcurrentAnalyses = 2
+	// maxStoredAnalyzeJobs is how many recent jobs can be polled
+	maxStoredAnalyzeJobs = 100
+	// callbackAttempts is how often a callback is tried before giving up
+	callbackAttempts = 3
+)
+
+// Analysis job states
+const (
+	analyzeJobQueued    = "queued"
+	analyzeJobRunning   = "running"
+	analyzeJobCompleted = "completed"
+	analyzeJobFailed    = "failed"
+)
+
+// AnalyzeAPIConfig enables POST /willknow/analyze, which lets CI, alerting
+// and ticketing systems run an analysis headlessly: the caller posts a
+// request ID, error text and time window, receives a job ID right away and
+// gets the AnalysisReport POSTed to its callback URL when the job finishes.
+// Jobs can also be polled at GET /willknow/analyze/<job_id>.
+type AnalyzeAPIConfig struct {
+	// Token authenticates callers, sent as "Authorization: Bearer <token>".
+	// Default: "" (endpoint disabled)
+	Token string
+
+	// CallbackSecret, if set, signs each callback body with HMAC-SHA256.
+	// The hex signature is sent in the X-Willknow-Signature header as
+	// "sha256=<hex>", like webhook payloads.
+	CallbackSecret string
+
+	// CallbackHosts limits the hosts callbacks may be sent to: host names or
+	// "*.suffix" patterns. Default: any host
+	CallbackHosts []string
+
+	// LogWindow is how far before end_time logs are searched when a job
+	// sets no start_time
+	// Default: 1 hour
+	LogWindow time.Duration
+
+	// MaxConcurrent limits the jobs analyzed at once; the others wait
+	// Default: 2
+	MaxConcurrent int
+}
+
+// AnalyzeRequest is the JSON body of POST /willknow/analyze. At least one of
+// RequestID, Error and Question is required.
+type AnalyzeRequest struct {
+	RequestID   string            `json:"request_id,omitempty"`
+	Error       string            `json:"error,omitempty"`      // error message or stack trace
+	StartTime   string            `json:"start_time,omitempty"` // RFC3339 or relative (e.g. "2h")
+	EndTime     string            `json:"end_time,omitempty"`   // default: now
+	Question    string            `json:"question,omitempty"`   // extra instructions
+	Target      string            `json:"target,omitempty"`     // see Config.Targets and Config.Environments
+	CallbackURL string            `json:"callback_url,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"` // echoed in the report, e.g. a ticket ID
+}
+
+// AnalysisReport is the structured result of an analysis job, POSTed to the
+// callback URL and returned by GET /willknow/analyze/<job_id>
+type AnalysisReport struct {
+	JobID           string            `json:"job_id"`
+	Status          string            `json:"status"` // queued, running, completed or failed
+	SessionID       string            `json:"session_id,omitempty"`
+	RequestID       string            `json:"request_id,omitempty"`
+	Target          string            `json:"target,omitempty"`
+	Report          string            `json:"report,omitempty"` // the assistant's answer, in Markdown
+	Citations       []Citation        `json:"citations,omitempty"`
+	ReferencedFiles []string          `json:"referenced_files,omitempty"`
+	Error           string            `json:"error,omitempty"`
+	CallbackError   string            `json:"callback_error,omitempty"`
+	Metadata        map[string]string `json:"metadata,omitempty"`
+	CreatedAt       time.Time         `json:"created_at"`
+	CompletedAt     *time.Time        `json:"completed_at,omitempty"`
+	Duration        string            `json:"duration,omitempty"`
+}
+
+// analyzeJob is a submitted analysis with its report
+type analyzeJob struct {
+	request AnalyzeRequest
+	target  *target
+	start   time.Time
+	end     time.Time
+	report  AnalysisReport
+}
+
+// analyzeAPI runs headless analysis jobs and delivers their reports
+type analyzeAPI struct {
+	a      *Assistant
+	config AnalyzeAPIConfig
+	client *http.Client
+	slots  chan struct{} // limits concurrent jobs
+
+	mu   sync.Mutex
+	jobs []*analyzeJob // most recent last
+}
+
+// newAnalyzeAPI creates the analysis API with defaults applied
+func newAnalyzeAPI(a *Assistant) *analyzeAPI {
+	config := a.config.AnalyzeAPI
+	if config.LogWindow <= 0 {
+		config.LogWindow = defaultAnalyzeLogWindow
+	}
+	if config.MaxConcurrent <= 0 {
+		config.MaxConcurrent = defaultMaxConcurrentAnalyses
+	}
+	return &analyzeAPI{
+		a:      a,
+		config: config,
+		client: &http.Client{Timeout: 30 * time.Second},
+		slots:  make(chan struct{}, config.MaxConcurrent),
+	}
+}
+
+// handle serves POST /willknow/analyze and GET /willknow/analyze/<job_id>
+func (api *analyzeAPI) handle(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(api.config.Token)) != 1 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if jobID := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, analyzePath), "/"); jobID != "" {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		report, ok := api.report(jobID)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(report)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req AnalyzeRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	job, err := api.newJob(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	api.mu.Lock()
+	api.jobs = append(api.jobs, job)
+	if len(api.jobs) > maxStoredAnalyzeJobs {
+		api.jobs = api.jobs[len(api.jobs)-maxStoredAnalyzeJobs:]
+	}
+	report := job.report
+	api.mu.Unlock()
+	go api.run(job)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", analyzePath+"/"+job.report.JobID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(report)
+}
+
+// newJob validates a request and creates its queued job
+func (api *analyzeAPI) newJob(req AnalyzeRequest) (*analyzeJob, error) {
+	if strings.TrimSpace(req.RequestID+req.Error+req.Question) == "" {
+		return nil, fmt.Errorf("one of request_id, error and question is required")
+	}
+	t := api.a.findTarget(req.Target)
+	if t == nil {
+		return nil, fmt.Errorf("unknown target %q", req.Target)
+	}
+
+	now := time.Now()
+	start, err := tools.ParseTimeParam(req.StartTime, now)
+	if err != nil {
+		return nil, fmt.Errorf("start_time: %w", err)
+	}
+	end, err := tools.ParseTimeParam(req.EndTime, now)
+	if err != nil {
+		return nil, fmt.Errorf("end_time: %w", err)
+	}
+	if end.IsZero() {
+		end = now
+	}
+	if start.IsZero() {
+		start = end.Add(-api.config.LogWindow)
+	}
+	if !start.Before(end) {
+		return nil, fmt.Errorf("start_time must be before end_time")
+	}
+
+	if req.CallbackURL != "" {
+		if err := api.checkCallbackURL(req.CallbackURL); err != nil {
+			return nil, err
+		}
+	}
+
+	return &analyzeJob{
+		request: req,
+		target:  t,
+		start:   start,
+		end:     end,
+		report: AnalysisReport{
+			JobID:     "job-" + generateSessionID(),
+			Status:    analyzeJobQueued,
+			RequestID: req.RequestID,
+			Target:    t.config.Name,
+			Metadata:  req.Metadata,
+			CreatedAt: now,
+		},
+	}, nil
+}
+
+// checkCallbackURL verifies a callback URL against CallbackHosts and the
+// air-gapped allowlist
+func (api *analyzeAPI) checkCallbackURL(raw string) error {
+	if !strings.HasPrefix(raw, "http://") && !strings.HasPrefix(raw, "https://") {
+		return fmt.Errorf("callback_url must be an http(s) URL")
+	}
+	if len(api.config.CallbackHosts) > 0 {
+		allowed := &guardrails{config: GuardrailsConfig{AllowedHosts: api.config.CallbackHosts}}
+		if host := urlHost(raw); !allowed.hostAllowed(host) {
+			return fmt.Errorf("callbacks to %s are not allowed", host)
+		}
+	}
+	if api.a.config.AirGapped {
+		return checkAirGappedURL(api.a.config, "callback_url", raw)
+	}
+	return nil
+}
+
+// report returns a copy of a job's report
+func (api *analyzeAPI) report(jobID string) (AnalysisReport, bool) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	for _, job := range api.jobs {
+		if job.report.JobID == jobID {
+			return job.report, true
+		}
+	}
+	return AnalysisReport{}, false
+}
+
+// update changes a job's report under the lock and returns a copy
+func (api *analyzeAPI) update(job *analyzeJob, change func(report *AnalysisReport)) AnalysisReport {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	change(&job.report)
+	return job.report
+}
+
+// run analyzes a job in a fresh session, then delivers the report
+func (api *analyzeAPI) run(job *analyzeJob) {
+	api.slots <- struct{}{}
+	defer func() { <-api.slots }()
+
+	sessionID := generateSessionID()
+	logFile, _ := initSessionLog(sessionID, api.a.transcripts)
+	session := &Session{
+		ID:         sessionID,
+		User:       &User{ID: "api:analyze", Name: "Analyze API"},
+		messages:   []provider.Message{},
+		logFile:    logFile,
+		unattended: true,
+		target:     job.target,
+	}
+	defer func() {
+		if logFile != nil {
+			logFile.Close()
+		}
+	}()
+
+	jobID := job.report.JobID
+	api.update(job, func(report *AnalysisReport) {
+		report.Status = analyzeJobRunning
+		report.SessionID = sessionID
+	})
+	log.Printf("[Analyze %s] Starting analysis in session %s", jobID, sessionID)
+	session.logEvent("session_start", map[string]interface{}{
+		"channel":    "analyze_api",
+		"job_id":     jobID,
+		"request_id": job.request.RequestID,
+		"metadata":   job.request.Metadata,
+	})
+
+	prompt := api.buildPrompt(job)
+	session.messages = append(session.messages, provider.Message{
+		Role: "user",
+		Content: []provider.ContentBlock{
+			{Type: "text", Text: prompt},
+		},
+	})
+	session.logEvent("user_message", map[string]interface{}{"content": prompt})
+
+	var answer string
+	err := processChatHTTP(api.a, session, &answer)
+	if err != nil {
+		log.Printf("[Analyze %s] Analysis failed: %v", jobID, err)
+	}
+	citations := session.answerCitations()
+	files := sessionReferencedFiles(session)
+	report := api.update(job, func(report *AnalysisReport) {
+		now := time.Now()
+		report.CompletedAt = &now
+		report.Duration = now.Sub(report.CreatedAt).Round(time.Second).String()
+		if err != nil {
+			report.Status = analyzeJobFailed
+			report.Error = err.Error()
+			return
+		}
+		report.Status = analyzeJobCompleted
+		report.Report = answer
+		report.Citations = citations
+		report.ReferencedFiles = files
+	})
+	api.a.ops.record("info", "analyze_job_completed", map[string]interface{}{
+		"job_id":     jobID,
+		"session_id": sessionID,
+		"status":     report.Status,
+	})
+
+	if job.request.CallbackURL == "" {
+		log.Printf("[Analyze %s] Analysis %s", jobID, report.Status)
+		return
+	}
+	if err := api.deliver(job.request.CallbackURL, report); err != nil {
+		log.Printf("[Analyze %s] Callback to %s failed: %v", jobID, job.request.CallbackURL, err)
+		api.update(job, func(report *AnalysisReport) {
+			report.CallbackError = err.Error()
+		})
+		return
+	}
+	log.Printf("[Analyze %s] Analysis %s, report delivered", jobID, report.Status)
+}
+
+// buildPrompt creates the question of an analysis job
+func (api *analyzeAPI) buildPrompt(job *analyzeJob) string {
+	req := job.request
+	var b strings.Builder
+	b.WriteString("This is an unattended analysis requested through the API; nobody can answer follow-up questions, so complete the investigation with the tools available and reply with the final report.\n\n")
+	if req.RequestID != "" {
+		fmt.Fprintf(&b, "Request ID: %s\n", req.RequestID)
+	}
+	if req.Error != "" {
+		fmt.Fprintf(&b, "Error:\n%s\n", strings.TrimSpace(req.Error))
+	}
+	fmt.Fprintf(&b, "\nInvestigate the logs between %s and %s", job.start.UTC().Format(time.RFC3339), job.end.UTC().Format(time.RFC3339))
+	if req.RequestID != "" {
+		b.WriteString(" for this request")
+	}
+	b.WriteString(", then find and read the relevant code. ")
+	if req.Question != "" {
+		fmt.Fprintf(&b, "%s\n\n", strings.TrimSpace(req.Question))
+	}
+	b.WriteString("Reply with a short report: Summary, Likely Root Cause (with file and line references), Evidence, and Suggested Next Steps.")
+	return b.String()
+}
+
+// deliver POSTs a report to a callback URL, retrying with backoff
+func (api *analyzeAPI) deliver(callbackURL string, report AnalysisReport) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	var lastErr error
+	for attempt := 0; attempt < callbackAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt*attempt) * 5 * time.Second)
+		}
+		req, err := http.NewRequest(http.MethodPost, callbackURL, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "willknow-analyze")
+		if api.config.CallbackSecret != "" {
+			mac := hmac.New(sha256.New, []byte(api.config.CallbackSecret))
+			mac.Write(body)
+			req.Header.Set("X-Willknow-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+		}
+		resp, err := api.client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return nil
+		}
+		lastErr = fmt.Errorf("status %d", resp.StatusCode)
+		// Client errors other than rate limiting will not improve on retry
+		if resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			break
+		}
+	}
+	return lastErr
+}
+
+// sessionReferencedFiles returns the files read by the session's tool calls
+func sessionReferencedFiles(session *Session) []string {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	var files []string
+	seen := make(map[string]bool)
+	for _, msg := range session.messages {
+		for _, block := range msg.Content {
+			if block.Type != "tool_use" {
+				continue
+			}
+			if path, ok := block.Input["file_path"].(string); ok && !seen[path] {
+				seen[path] = true
+				files = append(files, path)
+			}
+		}
+	}
+	return files
+}
@@ -0,0 +1,464 @@
+package aiassistant
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/willknow-ai/willknow-go/provider"
+)
+
+const (
+	// maxStoredAnomalies is how many recent anomaly reports are kept for the chat UI
+	maxStoredAnomalies = 50
+	// maxTrackedSignatures bounds the error signatures kept in the baseline
+	maxTrackedSignatures = 2000
+	// maxAnomalyReadBytes limits how much new log data is read per file and window
+	maxAnomalyReadBytes = 10 << 20
+	// anomalySamples is the number of example lines kept per signature
+	anomalySamples = 3
+	// baselineAlpha is the weight of the newest window in the moving baseline
+	baselineAlpha = 0.1
+)
+
+// AnomalyConfig configures log anomaly detection.
+//
+// The detector tails the configured log files (Config.LogFiles and each
+// target's LogFiles) and keeps a moving statistical baseline of log volume,
+// error volume and the frequency of each error signature (the error line with
+// numbers and IDs removed). When a window deviates from the baseline, the
+// assistant investigates it, and the explanation is shown in the chat UI, sent
+// to webhooks subscribed to anomaly_detected and included in the digest.
+// Remote log sources (Loki, Elasticsearch, ...) and files restricted with
+// Config.LogFileRoles are not monitored, since findings are shown to every
+// user.
+type AnomalyConfig struct {
+	// Enabled turns on anomaly detection
+	Enabled bool
+
+	// Interval is the length of each observation window
+	// Default: 5 minutes
+	Interval time.Duration
+
+	// Threshold is how many standard deviations above (or, for log volume,
+	// below) the baseline a window must be to count as an anomaly
+	// Default: 3
+	Threshold float64
+
+	// MinErrors is the minimum number of errors in a window before an error
+	// spike or a new error signature is reported
+	// Default: 5
+	MinErrors int
+
+	// Warmup is the number of windows observed before anomalies are reported
+	// Default: 12 (one hour at the default interval)
+	Warmup int
+
+	// Cooldown suppresses repeated reports of the same anomaly
+	// Default: 1 hour
+	Cooldown time.Duration
+}
+
+// AnomalyReport is a detected anomaly with its explanation, as shown in the chat UI
+type AnomalyReport struct {
+	Target      string    `json:"target"`
+	Findings    []string  `json:"findings"`
+	SessionID   string    `json:"session_id"`
+	Explanation string    `json:"explanation"`
+	WindowStart time.Time `json:"window_start"`
+	WindowEnd   time.Time `json:"window_end"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// baseline is an exponentially weighted mean and variance
+type baseline struct {
+	mean, variance float64
+	samples        int
+	lastSeen       int // window number the value was last non-zero
+}
+
+// update adds an observation to the baseline
+func (b *baseline) update(v float64) {
+	b.samples++
+	if b.samples == 1 {
+		b.mean = v
+		return
+	}
+	diff := v - b.mean
+	incr := baselineAlpha * diff
+	b.mean += incr
+	b.variance = (1 - baselineAlpha) * (b.variance + diff*incr)
+}
+
+// deviation returns how many standard deviations v is from the mean. The
+// deviation is floored at the Poisson noise of the mean (and at 1) so that
+// quiet, steady logs do not turn every small change into an anomaly.
+func (b *baseline) deviation(v float64) float64 {
+	std := math.Max(math.Sqrt(b.variance), math.Max(math.Sqrt(b.mean), 1))
+	return (v - b.mean) / std
+}
+
+// logWindow is what was observed in one interval
+type logWindow struct {
+	lines      int
+	errors     int
+	signatures map[string]int
+	samples    map[string][]string
+}
+
+// anomalyMonitor watches the log files of one target
+type anomalyMonitor struct {
+	target  *target
+	files   []string // the target's log files not restricted to roles
+	offsets map[string]int64
+
+	windows    int
+	volume     baseline
+	errors     baseline
+	signatures map[string]*baseline
+	reported   map[string]time.Time // finding key -> last report
+}
+
+// anomalyDetector runs a monitor per target and keeps recent reports
+type anomalyDetector struct {
+	a        *Assistant
+	config   AnomalyConfig
+	monitors []*anomalyMonitor
+
+	mu      sync.Mutex
+	reports []AnomalyReport
+}
+
+// errorLine matches log lines that report an error
+var errorLine = regexp.MustCompile(`(?i)\b(error|err|fatal|panic|exception|critical)\b`)
+
+// leadingTimestamp matches a timestamp at the start of a log line
+var leadingTimestamp = regexp.MustCompile(`^[\s\[]*\d{4}[-/]\d{2}[-/]\d{2}[T ]?[\d:.,]*(Z|[+-]\d{2}:?\d{2})?\]?\s*`)
+
+// newAnomalyDetector creates a detector with defaults applied, or nil if
+// detection is disabled or there are no log files to watch
+func newAnomalyDetector(a *Assistant, config AnomalyConfig) *anomalyDetector {
+	if !config.Enabled {
+		return nil
+	}
+	if config.Interval <= 0 {
+		config.Interval = 5 * time.Minute
+	}
+	if config.Threshold <= 0 {
+		config.Threshold = 3
+	}
+	if config.MinErrors <= 0 {
+		config.MinErrors = 5
+	}
+	if config.Warmup <= 0 {
+		config.Warmup = 12
+	}
+	if config.Cooldown <= 0 {
+		config.Cooldown = time.Hour
+	}
+
+	d := &anomalyDetector{a: a, config: config}
+	for _, t := range a.targets {
+		m := &anomalyMonitor{
+			target:     t,
+			offsets:    make(map[string]int64),
+			signatures: make(map[string]*baseline),
+			reported:   make(map[string]time.Time),
+		}
+		for _, f := range t.config.LogFiles {
+			if len(a.config.LogFileRoles[f]) > 0 {
+				continue
+			}
+			m.files = append(m.files, f)
+			// Only count lines written from now on
+			if info, err := os.Stat(f); err == nil {
+				m.offsets[f] = info.Size()
+			}
+		}
+		if len(m.files) > 0 {
+			d.monitors = append(d.monitors, m)
+		}
+	}
+	if len(d.monitors) == 0 {
+		log.Println("[AI Assistant] Warning: anomaly detection needs local log files; disabled")
+		return nil
+	}
+	return d
+}
+
+// run observes a window per interval; it never returns
+func (d *anomalyDetector) run() {
+	ticker := time.NewTicker(d.config.Interval)
+	defer ticker.Stop()
+	start := time.Now()
+	for end := range ticker.C {
+		for _, m := range d.monitors {
+			findings, window := m.observe(d.config)
+			if len(findings) > 0 {
+				go d.explain(m.target, findings, window, start, end)
+			}
+		}
+		start = end
+	}
+}
+
+// observe reads the new log lines, compares them to the baseline, updates
+// the baseline and returns the findings worth reporting
+func (m *anomalyMonitor) observe(config AnomalyConfig) ([]string, logWindow) {
+	w := logWindow{signatures: make(map[string]int), samples: make(map[string][]string)}
+	for _, f := range m.files {
+		m.read(f, &w)
+	}
+	m.windows++
+
+	var findings []string
+	now := time.Now()
+	for key, last := range m.reported {
+		if now.Sub(last) >= config.Cooldown {
+			delete(m.reported, key)
+		}
+	}
+	report := func(key, finding string) {
+		if _, ok := m.reported[key]; ok {
+			return
+		}
+		m.reported[key] = now
+		findings = append(findings, finding)
+	}
+
+	if m.windows > config.Warmup {
+		lines, errors := float64(w.lines), float64(w.errors)
+		if dev := m.volume.deviation(lines); dev >= config.Threshold {
+			report("volume-spike", fmt.Sprintf("Log volume spike: %d lines (baseline %.0f, %.1fσ above)", w.lines, m.volume.mean, dev))
+		} else if dev <= -config.Threshold && lines < m.volume.mean/2 {
+			report("volume-drop", fmt.Sprintf("Log volume drop: %d lines (baseline %.0f, %.1fσ below); the service may be down or stuck", w.lines, m.volume.mean, -dev))
+		}
+		if w.errors >= config.MinErrors {
+			if dev := m.errors.deviation(errors); dev >= config.Threshold {
+				report("error-spike", fmt.Sprintf("Error spike: %d error lines (baseline %.1f, %.1fσ above)", w.errors, m.errors.mean, dev))
+			}
+		}
+		for sig, n := range w.signatures {
+			if n < config.MinErrors {
+				continue
+			}
+			b, known := m.signatures[sig]
+			switch {
+			case !known:
+				report("new:"+sig, fmt.Sprintf("New error signature (%d times): %s", n, w.samples[sig][0]))
+			case b.deviation(float64(n)) >= config.Threshold:
+				report("spike:"+sig, fmt.Sprintf("Error signature spike (%d times, baseline %.1f): %s", n, b.mean, w.samples[sig][0]))
+			}
+		}
+	}
+
+	// Update the baseline, including signatures absent from this window
+	m.volume.update(float64(w.lines))
+	m.errors.update(float64(w.errors))
+	for sig, b := range m.signatures {
+		if w.signatures[sig] == 0 {
+			b.update(0)
+		}
+	}
+	for sig, n := range w.signatures {
+		b, ok := m.signatures[sig]
+		if !ok {
+			b = &baseline{}
+			m.signatures[sig] = b
+		}
+		b.update(float64(n))
+		b.lastSeen = m.windows
+	}
+	m.prune()
+	return findings, w
+}
+
+// read adds the lines appended to a file since the last window
+func (m *anomalyMonitor) read(path string, w *logWindow) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return
+	}
+
+	offset := m.offsets[path]
+	if info.Size() < offset {
+		// Truncated or rotated: start over
+		offset = 0
+	}
+	if info.Size()-offset > maxAnomalyReadBytes {
+		offset = info.Size() - maxAnomalyReadBytes
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(f, info.Size()-offset))
+	if err != nil {
+		return
+	}
+	// Leave a trailing partial line for the next window
+	if i := strings.LastIndexByte(string(data), '\n'); i >= 0 {
+		data = data[:i+1]
+	} else {
+		data = nil
+	}
+	m.offsets[path] = offset + int64(len(data))
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		w.lines++
+		if !errorLine.MatchString(line) {
+			continue
+		}
+		w.errors++
+		sig := errorSignature(line)
+		w.signatures[sig]++
+		if len(w.samples[sig]) < anomalySamples {
+			w.samples[sig] = append(w.samples[sig], truncate(strings.TrimSpace(line), 500))
+		}
+	}
+}
+
+// prune drops the signatures not seen for the longest time when the baseline grows too large
+func (m *anomalyMonitor) prune() {
+	if len(m.signatures) <= maxTrackedSignatures {
+		return
+	}
+	sigs := make([]string, 0, len(m.signatures))
+	for sig := range m.signatures {
+		sigs = append(sigs, sig)
+	}
+	sort.Slice(sigs, func(i, j int) bool { return m.signatures[sigs[i]].lastSeen < m.signatures[sigs[j]].lastSeen })
+	for _, sig := range sigs[:len(sigs)-maxTrackedSignatures] {
+		delete(m.signatures, sig)
+	}
+}
+
+// errorSignature normalizes an error line so that occurrences of the same
+// error group together
+func errorSignature(line string) string {
+	sig := leadingTimestamp.ReplaceAllString(line, "")
+	sig = volatileTokens.ReplaceAllString(strings.ToLower(sig), "#")
+	sig = strings.Join(strings.Fields(sig), " ")
+	if len(sig) > 200 {
+		sig = sig[:200]
+	}
+	return sig
+}
+
+// explain runs an automated analysis of the anomalies and publishes the result
+func (d *anomalyDetector) explain(t *target, findings []string, w logWindow, start, end time.Time) {
+	sessionID := generateSessionID()
+	logFile, _ := initSessionLog(sessionID, d.a.transcripts)
+	session := &Session{
+		ID:         sessionID,
+		User:       &User{ID: "anomaly:" + t.config.Name, Name: "Anomaly detector"},
+		messages:   []provider.Message{},
+		logFile:    logFile,
+		target:     t,
+		unattended: true,
+	}
+	defer func() {
+		if logFile != nil {
+			logFile.Close()
+		}
+	}()
+
+	log.Printf("[Anomaly] %d findings for %s, explaining in session %s", len(findings), t.config.Name, sessionID)
+	session.logEvent("session_start", map[string]interface{}{
+		"channel":  "anomaly",
+		"findings": findings,
+	})
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "The log anomaly detector flagged the window %s to %s as unusual compared to the recent baseline:\n\n",
+		start.UTC().Format(time.RFC3339), end.UTC().Format(time.RFC3339))
+	for _, f := range findings {
+		fmt.Fprintf(&b, "- %s\n", f)
+	}
+	fmt.Fprintf(&b, "\nIn that window: %d log lines, %d error lines. Most frequent error samples:\n", w.lines, w.errors)
+	sigs := make([]string, 0, len(w.signatures))
+	for sig := range w.signatures {
+		sigs = append(sigs, sig)
+	}
+	sort.Slice(sigs, func(i, j int) bool { return w.signatures[sigs[i]] > w.signatures[sigs[j]] })
+	for i, sig := range sigs {
+		if i == 5 {
+			break
+		}
+		fmt.Fprintf(&b, "\n(%dx)\n", w.signatures[sig])
+		for _, line := range w.samples[sig] {
+			fmt.Fprintf(&b, "  %s\n", line)
+		}
+	}
+	b.WriteString("\nInvestigate the logs around this window and the relevant code, and explain what is most likely happening. ")
+	b.WriteString("Reply with a short report: Summary, Likely Cause (with file and line references), Impact, and Suggested Next Steps. Say so if it looks like expected behavior (e.g. a deploy or a traffic peak).")
+	prompt := b.String()
+
+	session.messages = append(session.messages, provider.Message{
+		Role: "user",
+		Content: []provider.ContentBlock{
+			{Type: "text", Text: prompt},
+		},
+	})
+	session.logEvent("user_message", map[string]interface{}{"content": prompt})
+
+	var explanation string
+	if err := processChatHTTP(d.a, session, &explanation); err != nil {
+		log.Printf("[Anomaly] Explanation failed: %v", err)
+		explanation = fmt.Sprintf("Automated explanation failed: %v", err)
+	}
+
+	d.mu.Lock()
+	d.reports = append(d.reports, AnomalyReport{
+		Target:      t.config.Name,
+		Findings:    findings,
+		SessionID:   sessionID,
+		Explanation: explanation,
+		WindowStart: start,
+		WindowEnd:   end,
+		CreatedAt:   time.Now(),
+	})
+	if len(d.reports) > maxStoredAnomalies {
+		d.reports = d.reports[len(d.reports)-maxStoredAnomalies:]
+	}
+	d.mu.Unlock()
+
+	for _, f := range findings {
+		d.a.digest.record(digestEntry{Time: end, Kind: "anomaly", Title: f, Summary: explanation})
+	}
+	d.a.webhooks.notify(WebhookPayload{
+		Event:     WebhookEventAnomalyDetected,
+		Timestamp: time.Now(),
+		SessionID: sessionID,
+		Question:  strings.Join(findings, "\n"),
+		Summary:   truncate(explanation, maxWebhookSummaryChars),
+	})
+	log.Printf("[Anomaly] Explanation complete (session %s)", sessionID)
+}
+
+// handleList returns the recent anomaly reports as JSON for /api/anomalies
+func (d *anomalyDetector) handleList(w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	reports := make([]AnomalyReport, len(d.reports))
+	copy(reports, d.reports)
+	d.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reports)
+}
@@ -3,9 +3,10 @@ package aiassistant
 import (
 	"fmt"
 	"log"
-	"time"
 
 	"github.com/willknow-ai/willknow-go/analyzer"
+	"github.com/willknow-ai/willknow-go/graphqlapi"
+	"github.com/willknow-ai/willknow-go/grpcapi"
 	"github.com/willknow-ai/willknow-go/indexer"
 	"github.com/willknow-ai/willknow-go/openapi"
 	"github.com/willknow-ai/willknow-go/provider"
@@ -21,16 +22,60 @@ type Assistant struct {
 	codeIndex    *indexer.CodeIndex
 	apiTools     []*openapi.APITool // loaded from OpenAPI spec
 	apiSpec      *openapi.ParsedSpec
+	grpcService  *grpcapi.Service // loaded from gRPC reflection
+	graphqlAPI   *graphqlapi.API  // loaded from GraphQL schema
+	webhooks     *webhookNotifier
+	owners       *ownerChannels // nil unless owners have channels
+	digest       *digestReporter
+	targets      []*target // targets[0] is the default target
+	memory       *memoryStore
+	transcripts  *transcriptPolicy   // nil stores session logs in plaintext
+	requestIDs   *requestIDExtractor // nil without a registered request ID format
+	ops          *opsLog             // nil without an operational log
+	captures     *requestCapture     // nil unless request capture is enabled
+	workspaces   *workspaceStore     // nil without session workspaces
+	testGen      *testGenerator      // nil unless test generation is enabled
+	metrics      *toolMetrics
+	tokens       *tokenCounter
+	maintenance  *maintenanceSwitch
+	experiment   *experiment
+	fixtures     *fixtures      // nil unless recording or replaying
+	quotas       *userQuotas    // nil without per-user quotas
+	consent      *consentPolicy // nil unless consent prompts are enabled
+	deadLetters  *deadLetters
+	scheduler    *scheduler
+	anomalies    *anomalyDetector
+	budget       *budgetProvider  // nil without budgets
+	sharer       *sharer          // nil unless sharing is enabled
+	redactor     *redactor        // nil without redaction
+	guardrails   *guardrails      // nil without policies
+	dirtyBuild   bool             // the running build had uncommitted changes
+	gitSource    *tools.GitSource // nil unless the source comes from git
+	peers        *peerAgents      // nil without peer agents
 }
 
 // New creates a new AI Assistant instance
 func New(config Config) (*Assistant, error) {
 	config.setDefaults()
+	if err := config.applyEnvironment(); err != nil {
+		return nil, err
+	}
 
 	// Validate config
 	if config.APIKey == "" {
 		return nil, fmt.Errorf("APIKey is required")
 	}
+	if _, err := config.Editor.editorTemplate(); err != nil {
+		return nil, err
+	}
+
+	// Refuse to start if any destination is outside the air-gap allowlist
+	if config.AirGapped {
+		if err := checkAirGapped(config); err != nil {
+			return nil, err
+		}
+		log.Printf("[AI Assistant] Air-gapped mode: outbound traffic restricted to %d allowlisted hosts", len(config.AirGappedHosts))
+	}
 
 	// Create provider
 	aiProvider, err := provider.NewProvider(provider.ProviderType(config.Provider), config.APIKey, config.Model, config.BaseURL)
@@ -38,8 +83,105 @@ func New(config Config) (*Assistant, error) {
 		return nil, fmt.Errorf("failed to create provider: %w", err)
 	}
 
-	// Create tool registry
-	toolRegistry := tools.NewRegistry(config.SourcePath)
+	// Record or replay the provider's responses, if configured
+	fixtures, err := newFixtures(config.Fixtures)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure fixtures: %w", err)
+	}
+	if fixtures != nil {
+		aiProvider = fixtures.wrap(aiProvider)
+		log.Printf("[AI Assistant] Fixtures: %s (%s)", fixtures.config.Mode, fixtures.config.Dir)
+	}
+	// Exact token counts bypass the budget; they are redacted like every call
+	counted := aiProvider
+
+	// Account every LLM call against the budgets, if configured
+	budget, err := newBudgetProvider(aiProvider, config, fixtures)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure budget: %w", err)
+	}
+	if budget != nil {
+		aiProvider = budget
+		log.Println("[AI Assistant] Budget limits enabled")
+	}
+
+	// Mask sensitive data in everything sent to the provider, if configured
+	redactor, err := newRedactor(config.Redaction)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure redaction: %w", err)
+	}
+	if redactor != nil {
+		aiProvider = &redactingProvider{base: aiProvider, redactor: redactor}
+		log.Printf("[AI Assistant] Redaction enabled (%d patterns)", len(redactor.rules))
+	}
+	tokens := newTokenCounter(config, counted, redactor)
+
+	// Record the assistant's own failures in its operational log
+	ops, err := newOpsLog(config.OperationalLog)
+	if err != nil {
+		return nil, err
+	}
+	if ops != nil {
+		aiProvider = &opsLogProvider{base: aiProvider, ops: ops}
+	}
+
+	// Refuse every LLM call while paused for maintenance
+	maintenance := newMaintenanceSwitch(config)
+	aiProvider = &maintenanceProvider{base: aiProvider, maintenance: maintenance}
+
+	// Recognize the application's request IDs in questions
+	requestIDs, err := newRequestIDExtractor(config.RequestID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ask the user before sensitive capabilities, if configured
+	consent, err := newConsentPolicy(config.Consent)
+	if err != nil {
+		return nil, err
+	}
+
+	// Encrypt and filter session logs if configured
+	transcripts, err := newTranscriptPolicy(config.Transcripts)
+	if err != nil {
+		return nil, err
+	}
+	if transcripts.encrypted() {
+		log.Printf("[AI Assistant] Session logs are encrypted (key %s)", transcripts.keyID)
+	}
+
+	// Create tool registry over the source directory, archive or file system
+	var buildModified bool
+	if config.BuildRevision == "" {
+		config.BuildRevision, buildModified = buildRevision()
+	}
+	source := config.SourceFS
+	var gitSource *tools.GitSource
+	if source == nil && config.GitSource.URL != "" {
+		// Pin the source to the running build unless a ref is configured
+		pinned := config.GitSource.Ref == "" && config.BuildRevision != ""
+		if pinned {
+			config.GitSource.Ref = config.BuildRevision
+		}
+		log.Printf("[AI Assistant] Fetching source from %s...", config.GitSource.URL)
+		gitSource, err = tools.NewGitSource(config.GitSource)
+		if err != nil && pinned {
+			log.Printf("[AI Assistant] Warning: cannot fetch the running revision %s (%v); using the default branch", shortRevision(config.BuildRevision), err)
+			config.GitSource.Ref = ""
+			gitSource, err = tools.NewGitSource(config.GitSource)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch source: %w", err)
+		}
+		source = gitSource.FS()
+		config.SourcePath = gitSource.Dir()
+	} else if source == nil {
+		source, err = tools.OpenSource(config.SourcePath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	toolRegistry := tools.NewRegistryFS(source)
 
 	// Initialize auth manager
 	authManager := newAuthManager(config.Auth)
@@ -49,10 +191,57 @@ func New(config Config) (*Assistant, error) {
 		provider:     aiProvider,
 		toolRegistry: toolRegistry,
 		authManager:  authManager,
+		webhooks:     newWebhookNotifier(config.Webhooks),
+		owners:       newOwnerChannels(config.OwnerChannels),
+		budget:       budget,
+		sharer:       newSharer(config.Sharing, redactor, transcripts),
+		redactor:     redactor,
+		transcripts:  transcripts,
+		requestIDs:   requestIDs,
+		ops:          ops,
+		captures:     newRequestCapture(config.RequestCapture),
+		quotas:       newUserQuotas(config.Quotas),
+		consent:      consent,
+		deadLetters:  newDeadLetters(config.DeadLetterAfter, redactor),
+		metrics:      newToolMetrics(),
+		tokens:       tokens,
+		fixtures:     fixtures,
+		maintenance:  maintenance,
+		guardrails:   newGuardrails(config.Guardrails),
+		gitSource:    gitSource,
+		dirtyBuild:   buildModified,
+	}
+
+	// Register remote log sources
+	logSources := append([]tools.LogSource{}, config.LogSources...)
+	if config.Loki.URL != "" {
+		loki, err := tools.NewLokiSource(config.Loki)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure Loki: %w", err)
+		}
+		logSources = append(logSources, loki)
+	}
+	if config.Elasticsearch.URL != "" {
+		es, err := tools.NewElasticsearchSource(config.Elasticsearch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure Elasticsearch: %w", err)
+		}
+		logSources = append(logSources, es)
+	}
+	if config.CloudLogging.Enabled {
+		gcl, err := tools.NewCloudLoggingSource(config.CloudLogging)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure Cloud Logging: %w", err)
+		}
+		logSources = append(logSources, gcl)
+	}
+	for _, source := range logSources {
+		toolRegistry.RegisterLogSource(source)
+		log.Printf("[AI Assistant] Log source enabled: %s", source.Name())
 	}
 
 	// Auto-detect log files if not provided
-	if len(config.LogFiles) == 0 {
+	if len(config.LogFiles) == 0 && len(logSources) == 0 {
 		log.Println("[AI Assistant] No log files configured, attempting auto-detection...")
 		logFiles, err := analyzer.DetectLogFiles(aiProvider, toolRegistry, config.SourcePath)
 		if err != nil {
@@ -65,45 +254,158 @@ func New(config Config) (*Assistant, error) {
 	}
 
 	// Register log query tool with detected log files
-	toolRegistry.RegisterLogTool(assistant.config.LogFiles)
+	if len(assistant.config.LogFiles) > 0 || len(logSources) == 0 {
+		toolRegistry.RegisterLogTool(assistant.config.LogFiles)
+	}
 
 	// Build or load code index (if enabled)
 	if config.EnableCodeIndex {
-		const indexPath = "./code_index.json"
-		const maxAge = 24 * time.Hour
-
-		if indexer.IsIndexRecent(indexPath, maxAge) {
-			log.Println("[AI Assistant] Loading existing code index...")
-			codeIndex, err := indexer.LoadIndex(indexPath)
-			if err != nil {
-				log.Printf("[AI Assistant] Warning: Failed to load code index: %v", err)
-				log.Println("[AI Assistant] Will build new index...")
-			} else {
-				assistant.codeIndex = codeIndex
-				log.Printf("[AI Assistant] Code index loaded: %d files indexed", len(codeIndex.Files))
-			}
+		assistant.codeIndex = loadCodeIndex("./code_index.json", source, config.SourcePath, aiProvider, budget.allowIndexing())
+
+		// Register code index search tool if index is available
+		if assistant.codeIndex != nil {
+			toolRegistry.RegisterCodeIndexTool(assistant.codeIndex)
 		}
+	}
 
-		// Build new index if not loaded
-		if assistant.codeIndex == nil {
-			log.Println("[AI Assistant] Building code index (this may take a few minutes)...")
-			codeIndex, err := indexer.BuildCodeIndex(config.SourcePath, aiProvider)
-			if err != nil {
-				log.Printf("[AI Assistant] Warning: Failed to build code index: %v", err)
-			} else {
-				assistant.codeIndex = codeIndex
-				log.Printf("[AI Assistant] Code index built: %d files indexed", len(codeIndex.Files))
+	// Register GitHub tools if configured
+	if config.GitHub.Token != "" {
+		if config.GitHub.Repo == "" {
+			return nil, fmt.Errorf("GitHub.Repo is required when GitHub.Token is set")
+		}
+		toolRegistry.RegisterGitHubTools(config.GitHub)
+		log.Printf("[AI Assistant] GitHub tools enabled for %s", config.GitHub.Repo)
+	}
 
-				// Save index for future use
-				if err := indexer.SaveIndex(indexPath, codeIndex); err != nil {
-					log.Printf("[AI Assistant] Warning: Failed to save code index: %v", err)
-				}
-			}
+	// Register GitLab tools if configured
+	if config.GitLab.Token != "" {
+		if config.GitLab.Project == "" {
+			return nil, fmt.Errorf("GitLab.Project is required when GitLab.Token is set")
 		}
+		toolRegistry.RegisterGitLabTools(config.GitLab)
+		log.Printf("[AI Assistant] GitLab tools enabled for %s", config.GitLab.Project)
+	}
 
-		// Register code index search tool if index is available
-		if assistant.codeIndex != nil {
-			toolRegistry.RegisterCodeIndexTool(assistant.codeIndex)
+	// Register Sentry tool if configured
+	if config.Sentry.AuthToken != "" {
+		if err := toolRegistry.RegisterSentryTool(config.Sentry); err != nil {
+			return nil, fmt.Errorf("failed to configure Sentry: %w", err)
+		}
+		log.Println("[AI Assistant] Sentry tool enabled")
+	}
+
+	// Register trace tool if configured
+	if config.Tracing.URL != "" {
+		if err := toolRegistry.RegisterTraceTool(config.Tracing); err != nil {
+			return nil, fmt.Errorf("failed to configure tracing: %w", err)
+		}
+		log.Printf("[AI Assistant] Trace tool enabled (%s)", config.Tracing.URL)
+	}
+
+	// Register metrics tool if configured
+	if config.Prometheus.URL != "" {
+		toolRegistry.RegisterPrometheusTool(config.Prometheus)
+		log.Printf("[AI Assistant] Metrics tool enabled (%s)", config.Prometheus.URL)
+	}
+
+	// Register database schema tool if configured
+	if config.Database.DB != nil {
+		if err := toolRegistry.RegisterDatabaseTool(config.Database); err != nil {
+			return nil, fmt.Errorf("failed to configure database: %w", err)
+		}
+		log.Println("[AI Assistant] Database schema tool enabled")
+	}
+
+	// Register Redis tool if configured
+	if config.Redis.Addr != "" {
+		toolRegistry.RegisterRedisTool(config.Redis)
+		log.Printf("[AI Assistant] Redis tool enabled (%s)", config.Redis.Addr)
+	}
+
+	// Register Kafka tool if configured
+	if len(config.Kafka.Brokers) > 0 {
+		if err := toolRegistry.RegisterKafkaTool(config.Kafka); err != nil {
+			return nil, fmt.Errorf("failed to configure Kafka: %w", err)
+		}
+		log.Printf("[AI Assistant] Kafka tool enabled (%v)", config.Kafka.Brokers)
+	}
+
+	// Register knowledge base tools if configured
+	kb := config.KnowledgeBase
+	if kb.Dir != "" || kb.Notion.Token != "" || kb.Confluence.URL != "" || len(kb.Stores) > 0 {
+		if err := toolRegistry.RegisterKnowledgeBaseTools(kb); err != nil {
+			return nil, fmt.Errorf("failed to configure knowledge base: %w", err)
+		}
+		log.Println("[AI Assistant] Knowledge base tools enabled")
+	}
+
+	// Register Kubernetes tool if configured
+	if config.Kubernetes.InCluster || config.Kubernetes.Kubeconfig != "" {
+		if err := toolRegistry.RegisterKubernetesTool(config.Kubernetes); err != nil {
+			return nil, fmt.Errorf("failed to configure Kubernetes: %w", err)
+		}
+		log.Println("[AI Assistant] Kubernetes tool enabled")
+	}
+
+	// Register runbook search tool if configured
+	if config.Runbooks.Dir != "" || len(config.Runbooks.URLs) > 0 {
+		if err := toolRegistry.RegisterRunbookTool(config.Runbooks); err != nil {
+			return nil, fmt.Errorf("failed to configure runbooks: %w", err)
+		}
+		log.Println("[AI Assistant] Runbook search tool enabled")
+	}
+
+	// Register snippet execution tool if enabled
+	if config.Snippets.Enabled {
+		if err := toolRegistry.RegisterSnippetTool(config.Snippets); err != nil {
+			return nil, fmt.Errorf("failed to configure snippets: %w", err)
+		}
+		log.Println("[AI Assistant] Snippet execution tool enabled")
+	}
+
+	// Register ownership tool if enabled
+	if config.Ownership.Enabled {
+		if config.Ownership.GitDir == "" && isGitCheckout(config.SourcePath) {
+			config.Ownership.GitDir = config.SourcePath
+		}
+		toolRegistry.RegisterOwnershipTool(config.Ownership)
+		log.Println("[AI Assistant] Source ownership enabled")
+	}
+
+	// Compare the deployed revision with main when the source comes from git
+	if gitSource != nil {
+		toolRegistry.RegisterSourceDiffTool(gitSource, config.BuildRevision)
+	}
+
+	// Set up session workspaces if configured
+	assistant.workspaces, err = newWorkspaceStore(config.Workspace)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set up the failing test action if enabled
+	assistant.testGen, err = newTestGenerator(config.TestGeneration, config.SourcePath, assistant.workspaces)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set up per-user memory if configured
+	assistant.memory, err = newMemoryStore(config.Memory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure memory: %w", err)
+	}
+	if assistant.memory != nil {
+		log.Printf("[AI Assistant] Memory tool enabled (%s)", config.Memory.Dir)
+	}
+
+	// Discover peer agents if configured
+	assistant.peers, err = newPeerAgents(config.Peers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure peer agents: %w", err)
+	}
+	if assistant.peers != nil {
+		for _, peer := range assistant.peers.agents {
+			log.Printf("[AI Assistant] Peer agent enabled: %s (%s)", peer.name, peer.chatURL)
 		}
 	}
 
@@ -121,6 +423,11 @@ func New(config Config) (*Assistant, error) {
 
 		// Apply defaults from spec if not explicitly set
 		if config.HostBaseURL == "" && spec.ServerURL != "" {
+			if config.AirGapped {
+				if err := checkAirGappedURL(config, "OpenAPI spec server URL", spec.ServerURL); err != nil {
+					return nil, err
+				}
+			}
 			assistant.config.HostBaseURL = spec.ServerURL
 		}
 		if config.AgentInfo.Name == "" && spec.Title != "" {
@@ -131,18 +438,202 @@ func New(config Config) (*Assistant, error) {
 		}
 	}
 
+	// Load gRPC tools via reflection if configured
+	if config.GRPC.Target != "" {
+		log.Printf("[AI Assistant] Loading gRPC services via reflection: %s", config.GRPC.Target)
+		svc, err := grpcapi.Load(config.GRPC)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load gRPC services: %w", err)
+		}
+		assistant.grpcService = svc
+		log.Printf("[AI Assistant] Loaded %d gRPC method tools", len(svc.Tools))
+	}
+
+	// Load GraphQL tools from the schema if configured
+	if config.GraphQL.Endpoint != "" {
+		log.Printf("[AI Assistant] Loading GraphQL schema: %s", config.GraphQL.Endpoint)
+		api, err := graphqlapi.Load(config.GraphQL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load GraphQL schema: %w", err)
+		}
+		assistant.graphqlAPI = api
+		log.Printf("[AI Assistant] Loaded %d GraphQL operation tools", len(api.Tools))
+	}
+
+	// The default target is the top-level configuration; additional targets
+	// share its integrations, so they are built after everything is registered
+	assistant.targets = []*target{{
+		config: TargetConfig{
+			Name:            defaultTargetName,
+			Description:     assistant.config.AgentInfo.Description,
+			SourcePath:      config.SourcePath,
+			SourceFS:        source,
+			LogFiles:        assistant.config.LogFiles,
+			APISpec:         config.APISpec,
+			HostBaseURL:     assistant.config.HostBaseURL,
+			EnableCodeIndex: config.EnableCodeIndex,
+		},
+		toolRegistry: toolRegistry,
+		codeIndex:    assistant.codeIndex,
+		apiTools:     assistant.apiTools,
+		apiSpec:      assistant.apiSpec,
+		revision:     gitRevision(source),
+	}}
+	for _, tc := range config.Targets {
+		if !targetNameValid.MatchString(tc.Name) || assistant.findTarget(tc.Name) != nil {
+			return nil, fmt.Errorf("invalid or duplicate target name %q", tc.Name)
+		}
+		t, err := newTarget(tc, toolRegistry, logSources, aiProvider, budget.allowIndexing())
+		if err != nil {
+			return nil, err
+		}
+		if config.AirGapped && tc.HostBaseURL == "" && t.config.HostBaseURL != "" {
+			if err := checkAirGappedURL(config, "target "+tc.Name+" OpenAPI spec server URL", t.config.HostBaseURL); err != nil {
+				return nil, err
+			}
+		}
+		assistant.targets = append(assistant.targets, t)
+		log.Printf("[AI Assistant] Target enabled: %s (%s)", tc.Name, tc.SourcePath)
+	}
+
+	// Every environment but the one applied at startup is selectable per conversation
+	for _, env := range config.Environments {
+		if env.Name == config.Environment {
+			continue
+		}
+		if assistant.findTarget(env.Name) != nil {
+			return nil, fmt.Errorf("environment %q has the name of a target", env.Name)
+		}
+		t, err := assistant.newEnvironmentTarget(env, logSources)
+		if err != nil {
+			return nil, err
+		}
+		assistant.targets = append(assistant.targets, t)
+		log.Printf("[AI Assistant] Environment enabled: %s", env.Name)
+	}
+
+	// Experiment variants are wrapped like the assistant's provider
+	if assistant.experiment, err = newExperiment(assistant, config.Experiment); err != nil {
+		return nil, fmt.Errorf("failed to configure experiment: %w", err)
+	}
+	if e := assistant.experiment; e != nil {
+		for _, v := range e.variants {
+			log.Printf("[AI Assistant] Experiment %s: variant %s receives %d%% of sessions", e.config.Name, v.config.Name, v.config.Percent)
+		}
+	}
+
+	// Make the operational log searchable in every target
+	if ops != nil {
+		for _, t := range assistant.targets {
+			t.toolRegistry.RegisterLogTool([]string{ops.path})
+		}
+		log.Printf("[AI Assistant] Operational log: %s", ops.path)
+	}
+
+	// Keep files denied by policy out of the code shown with log entries
+	// and the compared source
+	for _, t := range assistant.targets {
+		guardrails := assistant.targetGuardrails(t)
+		t.toolRegistry.ExcludeFiles(func(p string) bool { return guardrails.deniedPath(p) != "" })
+	}
+
+	// Filter log levels and noise once every target registered its logs
+	for _, t := range assistant.targets {
+		if !t.toolRegistry.HasLogs() {
+			continue
+		}
+		if err := t.toolRegistry.SetLogFilter(config.LogFilter); err != nil {
+			return nil, fmt.Errorf("invalid LogFilter: %w", err)
+		}
+	}
+
+	// Restrict log files to roles once every target registered its logs
+	for name, roles := range config.LogFileRoles {
+		for _, t := range assistant.targets {
+			t.toolRegistry.RestrictLogSource(name, roles)
+		}
+		log.Printf("[AI Assistant] Log access restricted: %s (roles %v)", name, roles)
+	}
+
+	// Set up the email digest after AgentInfo defaults are applied, since it names the agent
+	assistant.digest = newDigestReporter(config.Digest, assistant.config.AgentInfo.Name)
+
+	// Set up anomaly detection over the log files of every target
+	assistant.anomalies = newAnomalyDetector(assistant, config.Anomalies)
+
+	// Set up scheduled analyses last, since jobs reference targets and the digest's SMTP settings
+	assistant.scheduler, err = newScheduler(assistant, config.Scheduler)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure scheduler: %w", err)
+	}
+
 	return assistant, nil
 }
 
+// isAgentMode reports whether the target exposes host API tools
+func (a *Assistant) isAgentMode(t *target) bool {
+	return t.config.APISpec != "" || a.config.GRPC.Target != "" || a.config.GraphQL.Endpoint != ""
+}
+
 // Start starts the AI Assistant web server
 func (a *Assistant) Start() error {
 	log.Printf("[AI Assistant] Starting on port %d...", a.config.Port)
-	log.Printf("[AI Assistant] Source path: %s", a.config.SourcePath)
+	if a.config.SourceFS != nil {
+		log.Println("[AI Assistant] Source: embedded file system")
+	} else if a.gitSource != nil {
+		log.Printf("[AI Assistant] Source: %s at %s", a.config.GitSource.URL, a.gitSource.Revision())
+		go a.gitSource.Run()
+	} else {
+		log.Printf("[AI Assistant] Source path: %s", a.config.SourcePath)
+	}
+	a.checkRevision()
 	log.Printf("[AI Assistant] Log files: %v", a.config.LogFiles)
 	if a.config.APISpec != "" {
 		log.Printf("[AI Assistant] Agent mode: %d API tools available", len(a.apiTools))
 		log.Printf("[AI Assistant] Host base URL: %s", a.config.HostBaseURL)
 	}
+	if a.grpcService != nil {
+		log.Printf("[AI Assistant] Agent mode: %d gRPC tools available (%s)", len(a.grpcService.Tools), a.config.GRPC.Target)
+	}
+	if a.graphqlAPI != nil {
+		log.Printf("[AI Assistant] Agent mode: %d GraphQL tools available (%s)", len(a.graphqlAPI.Tools), a.config.GraphQL.Endpoint)
+	}
+
+	if a.config.Teams.AppID != "" {
+		log.Printf("[AI Assistant] Teams connector enabled: /willknow/teams/messages")
+	}
+	if a.config.Alerts.Secret != "" {
+		log.Printf("[AI Assistant] Alert webhooks enabled: /willknow/alerts/pagerduty, /willknow/alerts/opsgenie")
+	}
+	if a.config.AnalyzeAPI.Token != "" {
+		log.Printf("[AI Assistant] Analysis API enabled: %s", analyzePath)
+	}
+	if a.config.AdminToken != "" {
+		log.Printf("[AI Assistant] Admin API enabled: %s", maintenancePath)
+	}
+	if a.config.Maintenance {
+		log.Printf("[AI Assistant] Starting in maintenance mode: LLM calls and tools are paused")
+	}
+	if a.digest != nil {
+		log.Printf("[AI Assistant] Email digest enabled: %s at %02d:00 to %v", a.digest.config.Frequency, a.digest.config.Hour, a.digest.config.To)
+		go a.digest.run()
+	}
+	if a.anomalies != nil {
+		log.Printf("[AI Assistant] Anomaly detection enabled: %s windows, %.1fσ threshold", a.anomalies.config.Interval, a.anomalies.config.Threshold)
+		go a.anomalies.run()
+	}
+	if a.workspaces != nil {
+		log.Printf("[AI Assistant] Session workspaces enabled: %s (kept %s)", a.workspaces.config.Dir, a.workspaces.config.Retention)
+		go a.workspaces.run()
+	}
+	if a.scheduler != nil {
+		for _, job := range a.scheduler.jobs {
+			log.Printf("[AI Assistant] Scheduled analysis %q: %s", job.Name, job.Schedule)
+		}
+		a.scheduler.start()
+	}
+
+	a.logCapabilities()
 
 	// Print auth startup message (password, open mode notice, etc.)
 	a.authManager.printStartupMessage(a.config.Port)
@@ -150,33 +641,168 @@ func (a *Assistant) Start() error {
 	return startServer(a)
 }
 
-// getAPIToolDefinitions returns provider.Tool definitions for all API tools
-func (a *Assistant) getAPIToolDefinitions() []provider.Tool {
+// getAPIToolDefinitions returns provider.Tool definitions for all API tools of a target
+func (a *Assistant) getAPIToolDefinitions(target *target) []provider.Tool {
 	var defs []provider.Tool
-	for _, t := range a.apiTools {
+	for _, t := range target.apiTools {
 		defs = append(defs, t.ToProviderTool())
 	}
+	if a.grpcService != nil {
+		for _, t := range a.grpcService.Tools {
+			defs = append(defs, t.ToProviderTool())
+		}
+	}
+	if a.graphqlAPI != nil {
+		for _, t := range a.graphqlAPI.Tools {
+			defs = append(defs, a.graphqlAPI.ToProviderTool(t))
+		}
+	}
 	return defs
 }
 
-// getAllToolDefinitions returns combined debug + API tool definitions
-func (a *Assistant) getAllToolDefinitions() []provider.Tool {
-	tools := a.toolRegistry.GetToolDefinitions()
-	tools = append(tools, a.getAPIToolDefinitions()...)
+// getAllToolDefinitions returns combined debug + API tool definitions of a target
+func (a *Assistant) getAllToolDefinitions(t *target) []provider.Tool {
+	tools := t.toolRegistry.GetToolDefinitions()
+	tools = append(tools, a.getAPIToolDefinitions(t)...)
+	if a.memory != nil {
+		tools = append(tools, a.memory.toolDefinition())
+	}
+	if a.peers != nil {
+		tools = append(tools, a.peers.toolDefinition())
+	}
+	if t.toolRegistry.HasLogs() {
+		tools = append(tools, exportLogsToolDefinition())
+	}
+	if a.captures != nil {
+		tools = append(tools, a.captures.toolDefinition())
+	}
+	if a.workspaces != nil {
+		tools = append(tools, a.workspaces.toolDefinitions()...)
+	}
+	if a.testGen != nil {
+		tools = append(tools, a.testGen.toolDefinitions()...)
+	}
+	tools = append(tools, suggestActionsToolDefinition())
 	return tools
 }
 
-// executeToolCall routes tool execution to the appropriate handler
-func (a *Assistant) executeToolCall(name string, params map[string]interface{}, authHeader string) (string, error) {
+// isHostAPITool reports whether a tool calls the host application's API
+func (a *Assistant) isHostAPITool(t *target, name string) bool {
+	return openapi.FindTool(t.apiTools, name) != nil || a.grpcService.FindTool(name) != nil || a.graphqlAPI.FindTool(name) != nil
+}
+
+// executeHostAPITool calls the host application's REST, gRPC or GraphQL API
+func (a *Assistant) executeHostAPITool(t *target, name string, params map[string]interface{}, authHeader string) (string, error) {
 	// Check if it's an API tool
-	if apiTool := openapi.FindTool(a.apiTools, name); apiTool != nil {
-		baseURL := a.config.HostBaseURL
+	if apiTool := openapi.FindTool(t.apiTools, name); apiTool != nil {
+		baseURL := t.config.HostBaseURL
 		if baseURL == "" {
 			return "", fmt.Errorf("HostBaseURL is not configured for API tool execution")
 		}
 		return openapi.ExecuteTool(apiTool, params, baseURL, authHeader)
 	}
 
+	// Check if it's a gRPC tool
+	if grpcTool := a.grpcService.FindTool(name); grpcTool != nil {
+		return a.grpcService.ExecuteTool(grpcTool, params, authHeader)
+	}
+
+	// Otherwise it's a GraphQL tool
+	return a.graphqlAPI.ExecuteTool(a.graphqlAPI.FindTool(name), params, authHeader)
+}
+
+// executeToolCall routes tool execution to the appropriate handler for the
+// session's user and target
+func (a *Assistant) executeToolCall(session *Session, name string, params map[string]interface{}) (result string, err error) {
+	t := a.sessionTarget(session)
+	authHeader := session.authHeader
+
+	// Run nothing while paused for maintenance
+	if err := a.maintenance.check(); err != nil {
+		return "", err
+	}
+
+	// Enforce guardrail policies before anything is executed
+	guardrails := a.targetGuardrails(t)
+	if err := guardrails.check(a, session, name, params); err != nil {
+		return "", err
+	}
+
+	// Ask the user before the first use of a sensitive capability
+	if err := a.checkConsent(session, t, name, params); err != nil {
+		return "", err
+	}
+
+	// Keep oversized results within MaxToolResultTokens
+	defer func() { result = a.tokens.truncate(result) }()
+
+	// Answer from the recorded fixtures, or record the result
+	if result, replayed, err := a.fixtures.replayTool(name, params); replayed {
+		return result, err
+	}
+	defer func() { a.fixtures.recordTool(name, params, result, err) }()
+
+	// Count the call and record failures in the operational log
+	defer func() {
+		a.metrics.recordCall(name, result, err)
+		if err != nil && a.ops != nil {
+			fields := a.ops.sessionFields(session)
+			fields["tool"] = name
+			fields["error"] = err.Error()
+			a.ops.record("warn", "tool_error", fields)
+		}
+	}()
+
+	// Check if it's the per-user memory tool
+	if name == memoryToolName && a.memory != nil {
+		return a.memory.execute(session, params)
+	}
+
+	// Check if it's a question for a peer agent
+	if name == callAgentToolName && a.peers != nil {
+		return a.peers.execute(session, params)
+	}
+
+	// Check if it's a workspace tool
+	if (name == listWorkspaceToolName || name == writeWorkspaceToolName) && a.workspaces != nil {
+		return a.workspaces.execute(session, name, params)
+	}
+
+	// Check if it's the offer of follow-up actions
+	if name == suggestActionsToolName {
+		return a.suggestActions(session, params)
+	}
+
+	// Check if it's a test generation tool
+	if a.testGen.isTool(name) {
+		return a.testGen.execute(session, name, params)
+	}
+
+	// Check if it's a replay of a captured request
+	if name == replayRequestToolName && a.captures != nil {
+		return a.captures.execute(a, session, params)
+	}
+
+	// Check if it's a log export
+	if name == exportLogsToolName && t.toolRegistry.HasLogs() {
+		return a.exportLogsTool(session, params)
+	}
+
+	// Check if it's a host API tool; an identical call failing repeatedly is
+	// dead-lettered instead of retried
+	if a.isHostAPITool(t, name) {
+		if err := a.deadLetters.check(session, name, params); err != nil {
+			return "", err
+		}
+		result, err = a.executeHostAPITool(t, name, params, authHeader)
+		return a.deadLetters.record(session, t, name, params, result, err), err
+	}
+
 	// Fall back to debug tools
-	return a.toolRegistry.Execute(name, params)
+	var roles []string
+	if session.User != nil {
+		roles = session.User.Roles
+	}
+	result, err = t.toolRegistry.ExecuteAs(name, params, roles)
+	return guardrails.filterResult(name, result), err
 }
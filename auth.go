@@ -18,6 +18,9 @@ type User struct {
 	ID    string
 	Name  string
 	Email string
+
+	// Roles grant access to restricted log files (see Config.LogFileRoles)
+	Roles []string
 }
 
 // NoAuth is a sentinel GetUserFunc that explicitly disables authentication.
@@ -197,3 +200,14 @@ func (am *AuthManager) isPasswordMode() bool {
 func (am *AuthManager) isOpenMode() bool {
 	return am.mode == authModeOpen
 }
+
+// modeName names the authentication mode: "password", "custom" or "open"
+func (am *AuthManager) modeName() string {
+	switch am.mode {
+	case authModeCustom:
+		return "custom"
+	case authModeOpen:
+		return "open"
+	}
+	return "password"
+}
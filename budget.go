@@ -0,0 +1,289 @@
+package aiassistant
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/willknow-ai/willknow-go/provider"
+)
+
+// defaultBudgetDegradeAt is the fraction of a budget after which the assistant degrades
+const defaultBudgetDegradeAt = 0.8
+
+// BudgetConfig limits how many tokens, or how many dollars, the assistant
+// spends on the LLM provider per day and per month (local time).
+//
+// As usage approaches a limit the assistant degrades gracefully: once DegradeAt
+// of any budget is used it switches to FallbackModel and stops building code
+// indexes; once a budget is used up it refuses new chat messages (and automated
+// analyses) with a clear message until the period resets.
+type BudgetConfig struct {
+	// DailyTokens and MonthlyTokens cap input + output tokens (0 = no limit)
+	DailyTokens   int64
+	MonthlyTokens int64
+
+	// DailyUSD and MonthlyUSD cap the estimated cost (0 = no limit).
+	// Requires InputPricePerMillion / OutputPricePerMillion.
+	DailyUSD   float64
+	MonthlyUSD float64
+
+	// InputPricePerMillion and OutputPricePerMillion are the USD prices per
+	// million tokens of Config.Model, used to estimate cost
+	InputPricePerMillion  float64
+	OutputPricePerMillion float64
+
+	// FallbackModel is a cheaper model of the same provider used once the
+	// assistant is degraded. When empty, the model does not change.
+	FallbackModel string
+
+	// FallbackInputPricePerMillion and FallbackOutputPricePerMillion are the
+	// USD prices per million tokens of FallbackModel
+	FallbackInputPricePerMillion  float64
+	FallbackOutputPricePerMillion float64
+
+	// DegradeAt is the fraction (0-1) of any budget after which the assistant degrades
+	// Default: 0.8
+	DegradeAt float64
+
+	// StateFile persists usage so restarts do not reset the budget
+	// Default: "" (usage is kept in memory)
+	StateFile string
+}
+
+// ErrBudgetExceeded is returned instead of calling the provider once a budget is used up
+var ErrBudgetExceeded = errors.New("the AI assistant has used up its budget")
+
+// budgetLevel is how far usage has progressed through the budgets
+type budgetLevel int
+
+const (
+	budgetNormal budgetLevel = iota
+	budgetDegraded
+	budgetExhausted
+)
+
+// budgetUsage is the usage of the current day and month
+type budgetUsage struct {
+	Day          string  `json:"day"` // 2006-01-02
+	DayTokens    int64   `json:"day_tokens"`
+	DayUSD       float64 `json:"day_usd"`
+	Month        string  `json:"month"` // 2006-01
+	MonthTokens  int64   `json:"month_tokens"`
+	MonthUSD     float64 `json:"month_usd"`
+	LastExceeded string  `json:"last_exceeded,omitempty"` // which budget was used up
+}
+
+// budgetProvider wraps the provider, accounts for usage and degrades as budgets are used
+type budgetProvider struct {
+	base      provider.Provider
+	fallback  provider.Provider // nil if no FallbackModel
+	config    BudgetConfig
+	tokenizer provider.Tokenizer // estimates streamed usage
+
+	mu    sync.Mutex
+	usage budgetUsage
+	level budgetLevel
+}
+
+// newBudgetProvider wraps base, or returns nil if no budget is configured.
+// The fallback model records or replays its responses like base.
+func newBudgetProvider(base provider.Provider, config Config, fixtures *fixtures) (*budgetProvider, error) {
+	b := config.Budget
+	if b.DailyTokens <= 0 && b.MonthlyTokens <= 0 && b.DailyUSD <= 0 && b.MonthlyUSD <= 0 {
+		return nil, nil
+	}
+	if (b.DailyUSD > 0 || b.MonthlyUSD > 0) && b.InputPricePerMillion <= 0 && b.OutputPricePerMillion <= 0 {
+		return nil, fmt.Errorf("USD budgets require InputPricePerMillion and OutputPricePerMillion")
+	}
+	if b.DegradeAt <= 0 || b.DegradeAt > 1 {
+		b.DegradeAt = defaultBudgetDegradeAt
+	}
+
+	p := &budgetProvider{base: base, config: b, tokenizer: config.Tokenizer}
+	if b.FallbackModel != "" {
+		fallback, err := provider.NewProvider(provider.ProviderType(config.Provider), config.APIKey, b.FallbackModel, config.BaseURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create fallback provider: %w", err)
+		}
+		p.fallback = fixtures.wrap(fallback)
+	}
+	if b.StateFile != "" {
+		if data, err := os.ReadFile(b.StateFile); err == nil {
+			if err := json.Unmarshal(data, &p.usage); err != nil {
+				log.Printf("[Budget] Ignoring unreadable state file %s: %v", b.StateFile, err)
+			}
+		}
+	}
+	p.mu.Lock()
+	p.refresh(time.Now())
+	p.mu.Unlock()
+	return p, nil
+}
+
+// SendMessage routes to the current model, or refuses once a budget is used up
+func (p *budgetProvider) SendMessage(messages []provider.Message, tools []provider.Tool, system string) (*provider.Response, error) {
+	current, degraded, err := p.acquire()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := current.SendMessage(messages, tools, system)
+	if resp != nil {
+		p.record(resp.Usage, degraded)
+	}
+	return resp, err
+}
+
+// SendMessageStream routes to the current model. Streamed usage is not
+// reported by providers, so the input tokens are estimated with the
+// tokenizer; the output is not counted.
+func (p *budgetProvider) SendMessageStream(messages []provider.Message, tools []provider.Tool, system string) (io.ReadCloser, error) {
+	current, degraded, err := p.acquire()
+	if err != nil {
+		return nil, err
+	}
+	stream, err := current.SendMessageStream(messages, tools, system)
+	if err == nil {
+		p.record(provider.Usage{InputTokens: provider.CountMessageTokens(p.tokenizer, messages, tools, system)}, degraded)
+	}
+	return stream, err
+}
+
+// GetName returns the wrapped provider's name
+func (p *budgetProvider) GetName() string {
+	return p.base.GetName()
+}
+
+// acquire returns the provider to use for the next request
+func (p *budgetProvider) acquire() (provider.Provider, bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.refresh(time.Now())
+	switch p.level {
+	case budgetExhausted:
+		return nil, false, p.exceededError()
+	case budgetDegraded:
+		if p.fallback != nil {
+			return p.fallback, true, nil
+		}
+	}
+	return p.base, false, nil
+}
+
+// record adds the usage of one response
+func (p *budgetProvider) record(usage provider.Usage, degraded bool) {
+	inPrice, outPrice := p.config.InputPricePerMillion, p.config.OutputPricePerMillion
+	if degraded {
+		inPrice, outPrice = p.config.FallbackInputPricePerMillion, p.config.FallbackOutputPricePerMillion
+	}
+	tokens := int64(usage.InputTokens + usage.OutputTokens)
+	cost := (float64(usage.InputTokens)*inPrice + float64(usage.OutputTokens)*outPrice) / 1e6
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.refresh(time.Now())
+	p.usage.DayTokens += tokens
+	p.usage.MonthTokens += tokens
+	p.usage.DayUSD += cost
+	p.usage.MonthUSD += cost
+	p.refresh(time.Now())
+	p.save()
+}
+
+// refresh resets counters at period boundaries and recomputes the level.
+// Callers hold p.mu.
+func (p *budgetProvider) refresh(now time.Time) {
+	if day := now.Format("2006-01-02"); p.usage.Day != day {
+		p.usage.Day, p.usage.DayTokens, p.usage.DayUSD = day, 0, 0
+	}
+	if month := now.Format("2006-01"); p.usage.Month != month {
+		p.usage.Month, p.usage.MonthTokens, p.usage.MonthUSD = month, 0, 0
+	}
+
+	level, exceeded := budgetNormal, ""
+	check := func(name string, used, limit float64) {
+		if limit <= 0 {
+			return
+		}
+		switch {
+		case used >= limit:
+			level = budgetExhausted
+			exceeded = name
+		case used >= limit*p.config.DegradeAt && level < budgetDegraded:
+			level = budgetDegraded
+		}
+	}
+	check("monthly token", float64(p.usage.MonthTokens), float64(p.config.MonthlyTokens))
+	check("monthly cost", p.usage.MonthUSD, p.config.MonthlyUSD)
+	check("daily token", float64(p.usage.DayTokens), float64(p.config.DailyTokens))
+	check("daily cost", p.usage.DayUSD, p.config.DailyUSD)
+
+	if level != p.level {
+		switch level {
+		case budgetExhausted:
+			log.Printf("[Budget] %s budget used up; refusing new requests until it resets", exceeded)
+		case budgetDegraded:
+			if p.fallback != nil {
+				log.Printf("[Budget] %.0f%% of a budget used; switching to %s and disabling indexing", p.config.DegradeAt*100, p.config.FallbackModel)
+			} else {
+				log.Printf("[Budget] %.0f%% of a budget used; disabling indexing", p.config.DegradeAt*100)
+			}
+		default:
+			log.Println("[Budget] Budget reset; back to normal operation")
+		}
+	}
+	p.level = level
+	p.usage.LastExceeded = exceeded
+}
+
+// exceededError explains which budget was used up and when it resets. Callers hold p.mu.
+func (p *budgetProvider) exceededError() error {
+	resets := "tomorrow"
+	if p.usage.LastExceeded == "monthly token" || p.usage.LastExceeded == "monthly cost" {
+		resets = "at the start of next month"
+	}
+	return fmt.Errorf("%w: the %s budget is exhausted, so new chats are paused until it resets %s. Please contact the administrator if this is urgent", ErrBudgetExceeded, p.usage.LastExceeded, resets)
+}
+
+// save persists usage to the state file. Callers hold p.mu.
+func (p *budgetProvider) save() {
+	if p.config.StateFile == "" {
+		return
+	}
+	data, _ := json.Marshal(p.usage)
+	if err := os.WriteFile(p.config.StateFile, data, 0644); err != nil {
+		log.Printf("[Budget] Failed to save state: %v", err)
+	}
+}
+
+// allowIndexing reports whether building code indexes fits the budget
+func (p *budgetProvider) allowIndexing() bool {
+	if p == nil {
+		return true
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.refresh(time.Now())
+	return p.level == budgetNormal
+}
+
+// checkAvailable returns ErrBudgetExceeded (wrapped with an explanation) if
+// new chats should be refused. Every chat, including automated analyses,
+// checks it before its first LLM call.
+func (p *budgetProvider) checkAvailable() error {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.refresh(time.Now())
+	if p.level == budgetExhausted {
+		return p.exceededError()
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package aiassistant
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Capabilities describes what this instance can do, as resolved from its
+// configuration at startup. It is served at GET /api/capabilities so UIs,
+// CLIs and peer agents can adapt to it.
+type Capabilities struct {
+	Provider      string               `json:"provider"`
+	Model         string               `json:"model"`     // "" if the provider's default model is used
+	AuthMode      string               `json:"auth_mode"` // "password", "custom" or "open"
+	Source        string               `json:"source"`    // "path", "embedded" or "git"
+	Revision      string               `json:"revision,omitempty"`
+	AirGapped     bool                 `json:"air_gapped"`
+	Features      []string             `json:"features"`                 // e.g. "sharing", "workspaces", "consent"
+	AnswerActions []string             `json:"answer_actions,omitempty"` // actions offered under answers
+	Targets       []TargetCapabilities `json:"targets"`                  // targets[0] is the default target
+}
+
+// TargetCapabilities describes the tools and data of a target
+type TargetCapabilities struct {
+	Name       string          `json:"name"`
+	Tools      []string        `json:"tools"`
+	LogSources []string        `json:"log_sources"`
+	CodeIndex  CodeIndexStatus `json:"code_index"`
+	APITools   int             `json:"api_tools"` // OpenAPI, gRPC and GraphQL operations
+}
+
+// CodeIndexStatus is the state of a target's code index
+type CodeIndexStatus struct {
+	Status    string     `json:"status"` // "disabled", "ready" or "unavailable"
+	Files     int        `json:"files,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+// Capabilities returns the resolved capabilities of the assistant
+func (a *Assistant) Capabilities() Capabilities {
+	c := Capabilities{
+		Provider:      a.provider.GetName(),
+		Model:         a.config.Model,
+		AuthMode:      a.authManager.modeName(),
+		Source:        "path",
+		AirGapped:     a.config.AirGapped,
+		AnswerActions: a.answerActions(),
+	}
+	switch {
+	case a.config.SourceFS != nil:
+		c.Source = "embedded"
+	case a.gitSource != nil:
+		c.Source = "git"
+		c.Revision = a.gitSource.Revision()
+	}
+	if c.Revision == "" {
+		c.Revision = a.config.BuildRevision
+	}
+
+	features := []struct {
+		name    string
+		enabled bool
+	}{
+		{"teams", a.config.Teams.AppID != ""},
+		{"alerts", a.config.Alerts.Secret != ""},
+		{"analyze_api", a.config.AnalyzeAPI.Token != ""},
+		{"anomalies", a.anomalies != nil},
+		{"scheduled_analyses", a.scheduler != nil},
+		{"digest", a.digest != nil},
+		{"sharing", a.sharer != nil},
+		{"memory", a.memory != nil},
+		{"peer_agents", a.peers != nil},
+		{"workspaces", a.workspaces != nil},
+		{"test_generation", a.testGen != nil},
+		{"request_capture", a.captures != nil},
+		{"consent", a.consent != nil},
+		{"redaction", a.redactor != nil},
+		{"guardrails", a.guardrails != nil},
+		{"budget", a.budget != nil},
+		{"quotas", a.quotas != nil},
+		{"experiments", a.experiment != nil},
+		{"ownership", a.config.Ownership.Enabled},
+		{"operational_log", a.ops != nil},
+		{"strict_evidence", a.config.StrictEvidence},
+	}
+	c.Features = []string{}
+	for _, f := range features {
+		if f.enabled {
+			c.Features = append(c.Features, f.name)
+		}
+	}
+
+	for _, t := range a.targets {
+		tc := TargetCapabilities{
+			Name:       t.config.Name,
+			Tools:      []string{},
+			LogSources: t.toolRegistry.LogSourceNames(),
+			CodeIndex:  CodeIndexStatus{Status: "disabled"},
+			APITools:   len(a.getAPIToolDefinitions(t)),
+		}
+		if tc.LogSources == nil {
+			tc.LogSources = []string{}
+		}
+		for _, tool := range a.getAllToolDefinitions(t) {
+			tc.Tools = append(tc.Tools, tool.Name)
+		}
+		if a.config.EnableCodeIndex {
+			tc.CodeIndex.Status = "unavailable"
+			if t.codeIndex != nil {
+				tc.CodeIndex = CodeIndexStatus{Status: "ready", Files: len(t.codeIndex.Files), CreatedAt: &t.codeIndex.CreatedAt}
+			}
+		}
+		c.Targets = append(c.Targets, tc)
+	}
+	return c
+}
+
+// logCapabilities prints a summary of the capabilities at startup
+func (a *Assistant) logCapabilities() {
+	c := a.Capabilities()
+	model := c.Model
+	if model == "" {
+		model = "default model"
+	}
+	log.Printf("[AI Assistant] Ready: %s (%s), auth %s, source %s", c.Provider, model, c.AuthMode, c.Source)
+	for _, t := range c.Targets {
+		log.Printf("[AI Assistant] Target %s: %d tools, %d log sources, code index %s", t.Name, len(t.Tools), len(t.LogSources), t.CodeIndex.Status)
+	}
+	if len(c.Features) > 0 {
+		log.Printf("[AI Assistant] Features: %s", strings.Join(c.Features, ", "))
+	}
+}
+
+// handleCapabilities serves GET /api/capabilities
+func handleCapabilities(w http.ResponseWriter, r *http.Request, a *Assistant) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(a.Capabilities())
+}
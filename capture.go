@@ -0,0 +1,405 @@
+package aiassistant
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/willknow-ai/willknow-go/provider"
+)
+
+const (
+	// replayRequestToolName is the tool that re-sends a captured request
+	replayRequestToolName = "replay_request"
+	// defaultCaptureMinStatus is the lowest status code captured
+	defaultCaptureMinStatus = 500
+	// defaultMaxCapturedRequests is the number of captured requests kept
+	defaultMaxCapturedRequests = 100
+	// defaultMaxCaptureBodyBytes limits the captured request body
+	defaultMaxCaptureBodyBytes = 64 << 10
+	// maxCapturedResponseBytes limits the captured and replayed response bodies
+	maxCapturedResponseBytes = 2 << 10
+	// maxListedCaptures is the number of captures listed by replay_request
+	maxListedCaptures = 20
+)
+
+// defaultCapturedHeaders are the request headers captured. Only headers
+// describing the content are kept: any other header may carry another
+// user's credentials (X-Api-Key, X-Auth-Token, ...), and replays use the
+// current user's own authorization.
+var defaultCapturedHeaders = []string{
+	"Accept",
+	"Accept-Language",
+	"Content-Encoding",
+	"Content-Type",
+	"User-Agent",
+}
+
+// RequestCaptureConfig records the host application's failing requests with
+// Assistant.CaptureMiddleware, so the assistant can re-send one with the
+// replay_request tool and check whether a fix or config change resolved it.
+// Only content headers are captured, never credentials or cookies; replays
+// send the user's own Authorization header, as API tool calls do. Replays
+// of requests that may change data need the user's approval in the web UI.
+type RequestCaptureConfig struct {
+	// Enabled records failing requests and registers replay_request
+	Enabled bool
+
+	// MinStatus is the lowest response status code captured
+	// Default: 500
+	MinStatus int
+
+	// MaxRequests is the number of captured requests kept; the oldest are
+	// dropped first
+	// Default: 100
+	MaxRequests int
+
+	// MaxBodyBytes limits the captured request body; larger bodies are not
+	// captured and their requests cannot be replayed
+	// Default: 64 KB
+	MaxBodyBytes int
+
+	// ReplayBaseURL is where requests are re-sent, e.g. a staging
+	// deployment with a candidate fix
+	// Default: the target's HostBaseURL
+	ReplayBaseURL string
+
+	// Headers are further request headers captured besides Accept,
+	// Accept-Language, Content-Encoding, Content-Type and User-Agent, e.g.
+	// "X-Tenant-ID". Do not add headers carrying credentials.
+	Headers []string
+}
+
+// capturedRequest is a failing request recorded by CaptureMiddleware
+type capturedRequest struct {
+	id        string // request ID, or a generated capture ID
+	time      time.Time
+	method    string
+	uri       string // path and query
+	header    http.Header
+	body      []byte
+	truncated bool // the body exceeded MaxBodyBytes
+	status    int
+	response  string // start of the response body
+}
+
+// requestCapture keeps the latest failing requests in memory
+type requestCapture struct {
+	config  RequestCaptureConfig
+	client  *http.Client
+	headers map[string]bool // canonical names of the captured headers
+
+	mu       sync.Mutex
+	requests []*capturedRequest // oldest first
+	seq      int
+}
+
+// newRequestCapture returns nil unless capture is enabled
+func newRequestCapture(config RequestCaptureConfig) *requestCapture {
+	if !config.Enabled {
+		return nil
+	}
+	if config.MinStatus <= 0 {
+		config.MinStatus = defaultCaptureMinStatus
+	}
+	if config.MaxRequests <= 0 {
+		config.MaxRequests = defaultMaxCapturedRequests
+	}
+	if config.MaxBodyBytes <= 0 {
+		config.MaxBodyBytes = defaultMaxCaptureBodyBytes
+	}
+	c := &requestCapture{
+		config:  config,
+		client:  &http.Client{Timeout: 30 * time.Second},
+		headers: make(map[string]bool),
+	}
+	for _, name := range append(defaultCapturedHeaders, config.Headers...) {
+		c.headers[http.CanonicalHeaderKey(name)] = true
+	}
+	return c
+}
+
+// captureRecorder records the status and start of the body of a response
+type captureRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *captureRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *captureRecorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	if room := maxCapturedResponseBytes - r.body.Len(); room > 0 {
+		r.body.Write(p[:min(room, len(p))])
+	}
+	return r.ResponseWriter.Write(p)
+}
+
+// Flush passes flushes through for streaming handlers
+func (r *captureRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack passes connection takeovers through, e.g. for WebSocket upgrades
+func (r *captureRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("the response writer does not support hijacking")
+	}
+	return h.Hijack()
+}
+
+// Unwrap returns the wrapped writer, for http.ResponseController
+func (r *captureRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// CaptureMiddleware records the host application's requests that fail with
+// a status of at least RequestCaptureConfig.MinStatus, for replay_request.
+// Wrap it in RequestIDMiddleware so captures are found by the request IDs
+// users paste: a.RequestIDMiddleware(a.CaptureMiddleware(mux)).
+// Without RequestCapture.Enabled it returns next unchanged.
+func (a *Assistant) CaptureMiddleware(next http.Handler) http.Handler {
+	c := a.captures
+	if c == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body []byte
+		truncated := false
+		if r.Body != nil {
+			read, _ := io.ReadAll(io.LimitReader(r.Body, int64(c.config.MaxBodyBytes)+1))
+			if len(read) > c.config.MaxBodyBytes {
+				truncated = true
+			} else {
+				body = read
+			}
+			// The handler reads the captured part followed by the rest
+			r.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(read), r.Body), r.Body}
+		}
+
+		rec := &captureRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		if rec.status < c.config.MinStatus {
+			return
+		}
+
+		header := make(http.Header)
+		for name, values := range r.Header {
+			if c.headers[http.CanonicalHeaderKey(name)] {
+				header[name] = values
+			}
+		}
+		id := RequestIDFromContext(r.Context())
+		c.add(&capturedRequest{
+			id:        id,
+			time:      time.Now(),
+			method:    r.Method,
+			uri:       r.URL.RequestURI(),
+			header:    header,
+			body:      body,
+			truncated: truncated,
+			status:    rec.status,
+			response:  rec.body.String(),
+		})
+	})
+}
+
+// add stores a captured request, dropping the oldest beyond MaxRequests
+func (c *requestCapture) add(req *capturedRequest) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seq++
+	if req.id == "" {
+		req.id = fmt.Sprintf("capture-%d", c.seq)
+	}
+	c.requests = append(c.requests, req)
+	if len(c.requests) > c.config.MaxRequests {
+		c.requests = c.requests[len(c.requests)-c.config.MaxRequests:]
+	}
+}
+
+// find returns the latest captured request with the given ID
+func (c *requestCapture) find(id string) *capturedRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := len(c.requests) - 1; i >= 0; i-- {
+		if c.requests[i].id == id {
+			return c.requests[i]
+		}
+	}
+	return nil
+}
+
+// list describes the latest captured requests, newest first
+func (c *requestCapture) list() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.requests) == 0 {
+		return fmt.Sprintf("No failing requests (status %d or above) have been captured yet.", c.config.MinStatus)
+	}
+	var b strings.Builder
+	b.WriteString("Captured failing requests, newest first:\n")
+	for i := len(c.requests) - 1; i >= 0 && len(c.requests)-i <= maxListedCaptures; i-- {
+		req := c.requests[i]
+		fmt.Fprintf(&b, "- %s: %s %s -> %d at %s\n", req.id, req.method, req.uri, req.status, req.time.UTC().Format(time.RFC3339))
+	}
+	return b.String()
+}
+
+// baseURL returns where a target's requests are replayed, or ""
+func (c *requestCapture) baseURL(t *target) string {
+	if c.config.ReplayBaseURL != "" {
+		return c.config.ReplayBaseURL
+	}
+	return t.config.HostBaseURL
+}
+
+// execute lists captured requests or replays one
+func (c *requestCapture) execute(a *Assistant, session *Session, params map[string]interface{}) (string, error) {
+	id, _ := params["request_id"].(string)
+	if id == "" {
+		return c.list(), nil
+	}
+	req := c.find(id)
+	if req == nil {
+		return "", fmt.Errorf("no captured request with ID %s; call replay_request without request_id to list captured requests", id)
+	}
+	if req.truncated {
+		return "", fmt.Errorf("the body of request %s exceeded %d bytes and was not captured, so it cannot be replayed", id, c.config.MaxBodyBytes)
+	}
+	baseURL := c.baseURL(a.sessionTarget(session))
+	if baseURL == "" {
+		return "", fmt.Errorf("no replay target: set RequestCapture.ReplayBaseURL or HostBaseURL")
+	}
+
+	// Requests that may change data need the user's go-ahead
+	if !safeMethod(req.method) {
+		if err := a.approveReplay(session, req, baseURL, params); err != nil {
+			return "", err
+		}
+	}
+
+	replay, err := http.NewRequest(req.method, strings.TrimSuffix(baseURL, "/")+req.uri, bytes.NewReader(req.body))
+	if err != nil {
+		return "", fmt.Errorf("failed to build replay request: %w", err)
+	}
+	for name, values := range req.header {
+		replay.Header[name] = values
+	}
+	// The replay gets a request ID of its own, so its logs can be told apart
+	idHeader := a.requestIDHeader()
+	replay.Header.Del(idHeader)
+	if session.authHeader != "" {
+		replay.Header.Set("Authorization", session.authHeader)
+	}
+
+	start := time.Now()
+	resp, err := c.client.Do(replay)
+	if err != nil {
+		return "", fmt.Errorf("replay failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxCapturedResponseBytes))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Replayed %s %s against %s in %s\n", req.method, req.uri, baseURL, time.Since(start).Round(time.Millisecond))
+	fmt.Fprintf(&b, "Original: status %d at %s\n", req.status, req.time.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&b, "Replay: status %d", resp.StatusCode)
+	if replayID := resp.Header.Get(idHeader); replayID != "" {
+		fmt.Fprintf(&b, " (%s: %s)", idHeader, replayID)
+	}
+	b.WriteString("\n")
+	if resp.StatusCode < c.config.MinStatus {
+		b.WriteString("The request no longer fails.\n")
+	} else {
+		b.WriteString("The request still fails.\n")
+	}
+	if req.response != "" {
+		b.WriteString("\n--- original response ---\n" + req.response + "\n")
+	}
+	if len(body) > 0 {
+		b.WriteString("\n--- replay response ---\n" + string(body) + "\n")
+	}
+	return b.String(), nil
+}
+
+// approveReplay asks the session's user to approve replaying a request that
+// may change data. With Consent covering ConsentWriteAPI, the session's
+// consent was already given before the call; otherwise each replay is
+// approved on its own.
+func (a *Assistant) approveReplay(session *Session, req *capturedRequest, baseURL string, params map[string]interface{}) error {
+	if a.consent != nil && a.consent.capabilities[ConsentWriteAPI] {
+		return nil
+	}
+	session.mu.Lock()
+	ask := session.askConsent
+	session.mu.Unlock()
+	if ask == nil {
+		return fmt.Errorf("request %s is a %s, which may change data when replayed; replays like this need the user's approval in the web UI and are not available here", req.id, req.method)
+	}
+
+	timeout := defaultConsentTimeout
+	approved, answered := ask(ConsentRequest{
+		ID:          generateSessionID(),
+		Capability:  ConsentWriteAPI,
+		Description: fmt.Sprintf("replay %s %s against %s, which may change data", req.method, req.uri, baseURL),
+		Tool:        replayRequestToolName,
+		Input:       params,
+		Once:        true,
+	}, timeout)
+	event := "replay_denied"
+	if approved {
+		event = "replay_approved"
+	}
+	if answered {
+		session.logEvent(event, map[string]interface{}{
+			"request_id": req.id,
+			"method":     req.method,
+			"uri":        req.uri,
+		})
+	}
+	switch {
+	case !answered:
+		return fmt.Errorf("the user did not answer the request to replay %s within %s. Do not replay it; ask the user in your answer", req.id, timeout)
+	case !approved:
+		return fmt.Errorf("the user declined to replay %s. Do not ask again; continue without it", req.id)
+	}
+	return nil
+}
+
+// toolDefinition describes the replay_request tool to the model
+func (c *requestCapture) toolDefinition() provider.Tool {
+	return provider.Tool{
+		Name:        replayRequestToolName,
+		Description: "Re-send a captured failing request of the application (same method, path, query, headers and body, with the user's authorization) and compare the new response with the original one, to confirm whether a fix or config change resolved the error. Without request_id, lists the captured failing requests. Replays of requests other than GET, HEAD and OPTIONS may change data: the user is asked to approve them.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"request_id": map[string]interface{}{
+					"type":        "string",
+					"description": "Optional: Request ID of the captured request to replay; omit to list captured requests",
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,173 @@
+package aiassistant
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/willknow-ai/willknow-go/openapi"
+	"github.com/willknow-ai/willknow-go/provider"
+)
+
+// maxCitationExcerptChars limits the tool output quoted in a citation
+const maxCitationExcerptChars = 600
+
+// citationPrompt tells the model how to cite the numbered tool results
+const citationPrompt = `
+
+Citing evidence:
+Tool results that can back a conclusion start with "[Evidence N]". In your final answer, cite the evidence each finding rests on with its number in square brackets, e.g. "The handler dereferences a nil user [2] for the failing request [3]." Only cite evidence you actually used, and never invent numbers.`
+
+// evidenceRequiredPrompt is added to the system prompt in strict evidence
+// mode once the model concluded without evidence
+const evidenceRequiredPrompt = `
+
+Strict evidence mode: your draft answer to the latest question rested on no tool evidence (no file read, no log searched) and was discarded. Do not conclude from assumptions: gather evidence with the tools first (read the relevant code, search the logs) and cite it. Only if the question cannot be answered with the tools or needs no evidence (a greeting, a clarifying question, a question about yourself), answer without tools and say so.`
+
+// citationRef matches a citation such as [2] in an answer
+var citationRef = regexp.MustCompile(`\[(\d+)\]`)
+
+// Citation is a piece of evidence a final answer rests on: a file range that
+// was read, log entries that matched or an API response
+type Citation struct {
+	ID      int    `json:"id"`      // number the answer cites as [ID]
+	Kind    string `json:"kind"`    // "file", "code", "logs", "api" or "tool"
+	Tool    string `json:"tool"`    // tool that produced the evidence
+	Label   string `json:"label"`   // e.g. "handlers/user.go:10-60" or `logs matching "req-123"`
+	Excerpt string `json:"excerpt"` // start of the tool result
+}
+
+// nonEvidenceTools act on the world or on the assistant's memory instead of
+// producing evidence, so their results are not cited
+var nonEvidenceTools = map[string]bool{
+	memoryToolName:                 true,
+	exportLogsToolName:             true,
+	listWorkspaceToolName:          true,
+	writeWorkspaceToolName:         true,
+	"create_github_issue":          true,
+	"create_github_pull_request":   true,
+	"create_gitlab_issue":          true,
+	"comment_gitlab_merge_request": true,
+	"record_root_cause":            true,
+	writeTestFileToolName:          true,
+	suggestActionsToolName:         true,
+}
+
+// citeToolResult records a successful tool result as evidence of the current
+// answer and returns the result numbered for the model to cite
+func (a *Assistant) citeToolResult(session *Session, name string, input map[string]interface{}, result string) string {
+	if nonEvidenceTools[name] {
+		return result
+	}
+	kind, label := citationLabel(a, session, name, input)
+
+	excerpt := strings.TrimSpace(result)
+	if runes := []rune(excerpt); len(runes) > maxCitationExcerptChars {
+		excerpt = string(runes[:maxCitationExcerptChars]) + "…"
+	}
+
+	session.mu.Lock()
+	session.evidence++
+	id := session.evidence
+	session.citations = append(session.citations, Citation{ID: id, Kind: kind, Tool: name, Label: label, Excerpt: excerpt})
+	session.mu.Unlock()
+
+	return fmt.Sprintf("[Evidence %d]\n%s", id, result)
+}
+
+// citationLabel describes where a piece of evidence came from
+func citationLabel(a *Assistant, session *Session, name string, input map[string]interface{}) (kind, label string) {
+	str := func(key string) string {
+		s, _ := input[key].(string)
+		return s
+	}
+
+	switch name {
+	case "read_file":
+		label = str("file_path")
+		start, _ := input["start_line"].(float64)
+		end, _ := input["end_line"].(float64)
+		switch {
+		case start > 0 && end > 0:
+			label += fmt.Sprintf(":%d-%d", int(start), int(end))
+		case start > 0:
+			label += fmt.Sprintf(":%d", int(start))
+		}
+		return "file", label
+	case "grep":
+		return "code", fmt.Sprintf("code matching %q", str("pattern"))
+	case "glob":
+		return "code", fmt.Sprintf("files matching %s", str("pattern"))
+	case "search_code_index":
+		return "code", fmt.Sprintf("code index search %q", str("query"))
+	case "compare_deployed_source":
+		if p := str("path"); p != "" {
+			return "code", "undeployed changes to " + p
+		}
+		return "code", "undeployed changes on main"
+	case "read_logs":
+		if correlate, _ := input["correlate"].(bool); correlate {
+			return "logs", fmt.Sprintf("log timeline of %q", str("query"))
+		}
+		return "logs", fmt.Sprintf("logs matching %q", str("query"))
+	}
+
+	if openapi.FindTool(a.sessionTarget(session).apiTools, name) != nil ||
+		a.grpcService.FindTool(name) != nil || a.graphqlAPI.FindTool(name) != nil {
+		return "api", name + " API response"
+	}
+	if name == callAgentToolName {
+		return "api", "answer of " + str("agent")
+	}
+	return "tool", name
+}
+
+// lacksEvidence reports whether strict evidence mode discards a response:
+// it is a final answer, no evidence was gathered for it and it cites no
+// evidence of earlier answers
+func (a *Assistant) lacksEvidence(session *Session, response *provider.Response) bool {
+	if !a.config.StrictEvidence {
+		return false
+	}
+	var text string
+	for _, block := range response.Content {
+		switch block.Type {
+		case "tool_use":
+			return false
+		case "text":
+			text += block.Text
+		}
+	}
+	session.mu.Lock()
+	gathered, total := len(session.citations), session.evidence
+	session.mu.Unlock()
+	if gathered > 0 {
+		return false
+	}
+	for _, m := range citationRef.FindAllStringSubmatch(text, -1) {
+		if id, _ := strconv.Atoi(m[1]); id > 0 && id <= total {
+			return false
+		}
+	}
+	return true
+}
+
+// answerCitations returns the evidence gathered for the latest answer
+func (s *Session) answerCitations() []Citation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Citation(nil), s.citations...)
+}
+
+// resetCitations starts collecting evidence for a new answer. Evidence
+// numbers keep counting across answers, so earlier citations stay unambiguous.
+// Failed API calls are forgotten too, so a new question may retry them, and
+// so are the actions suggested with the previous answer.
+func (s *Session) resetCitations() {
+	s.mu.Lock()
+	s.citations = nil
+	s.failedCalls = nil
+	s.suggestions = nil
+	s.mu.Unlock()
+}
@@ -0,0 +1,424 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/websocket"
+	aiassistant "github.com/willknow-ai/willknow-go"
+)
+
+// chatOptions holds the flags of the chat command
+type chatOptions struct {
+	server   string
+	password string
+	headers  headerFlags
+	message  string
+	rest     bool
+	noColor  bool
+	traces   bool
+}
+
+// headerFlags collects repeated -H "Name: value" flags
+type headerFlags []string
+
+func (h *headerFlags) String() string { return strings.Join(*h, ", ") }
+
+func (h *headerFlags) Set(v string) error {
+	if !strings.Contains(v, ":") {
+		return fmt.Errorf("header must be in the form \"Name: value\"")
+	}
+	*h = append(*h, v)
+	return nil
+}
+
+// chatClient is a protocol-agnostic connection to the assistant
+type chatClient interface {
+	// Send sends a user message and invokes the handler for every response event
+	// until the assistant finishes its answer.
+	Send(message string, handle func(aiassistant.ChatResponse)) error
+	Close() error
+}
+
+// runChat implements the "willknow chat" command
+func runChat(args []string) error {
+	var opts chatOptions
+	fs := flag.NewFlagSet("chat", flag.ExitOnError)
+	fs.StringVar(&opts.server, "server", envOr("WILLKNOW_SERVER", "http://localhost:8888"), "Assistant base URL (env WILLKNOW_SERVER)")
+	fs.StringVar(&opts.password, "password", os.Getenv("WILLKNOW_PASSWORD"), "Password for password-protected assistants (env WILLKNOW_PASSWORD)")
+	fs.Var(&opts.headers, "H", "Extra request header, e.g. -H \"Authorization: Bearer <token>\" (repeatable)")
+	fs.StringVar(&opts.message, "m", "", "Send a single message and exit")
+	fs.BoolVar(&opts.rest, "rest", false, "Use the REST endpoint (/willknow/chat) instead of WebSocket")
+	fs.BoolVar(&opts.noColor, "no-color", os.Getenv("NO_COLOR") != "", "Disable ANSI colors")
+	fs.BoolVar(&opts.traces, "traces", true, "Show tool-call traces")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: willknow chat [flags]\n\nPipe a log snippet on stdin to use it as initial context:\n  tail -n 50 app.log | willknow chat -m \"why did this fail?\"\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	header := http.Header{}
+	for _, h := range opts.headers {
+		parts := strings.SplitN(h, ":", 2)
+		header.Add(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
+	}
+
+	if opts.password != "" {
+		cookie, err := login(opts.server, opts.password)
+		if err != nil {
+			return err
+		}
+		header.Add("Cookie", cookie.String())
+	}
+
+	// Piped stdin becomes the initial context of the first message
+	var snippet string
+	interactiveIn := io.Reader(os.Stdin)
+	if stat, err := os.Stdin.Stat(); err == nil && stat.Mode()&os.ModeCharDevice == 0 {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read stdin: %w", err)
+		}
+		snippet = strings.TrimSpace(string(data))
+		// stdin is consumed, read follow-up questions from the terminal if there is one
+		if tty, err := os.Open("/dev/tty"); err == nil {
+			defer tty.Close()
+			interactiveIn = tty
+		} else {
+			interactiveIn = nil
+		}
+	}
+
+	var scanner *bufio.Scanner
+	if interactiveIn != nil {
+		scanner = bufio.NewScanner(interactiveIn)
+	}
+	renderer := newMarkdownRenderer(os.Stdout, !opts.noColor)
+
+	var client chatClient
+	if opts.rest {
+		client = newRESTClient(opts.server, header)
+	} else {
+		ws, err := dialWebSocket(opts.server, header)
+		if err != nil {
+			return err
+		}
+		ws.consent = func(req aiassistant.ConsentRequest) bool {
+			return askConsent(scanner, renderer, req)
+		}
+		client = ws
+	}
+	defer client.Close()
+
+	first := opts.message
+	if snippet != "" {
+		if first == "" {
+			first = "Please analyze this log snippet and explain what went wrong."
+		}
+		first = first + "\n\nLog snippet:\n```\n" + snippet + "\n```"
+	}
+
+	if first != "" {
+		if err := sendAndRender(client, first, renderer, opts); err != nil {
+			return err
+		}
+		if opts.message != "" || interactiveIn == nil {
+			return nil
+		}
+	}
+
+	if interactiveIn == nil {
+		return fmt.Errorf("no message given and no terminal available; use -m")
+	}
+
+	for {
+		fmt.Print(renderer.style(ansiBold, "you> "))
+		if !scanner.Scan() {
+			fmt.Println()
+			return scanner.Err()
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if line == "/quit" || line == "/exit" {
+			return nil
+		}
+		if err := sendAndRender(client, line, renderer, opts); err != nil {
+			return err
+		}
+	}
+}
+
+// sendAndRender sends a message and renders the streamed answer
+func sendAndRender(client chatClient, message string, renderer *markdownRenderer, opts chatOptions) error {
+	return client.Send(message, func(resp aiassistant.ChatResponse) {
+		switch resp.Type {
+		case "text":
+			renderer.Write(resp.Content)
+		case "tool_use":
+			if opts.traces {
+				renderer.Flush()
+				fmt.Println(renderer.style(ansiDim, formatToolCall(resp.ToolName, resp.ToolInput)))
+			}
+		case "tool_result":
+			if opts.traces {
+				color := ansiDim
+				if resp.IsError {
+					color = ansiRed
+				}
+				fmt.Println(renderer.style(color, formatToolResult(resp.Content)))
+			}
+		case "error":
+			renderer.Flush()
+			fmt.Fprintln(os.Stderr, renderer.style(ansiRed, resp.Content))
+		case "maintenance":
+			renderer.Flush()
+			fmt.Fprintln(os.Stderr, renderer.style(ansiYellow, resp.Content))
+		case "done":
+			renderer.Flush()
+			fmt.Println()
+		}
+	})
+}
+
+// askConsent asks the user on the terminal to allow a capability the
+// assistant needs; without a terminal the request is declined
+func askConsent(scanner *bufio.Scanner, renderer *markdownRenderer, req aiassistant.ConsentRequest) bool {
+	renderer.Flush()
+	scope := " for this session"
+	if req.Once {
+		scope = ""
+	}
+	question := fmt.Sprintf("Allow the assistant to %s%s (%s)? [y/N] ", req.Description, scope, req.Tool)
+	if scanner == nil {
+		fmt.Println(renderer.style(ansiYellow, question+"no terminal, declined"))
+		return false
+	}
+	fmt.Print(renderer.style(ansiYellow, question))
+	if !scanner.Scan() {
+		fmt.Println()
+		return false
+	}
+	answer := strings.ToLower(strings.TrimSpace(scanner.Text()))
+	return answer == "y" || answer == "yes"
+}
+
+// login authenticates against a password-protected assistant and returns the session cookie
+func login(server, password string) (*http.Cookie, error) {
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.PostForm(strings.TrimRight(server, "/")+"/auth/login", url.Values{"password": {password}})
+	if err != nil {
+		return nil, fmt.Errorf("login failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	for _, c := range resp.Cookies() {
+		if c.Name == "willknow_session" && c.Value != "" {
+			return &http.Cookie{Name: c.Name, Value: c.Value}, nil
+		}
+	}
+	return nil, fmt.Errorf("login failed: incorrect password")
+}
+
+// --- WebSocket client ---
+
+// wsClient speaks version 2 of the streaming WebSocket protocol of /api/ws
+type wsClient struct {
+	conn    *websocket.Conn
+	legacy  bool                                      // the server predates protocol version 2 and sent version 1 responses
+	consent func(req aiassistant.ConsentRequest) bool // asks the user; nil declines
+}
+
+// dialWebSocket connects to the assistant's WebSocket endpoint
+func dialWebSocket(server string, header http.Header) (*wsClient, error) {
+	u, err := url.Parse(strings.TrimRight(server, "/") + "/api/ws?v=" + strconv.Itoa(aiassistant.ProtocolV2))
+	if err != nil {
+		return nil, fmt.Errorf("invalid server URL: %w", err)
+	}
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+	default:
+		u.Scheme = "ws"
+	}
+
+	conn, resp, err := websocket.DefaultDialer.Dial(u.String(), header)
+	if err != nil {
+		if resp != nil && (resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusFound) {
+			return nil, fmt.Errorf("not authenticated: use -password or -H \"Authorization: ...\"")
+		}
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+
+	// Consume the session greeting
+	var info aiassistant.ClientEvent
+	if err := conn.ReadJSON(&info); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to read session info: %w", err)
+	}
+	return &wsClient{conn: conn, legacy: info.V == 0}, nil
+}
+
+func (c *wsClient) Send(message string, handle func(aiassistant.ChatResponse)) error {
+	if err := c.conn.WriteJSON(aiassistant.ChatMessage{Content: message}); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	for c.legacy {
+		var resp aiassistant.ChatResponse
+		if err := c.conn.ReadJSON(&resp); err != nil {
+			return fmt.Errorf("connection closed: %w", err)
+		}
+		if resp.Type == "consent" && resp.Consent != nil {
+			if err := c.answerConsent(*resp.Consent); err != nil {
+				return err
+			}
+			continue
+		}
+		handle(resp)
+		if resp.Type == "done" {
+			return nil
+		}
+	}
+	for {
+		var event aiassistant.ClientEvent
+		if err := c.conn.ReadJSON(&event); err != nil {
+			return fmt.Errorf("connection closed: %w", err)
+		}
+		if event.Event == aiassistant.EventConsent {
+			var req aiassistant.ConsentRequest
+			if json.Unmarshal(event.Data, &req) == nil {
+				if err := c.answerConsent(req); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if resp, ok := chatResponse(event); ok {
+			handle(resp)
+		}
+		if event.Event == aiassistant.EventDone {
+			return nil
+		}
+	}
+}
+
+// answerConsent asks the user to allow a capability and sends the decision;
+// the assistant waits for it before running the tool
+func (c *wsClient) answerConsent(req aiassistant.ConsentRequest) error {
+	approved := c.consent != nil && c.consent(req)
+	reply := aiassistant.ConsentReply{ID: req.ID, Approved: approved}
+	if err := c.conn.WriteJSON(aiassistant.ChatMessage{Consent: &reply}); err != nil {
+		return fmt.Errorf("failed to send consent: %w", err)
+	}
+	return nil
+}
+
+// chatResponse converts the protocol events the chat command renders;
+// ok is false for events it ignores
+func chatResponse(event aiassistant.ClientEvent) (resp aiassistant.ChatResponse, ok bool) {
+	switch event.Event {
+	case aiassistant.EventDelta:
+		var data aiassistant.DeltaEvent
+		ok = json.Unmarshal(event.Data, &data) == nil
+		return aiassistant.ChatResponse{Type: "text", Content: data.Text}, ok
+	case aiassistant.EventToolStart:
+		var data aiassistant.ToolStartEvent
+		ok = json.Unmarshal(event.Data, &data) == nil
+		return aiassistant.ChatResponse{Type: "tool_use", ToolName: data.Tool, ToolInput: data.Input}, ok
+	case aiassistant.EventToolResult:
+		var data aiassistant.ToolResultEvent
+		ok = json.Unmarshal(event.Data, &data) == nil
+		return aiassistant.ChatResponse{Type: "tool_result", ToolName: data.Tool, Content: data.Output, IsError: data.IsError}, ok
+	case aiassistant.EventError:
+		var data aiassistant.ErrorEvent
+		ok = json.Unmarshal(event.Data, &data) == nil
+		return aiassistant.ChatResponse{Type: "error", Content: data.Message}, ok
+	case aiassistant.EventPaused:
+		var data aiassistant.PausedEvent
+		ok = json.Unmarshal(event.Data, &data) == nil
+		return aiassistant.ChatResponse{Type: "maintenance", Content: data.Message}, ok
+	case aiassistant.EventDone:
+		return aiassistant.ChatResponse{Type: "done"}, true
+	}
+	return aiassistant.ChatResponse{}, false
+}
+
+func (c *wsClient) Close() error {
+	return c.conn.Close()
+}
+
+// --- REST client ---
+
+// restClient speaks the request/response protocol of /willknow/chat
+type restClient struct {
+	endpoint  string
+	header    http.Header
+	sessionID string
+}
+
+// newRESTClient creates a client for the /willknow/chat endpoint
+func newRESTClient(server string, header http.Header) *restClient {
+	return &restClient{
+		endpoint: strings.TrimRight(server, "/") + "/willknow/chat",
+		header:   header,
+	}
+}
+
+func (c *restClient) Send(message string, handle func(aiassistant.ChatResponse)) error {
+	body, _ := json.Marshal(aiassistant.AgentChatRequest{Message: message, SessionID: c.sessionID})
+	req, err := http.NewRequest(http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header = c.header.Clone()
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		handle(aiassistant.ChatResponse{Type: "error", Content: fmt.Sprintf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))})
+		handle(aiassistant.ChatResponse{Type: "done"})
+		return nil
+	}
+
+	var chatResp aiassistant.AgentChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	c.sessionID = chatResp.SessionID
+
+	handle(aiassistant.ChatResponse{Type: "text", Content: chatResp.Message})
+	handle(aiassistant.ChatResponse{Type: "done"})
+	return nil
+}
+
+func (c *restClient) Close() error {
+	return nil
+}
+
+// envOr returns the environment variable value or a fallback
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	aiassistant "github.com/willknow-ai/willknow-go"
+)
+
+// runDoctor implements the "willknow doctor" command
+func runDoctor(args []string) error {
+	var (
+		config   aiassistant.Config
+		logFiles string
+		asJSON   bool
+	)
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	fs.StringVar(&config.Provider, "provider", envOr("WILLKNOW_PROVIDER", "anthropic"), "AI provider (env WILLKNOW_PROVIDER)")
+	fs.StringVar(&config.Model, "model", os.Getenv("WILLKNOW_MODEL"), "Model; empty uses the provider's default (env WILLKNOW_MODEL)")
+	fs.StringVar(&config.APIKey, "api-key", os.Getenv("WILLKNOW_API_KEY"), "Provider API key (env WILLKNOW_API_KEY)")
+	fs.StringVar(&config.BaseURL, "base-url", os.Getenv("WILLKNOW_BASE_URL"), "Provider endpoint (env WILLKNOW_BASE_URL)")
+	fs.StringVar(&config.SourcePath, "source", envOr("WILLKNOW_SOURCE", "/app/source"), "Application source path (env WILLKNOW_SOURCE)")
+	fs.StringVar(&logFiles, "logs", os.Getenv("WILLKNOW_LOGS"), "Comma-separated log files (env WILLKNOW_LOGS)")
+	fs.StringVar(&config.APISpec, "spec", os.Getenv("WILLKNOW_API_SPEC"), "OpenAPI spec file (env WILLKNOW_API_SPEC)")
+	fs.BoolVar(&config.EnableCodeIndex, "code-index", false, "Check (and build, if missing) the code index")
+	fs.BoolVar(&asJSON, "json", false, "Print the report as JSON")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: willknow doctor [flags]\n\nChecks provider connectivity, log files, the source path, the code index\nand the OpenAPI spec with the assistant's configuration, and prints a\ndiagnostic report. Exits with status 1 if a check fails.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if logFiles != "" {
+		config.LogFiles = strings.Split(logFiles, ",")
+	}
+	config.Auth.GetUser = aiassistant.NoAuth
+
+	var report *aiassistant.SelfTestReport
+	assistant, err := aiassistant.New(config)
+	if err != nil {
+		// A configuration the assistant cannot start with is a finding too
+		report = &aiassistant.SelfTestReport{Checks: []aiassistant.SelfTestCheck{{
+			Name:   "configuration",
+			Status: aiassistant.CheckFail,
+			Detail: err.Error(),
+		}}}
+	} else {
+		report = assistant.SelfTest()
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+	} else {
+		report.WriteText(os.Stdout)
+	}
+	if !report.OK() {
+		os.Exit(1)
+	}
+	return nil
+}
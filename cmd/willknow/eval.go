@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	aiassistant "github.com/willknow-ai/willknow-go"
+)
+
+// runEval implements the "willknow eval" command
+func runEval(args []string) error {
+	var (
+		config     aiassistant.Config
+		logFiles   string
+		promptFile string
+		transcript string
+		liveTools  bool
+		asJSON     bool
+	)
+	fs := flag.NewFlagSet("eval", flag.ExitOnError)
+	fs.StringVar(&config.Provider, "provider", envOr("WILLKNOW_PROVIDER", "anthropic"), "Candidate provider (env WILLKNOW_PROVIDER)")
+	fs.StringVar(&config.Model, "model", os.Getenv("WILLKNOW_MODEL"), "Candidate model; empty uses the provider's default (env WILLKNOW_MODEL)")
+	fs.StringVar(&config.APIKey, "api-key", os.Getenv("WILLKNOW_API_KEY"), "Candidate provider API key (env WILLKNOW_API_KEY)")
+	fs.StringVar(&config.BaseURL, "base-url", os.Getenv("WILLKNOW_BASE_URL"), "Candidate provider endpoint (env WILLKNOW_BASE_URL)")
+	fs.StringVar(&config.SourcePath, "source", ".", "Application source path, for -live-tools")
+	fs.StringVar(&logFiles, "logs", "", "Comma-separated log files, for -live-tools")
+	fs.StringVar(&promptFile, "prompt", "", "File with a system prompt to evaluate instead of the built-in one")
+	fs.StringVar(&transcript, "transcript-key", os.Getenv("WILLKNOW_TRANSCRIPT_KEY"), "Base64 key of encrypted session logs (env WILLKNOW_TRANSCRIPT_KEY)")
+	fs.BoolVar(&liveTools, "live-tools", false, "Execute debug tool calls that have no recorded result")
+	fs.BoolVar(&asJSON, "json", false, "Print the report as JSON instead of markdown")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: willknow eval [flags] <session.jsonl>...\n\nReplays recorded sessions against a candidate provider, model or prompt and\ncompares tool choices and final answers with the originals:\n  willknow eval -model claude-sonnet-4-5 sessions/*.jsonl\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	var files []string
+	for _, pattern := range fs.Args() {
+		matches, err := filepath.Glob(pattern)
+		if err != nil || len(matches) == 0 {
+			return fmt.Errorf("no session logs match %s", pattern)
+		}
+		files = append(files, matches...)
+	}
+	if len(files) == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	var opts aiassistant.EvalOptions
+	opts.LiveTools = liveTools
+	if promptFile != "" {
+		data, err := os.ReadFile(promptFile)
+		if err != nil {
+			return fmt.Errorf("failed to read prompt: %w", err)
+		}
+		opts.SystemPrompt = string(data)
+	}
+	if logFiles != "" {
+		config.LogFiles = strings.Split(logFiles, ",")
+	}
+	if transcript != "" {
+		key, err := base64.StdEncoding.DecodeString(transcript)
+		if err != nil {
+			return fmt.Errorf("invalid -transcript-key: %w", err)
+		}
+		config.Transcripts.EncryptionKey = key
+	}
+	config.Auth.GetUser = aiassistant.NoAuth
+
+	assistant, err := aiassistant.New(config)
+	if err != nil {
+		return err
+	}
+	report, err := assistant.Evaluate(files, opts)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	report.WriteMarkdown(os.Stdout)
+	return nil
+}
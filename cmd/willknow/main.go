@@ -0,0 +1,51 @@
+// Command willknow is the command-line companion for the Willknow AI assistant.
+//
+// Usage:
+//
+//	willknow chat [flags]
+//	willknow eval [flags] <session.jsonl>...
+//	willknow doctor [flags]
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+const usage = `Usage: willknow <command> [flags]
+
+Commands:
+  chat    Chat with a running Willknow assistant from the terminal
+  eval    Replay recorded sessions against another provider, model or prompt
+  doctor  Check the assistant's provider, logs, source, code index and API spec
+
+Run "willknow <command> -h" for command flags.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "chat":
+		err = runChat(os.Args[2:])
+	case "eval":
+		err = runEval(os.Args[2:])
+	case "doctor":
+		err = runDoctor(os.Args[2:])
+	case "-h", "--help", "help":
+		fmt.Print(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command: %s\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
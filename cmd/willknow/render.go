@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ANSI escape sequences used by the renderer
+const (
+	ansiReset  = "\033[0m"
+	ansiBold   = "\033[1m"
+	ansiDim    = "\033[2m"
+	ansiItalic = "\033[3m"
+	ansiRed    = "\033[31m"
+	ansiGreen  = "\033[32m"
+	ansiYellow = "\033[33m"
+	ansiCyan   = "\033[36m"
+)
+
+var (
+	inlineCodeRegex = regexp.MustCompile("`([^`]+)`")
+	boldRegex       = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+	italicRegex     = regexp.MustCompile(`\*([^*]+)\*`)
+	headingRegex    = regexp.MustCompile(`^(#{1,6})\s+(.+)$`)
+	listItemRegex   = regexp.MustCompile(`^(\s*)[-*]\s+(.+)$`)
+)
+
+// markdownRenderer renders streamed markdown to a terminal line by line.
+// Partial lines are buffered until a newline arrives or Flush is called.
+type markdownRenderer struct {
+	out     io.Writer
+	color   bool
+	pending string
+	inCode  bool
+}
+
+// newMarkdownRenderer creates a renderer writing to out
+func newMarkdownRenderer(out io.Writer, color bool) *markdownRenderer {
+	return &markdownRenderer{out: out, color: color}
+}
+
+// Write renders a chunk of streamed markdown
+func (r *markdownRenderer) Write(chunk string) {
+	r.pending += chunk
+	for {
+		idx := strings.IndexByte(r.pending, '\n')
+		if idx < 0 {
+			return
+		}
+		r.renderLine(r.pending[:idx])
+		r.pending = r.pending[idx+1:]
+	}
+}
+
+// Flush renders any buffered partial line and resets code block state
+func (r *markdownRenderer) Flush() {
+	if r.pending != "" {
+		r.renderLine(r.pending)
+		r.pending = ""
+	}
+	r.inCode = false
+}
+
+// renderLine renders a single complete markdown line
+func (r *markdownRenderer) renderLine(line string) {
+	if strings.HasPrefix(strings.TrimSpace(line), "```") {
+		r.inCode = !r.inCode
+		fmt.Fprintln(r.out, r.style(ansiDim, line))
+		return
+	}
+	if r.inCode {
+		fmt.Fprintln(r.out, r.style(ansiGreen, "  "+line))
+		return
+	}
+
+	if m := headingRegex.FindStringSubmatch(line); m != nil {
+		fmt.Fprintln(r.out, r.style(ansiBold+ansiCyan, r.inline(m[2])))
+		return
+	}
+	if m := listItemRegex.FindStringSubmatch(line); m != nil {
+		fmt.Fprintln(r.out, m[1]+"• "+r.inline(m[2]))
+		return
+	}
+	fmt.Fprintln(r.out, r.inline(line))
+}
+
+// inline applies inline code, bold and italic styling
+func (r *markdownRenderer) inline(text string) string {
+	if !r.color {
+		return text
+	}
+	text = inlineCodeRegex.ReplaceAllString(text, ansiYellow+"$1"+ansiReset)
+	text = boldRegex.ReplaceAllString(text, ansiBold+"$1"+ansiReset)
+	text = italicRegex.ReplaceAllString(text, ansiItalic+"$1"+ansiReset)
+	return text
+}
+
+// style wraps text in an ANSI style when color is enabled
+func (r *markdownRenderer) style(code, text string) string {
+	if !r.color {
+		return text
+	}
+	return code + text + ansiReset
+}
+
+// formatToolCall renders a one-line trace of a tool invocation
+func formatToolCall(name string, input map[string]interface{}) string {
+	keys := make([]string, 0, len(input))
+	for k := range input {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var args []string
+	for _, k := range keys {
+		args = append(args, fmt.Sprintf("%s=%v", k, input[k]))
+	}
+	return fmt.Sprintf("⚙ %s(%s)", name, strings.Join(args, ", "))
+}
+
+// formatToolResult renders the first line of a tool result for the trace
+func formatToolResult(result string) string {
+	first := strings.SplitN(strings.TrimSpace(result), "\n", 2)[0]
+	if runes := []rune(first); len(runes) > 120 {
+		first = string(runes[:120]) + "..."
+	}
+	return "  ↳ " + first
+}
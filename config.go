@@ -1,15 +1,159 @@
 package aiassistant
 
+import (
+	"io/fs"
+
+	"github.com/willknow-ai/willknow-go/graphqlapi"
+	"github.com/willknow-ai/willknow-go/grpcapi"
+	"github.com/willknow-ai/willknow-go/provider"
+	"github.com/willknow-ai/willknow-go/tools"
+)
+
+// GitHubConfig configures the GitHub issue and pull request tools.
+// See tools.GitHubConfig for the available fields.
+type GitHubConfig = tools.GitHubConfig
+
+// GitLabConfig configures the GitLab issue and merge request tools.
+// See tools.GitLabConfig for the available fields.
+type GitLabConfig = tools.GitLabConfig
+
+// SentryConfig configures the Sentry error context tool.
+// See tools.SentryConfig for the available fields.
+type SentryConfig = tools.SentryConfig
+
+// LogSource is a log backend searched by the read_logs tool.
+// See tools.LogSource.
+type LogSource = tools.LogSource
+
+// LokiConfig configures the Grafana Loki log source.
+// See tools.LokiConfig for the available fields.
+type LokiConfig = tools.LokiConfig
+
+// ElasticsearchConfig configures the Elasticsearch/OpenSearch log source.
+// See tools.ElasticsearchConfig for the available fields.
+type ElasticsearchConfig = tools.ElasticsearchConfig
+
+// CloudLoggingConfig configures the Google Cloud Logging log source.
+// See tools.CloudLoggingConfig for the available fields.
+type CloudLoggingConfig = tools.CloudLoggingConfig
+
+// TracingConfig configures the Jaeger/Tempo trace tool.
+// See tools.TracingConfig for the available fields.
+type TracingConfig = tools.TracingConfig
+
+// PrometheusConfig configures the PromQL metrics tool.
+// See tools.PrometheusConfig for the available fields.
+type PrometheusConfig = tools.PrometheusConfig
+
+// DatabaseConfig registers the application's database for schema introspection.
+// See tools.DatabaseConfig for the available fields.
+type DatabaseConfig = tools.DatabaseConfig
+
+// RedisConfig configures the read-only Redis inspection tool.
+// See tools.RedisConfig for the available fields.
+type RedisConfig = tools.RedisConfig
+
+// KafkaConfig configures the Kafka inspection tool.
+// See tools.KafkaConfig for the available fields.
+type KafkaConfig = tools.KafkaConfig
+
+// KnowledgeBaseConfig configures the root-cause knowledge base.
+// See tools.KnowledgeBaseConfig for the available fields.
+type KnowledgeBaseConfig = tools.KnowledgeBaseConfig
+
+// KubernetesConfig configures the read-only Kubernetes tool.
+// See tools.KubernetesConfig for the available fields.
+type KubernetesConfig = tools.KubernetesConfig
+
+// RunbooksConfig configures the runbooks searched by search_runbooks.
+// See tools.RunbooksConfig for the available fields.
+type RunbooksConfig = tools.RunbooksConfig
+
+// SnippetConfig configures the run_snippet tool.
+// See tools.SnippetConfig for the available fields.
+type SnippetConfig = tools.SnippetConfig
+
+// LogFilterConfig configures the level and noise filters of read_logs.
+// See tools.LogFilterConfig for the available fields.
+type LogFilterConfig = tools.LogFilterConfig
+
+// OwnershipConfig configures the owners of source files (CODEOWNERS).
+// See tools.OwnershipConfig for the available fields.
+type OwnershipConfig = tools.OwnershipConfig
+
+// GitSourceConfig fetches the source code from a git remote.
+// See tools.GitSourceConfig for the available fields.
+type GitSourceConfig = tools.GitSourceConfig
+
+// GitAuth holds credentials for GitSourceConfig.
+// See tools.GitAuth for the available fields.
+type GitAuth = tools.GitAuth
+
+// GRPCConfig configures gRPC reflection-based agent tools.
+// See grpcapi.Config for the available fields.
+type GRPCConfig = grpcapi.Config
+
+// GraphQLConfig configures GraphQL schema-based agent tools.
+// See graphqlapi.Config for the available fields.
+type GraphQLConfig = graphqlapi.Config
+
 // Config holds the configuration for the AI Assistant
 type Config struct {
-	// SourcePath is the path to the application source code
+	// SourcePath is the path to the application source code: a directory or a
+	// .zip, .tar, .tar.gz or .tgz archive (for containers that ship the source
+	// as a single file). Archives with one top-level directory are served from
+	// inside it.
 	// Default: /app/source
 	SourcePath string
 
+	// SourceFS serves the source code from a file system instead of
+	// SourcePath, e.g. an embed.FS compiled into the binary:
+	//
+	//	//go:embed *.go internal
+	//	var sourceFS embed.FS
+	//
+	// Default: nil (use SourcePath)
+	SourceFS fs.FS
+
+	// GitSource clones the source code from a git remote at startup (shallow,
+	// into a local cache) and re-fetches it periodically, for deployments
+	// where mounting the source isn't feasible. Used instead of SourcePath
+	// when URL is set and SourceFS is nil.
+	// Default: disabled (empty URL)
+	GitSource GitSourceConfig
+
+	// BuildRevision is the VCS revision of the running build. The assistant
+	// warns when the source it reads is at another revision, tells the model
+	// which revision it analyzes, and GitSource checks it out when Ref is empty.
+	// Set it (e.g. with -ldflags "-X main.revision=...") for builds without
+	// VCS stamping, such as Docker builds without the .git directory.
+	// Default: vcs.revision from runtime/debug.ReadBuildInfo
+	BuildRevision string
+
 	// LogFiles are the paths to log files
-	// If empty, the assistant will try to auto-detect log files on startup
+	// If empty and no other log source is configured, the assistant will try
+	// to auto-detect log files on startup
 	LogFiles []string
 
+	// LogSources are additional log backends searched by read_logs, for logs
+	// that do not live on local disk. Implement LogSource for custom backends,
+	// or use tools.NewLogBuffer (an in-memory io.Writer for apps that never
+	// write log files), tools.NewReaderLogSource or tools.NewFuncLogSource.
+	LogSources []LogSource
+
+	// Loki adds a Grafana Loki log source queried with LogQL.
+	// Default: disabled (empty URL)
+	Loki LokiConfig
+
+	// Elasticsearch adds an Elasticsearch/OpenSearch log source (ELK stack).
+	// Default: disabled (empty URL)
+	Elasticsearch ElasticsearchConfig
+
+	// CloudLogging adds a Google Cloud Logging log source, for Cloud Run and
+	// GKE apps whose stdout logs only live in Cloud Logging.
+	// Default: disabled (Enabled is false)
+	CloudLogging CloudLoggingConfig
+
 	// Port is the port to run the web UI on
 	// Default: 8888
 	Port int
@@ -28,6 +172,7 @@ type Config struct {
 
 	// BaseURL is the custom API endpoint (for custom or self-hosted providers)
 	// If empty, uses the provider's default endpoint
+	// For Provider="anthropic" it is the root serving /v1/messages
 	// Required for Provider="custom"
 	BaseURL string
 
@@ -56,6 +201,346 @@ type Config struct {
 	// AgentInfo describes this agent's identity for the /willknow/info discovery endpoint.
 	// Defaults to values from the OpenAPI spec's info section.
 	AgentInfo AgentInfo
+
+	// GRPC points the assistant at a gRPC server with reflection enabled.
+	// Like APISpec, it turns the assistant into an agent: each unary method
+	// becomes a callable tool, with JSON arguments transcoded to protobuf.
+	// Default: disabled (empty Target)
+	GRPC GRPCConfig
+
+	// GraphQL points the assistant at a GraphQL endpoint. Like APISpec, it turns
+	// the assistant into an agent: each query and mutation field becomes a
+	// callable tool whose arguments are derived from the schema's input types.
+	// Default: disabled (empty Endpoint)
+	GraphQL GraphQLConfig
+
+	// Teams configures the Microsoft Teams connector.
+	// See TeamsConfig for details.
+	// Default: disabled
+	Teams TeamsConfig
+
+	// Webhooks receive a JSON payload when the assistant completes an analysis
+	// or an incident is reported. See WebhookConfig and WebhookPayload.
+	// Default: none
+	Webhooks []WebhookConfig
+
+	// GitHub enables tools that turn a diagnosis into a GitHub issue or a
+	// suggested fix into a draft pull request.
+	// Default: disabled (empty Token)
+	GitHub GitHubConfig
+
+	// GitLab enables tools that turn a diagnosis into a GitLab issue or post
+	// the analysis as a comment on the offending merge request.
+	// Default: disabled (empty Token)
+	GitLab GitLabConfig
+
+	// Sentry enables a tool that pulls the latest event, breadcrumbs and
+	// release info for a Sentry issue the user references.
+	// Default: disabled (empty AuthToken)
+	Sentry SentryConfig
+
+	// Alerts enables PagerDuty/Opsgenie webhooks that trigger automated analyses.
+	// See AlertsConfig for details.
+	// Default: disabled
+	Alerts AlertsConfig
+
+	// Tracing enables the get_trace tool backed by Jaeger or Tempo.
+	// Default: disabled (empty URL)
+	Tracing TracingConfig
+
+	// Prometheus enables the query_metrics tool for PromQL queries.
+	// Default: disabled (empty URL)
+	Prometheus PrometheusConfig
+
+	// Database registers the application's *sql.DB for the describe_schema tool.
+	// Default: disabled (nil DB)
+	Database DatabaseConfig
+
+	// Redis enables the read-only inspect_redis tool.
+	// Default: disabled (empty Addr)
+	Redis RedisConfig
+
+	// Kafka enables the inspect_kafka tool for topics, consumer lag and messages.
+	// Default: disabled (no Brokers)
+	Kafka KafkaConfig
+
+	// Digest emails a daily or weekly summary of errors detected, analyses
+	// performed and top recurring issues. See DigestConfig for details.
+	// Default: disabled (empty SMTPHost)
+	Digest DigestConfig
+
+	// KnowledgeBase stores confirmed root-cause reports (Markdown folder,
+	// Notion or Confluence) and lets the assistant search past incidents.
+	// Default: disabled (no store configured)
+	KnowledgeBase KnowledgeBaseConfig
+
+	// Kubernetes enables the read-only inspect_kubernetes tool (pods, events,
+	// resource limits) for the application's namespace.
+	// Default: disabled (InCluster false and empty Kubeconfig)
+	Kubernetes KubernetesConfig
+
+	// Targets registers additional services, each with its own SourcePath,
+	// LogFiles, APISpec and code index, so one assistant can debug several
+	// services. The top-level fields form the "default" target; users pick a
+	// target in the UI or with the "target" field of /willknow/chat.
+	// Default: none (single target)
+	Targets []TargetConfig
+
+	// Memory lets the assistant save facts about each user's systems with
+	// the memory tool and recall them in later sessions. See MemoryConfig.
+	// Default: disabled (empty Dir)
+	Memory MemoryConfig
+
+	// Runbooks points at a folder and/or URLs of documented procedures. They
+	// are indexed separately from the code and searched with search_runbooks,
+	// and the assistant is told to prefer them for known failure modes.
+	// Default: disabled (empty Dir and no URLs)
+	Runbooks RunbooksConfig
+
+	// Scheduler runs predefined analysis prompts unattended on cron schedules
+	// (e.g. a nightly summary of ERROR logs grouped by cause) and delivers the
+	// results via webhooks and email. See SchedulerConfig.
+	// Default: disabled (no Jobs)
+	Scheduler SchedulerConfig
+
+	// Anomalies keeps a statistical baseline of log volume and error
+	// signatures and, when a window deviates, has the assistant explain it.
+	// See AnomalyConfig.
+	// Default: disabled
+	Anomalies AnomalyConfig
+
+	// Budget caps daily/monthly token or dollar spend. Near a limit the
+	// assistant switches to a cheaper model and stops indexing; past it, new
+	// chats are refused with a clear message. See BudgetConfig.
+	// Default: no limits
+	Budget BudgetConfig
+
+	// Redaction masks API keys, tokens, emails and custom patterns in tool
+	// results, log excerpts and file contents before they are sent to the
+	// provider. See RedactionConfig.
+	// Default: disabled
+	Redaction RedactionConfig
+
+	// AirGapped refuses to start unless every outbound destination (the model
+	// endpoint, host APIs, log backends and integrations) is an internal host
+	// in AirGappedHosts. Spec-from-URL and SaaS-only integrations are refused.
+	// The guarantee is reported by /willknow/info.
+	// Default: false
+	AirGapped bool
+
+	// AirGappedHosts allowlists internal destinations in air-gapped mode:
+	// host names ("llm.internal"), domain suffixes ("*.corp.example.com"),
+	// IPs or CIDRs ("10.0.0.0/8"). Hosts must also resolve to private addresses.
+	AirGappedHosts []string
+
+	// Sharing lets users create expiring, signed read-only links to a
+	// conversation for teammates without assistant credentials.
+	// See SharingConfig.
+	// Default: disabled
+	Sharing SharingConfig
+
+	// Guardrails are policies checked before every tool call: denied source
+	// paths, allowed hosts, maximum log query ranges and a custom callback.
+	// See GuardrailsConfig.
+	// Default: no policies
+	Guardrails GuardrailsConfig
+
+	// Peers registers the assistants of other services (their /willknow/info
+	// URLs). The model can delegate questions about them with the call_agent
+	// tool and combine their answers with its own findings.
+	// Default: none
+	Peers []PeerAgentConfig
+
+	// Editor links file:line references in answers to the users' editor
+	// (VS Code, Cursor, JetBrains or a custom URL scheme). See EditorConfig.
+	// Default: references are not linked
+	Editor EditorConfig
+
+	// SourceViewerSecrets lets the source viewer (/api/source) show
+	// environment files and files matching DefaultSecretPaths. Otherwise
+	// they are refused, since the viewer does not ask for consent like the
+	// file tools do. Shown files are redacted either way (see Redaction).
+	// Default: false
+	SourceViewerSecrets bool
+
+	// Transcripts encrypts session logs at rest (key from config or a KMS
+	// callback) and excludes event types from them. See TranscriptConfig.
+	// Default: plaintext logs of every event
+	Transcripts TranscriptConfig
+
+	// LogFileRoles restricts log files to users with one of the listed roles
+	// (User.Roles), keyed by log file path or LogSource name, e.g.
+	// {"/var/log/app/audit.log": {"admin"}}. read_logs skips restricted files
+	// for other users. It applies to every target.
+	// Default: every user may query every log file
+	LogFileRoles map[string][]string
+
+	// RequestID registers the application's request ID header and format, so
+	// IDs in pasted error responses are looked up in the logs right away.
+	// See RequestIDConfig and Assistant.RequestIDMiddleware.
+	// Default: disabled
+	RequestID RequestIDConfig
+
+	// OperationalLog is a file the assistant writes its own failures to as
+	// JSON lines: provider errors, tool errors, blocked tool calls and
+	// authentication denials. It is searchable with read_logs, so users can
+	// ask the assistant why it misbehaved (e.g. "./sessions/willknow.log").
+	// Default: "" (disabled)
+	OperationalLog string
+
+	// Snippets enables the opt-in run_snippet tool, which compiles and runs
+	// short Go programs in a resource-limited subprocess to check the
+	// behavior of a suspect function. It needs a Go toolchain on the host.
+	// See SnippetConfig.
+	// Default: disabled (Enabled is false)
+	Snippets SnippetConfig
+
+	// RequestCapture records the application's failing requests (with
+	// Assistant.CaptureMiddleware) so the replay_request tool can re-send
+	// them after a fix. See RequestCaptureConfig.
+	// Default: disabled (Enabled is false)
+	RequestCapture RequestCaptureConfig
+
+	// Environments define the application's deployment environments (dev,
+	// staging, prod), each with its own log sources, base URL, model and
+	// guardrails. See EnvironmentConfig.
+	// Default: none
+	Environments []EnvironmentConfig
+
+	// Environment selects the environment applied at startup; the others
+	// stay selectable per conversation.
+	// Default: "" (the top-level configuration as is)
+	Environment string
+
+	// Workspace gives each session a scratch directory for artifacts such
+	// as log exports and generated patches, downloadable by the session's
+	// user and deleted after a retention period. See WorkspaceConfig.
+	// Default: disabled (empty Dir)
+	Workspace WorkspaceConfig
+
+	// StrictEvidence rejects final answers that rest on no tool evidence (no
+	// file read, no log searched, no earlier evidence cited): the draft is
+	// discarded once and the model is told to gather evidence first, which
+	// reduces diagnoses made up from assumptions.
+	// Default: false
+	StrictEvidence bool
+
+	// MetricsToken lets Prometheus scrape the per-tool efficacy metrics at
+	// /willknow/metrics with "Authorization: Bearer <token>"; users can
+	// always read them after authenticating.
+	// Default: "" (authenticated users only)
+	MetricsToken string
+
+	// AnalyzeAPI enables POST /willknow/analyze for CI, alerting and
+	// ticketing systems: an asynchronous analysis of a request ID, error
+	// and time window whose report is POSTed to a callback URL.
+	// See AnalyzeAPIConfig.
+	// Default: disabled (empty Token)
+	AnalyzeAPI AnalyzeAPIConfig
+
+	// Greeting is the welcome message of the web UI: a description of the
+	// application, example questions and the capabilities detected at
+	// startup. See GreetingConfig.
+	// Default: a generic welcome listing the capabilities
+	Greeting GreetingConfig
+
+	// Quotas limit the questions and tokens of each authenticated user per
+	// day, for deployments shared by a whole organization. Users see their
+	// remaining quota in the UI header. See UserQuotaConfig.
+	// Default: no limits
+	Quotas UserQuotaConfig
+
+	// DeadLetterAfter is the number of failures of an identical host API
+	// call within one answer after which the call is not retried: it is
+	// recorded as a dead letter, listed at /willknow/dead-letters (with the
+	// same access as /willknow/metrics), and the assistant reports the
+	// concrete failure instead.
+	// Default: 2
+	DeadLetterAfter int
+
+	// Consent asks the user in the web UI before the first use, in each
+	// session, of sensitive capabilities: reading environment files, querying
+	// the database, calling write APIs and running code. Decisions are
+	// recorded in the session log. See ConsentConfig.
+	// Default: disabled
+	Consent ConsentConfig
+
+	// Ownership annotates source files with their owners from CODEOWNERS
+	// (or, with GitDir, their recent committers), adds the find_owners tool
+	// and has diagnoses name the owner of the faulty code ("owned by
+	// @payments-team"). GitDir defaults to SourcePath if it is a git
+	// checkout. See OwnershipConfig.
+	// Default: disabled
+	Ownership OwnershipConfig
+
+	// OwnerChannels are webhooks of owners, keyed by owner as written in
+	// CODEOWNERS (e.g. "@payments-team"). When an answer cites files of an
+	// owner, the owner's channel receives an owner_notified payload whose
+	// text field suits Slack-compatible incoming webhooks. Needs Ownership.
+	// Default: none
+	OwnerChannels map[string]WebhookConfig
+
+	// TestGeneration adds a "Write failing test" action under answers: the
+	// assistant writes a Go test reproducing the diagnosed bug to the session
+	// workspace (or the source tree) and can run it to confirm it fails.
+	// See TestGenerationConfig.
+	// Default: disabled
+	TestGeneration TestGenerationConfig
+
+	// Tokenizer counts tokens for the context window, tool result limits and
+	// cost estimates, e.g. a tiktoken encoding wrapped in
+	// provider.TokenizerFunc for OpenAI-compatible models. Requests to
+	// Anthropic near MaxContextTokens are counted exactly with its
+	// count_tokens endpoint (redacted, and never in air-gapped mode).
+	// Default: provider.EstimateTokenizer
+	Tokenizer provider.Tokenizer
+
+	// MaxContextTokens is the size of the model's context window. When a
+	// request would exceed it, the oldest tool results of the conversation
+	// are elided until it fits.
+	// Default: 0 (requests are not trimmed)
+	MaxContextTokens int
+
+	// MaxToolResultTokens truncates longer tool results, so that a single
+	// large file or log search cannot fill the context window
+	// Default: 20000
+	MaxToolResultTokens int
+
+	// LogFilter sets the lowest level read_logs returns and the noisy
+	// entries (health checks, readiness probes, metrics scrapes) it leaves
+	// out. It applies to every target. See LogFilterConfig.
+	// Default: every level, without tools.DefaultLogNoisePatterns
+	LogFilter LogFilterConfig
+
+	// Fixtures records the provider's responses and the tools' results, or
+	// replays them without network access, for end-to-end tests of the
+	// integration. See FixturesConfig.
+	// Default: disabled
+	Fixtures FixturesConfig
+
+	// Maintenance starts the assistant paused: no LLM call is made and no
+	// tool runs, and users see MaintenanceMessage. It can be switched at
+	// runtime with Assistant.SetMaintenance or the admin API.
+	// Default: false
+	Maintenance bool
+
+	// MaintenanceMessage is shown to users while the assistant is paused
+	// Default: "The AI assistant is paused for maintenance. Please try again later."
+	MaintenanceMessage string
+
+	// AdminToken enables the admin API at /willknow/maintenance, called with
+	// "Authorization: Bearer <token>": GET returns the maintenance state,
+	// POST {"enabled": true, "message": "..."} pauses or resumes the
+	// assistant without redeploying the host application.
+	// Default: "" (admin API disabled)
+	AdminToken string
+
+	// Experiment assigns a percentage of sessions to other providers, models
+	// or system prompts, tagging session logs with the variant so answer
+	// quality and cost can be compared across them. Statistics per variant
+	// are served at /willknow/experiments.
+	// Default: no experiment
+	Experiment ExperimentConfig
 }
 
 // AgentInfo holds identity information for the agent discovery endpoint
@@ -80,6 +565,12 @@ func (c *Config) setDefaults() {
 	if c.Provider == "" {
 		c.Provider = "anthropic"
 	}
+	if c.Tokenizer == nil {
+		c.Tokenizer = provider.EstimateTokenizer{}
+	}
+	if c.MaxToolResultTokens == 0 {
+		c.MaxToolResultTokens = defaultMaxToolResultTokens
+	}
 	// EnableCodeIndex defaults to false (disabled)
 	// Model defaults are set by the provider if not specified
 }
@@ -0,0 +1,273 @@
+package aiassistant
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/willknow-ai/willknow-go/openapi"
+)
+
+// Sensitive capabilities that can require the user's consent
+const (
+	// ConsentEnvFiles covers reading environment files (.env, .env.*, *.env)
+	ConsentEnvFiles = "env_files"
+	// ConsentDatabase covers inspecting the database and Redis
+	ConsentDatabase = "database"
+	// ConsentWriteAPI covers host API calls that may change data: REST
+	// operations and replayed requests other than GET, HEAD and OPTIONS,
+	// GraphQL mutations, and gRPC methods whose idempotency_level is not
+	// NO_SIDE_EFFECTS
+	ConsentWriteAPI = "write_api"
+	// ConsentCodeExecution covers running Go snippets and tests
+	ConsentCodeExecution = "code_execution"
+)
+
+// defaultConsentTimeout is how long the assistant waits for a decision
+const defaultConsentTimeout = 5 * time.Minute
+
+// consentDescriptions explain the capabilities in consent prompts
+var consentDescriptions = map[string]string{
+	ConsentEnvFiles:      "read environment files, which often contain secrets",
+	ConsentDatabase:      "query the database",
+	ConsentWriteAPI:      "call application APIs that may change data",
+	ConsentCodeExecution: "run code",
+}
+
+// ConsentConfig asks the user before the first use, in each session, of a
+// sensitive capability. The web UI shows the request with Allow and Deny
+// buttons; the decision applies to the rest of the session and is recorded
+// in the session log and the operational log. Sessions without the web UI
+// (the chat API, A2A, Teams and automated analyses) cannot give consent, so
+// these capabilities are not available to them.
+type ConsentConfig struct {
+	// Enabled asks for consent
+	Enabled bool
+
+	// Capabilities that need consent: ConsentEnvFiles, ConsentDatabase,
+	// ConsentWriteAPI and ConsentCodeExecution
+	// Default: all of them
+	Capabilities []string
+
+	// Tools are further tool names that need consent, each on its own
+	Tools []string
+
+	// Timeout is how long the assistant waits for the user's decision
+	// Default: 5 minutes
+	Timeout time.Duration
+}
+
+// ConsentRequest asks the user to allow a capability, sent to the web UI
+// with a "consent" response
+type ConsentRequest struct {
+	ID          string                 `json:"id"`
+	Capability  string                 `json:"capability"`
+	Description string                 `json:"description"` // e.g. "query the database"
+	Tool        string                 `json:"tool"`
+	Input       map[string]interface{} `json:"input,omitempty"`
+	Once        bool                   `json:"once,omitempty"` // the decision applies to this call only
+}
+
+// ConsentReply is the user's decision, sent by the web UI as the consent
+// field of a chat message
+type ConsentReply struct {
+	ID       string `json:"id"`
+	Approved bool   `json:"approved"`
+}
+
+// consentPolicy decides which tool calls need consent
+type consentPolicy struct {
+	config       ConsentConfig
+	capabilities map[string]bool
+	tools        map[string]bool
+}
+
+// newConsentPolicy returns nil unless consent is enabled
+func newConsentPolicy(config ConsentConfig) (*consentPolicy, error) {
+	if !config.Enabled {
+		return nil, nil
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultConsentTimeout
+	}
+	if len(config.Capabilities) == 0 {
+		config.Capabilities = []string{ConsentEnvFiles, ConsentDatabase, ConsentWriteAPI, ConsentCodeExecution}
+	}
+	p := &consentPolicy{config: config, capabilities: make(map[string]bool), tools: make(map[string]bool)}
+	for _, capability := range config.Capabilities {
+		if consentDescriptions[capability] == "" {
+			return nil, fmt.Errorf("unknown consent capability %q", capability)
+		}
+		p.capabilities[capability] = true
+	}
+	for _, name := range config.Tools {
+		p.tools[name] = true
+	}
+	return p, nil
+}
+
+// capability returns the sensitive capability a tool call uses, with its
+// description, or "" if it needs no consent
+func (p *consentPolicy) capability(a *Assistant, t *target, name string, input map[string]interface{}) (string, string) {
+	if p.tools[name] {
+		return "tool:" + name, "use the " + name + " tool"
+	}
+
+	capability := ""
+	switch name {
+	case "read_file", "grep", "glob":
+		for _, key := range []string{"file_path", "file_pattern", "pattern"} {
+			if p, _ := input[key].(string); isEnvFile(p) {
+				capability = ConsentEnvFiles
+			}
+		}
+	case "describe_schema", "inspect_redis":
+		capability = ConsentDatabase
+	case "run_snippet", runGoTestToolName:
+		capability = ConsentCodeExecution
+	default:
+		if apiTool := openapi.FindTool(t.apiTools, name); apiTool != nil {
+			if !safeMethod(apiTool.Method) {
+				capability = ConsentWriteAPI
+			}
+		} else if gqlTool := a.graphqlAPI.FindTool(name); gqlTool != nil && gqlTool.Operation == "mutation" {
+			capability = ConsentWriteAPI
+		} else if grpcTool := a.grpcService.FindTool(name); grpcTool != nil && !grpcTool.ReadOnly {
+			capability = ConsentWriteAPI
+		}
+	case replayRequestToolName:
+		// Listing captures is harmless; replays are checked by method
+		id, _ := input["request_id"].(string)
+		if id != "" && a.captures != nil {
+			if req := a.captures.find(id); req != nil && !safeMethod(req.method) {
+				capability = ConsentWriteAPI
+			}
+		}
+	}
+	if capability == "" || !p.capabilities[capability] {
+		return "", ""
+	}
+	return capability, consentDescriptions[capability]
+}
+
+// safeMethod reports whether an HTTP method does not change data
+func safeMethod(method string) bool {
+	switch strings.ToUpper(method) {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// isEnvFile reports whether a path or pattern names an environment file
+func isEnvFile(p string) bool {
+	base := path.Base(strings.ReplaceAll(p, "\\", "/"))
+	return base == ".env" || strings.HasPrefix(base, ".env.") || strings.HasSuffix(base, ".env")
+}
+
+// checkConsent asks the session's user before the first use of a sensitive
+// capability and returns an error explaining why the call may not run
+func (a *Assistant) checkConsent(session *Session, t *target, name string, input map[string]interface{}) error {
+	p := a.consent
+	if p == nil {
+		return nil
+	}
+	capability, description := p.capability(a, t, name, input)
+	if capability == "" {
+		return nil
+	}
+
+	session.mu.Lock()
+	approved, decided := session.consents[capability]
+	ask := session.askConsent
+	session.mu.Unlock()
+	if decided {
+		if approved {
+			return nil
+		}
+		return fmt.Errorf("the user declined to let you %s in this session. Do not ask again; continue without it or tell the user what you could not check", description)
+	}
+	if ask == nil {
+		return fmt.Errorf("this call would %s, which needs the user's consent in the web UI and is not available here. Continue without it or tell the user what you could not check", description)
+	}
+
+	req := ConsentRequest{
+		ID:          generateSessionID(),
+		Capability:  capability,
+		Description: description,
+		Tool:        name,
+		Input:       input,
+	}
+	approved, answered := ask(req, p.config.Timeout)
+	if !answered {
+		return fmt.Errorf("the user did not answer the request to %s within %s. Continue without it or ask the user in your answer", description, p.config.Timeout)
+	}
+
+	session.mu.Lock()
+	if session.consents == nil {
+		session.consents = make(map[string]bool)
+	}
+	session.consents[capability] = approved
+	session.mu.Unlock()
+
+	event := "consent_denied"
+	if approved {
+		event = "consent_granted"
+	}
+	log.Printf("[Session %s] %s: %s (%s)", session.ID, strings.ReplaceAll(event, "_", " "), capability, name)
+	session.logEvent(event, map[string]interface{}{
+		"capability": capability,
+		"tool_name":  name,
+		"input":      input,
+	})
+	if a.ops != nil {
+		fields := a.ops.sessionFields(session)
+		fields["capability"] = capability
+		fields["tool"] = name
+		a.ops.record("info", event, fields)
+	}
+	if !approved {
+		return fmt.Errorf("the user declined to let you %s. Do not ask again; continue without it or tell the user what you could not check", description)
+	}
+	return nil
+}
+
+// askConsent sends a consent request to the client and waits for the
+// decision. The request is replayed to a client reconnecting meanwhile.
+func (ws *wsSession) askConsent(req ConsentRequest, timeout time.Duration) (approved, answered bool) {
+	reply := make(chan bool, 1)
+	ws.mu.Lock()
+	if ws.consents == nil {
+		ws.consents = make(map[string]chan bool)
+	}
+	ws.consents[req.ID] = reply
+	ws.mu.Unlock()
+	defer func() {
+		ws.mu.Lock()
+		delete(ws.consents, req.ID)
+		ws.mu.Unlock()
+	}()
+
+	ws.WriteJSON(ChatResponse{Type: "consent", Content: "Allow the assistant to " + req.Description + "?", Consent: &req})
+	select {
+	case approved := <-reply:
+		return approved, true
+	case <-time.After(timeout):
+		ws.WriteJSON(ChatResponse{Type: "status", Content: "No answer to the consent request; continuing without it…"})
+		return false, false
+	}
+}
+
+// answerConsent delivers the user's decision to the waiting tool call.
+// Callers hold ws.mu.
+func (ws *wsSession) answerConsent(reply ConsentReply) {
+	if ch := ws.consents[reply.ID]; ch != nil {
+		select {
+		case ch <- reply.Approved:
+		default:
+		}
+	}
+}
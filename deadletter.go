@@ -0,0 +1,178 @@
+package aiassistant
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultDeadLetterAfter is the number of failures of an identical host
+	// API call after which it is dead-lettered
+	defaultDeadLetterAfter = 2
+	// maxDeadLetters is how many dead letters are kept
+	maxDeadLetters = 100
+	// maxDeadLetterExcerpt limits the response excerpt of a dead letter
+	maxDeadLetterExcerpt = 500
+)
+
+// apiFailureResult matches the results of failed REST, gRPC and GraphQL
+// calls, which the executors return as text so the model can read them
+var apiFailureResult = regexp.MustCompile(`^(?:(?:API|gRPC) call failed with status (\S+)|GraphQL call failed): `)
+
+// DeadLetter is a host API call that failed repeatedly with the same
+// arguments within one answer, served by GET /willknow/dead-letters
+type DeadLetter struct {
+	Time      time.Time              `json:"time"`
+	SessionID string                 `json:"session_id"`
+	UserID    string                 `json:"user_id,omitempty"`
+	Target    string                 `json:"target"`
+	Tool      string                 `json:"tool"`
+	Input     map[string]interface{} `json:"input"`
+	Attempts  int                    `json:"attempts"`
+	Status    string                 `json:"status,omitempty"` // HTTP status or gRPC code, if any
+	Error     string                 `json:"error"`            // start of the response or error message
+}
+
+// failedCall counts the failures of one host API call in the current answer
+type failedCall struct {
+	attempts int
+	status   string
+	detail   string
+}
+
+// deadLetters records host API calls that keep failing, so they are
+// reported instead of retried blindly
+type deadLetters struct {
+	after    int
+	redactor *redactor // masks secrets in the stored errors
+
+	mu      sync.Mutex
+	letters []DeadLetter // oldest first
+}
+
+func newDeadLetters(after int, redactor *redactor) *deadLetters {
+	if after <= 0 {
+		after = defaultDeadLetterAfter
+	}
+	return &deadLetters{after: after, redactor: redactor}
+}
+
+// callKey identifies a call by tool and arguments
+func callKey(name string, params map[string]interface{}) string {
+	input, _ := json.Marshal(params)
+	return name + " " + string(input)
+}
+
+// check refuses a call that was already dead-lettered in the current answer
+func (d *deadLetters) check(session *Session, name string, params map[string]interface{}) error {
+	session.mu.Lock()
+	failed := session.failedCalls[callKey(name, params)]
+	session.mu.Unlock()
+	if failed == nil || failed.attempts < d.after {
+		return nil
+	}
+	return fmt.Errorf("not retried: this exact call already failed %d times in this answer (%s). Do not retry it; tell the user the concrete failure and what could cause it", failed.attempts, failed.describe())
+}
+
+// record counts a host API call's outcome. Once the same call has failed
+// DeadLetterAfter times it is recorded as a dead letter, and the result tells
+// the model to report the failure instead of retrying.
+func (d *deadLetters) record(session *Session, t *target, name string, params map[string]interface{}, result string, err error) string {
+	status, detail, failed := apiFailure(result, err)
+	key := callKey(name, params)
+
+	session.mu.Lock()
+	if !failed {
+		delete(session.failedCalls, key)
+		session.mu.Unlock()
+		return result
+	}
+	if session.failedCalls == nil {
+		session.failedCalls = make(map[string]*failedCall)
+	}
+	call := session.failedCalls[key]
+	if call == nil {
+		call = &failedCall{}
+		session.failedCalls[key] = call
+	}
+	call.attempts++
+	call.status, call.detail = status, detail
+	attempts := call.attempts
+	session.mu.Unlock()
+
+	if attempts != d.after {
+		return result
+	}
+
+	letter := DeadLetter{
+		Time:      time.Now(),
+		SessionID: session.ID,
+		Target:    t.config.Name,
+		Tool:      name,
+		Input:     params,
+		Attempts:  attempts,
+		Status:    status,
+		Error:     d.redactor.redact(detail),
+	}
+	if session.User != nil {
+		letter.UserID = session.User.ID
+	}
+	d.mu.Lock()
+	d.letters = append(d.letters, letter)
+	if len(d.letters) > maxDeadLetters {
+		d.letters = d.letters[len(d.letters)-maxDeadLetters:]
+	}
+	d.mu.Unlock()
+
+	log.Printf("[Dead letter] %s failed %d times in session %s: %s", name, attempts, session.ID, call.describe())
+	session.logEvent("dead_letter", map[string]interface{}{
+		"tool_name": name,
+		"input":     params,
+		"attempts":  attempts,
+		"status":    status,
+		"error":     detail,
+	})
+	return result + fmt.Sprintf("\n\nThis call has now failed %d times with the same arguments and was recorded as a dead letter for the administrators. Do not retry it: report the concrete failure (%s) to the user, with what it suggests about the cause.", attempts, call.describe())
+}
+
+// describe summarizes a failure for the model
+func (c *failedCall) describe() string {
+	if c.status != "" {
+		return fmt.Sprintf("status %s: %s", c.status, c.detail)
+	}
+	return c.detail
+}
+
+// apiFailure reports whether a host API call failed, with its status and the
+// start of the response or error
+func apiFailure(result string, err error) (status, detail string, failed bool) {
+	if err != nil {
+		return "", truncate(err.Error(), maxDeadLetterExcerpt), true
+	}
+	if m := apiFailureResult.FindStringSubmatch(result); m != nil {
+		return m[1], truncate(result[len(m[0]):], maxDeadLetterExcerpt), true
+	}
+	return "", "", false
+}
+
+// handleList serves GET /willknow/dead-letters, newest first
+func (d *deadLetters) handleList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	d.mu.Lock()
+	letters := make([]DeadLetter, 0, len(d.letters))
+	for i := len(d.letters) - 1; i >= 0; i-- {
+		letters = append(letters, d.letters[i])
+	}
+	d.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(letters)
+}
@@ -0,0 +1,317 @@
+package aiassistant
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxDigestEntries bounds the events kept between two digests
+	maxDigestEntries = 1000
+	// digestTopIssues is the number of recurring issues listed in a digest
+	digestTopIssues = 10
+)
+
+// DigestConfig configures the scheduled email digest.
+//
+// The digest summarizes the errors detected (reported incidents and alerts),
+// the analyses performed and the top recurring issues since the previous one.
+type DigestConfig struct {
+	// SMTPHost is the mail server host. When empty, the digest is disabled.
+	SMTPHost string
+
+	// SMTPPort is the mail server port. Port 465 uses implicit TLS; other
+	// ports upgrade with STARTTLS when the server supports it.
+	// Default: 587
+	SMTPPort int
+
+	// Username and Password authenticate with SMTP PLAIN auth (optional)
+	Username string
+	Password string
+
+	// From is the sender address
+	From string
+
+	// To lists the recipients
+	To []string
+
+	// Frequency is "daily" or "weekly" (sent on Mondays).
+	// Default: "daily"
+	Frequency string
+
+	// Hour is the local hour of day (1-23) the digest is sent at.
+	// Default: 8
+	Hour int
+}
+
+// digestEntry is one recorded event
+type digestEntry struct {
+	Time    time.Time
+	Kind    string // "incident", "alert", "anomaly" or "analysis"
+	Title   string
+	Summary string
+	Files   []string
+}
+
+// digestReporter collects events and emails them on a schedule
+type digestReporter struct {
+	config DigestConfig
+	name   string
+
+	mu      sync.Mutex
+	entries []digestEntry
+	since   time.Time
+}
+
+// newDigestReporter creates a reporter, or nil if the digest is not configured
+func newDigestReporter(config DigestConfig, name string) *digestReporter {
+	if config.SMTPHost == "" || len(config.To) == 0 {
+		return nil
+	}
+	if config.SMTPPort == 0 {
+		config.SMTPPort = 587
+	}
+	config.Frequency = strings.ToLower(config.Frequency)
+	if config.Frequency != "weekly" {
+		config.Frequency = "daily"
+	}
+	if config.Hour <= 0 || config.Hour > 23 {
+		config.Hour = 8
+	}
+	if name == "" {
+		name = "Willknow"
+	}
+	return &digestReporter{config: config, name: name, since: time.Now()}
+}
+
+// record adds an event to the next digest
+func (d *digestReporter) record(entry digestEntry) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.entries = append(d.entries, entry)
+	if len(d.entries) > maxDigestEntries {
+		d.entries = d.entries[len(d.entries)-maxDigestEntries:]
+	}
+}
+
+// run sends the digest at every scheduled time; it never returns
+func (d *digestReporter) run() {
+	for {
+		next := d.nextRun(time.Now())
+		time.Sleep(time.Until(next))
+		if err := d.send(); err != nil {
+			log.Printf("[Digest] Failed to send digest: %v", err)
+		}
+	}
+}
+
+// nextRun returns the next scheduled send time after now
+func (d *digestReporter) nextRun(now time.Time) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), d.config.Hour, 0, 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	if d.config.Frequency == "weekly" {
+		for next.Weekday() != time.Monday {
+			next = next.AddDate(0, 0, 1)
+		}
+	}
+	return next
+}
+
+// send emails the digest for the events recorded since the last one.
+// Events are kept for the next digest if delivery fails.
+func (d *digestReporter) send() error {
+	d.mu.Lock()
+	entries := d.entries
+	since := d.since
+	d.mu.Unlock()
+
+	now := time.Now()
+	subject, body := d.compose(entries, since, now)
+	if err := d.deliver(d.config.To, subject, body); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	d.entries = d.entries[len(entries):]
+	d.since = now
+	d.mu.Unlock()
+	log.Printf("[Digest] Sent %s digest to %v (%d events)", d.config.Frequency, d.config.To, len(entries))
+	return nil
+}
+
+// compose renders the digest subject and plain text body
+func (d *digestReporter) compose(entries []digestEntry, since, now time.Time) (string, string) {
+	var errors, analyses []digestEntry
+	for _, e := range entries {
+		if e.Kind == "analysis" {
+			analyses = append(analyses, e)
+		} else {
+			errors = append(errors, e)
+		}
+	}
+
+	subject := fmt.Sprintf("[%s] %s digest: %d errors, %d analyses", d.name, strings.ToUpper(d.config.Frequency[:1])+d.config.Frequency[1:], len(errors), len(analyses))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s digest for %s - %s\n\n", d.name, since.Format("2006-01-02 15:04"), now.Format("2006-01-02 15:04 MST"))
+
+	fmt.Fprintf(&b, "ERRORS DETECTED (%d)\n", len(errors))
+	if len(errors) == 0 {
+		b.WriteString("  None\n")
+	}
+	for _, e := range errors {
+		fmt.Fprintf(&b, "  %s  [%s] %s\n", e.Time.Format("01-02 15:04"), e.Kind, firstLine(e.Title))
+	}
+
+	fmt.Fprintf(&b, "\nANALYSES PERFORMED (%d)\n", len(analyses))
+	if len(analyses) == 0 {
+		b.WriteString("  None\n")
+	}
+	for _, e := range analyses {
+		fmt.Fprintf(&b, "  %s  %s\n", e.Time.Format("01-02 15:04"), firstLine(e.Title))
+		if e.Summary != "" {
+			fmt.Fprintf(&b, "      %s\n", truncate(firstLine(e.Summary), 200))
+		}
+		if len(e.Files) > 0 {
+			fmt.Fprintf(&b, "      Files: %s\n", strings.Join(e.Files, ", "))
+		}
+	}
+
+	b.WriteString("\nTOP RECURRING ISSUES\n")
+	top := topIssues(errors)
+	if len(top) == 0 {
+		b.WriteString("  None\n")
+	}
+	for _, issue := range top {
+		fmt.Fprintf(&b, "  %3dx  %s\n", issue.count, issue.example)
+	}
+	return subject, b.String()
+}
+
+// recurringIssue groups events with the same normalized title
+type recurringIssue struct {
+	example string
+	count   int
+}
+
+// volatileTokens matches IDs, numbers and hex strings that differ between
+// otherwise identical errors
+var volatileTokens = regexp.MustCompile(`\b[0-9a-f]{8,}\b|\b[0-9a-f-]{36}\b|\d+`)
+
+// topIssues groups detected errors by normalized title and returns those
+// seen more than once, most frequent first
+func topIssues(entries []digestEntry) []recurringIssue {
+	groups := make(map[string]*recurringIssue)
+	var order []string
+	for _, e := range entries {
+		if e.Kind == "analysis" {
+			continue
+		}
+		title := firstLine(e.Title)
+		if title == "" {
+			continue
+		}
+		key := volatileTokens.ReplaceAllString(strings.ToLower(title), "#")
+		if g, ok := groups[key]; ok {
+			g.count++
+			continue
+		}
+		groups[key] = &recurringIssue{example: title, count: 1}
+		order = append(order, key)
+	}
+
+	var issues []recurringIssue
+	for _, key := range order {
+		if groups[key].count > 1 {
+			issues = append(issues, *groups[key])
+		}
+	}
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].count > issues[j].count })
+	if len(issues) > digestTopIssues {
+		issues = issues[:digestTopIssues]
+	}
+	return issues
+}
+
+// deliver sends a plain text email with the digest's SMTP settings
+func (d *digestReporter) deliver(recipients []string, subject, body string) error {
+	addr := net.JoinHostPort(d.config.SMTPHost, strconv.Itoa(d.config.SMTPPort))
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", d.config.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(recipients, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	var auth smtp.Auth
+	if d.config.Username != "" {
+		auth = smtp.PlainAuth("", d.config.Username, d.config.Password, d.config.SMTPHost)
+	}
+
+	if d.config.SMTPPort != 465 {
+		return smtp.SendMail(addr, auth, d.config.From, recipients, []byte(msg.String()))
+	}
+
+	// Implicit TLS (SMTPS)
+	conn, err := tls.Dial("tcp", addr, &tls.Config{ServerName: d.config.SMTPHost})
+	if err != nil {
+		return err
+	}
+	client, err := smtp.NewClient(conn, d.config.SMTPHost)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+	if auth != nil {
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(d.config.From); err != nil {
+		return err
+	}
+	for _, to := range recipients {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte(msg.String())); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// firstLine returns the first non-empty line of s, trimmed
+func firstLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
@@ -0,0 +1,93 @@
+package aiassistant
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// editorSchemes are the URL templates of the supported editors. {root} is
+// the checkout on the user's machine, always starting with "/", {path} the
+// file relative to it and {project} the JetBrains project name.
+var editorSchemes = map[string]string{
+	"vscode":    "vscode://file{root}/{path}:{line}",
+	"cursor":    "cursor://file{root}/{path}:{line}",
+	"windsurf":  "windsurf://file{root}/{path}:{line}",
+	"jetbrains": "jetbrains://idea/navigate/reference?project={project}&path={path}:{line}",
+}
+
+// EditorConfig turns file:line references in answers (e.g. handlers/user.go:42)
+// into links that open the file in the users' editor
+type EditorConfig struct {
+	// Scheme is "vscode", "cursor", "windsurf", "jetbrains", or a custom URL
+	// template using {root}, {path}, {line} and {project}
+	// (e.g. "idea://open?file={root}/{path}&line={line}"; {root} starts with "/").
+	// Default: "" (references are not linked)
+	Scheme string
+
+	// LocalRoot is where developers check out the repository, used for
+	// {root} (e.g. "/Users/me/src/shop")
+	LocalRoot string
+
+	// Project is the JetBrains project name, used for {project}
+	Project string
+
+	// TargetRoots overrides LocalRoot for additional targets, keyed by target name
+	TargetRoots map[string]string
+}
+
+// editorTemplate returns the URL template of the configured editor, or "" if none
+func (c EditorConfig) editorTemplate() (string, error) {
+	if c.Scheme == "" {
+		return "", nil
+	}
+	template, ok := editorSchemes[strings.ToLower(c.Scheme)]
+	if !ok {
+		if !strings.Contains(c.Scheme, "{path}") {
+			return "", fmt.Errorf("unknown editor scheme %q (use vscode, cursor, windsurf, jetbrains or a URL template with {path})", c.Scheme)
+		}
+		template = c.Scheme
+	}
+	if strings.Contains(template, "{root}") && c.LocalRoot == "" {
+		return "", fmt.Errorf("Editor.LocalRoot is required for the %s scheme", c.Scheme)
+	}
+	return strings.ReplaceAll(template, "{project}", c.Project), nil
+}
+
+// editorLinks describes for the UI how to link file references of a target
+type editorLinks struct {
+	URL        string `json:"url"`         // template with {path} and {line}; "" if no editor is configured
+	SourceRoot string `json:"source_root"` // server-side source path, stripped from absolute references
+}
+
+// handleEditor serves GET /api/editor?target=
+func handleEditor(w http.ResponseWriter, r *http.Request, a *Assistant) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	t := a.findTarget(r.URL.Query().Get("target"))
+	if t == nil {
+		http.Error(w, "unknown target", http.StatusBadRequest)
+		return
+	}
+
+	// The template was validated at startup
+	template, _ := a.config.Editor.editorTemplate()
+	root := a.config.Editor.LocalRoot
+	if t != a.targets[0] {
+		root = a.config.Editor.TargetRoots[t.config.Name]
+	}
+	if strings.Contains(template, "{root}") && root == "" {
+		template = "" // no checkout known for this target
+	}
+	root = strings.TrimRight(strings.ReplaceAll(root, "\\", "/"), "/")
+	if !strings.HasPrefix(root, "/") {
+		root = "/" + root // Windows paths such as C:/src/shop
+	}
+	template = strings.ReplaceAll(template, "{root}", root)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(editorLinks{URL: template, SourceRoot: t.config.SourcePath})
+}
@@ -0,0 +1,235 @@
+package aiassistant
+
+import (
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/willknow-ai/willknow-go/provider"
+	"github.com/willknow-ai/willknow-go/tools"
+)
+
+// EnvironmentConfig overrides parts of the configuration for one deployment
+// environment of the application (e.g. "dev", "staging", "prod"), so the
+// same integration code serves every environment. Fields left empty keep
+// their top-level value; the source code, code index and all other
+// integrations are shared.
+//
+// The environment named by Config.Environment is applied to the top-level
+// configuration at startup. Every other environment is a target sessions can
+// select per conversation, like the ones in Config.Targets.
+type EnvironmentConfig struct {
+	// Name identifies the environment in the UI and the chat API (e.g.
+	// "staging"). Must be unique among environments and targets.
+	Name string
+
+	// Description is shown in the target selector and the system prompt
+	Description string
+
+	// LogFiles and LogSources are the environment's logs. When either is
+	// set, they replace the top-level log files and every top-level log
+	// source (LogSources, Loki, Elasticsearch and Cloud Logging).
+	LogFiles   []string
+	LogSources []LogSource
+
+	// HostBaseURL is the base URL of the environment's API
+	HostBaseURL string
+
+	// Model is the model used for the environment's sessions, with the
+	// top-level provider and API key. With a Budget, its usage is priced
+	// at the top-level model's prices.
+	Model string
+
+	// Guardrails replaces the top-level guardrails when set, e.g. to
+	// restrict prod more than dev
+	Guardrails *GuardrailsConfig
+}
+
+// ownsLogs reports whether the environment replaces the top-level logs
+func (e *EnvironmentConfig) ownsLogs() bool {
+	return len(e.LogFiles) > 0 || len(e.LogSources) > 0
+}
+
+// applyEnvironment validates the environments and applies the one selected
+// by Environment to the top-level fields
+func (c *Config) applyEnvironment() error {
+	names := make(map[string]bool)
+	for _, env := range c.Environments {
+		if !targetNameValid.MatchString(env.Name) || env.Name == defaultTargetName || names[env.Name] {
+			return fmt.Errorf("invalid or duplicate environment name %q", env.Name)
+		}
+		names[env.Name] = true
+	}
+	if c.Environment == "" {
+		return nil
+	}
+
+	env := c.environment(c.Environment)
+	if env == nil {
+		return fmt.Errorf("unknown environment %q", c.Environment)
+	}
+	if env.ownsLogs() {
+		c.LogFiles = env.LogFiles
+		c.LogSources = env.LogSources
+		c.Loki.URL = ""
+		c.Elasticsearch.URL = ""
+		c.CloudLogging.Enabled = false
+	}
+	if env.HostBaseURL != "" {
+		c.HostBaseURL = env.HostBaseURL
+	}
+	if env.Model != "" {
+		c.Model = env.Model
+	}
+	if env.Guardrails != nil {
+		c.Guardrails = *env.Guardrails
+	}
+	if env.Description != "" && c.AgentInfo.Description == "" {
+		c.AgentInfo.Description = env.Description
+	}
+	log.Printf("[AI Assistant] Environment: %s", env.Name)
+	return nil
+}
+
+// environment returns the environment with the given name, or nil
+func (c *Config) environment(name string) *EnvironmentConfig {
+	for i := range c.Environments {
+		if c.Environments[i].Name == name {
+			return &c.Environments[i]
+		}
+	}
+	return nil
+}
+
+// newEnvironmentTarget builds the target of an environment selectable per
+// conversation. It reads the default target's source tree and shares its
+// code index; logSources are the top-level remote log sources.
+func (a *Assistant) newEnvironmentTarget(env EnvironmentConfig, logSources []tools.LogSource) (*target, error) {
+	base := a.targets[0]
+	config := TargetConfig{
+		Name:        env.Name,
+		Description: env.Description,
+		SourcePath:  base.config.SourcePath,
+		SourceFS:    base.config.SourceFS,
+		LogFiles:    base.config.LogFiles,
+		APISpec:     base.config.APISpec,
+		HostBaseURL: env.HostBaseURL,
+	}
+	if env.ownsLogs() {
+		config.LogFiles = env.LogFiles
+		logSources = env.LogSources
+	}
+	if config.HostBaseURL == "" {
+		config.HostBaseURL = base.config.HostBaseURL
+	}
+	if a.config.AirGapped && env.HostBaseURL != "" {
+		if err := checkAirGappedURL(a.config, "environment "+env.Name+" HostBaseURL", env.HostBaseURL); err != nil {
+			return nil, err
+		}
+	}
+
+	t, err := newTarget(config, base.toolRegistry, logSources, a.provider, false)
+	if err != nil {
+		return nil, err
+	}
+	t.environment = &env
+	if base.codeIndex != nil {
+		t.codeIndex = base.codeIndex
+		t.toolRegistry.RegisterCodeIndexTool(base.codeIndex)
+	}
+	if env.Model != "" {
+		if t.provider, err = a.environmentProvider(env.Model); err != nil {
+			return nil, fmt.Errorf("environment %s: %w", env.Name, err)
+		}
+	}
+	if env.Guardrails != nil {
+		t.guardrails = newGuardrails(*env.Guardrails)
+	}
+	return t, nil
+}
+
+// environmentProvider creates a provider for another model, wrapped like
+// the assistant's own
+func (a *Assistant) environmentProvider(model string) (provider.Provider, error) {
+	p, err := provider.NewProvider(provider.ProviderType(a.config.Provider), a.config.APIKey, model, a.config.BaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create provider: %w", err)
+	}
+	return a.wrapProvider(p), nil
+}
+
+// wrapProvider wraps another model's provider like the assistant's own:
+// fixtures, budget, redaction, the operational log and maintenance mode
+func (a *Assistant) wrapProvider(p provider.Provider) provider.Provider {
+	p = a.fixtures.wrap(p)
+	if a.budget != nil {
+		p = &budgetedModel{budget: a.budget, base: p}
+	}
+	if a.redactor != nil {
+		p = &redactingProvider{base: p, redactor: a.redactor}
+	}
+	if a.ops != nil {
+		p = &opsLogProvider{base: p, ops: a.ops}
+	}
+	return &maintenanceProvider{base: p, maintenance: a.maintenance}
+}
+
+// targetProvider returns the provider for a target's sessions
+func (a *Assistant) targetProvider(t *target) provider.Provider {
+	if t.provider != nil {
+		return t.provider
+	}
+	return a.provider
+}
+
+// targetGuardrails returns the policies applying to a target's tool calls
+func (a *Assistant) targetGuardrails(t *target) *guardrails {
+	if t.environment != nil && t.environment.Guardrails != nil {
+		return t.guardrails
+	}
+	return a.guardrails
+}
+
+// budgetedModel counts another model's usage against the assistant's budget
+type budgetedModel struct {
+	budget *budgetProvider
+	base   provider.Provider
+}
+
+// SendMessage sends to the model, or to the budget's fallback model when degraded
+func (m *budgetedModel) SendMessage(messages []provider.Message, tools []provider.Tool, system string) (*provider.Response, error) {
+	current, degraded, err := m.budget.acquire()
+	if err != nil {
+		return nil, err
+	}
+	if !degraded {
+		current = m.base
+	}
+	resp, err := current.SendMessage(messages, tools, system)
+	if resp != nil {
+		m.budget.record(resp.Usage, degraded)
+	}
+	return resp, err
+}
+
+// SendMessageStream sends to the model; the input tokens are estimated as
+// the budget does for the assistant's own model
+func (m *budgetedModel) SendMessageStream(messages []provider.Message, tools []provider.Tool, system string) (io.ReadCloser, error) {
+	current, degraded, err := m.budget.acquire()
+	if err != nil {
+		return nil, err
+	}
+	if !degraded {
+		current = m.base
+	}
+	stream, err := current.SendMessageStream(messages, tools, system)
+	if err == nil {
+		m.budget.record(provider.Usage{InputTokens: provider.CountMessageTokens(m.budget.tokenizer, messages, tools, system)}, degraded)
+	}
+	return stream, err
+}
+
+// GetName returns the model's provider name
+func (m *budgetedModel) GetName() string {
+	return m.base.GetName()
+}
@@ -0,0 +1,384 @@
+package aiassistant
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/willknow-ai/willknow-go/openapi"
+	"github.com/willknow-ai/willknow-go/provider"
+)
+
+// EvalOptions configures a replay evaluation. Empty fields fall back to the
+// assistant's Config, so an evaluation with no options measures how
+// reproducible the current setup is.
+type EvalOptions struct {
+	// Provider, APIKey, Model and BaseURL select the candidate model
+	Provider string
+	APIKey   string
+	Model    string
+	BaseURL  string
+
+	// SystemPrompt replaces the system prompt of the candidate
+	SystemPrompt string
+
+	// LiveTools executes debug tool calls (read_file, grep, read_logs, ...)
+	// that have no recorded result. Host API, gRPC and GraphQL calls are
+	// never executed live. By default such calls return an error explaining
+	// that no recorded result exists.
+	LiveTools bool
+}
+
+// EvalReport compares a candidate against recorded sessions
+type EvalReport struct {
+	Candidate string        `json:"candidate"`
+	Sessions  []EvalSession `json:"sessions"`
+	Summary   EvalSummary   `json:"summary"`
+}
+
+// EvalSummary aggregates the turns of a report
+type EvalSummary struct {
+	Turns int `json:"turns"`
+	// SameTools counts turns where the candidate called the same tools in the same order
+	SameTools int `json:"same_tools"`
+	// MeanSimilarity is the mean word overlap (0-1) of final answers
+	MeanSimilarity float64 `json:"mean_similarity"`
+	// Errors counts turns the candidate failed to answer
+	Errors int `json:"errors"`
+}
+
+// EvalSession is the replay of one recorded session
+type EvalSession struct {
+	File  string     `json:"file"`
+	Turns []EvalTurn `json:"turns"`
+}
+
+// EvalTurn compares the original and candidate handling of one user message
+type EvalTurn struct {
+	Message         string   `json:"message"`
+	OriginalTools   []string `json:"original_tools"`
+	CandidateTools  []string `json:"candidate_tools"`
+	SameTools       bool     `json:"same_tools"`
+	OriginalAnswer  string   `json:"original_answer"`
+	CandidateAnswer string   `json:"candidate_answer"`
+	Similarity      float64  `json:"similarity"`
+	Error           string   `json:"error,omitempty"`
+}
+
+// recordedTurn is one user message of a session log and what the original model did
+type recordedTurn struct {
+	message string
+	tools   []string
+	answer  string
+}
+
+// recordedResults serves tool results captured in a session log
+type recordedResults map[string][]string // tool name + canonical input → results in call order
+
+// recordedCallKey identifies a tool call by name and arguments
+func recordedCallKey(name string, input interface{}) string {
+	data, _ := json.Marshal(input) // map keys are sorted
+	return name + " " + string(data)
+}
+
+// Evaluate replays the user messages of recorded session logs (./sessions/*.jsonl)
+// against a candidate provider, model or system prompt, and compares the tools
+// called and the final answers with the originals. Tool calls identical to a
+// recorded call get the recorded result, so the candidate sees the same data.
+func (a *Assistant) Evaluate(sessionFiles []string, opts EvalOptions) (*EvalReport, error) {
+	providerType, model, baseURL, apiKey := a.config.Provider, a.config.Model, a.config.BaseURL, a.config.APIKey
+	if opts.Provider != "" {
+		providerType, baseURL = opts.Provider, ""
+	}
+	if opts.Model != "" {
+		model = opts.Model
+	}
+	if opts.BaseURL != "" {
+		baseURL = opts.BaseURL
+	}
+	if opts.APIKey != "" {
+		apiKey = opts.APIKey
+	}
+	if a.config.AirGapped && baseURL == "" {
+		return nil, fmt.Errorf("air-gapped mode: the candidate needs a BaseURL pointing at an internal model endpoint")
+	}
+	if a.config.AirGapped && baseURL != a.config.BaseURL {
+		if err := checkAirGappedURL(a.config, "candidate BaseURL", baseURL); err != nil {
+			return nil, err
+		}
+	}
+	candidate, err := provider.NewProvider(provider.ProviderType(providerType), apiKey, model, baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create candidate provider: %w", err)
+	}
+	if a.redactor != nil {
+		candidate = &redactingProvider{base: candidate, redactor: a.redactor}
+	}
+
+	name := providerType
+	if model != "" {
+		name += "/" + model
+	}
+	if opts.SystemPrompt != "" {
+		name += " (custom prompt)"
+	}
+	report := &EvalReport{Candidate: name}
+
+	var similarity float64
+	for _, file := range sessionFiles {
+		events, err := readSessionLog(file, a.transcripts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		session := a.replaySession(candidate, events, opts)
+		session.File = file
+		for _, turn := range session.Turns {
+			report.Summary.Turns++
+			if turn.SameTools {
+				report.Summary.SameTools++
+			}
+			if turn.Error != "" {
+				report.Summary.Errors++
+			}
+			similarity += turn.Similarity
+		}
+		report.Sessions = append(report.Sessions, session)
+	}
+	if report.Summary.Turns > 0 {
+		report.Summary.MeanSimilarity = similarity / float64(report.Summary.Turns)
+	}
+	return report, nil
+}
+
+// replaySession sends the recorded user messages to the candidate, keeping
+// the candidate's own answers as conversation history
+func (a *Assistant) replaySession(candidate provider.Provider, events []sessionEvent, opts EvalOptions) EvalSession {
+	const maxTurns = 10 // same tool use limit as a live chat
+
+	turns, results, targetName := parseRecordedSession(events)
+	target := a.findTarget(targetName)
+	if target == nil {
+		target = a.targets[0]
+	}
+	session := &Session{ID: "eval", messages: []provider.Message{}, target: target}
+	system := opts.SystemPrompt
+	if system == "" {
+		system = buildSystemPrompt(a, session)
+	}
+	tools := a.getAllToolDefinitions(target)
+
+	var out EvalSession
+	for _, recorded := range turns {
+		turn := EvalTurn{Message: recorded.message, OriginalTools: recorded.tools, OriginalAnswer: recorded.answer}
+		session.messages = append(session.messages, provider.Message{
+			Role:    "user",
+			Content: []provider.ContentBlock{{Type: "text", Text: recorded.message}},
+		})
+
+		for i := 0; i < maxTurns; i++ {
+			response, err := candidate.SendMessage(session.messages, tools, system)
+			if err != nil {
+				turn.Error = err.Error()
+				break
+			}
+			session.messages = append(session.messages, provider.Message{Role: "assistant", Content: response.Content})
+
+			var toolResults []provider.ContentBlock
+			for _, block := range response.Content {
+				switch block.Type {
+				case "text":
+					if turn.CandidateAnswer != "" {
+						turn.CandidateAnswer += "\n\n"
+					}
+					turn.CandidateAnswer += block.Text
+				case "tool_use":
+					turn.CandidateTools = append(turn.CandidateTools, block.Name)
+					toolResults = append(toolResults, provider.ContentBlock{
+						Type:      "tool_result",
+						ToolUseID: block.ID,
+						Content:   a.replayToolCall(session, results, block.Name, block.Input, opts.LiveTools),
+					})
+				}
+			}
+			if len(toolResults) == 0 {
+				break
+			}
+			session.messages = append(session.messages, provider.Message{Role: "user", Content: toolResults})
+		}
+
+		turn.SameTools = equalStrings(turn.OriginalTools, turn.CandidateTools)
+		turn.Similarity = answerSimilarity(turn.OriginalAnswer, turn.CandidateAnswer)
+		out.Turns = append(out.Turns, turn)
+	}
+	return out
+}
+
+// replayToolCall returns the recorded result of a call, or executes it live if allowed
+func (a *Assistant) replayToolCall(session *Session, results recordedResults, name string, input map[string]interface{}, live bool) string {
+	key := recordedCallKey(name, input)
+	if queue := results[key]; len(queue) > 0 {
+		results[key] = queue[1:]
+		return queue[0]
+	}
+
+	t := a.sessionTarget(session)
+	isHostAPI := openapi.FindTool(t.apiTools, name) != nil || a.grpcService.FindTool(name) != nil || a.graphqlAPI.FindTool(name) != nil
+	if !live || isHostAPI || name == memoryToolName {
+		return "Error: this is a replay of a recorded session and there is no recorded result for this call. Continue with the information you have."
+	}
+	result, err := a.executeToolCall(session, name, input)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	return result
+}
+
+// parseRecordedSession splits a session log into user turns and collects
+// the recorded tool results
+func parseRecordedSession(events []sessionEvent) ([]recordedTurn, recordedResults, string) {
+	var turns []recordedTurn
+	results := make(recordedResults)
+	inputs := make(map[string]interface{}) // tool_use ID → input
+	var targetName string
+
+	for _, event := range events {
+		switch event.Type {
+		case "session_start":
+			targetName, _ = event.Data["target"].(string)
+		case "user_message":
+			content, _ := event.Data["content"].(string)
+			turns = append(turns, recordedTurn{message: content})
+		case "assistant_message":
+			if len(turns) > 0 {
+				content, _ := event.Data["content"].(string)
+				turn := &turns[len(turns)-1]
+				if turn.answer != "" {
+					turn.answer += "\n\n"
+				}
+				turn.answer += content
+			}
+		case "tool_use":
+			name, _ := event.Data["tool_name"].(string)
+			id, _ := event.Data["tool_id"].(string)
+			inputs[id] = event.Data["input"]
+			if len(turns) > 0 {
+				turns[len(turns)-1].tools = append(turns[len(turns)-1].tools, name)
+			}
+		case "tool_result":
+			name, _ := event.Data["tool_name"].(string)
+			id, _ := event.Data["tool_id"].(string)
+			result, _ := event.Data["result"].(string)
+			key := recordedCallKey(name, inputs[id])
+			results[key] = append(results[key], result)
+		}
+	}
+	return turns, results, targetName
+}
+
+// answerSimilarity is the overlap (Jaccard index) of the words of two answers
+func answerSimilarity(a, b string) float64 {
+	words := func(s string) map[string]bool {
+		set := make(map[string]bool)
+		for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' || r > 127)
+		}) {
+			set[w] = true
+		}
+		return set
+	}
+	wa, wb := words(a), words(b)
+	if len(wa) == 0 && len(wb) == 0 {
+		return 1
+	}
+	shared := 0
+	for w := range wa {
+		if wb[w] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(wa)+len(wb)-shared)
+}
+
+// equalStrings reports whether two slices hold the same strings in the same order
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// diffTools returns a line diff of two tool sequences: "  " unchanged,
+// "- " only called originally, "+ " only called by the candidate
+func diffTools(original, candidate []string) []string {
+	// Longest common subsequence table
+	lcs := make([][]int, len(original)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(candidate)+1)
+	}
+	for i := len(original) - 1; i >= 0; i-- {
+		for j := len(candidate) - 1; j >= 0; j-- {
+			if original[i] == candidate[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var lines []string
+	i, j := 0, 0
+	for i < len(original) || j < len(candidate) {
+		switch {
+		case i < len(original) && j < len(candidate) && original[i] == candidate[j]:
+			lines = append(lines, "  "+original[i])
+			i++
+			j++
+		case j == len(candidate) || i < len(original) && lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, "- "+original[i])
+			i++
+		default:
+			lines = append(lines, "+ "+candidate[j])
+			j++
+		}
+	}
+	return lines
+}
+
+// WriteMarkdown writes a human-readable report with tool diffs and both answers
+func (r *EvalReport) WriteMarkdown(w io.Writer) {
+	fmt.Fprintf(w, "# Replay evaluation: %s\n\n", r.Candidate)
+	fmt.Fprintf(w, "- Sessions: %d\n- Turns: %d\n", len(r.Sessions), r.Summary.Turns)
+	if r.Summary.Turns > 0 {
+		fmt.Fprintf(w, "- Same tool choices: %d/%d (%.0f%%)\n", r.Summary.SameTools, r.Summary.Turns, 100*float64(r.Summary.SameTools)/float64(r.Summary.Turns))
+		fmt.Fprintf(w, "- Mean answer similarity: %.2f\n", r.Summary.MeanSimilarity)
+	}
+	fmt.Fprintf(w, "- Errors: %d\n", r.Summary.Errors)
+
+	for _, session := range r.Sessions {
+		fmt.Fprintf(w, "\n## %s\n", filepath.Base(session.File))
+		for i, turn := range session.Turns {
+			fmt.Fprintf(w, "\n### Turn %d: %s\n\n", i+1, truncate(turn.Message, maxTraceChars))
+			if turn.Error != "" {
+				fmt.Fprintf(w, "**Candidate error:** %s\n\n", turn.Error)
+			}
+			status := "same tool choices"
+			if !turn.SameTools {
+				status = "different tool choices"
+			}
+			fmt.Fprintf(w, "Tools (%s), answer similarity %.2f:\n\n```diff\n", status, turn.Similarity)
+			for _, line := range diffTools(turn.OriginalTools, turn.CandidateTools) {
+				fmt.Fprintln(w, line)
+			}
+			fmt.Fprintf(w, "```\n\n**Original answer:**\n\n%s\n\n**Candidate answer:**\n\n%s\n", turn.OriginalAnswer, turn.CandidateAnswer)
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package aiassistant
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/willknow-ai/willknow-go/provider"
+)
+
+// controlVariant names the sessions not assigned to a variant, which use the
+// assistant's own provider, model and prompt
+const controlVariant = "control"
+
+// ExperimentConfig assigns sessions to provider, model and prompt variants
+// by percentage, so teams can compare answer quality and cost across them.
+// A session is assigned by a hash of its ID, so it keeps its variant for its
+// lifetime; the rest of the sessions form the "control" variant. Each
+// session log records its variant in an "experiment" event and the tokens
+// of every response in "usage" events, and /willknow/experiments
+// summarizes the variants.
+// Sessions of an environment with its own Model are not part of the
+// experiment.
+type ExperimentConfig struct {
+	// Name identifies the experiment in session logs
+	Name string
+
+	// Variants and the percentage of sessions each receives, at most 100
+	// in total
+	Variants []VariantConfig
+
+	// ControlInputPricePerMillion and ControlOutputPricePerMillion are the
+	// USD prices per million tokens of Config.Model, to estimate the cost
+	// of the control variant
+	// Default: the prices of Budget
+	ControlInputPricePerMillion  float64
+	ControlOutputPricePerMillion float64
+}
+
+// VariantConfig is a variant of an experiment. Empty fields keep the
+// assistant's own setting.
+type VariantConfig struct {
+	// Name identifies the variant, e.g. "haiku" or "terse-prompt"
+	Name string
+
+	// Percent of the sessions assigned to the variant
+	Percent int
+
+	// Provider, APIKey, BaseURL and Model select the variant's model.
+	// APIKey is required when Provider differs from the main provider; in
+	// air-gapped mode BaseURL must be an allowlisted internal endpoint.
+	Provider string
+	APIKey   string
+	BaseURL  string
+	Model    string
+
+	// SystemPrompt is appended to the system prompt
+	SystemPrompt string
+
+	// InputPricePerMillion and OutputPricePerMillion are the USD prices per
+	// million tokens of the variant's model, to estimate its cost
+	InputPricePerMillion  float64
+	OutputPricePerMillion float64
+}
+
+// VariantStats summarizes the sessions of a variant
+type VariantStats struct {
+	Variant      string  `json:"variant"`
+	Model        string  `json:"model,omitempty"`
+	Sessions     int64   `json:"sessions"`
+	Responses    int64   `json:"responses"` // provider responses
+	Answers      int64   `json:"answers"`   // final answers
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	CostUSD      float64 `json:"cost_usd,omitempty"` // estimated, if prices are configured
+}
+
+// variant is a configured variant with its provider
+type variant struct {
+	config   VariantConfig
+	provider provider.Provider // nil uses the assistant's
+}
+
+// experiment assigns sessions to variants and counts their usage
+type experiment struct {
+	config   ExperimentConfig
+	variants []*variant
+	control  *variant
+
+	mu    sync.Mutex
+	stats map[string]*VariantStats
+}
+
+// newExperiment returns nil unless variants are configured. Variants are
+// created with the assistant's provider wrappers, so a is fully set up.
+func newExperiment(a *Assistant, config ExperimentConfig) (*experiment, error) {
+	if len(config.Variants) == 0 {
+		return nil, nil
+	}
+	if config.Name == "" {
+		return nil, fmt.Errorf("Experiment.Name is required")
+	}
+	if config.ControlInputPricePerMillion == 0 && config.ControlOutputPricePerMillion == 0 {
+		config.ControlInputPricePerMillion = a.config.Budget.InputPricePerMillion
+		config.ControlOutputPricePerMillion = a.config.Budget.OutputPricePerMillion
+	}
+
+	e := &experiment{
+		config: config,
+		control: &variant{config: VariantConfig{
+			Name:                  controlVariant,
+			Model:                 a.config.Model,
+			InputPricePerMillion:  config.ControlInputPricePerMillion,
+			OutputPricePerMillion: config.ControlOutputPricePerMillion,
+		}},
+		stats: make(map[string]*VariantStats),
+	}
+	total := 0
+	names := map[string]bool{controlVariant: true}
+	for _, vc := range config.Variants {
+		if vc.Name == "" || names[vc.Name] {
+			return nil, fmt.Errorf("variant names must be unique, non-empty and not %q", controlVariant)
+		}
+		names[vc.Name] = true
+		if vc.Percent <= 0 {
+			return nil, fmt.Errorf("variant %s: Percent must be positive", vc.Name)
+		}
+		total += vc.Percent
+
+		v := &variant{config: vc}
+		if vc.Provider != "" || vc.APIKey != "" || vc.BaseURL != "" || vc.Model != "" {
+			providerType, apiKey, baseURL := vc.Provider, vc.APIKey, vc.BaseURL
+			if providerType == "" {
+				providerType = a.config.Provider
+			}
+			// The main API key belongs to the main provider's vendor
+			if apiKey == "" {
+				if providerType != a.config.Provider {
+					return nil, fmt.Errorf("variant %s: APIKey is required when Provider differs from the main provider", vc.Name)
+				}
+				apiKey = a.config.APIKey
+			}
+			if a.config.AirGapped {
+				if vc.Provider != "" && vc.Provider != a.config.Provider && vc.BaseURL == "" {
+					return nil, fmt.Errorf("variant %s: BaseURL must point at an internal model endpoint when Provider is changed", vc.Name)
+				}
+				if vc.BaseURL != "" {
+					if err := checkAirGappedURL(a.config, "variant "+vc.Name+" BaseURL", vc.BaseURL); err != nil {
+						return nil, err
+					}
+				}
+			}
+			if baseURL == "" && vc.Provider == "" {
+				baseURL = a.config.BaseURL
+			}
+			p, err := provider.NewProvider(provider.ProviderType(providerType), apiKey, vc.Model, baseURL)
+			if err != nil {
+				return nil, fmt.Errorf("variant %s: failed to create provider: %w", vc.Name, err)
+			}
+			v.provider = a.wrapProvider(p)
+		}
+		e.variants = append(e.variants, v)
+	}
+	if total > 100 {
+		return nil, fmt.Errorf("experiment %s: variants receive %d%% of sessions, more than 100%%", config.Name, total)
+	}
+	return e, nil
+}
+
+// pick returns the variant of a session, chosen by a hash of its ID
+func (e *experiment) pick(sessionID string) *variant {
+	h := fnv.New32a()
+	h.Write([]byte(sessionID))
+	bucket := int(h.Sum32() % 100)
+	for _, v := range e.variants {
+		if bucket < v.config.Percent {
+			return v
+		}
+		bucket -= v.config.Percent
+	}
+	return e.control
+}
+
+// assign puts a session in its variant before its first question
+func (e *experiment) assign(session *Session) {
+	if e == nil || session.target != nil && session.target.provider != nil {
+		return
+	}
+	session.mu.Lock()
+	if session.variant != nil {
+		session.mu.Unlock()
+		return
+	}
+	v := e.pick(session.ID)
+	session.variant = v
+	session.mu.Unlock()
+
+	e.stat(v, func(s *VariantStats) { s.Sessions++ })
+	session.logEvent("experiment", map[string]interface{}{
+		"experiment": e.config.Name,
+		"variant":    v.config.Name,
+		"model":      v.config.Model,
+	})
+	log.Printf("[Session %s] Experiment %s: variant %s", session.ID, e.config.Name, v.config.Name)
+}
+
+// stat updates the statistics of a variant
+func (e *experiment) stat(v *variant, update func(s *VariantStats)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	s := e.stats[v.config.Name]
+	if s == nil {
+		s = &VariantStats{Variant: v.config.Name, Model: v.config.Model}
+		e.stats[v.config.Name] = s
+	}
+	update(s)
+}
+
+// record counts a provider response of a session and logs its usage
+func (e *experiment) record(session *Session, response *provider.Response) {
+	v := session.experimentVariant()
+	if e == nil || v == nil {
+		return
+	}
+	cost := (float64(response.Usage.InputTokens)*v.config.InputPricePerMillion +
+		float64(response.Usage.OutputTokens)*v.config.OutputPricePerMillion) / 1e6
+	e.stat(v, func(s *VariantStats) {
+		s.Responses++
+		if response.StopReason != "tool_use" {
+			s.Answers++
+		}
+		s.InputTokens += int64(response.Usage.InputTokens)
+		s.OutputTokens += int64(response.Usage.OutputTokens)
+		s.CostUSD += cost
+	})
+	session.logEvent("usage", map[string]interface{}{
+		"variant":       v.config.Name,
+		"input_tokens":  response.Usage.InputTokens,
+		"output_tokens": response.Usage.OutputTokens,
+		"cost_usd":      cost,
+	})
+}
+
+// experimentVariant returns the session's variant, or nil
+func (s *Session) experimentVariant() *variant {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.variant
+}
+
+// sessionProvider returns the provider answering a session: its
+// environment's model, its variant's, or the assistant's
+func (a *Assistant) sessionProvider(session *Session) provider.Provider {
+	t := a.sessionTarget(session)
+	if t.provider == nil {
+		if v := session.experimentVariant(); v != nil && v.provider != nil {
+			return v.provider
+		}
+	}
+	return a.targetProvider(t)
+}
+
+// variantPrompt returns the system prompt addition of a session's variant
+func (s *Session) variantPrompt() string {
+	if v := s.experimentVariant(); v != nil && v.config.SystemPrompt != "" {
+		return "\n\n" + v.config.SystemPrompt
+	}
+	return ""
+}
+
+// handleExperiments serves GET /willknow/experiments, the statistics of
+// every variant
+func (e *experiment) handleExperiments(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	resp := struct {
+		Experiment string         `json:"experiment,omitempty"`
+		Variants   []VariantStats `json:"variants"`
+	}{Variants: []VariantStats{}}
+	if e != nil {
+		resp.Experiment = e.config.Name
+		e.mu.Lock()
+		for _, s := range e.stats {
+			resp.Variants = append(resp.Variants, *s)
+		}
+		e.mu.Unlock()
+		sort.Slice(resp.Variants, func(i, j int) bool { return resp.Variants[i].Variant < resp.Variants[j].Variant })
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
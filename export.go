@@ -0,0 +1,223 @@
+package aiassistant
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/willknow-ai/willknow-go/provider"
+	"github.com/willknow-ai/willknow-go/tools"
+)
+
+const (
+	// exportLogsToolName is the tool that prepares a log export download
+	exportLogsToolName = "export_logs"
+	// maxLogExportBytes caps the size of a log export
+	maxLogExportBytes = 20 << 20
+)
+
+// exportFileNameUnsafe matches characters not kept in export file names
+var exportFileNameUnsafe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// logExportQuery parses the query, filter and time range of an export
+// from tool-style parameters
+func logExportQuery(params map[string]interface{}) (tools.LogQuery, error) {
+	query, _ := params["query"].(string)
+	if strings.TrimSpace(query) == "" {
+		return tools.LogQuery{}, fmt.Errorf("query is required")
+	}
+	now := time.Now()
+	startStr, _ := params["start_time"].(string)
+	start, err := tools.ParseTimeParam(startStr, now)
+	if err != nil {
+		return tools.LogQuery{}, err
+	}
+	endStr, _ := params["end_time"].(string)
+	end, err := tools.ParseTimeParam(endStr, now)
+	if err != nil {
+		return tools.LogQuery{}, err
+	}
+	filter, _ := params["filter"].(string)
+	return tools.LogQuery{Text: query, Filter: filter, Start: start, End: end}, nil
+}
+
+// exportLogs collects the redacted export of the logs matching params that
+// user may read. The guardrails' log range limit applies as for read_logs.
+func (a *Assistant) exportLogs(t *target, user *User, params map[string]interface{}) (data []byte, entries int, truncated bool, err error) {
+	query, err := logExportQuery(params)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	if guardrails := a.targetGuardrails(t); guardrails != nil {
+		if reason := guardrails.violation(a, t, "read_logs", params); reason != "" {
+			return nil, 0, false, fmt.Errorf("blocked by policy: %s", reason)
+		}
+	}
+	var roles []string
+	if user != nil {
+		roles = user.Roles
+	}
+
+	var buf bytes.Buffer
+	entries, truncated, err = t.toolRegistry.ExportLogs(&buf, query, roles, maxLogExportBytes)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	return []byte(a.redactor.redact(buf.String())), entries, truncated, nil
+}
+
+// handleLogExport serves GET /api/logs/export?query=&start_time=&end_time=&filter=&target=,
+// a downloadable file of every matching log entry, for handing evidence to
+// another team. Exports are capped at maxLogExportBytes and redacted.
+func handleLogExport(w http.ResponseWriter, r *http.Request, a *Assistant) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	t := a.findTarget(r.URL.Query().Get("target"))
+	if t == nil {
+		http.Error(w, "unknown target", http.StatusBadRequest)
+		return
+	}
+	user, _ := r.Context().Value(userContextKey).(*User)
+	params := make(map[string]interface{})
+	for _, key := range []string{"query", "start_time", "end_time", "filter"} {
+		if v := r.URL.Query().Get(key); v != "" {
+			params[key] = v
+		}
+	}
+
+	data, entries, truncated, err := a.exportLogs(t, user, params)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	userID := ""
+	if user != nil {
+		userID = user.ID
+	}
+	a.ops.record("info", "logs_exported", map[string]interface{}{
+		"user_id":   userID,
+		"target":    t.config.Name,
+		"query":     params["query"],
+		"entries":   entries,
+		"truncated": truncated,
+	})
+
+	name, content := logExportFile(params, userID, entries, truncated, data)
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, name))
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(content)
+}
+
+// logExportFile returns the file name and content of an export: data after
+// header comments describing the query, range and exporting user
+func logExportFile(params map[string]interface{}, userID string, entries int, truncated bool, data []byte) (string, []byte) {
+	now := time.Now()
+	var header strings.Builder
+	fmt.Fprintf(&header, "# Log export of %q", params["query"])
+	if start, _ := params["start_time"].(string); start != "" {
+		fmt.Fprintf(&header, " from %s", start)
+	}
+	if end, _ := params["end_time"].(string); end != "" {
+		fmt.Fprintf(&header, " to %s", end)
+	}
+	fmt.Fprintf(&header, "\n# Exported %s", now.Format(time.RFC3339))
+	if userID != "" {
+		fmt.Fprintf(&header, " by %s", userID)
+	}
+	fmt.Fprintf(&header, ": %d entries", entries)
+	if truncated {
+		fmt.Fprintf(&header, " (truncated at %d MB)", maxLogExportBytes>>20)
+	}
+	header.WriteString("\n")
+
+	query, _ := params["query"].(string)
+	name := strings.Trim(exportFileNameUnsafe.ReplaceAllString(query, "_"), "_._-")
+	if len(name) > 40 {
+		name = name[:40]
+	}
+	if name == "" {
+		name = "query"
+	}
+	return fmt.Sprintf("logs-%s-%s.log", name, now.Format("20060102-150405")), append([]byte(header.String()), data...)
+}
+
+// exportLogsTool checks that an export has entries and returns its download
+// link. With session workspaces, the export is saved to the workspace.
+func (a *Assistant) exportLogsTool(session *Session, params map[string]interface{}) (string, error) {
+	t := a.sessionTarget(session)
+	data, entries, truncated, err := a.exportLogs(t, session.User, params)
+	if err != nil {
+		return "", err
+	}
+	if entries == 0 {
+		return "No log entries match this query and time range; nothing to export.", nil
+	}
+	note := ""
+	if truncated {
+		note = fmt.Sprintf(" The export is truncated at %d MB; narrow the time range for a complete export.", maxLogExportBytes>>20)
+	}
+
+	if a.workspaces != nil {
+		userID := ""
+		if session.User != nil {
+			userID = session.User.ID
+		}
+		name, content := logExportFile(params, userID, entries, truncated, data)
+		link, err := a.workspaces.write(session, name, content)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Export of %d log entries saved to the session workspace.%s Give the user this download link verbatim: [%s](%s)",
+			entries, note, name, link), nil
+	}
+
+	values := url.Values{}
+	for _, key := range []string{"query", "start_time", "end_time", "filter"} {
+		if v, _ := params[key].(string); v != "" {
+			values.Set(key, v)
+		}
+	}
+	if t != a.targets[0] {
+		values.Set("target", t.config.Name)
+	}
+	return fmt.Sprintf("Export of %d log entries ready.%s Give the user this download link verbatim: [Download logs](/api/logs/export?%s)",
+		entries, note, values.Encode()), nil
+}
+
+// exportLogsToolDefinition returns the definition of the export_logs tool
+func exportLogsToolDefinition() provider.Tool {
+	return provider.Tool{
+		Name:        exportLogsToolName,
+		Description: "Prepare a downloadable file with ALL log entries matching a query and time range (redacted, size-capped), for when the user wants to hand log evidence to another team or analyze it offline. Returns a download link to give to the user. Use read_logs to investigate; use this only when the user asks for an export.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"query": map[string]interface{}{
+					"type":        "string",
+					"description": "The search query (e.g., request ID, error message)",
+				},
+				"start_time": map[string]interface{}{
+					"type":        "string",
+					"description": "Optional: Only export entries at or after this time. RFC3339 or relative duration ago (e.g., '2h')",
+				},
+				"end_time": map[string]interface{}{
+					"type":        "string",
+					"description": "Optional: Only export entries at or before this time. RFC3339 or relative duration ago",
+				},
+				"filter": map[string]interface{}{
+					"type":        "string",
+					"description": "Optional: Backend-native filter for remote log sources, as for read_logs",
+				},
+			},
+			"required": []string{"query"},
+		},
+	}
+}
@@ -0,0 +1,258 @@
+package aiassistant
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/willknow-ai/willknow-go/provider"
+)
+
+// Fixture modes
+const (
+	// FixturesRecord calls the provider and the tools and records their
+	// responses and results
+	FixturesRecord = "record"
+	// FixturesReplay answers from the recorded fixtures without calling the
+	// provider or running tools
+	FixturesReplay = "replay"
+)
+
+const (
+	// providerFixturesFile holds the recorded provider responses
+	providerFixturesFile = "provider.jsonl"
+	// toolFixturesFile holds the recorded tool results
+	toolFixturesFile = "tools.jsonl"
+)
+
+// FixturesConfig records the provider's responses and the tools' results to
+// fixture files and replays them deterministically, so host applications can
+// write end-to-end tests of their integration that run without network
+// access or an API key. Responses are matched by the conversation so far,
+// tool results by the tool name and input; a request without a fixture
+// fails in replay mode.
+type FixturesConfig struct {
+	// Mode is FixturesRecord or FixturesReplay
+	// Default: "" (disabled)
+	Mode string
+
+	// Dir holds the fixture files, provider.jsonl and tools.jsonl. Record
+	// mode appends to them, so delete them to record afresh.
+	Dir string
+}
+
+// providerFixture is a recorded provider response
+type providerFixture struct {
+	Key      string             `json:"key"`
+	Response *provider.Response `json:"response"`
+}
+
+// toolFixture is a recorded tool result
+type toolFixture struct {
+	Key    string                 `json:"key"`
+	Tool   string                 `json:"tool"`
+	Input  map[string]interface{} `json:"input,omitempty"`
+	Result string                 `json:"result"`
+	Error  string                 `json:"error,omitempty"`
+}
+
+// fixtures records or replays provider responses and tool results
+type fixtures struct {
+	config FixturesConfig
+
+	mu        sync.Mutex
+	responses map[string]*provider.Response
+	results   map[string]toolFixture
+}
+
+// newFixtures returns nil unless a mode is configured, and loads the
+// fixtures to replay
+func newFixtures(config FixturesConfig) (*fixtures, error) {
+	switch config.Mode {
+	case "":
+		return nil, nil
+	case FixturesRecord, FixturesReplay:
+	default:
+		return nil, fmt.Errorf("unknown fixtures mode %q", config.Mode)
+	}
+	if config.Dir == "" {
+		return nil, fmt.Errorf("Fixtures.Dir is required")
+	}
+	f := &fixtures{config: config, responses: make(map[string]*provider.Response), results: make(map[string]toolFixture)}
+	if config.Mode == FixturesRecord {
+		return f, os.MkdirAll(config.Dir, 0755)
+	}
+
+	err := readFixtures(filepath.Join(config.Dir, providerFixturesFile), func(data []byte) error {
+		var p providerFixture
+		if err := json.Unmarshal(data, &p); err != nil {
+			return err
+		}
+		f.responses[p.Key] = p.Response
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = readFixtures(filepath.Join(config.Dir, toolFixturesFile), func(data []byte) error {
+		var t toolFixture
+		if err := json.Unmarshal(data, &t); err != nil {
+			return err
+		}
+		f.results[t.Key] = t
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// readFixtures calls add for each line of a fixture file; a missing file
+// has no fixtures
+func readFixtures(path string, add func(data []byte) error) error {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if err := add(scanner.Bytes()); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// append adds a fixture to a fixture file
+func (f *fixtures) append(name string, fixture interface{}) {
+	data, err := json.Marshal(fixture)
+	if err != nil {
+		log.Printf("[Fixtures] Failed to marshal fixture: %v", err)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	file, err := os.OpenFile(filepath.Join(f.config.Dir, name), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		log.Printf("[Fixtures] Failed to record fixture: %v", err)
+		return
+	}
+	defer file.Close()
+	file.Write(append(data, '\n'))
+}
+
+// fixtureKey hashes what identifies a request. The IDs of tool calls are
+// left out, since they differ between runs.
+func fixtureKey(v interface{}) string {
+	data, _ := json.Marshal(v)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// conversationKey identifies a provider request by its messages
+func conversationKey(messages []provider.Message) string {
+	normalized := make([]provider.Message, len(messages))
+	for i, m := range messages {
+		normalized[i] = provider.Message{Role: m.Role, Content: make([]provider.ContentBlock, len(m.Content))}
+		for j, block := range m.Content {
+			block.ID = ""
+			block.ToolUseID = ""
+			normalized[i].Content[j] = block
+		}
+	}
+	return fixtureKey(normalized)
+}
+
+// wrap returns the provider that records or replays p's responses
+func (f *fixtures) wrap(p provider.Provider) provider.Provider {
+	if f == nil {
+		return p
+	}
+	return &fixtureProvider{base: p, fixtures: f}
+}
+
+// fixtureProvider records or replays the responses of a provider
+type fixtureProvider struct {
+	base     provider.Provider
+	fixtures *fixtures
+}
+
+// SendMessage answers from the fixtures, or calls the provider and records
+// the response
+func (p *fixtureProvider) SendMessage(messages []provider.Message, tools []provider.Tool, system string) (*provider.Response, error) {
+	key := conversationKey(messages)
+	if p.fixtures.config.Mode == FixturesReplay {
+		p.fixtures.mu.Lock()
+		resp := p.fixtures.responses[key]
+		p.fixtures.mu.Unlock()
+		if resp == nil {
+			return nil, fmt.Errorf("no recorded provider response for this conversation (fixture %s); record it first", key[:12])
+		}
+		copied := *resp
+		return &copied, nil
+	}
+	resp, err := p.base.SendMessage(messages, tools, system)
+	if err == nil {
+		p.fixtures.append(providerFixturesFile, providerFixture{Key: key, Response: resp})
+	}
+	return resp, err
+}
+
+// SendMessageStream is not recorded; it is unavailable in replay mode
+func (p *fixtureProvider) SendMessageStream(messages []provider.Message, tools []provider.Tool, system string) (io.ReadCloser, error) {
+	if p.fixtures.config.Mode == FixturesReplay {
+		return nil, fmt.Errorf("streaming is not available when replaying fixtures")
+	}
+	return p.base.SendMessageStream(messages, tools, system)
+}
+
+// GetName returns the wrapped provider's name
+func (p *fixtureProvider) GetName() string {
+	return p.base.GetName()
+}
+
+// replayTool returns the recorded result of a tool call in replay mode;
+// replayed is false if the call must run
+func (f *fixtures) replayTool(name string, input map[string]interface{}) (result string, replayed bool, err error) {
+	if f == nil || f.config.Mode != FixturesReplay || name == suggestActionsToolName {
+		return "", false, nil
+	}
+	key := fixtureKey(toolFixture{Tool: name, Input: input})
+	f.mu.Lock()
+	t, found := f.results[key]
+	f.mu.Unlock()
+	if !found {
+		return "", true, fmt.Errorf("no recorded result for this %s call (fixture %s); record it first", name, key[:12])
+	}
+	if t.Error != "" {
+		return t.Result, true, errors.New(t.Error)
+	}
+	return t.Result, true, nil
+}
+
+// recordTool records the result of a tool call in record mode. Session
+// state tools such as suggest_actions run in both modes.
+func (f *fixtures) recordTool(name string, input map[string]interface{}, result string, err error) {
+	if f == nil || f.config.Mode != FixturesRecord || name == suggestActionsToolName {
+		return
+	}
+	t := toolFixture{Key: fixtureKey(toolFixture{Tool: name, Input: input}), Tool: name, Input: input, Result: result}
+	if err != nil {
+		t.Error = err.Error()
+	}
+	f.append(toolFixturesFile, t)
+}
@@ -0,0 +1,121 @@
+package aiassistant
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/willknow-ai/willknow-go/provider"
+)
+
+// errForkNotFound is returned when the session to fork is not live
+var errForkNotFound = errors.New("session not found or expired")
+
+// forkRequest is the JSON body of POST /api/fork
+type forkRequest struct {
+	SessionID string `json:"session_id"`
+	// Before is the number of user questions to keep: the fork starts with
+	// the conversation up to (excluding) question Before. 0 forks an empty
+	// conversation; a value past the last question copies everything.
+	Before int `json:"before"`
+}
+
+// fork branches the user's live session with the given ID into a new
+// session holding a copy of the history before question before. The new
+// session starts detached; the client attaches to it like a resume.
+func (s *wsSessionStore) fork(id string, before int, user *User) (*Session, error) {
+	s.mu.Lock()
+	source := s.sessions[id]
+	s.mu.Unlock()
+	if source == nil || user == nil || source.session.User == nil || source.session.User.ID != user.ID {
+		return nil, errForkNotFound
+	}
+	orig := source.session
+
+	orig.mu.Lock()
+	cut := len(orig.messages)
+	questions := 0
+	for i, msg := range orig.messages {
+		if msg.Role != "user" || len(msg.Content) == 0 || msg.Content[0].Type != "text" {
+			continue // tool results are sent as user messages too
+		}
+		if questions == before {
+			cut = i
+			break
+		}
+		questions++
+	}
+	messages := make([]provider.Message, cut)
+	copy(messages, orig.messages[:cut])
+	evidence := orig.evidence
+	orig.mu.Unlock()
+
+	sessionID := generateSessionID()
+	logFile, err := initSessionLog(sessionID, s.a.transcripts)
+	if err != nil {
+		log.Printf("Failed to create session log: %v", err)
+	}
+	session := &Session{
+		ID:         sessionID,
+		User:       orig.User,
+		messages:   messages,
+		logFile:    logFile,
+		authHeader: orig.authHeader,
+		target:     orig.target,
+		evidence:   evidence,
+	}
+	session.logEvent("session_start", map[string]interface{}{
+		"timestamp":   time.Now().Format(time.RFC3339),
+		"user_id":     user.ID,
+		"user_name":   user.Name,
+		"target":      s.a.sessionTarget(session).config.Name,
+		"forked_from": id,
+		"fork_point":  before,
+	})
+
+	// Copy the conversation into the fork's transcript, so its shares and
+	// pins cover the inherited answers too
+	for _, msg := range messages {
+		for _, block := range msg.Content {
+			if block.Type != "text" {
+				continue
+			}
+			eventType := "user_message"
+			if msg.Role == "assistant" {
+				eventType = "assistant_message"
+			}
+			session.logEvent(eventType, map[string]interface{}{"content": block.Text})
+		}
+	}
+
+	ws := s.start(session, nil)
+	ws.detach(s, nil, fmt.Errorf("forked session was not connected"))
+	log.Printf("[Session %s] Forked from %s before question %d (user: %s)", sessionID, id, before, user.ID)
+	return session, nil
+}
+
+// handleFork serves POST /api/fork, which branches a conversation so an
+// alternative hypothesis can be explored without losing the original thread
+func handleFork(w http.ResponseWriter, r *http.Request, store *wsSessionStore) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req forkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.SessionID == "" || req.Before < 0 {
+		http.Error(w, "session_id and a non-negative before are required", http.StatusBadRequest)
+		return
+	}
+	user, _ := r.Context().Value(userContextKey).(*User)
+
+	session, err := store.fork(req.SessionID, req.Before, user)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"session_id": session.ID})
+}
@@ -0,0 +1,76 @@
+package graphqlapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ExecuteTool runs a query or mutation, passing the tool params as variables
+func (a *API) ExecuteTool(tool *OperationTool, params map[string]interface{}, authHeader string) (string, error) {
+	selection := a.defaultSelection(tool.ReturnType)
+	if s, ok := params["__selection"].(string); ok && strings.TrimSpace(s) != "" {
+		selection = strings.TrimSpace(s)
+		if !strings.HasPrefix(selection, "{") {
+			selection = "{ " + selection + " }"
+		}
+	}
+
+	// Declare and pass only the arguments that were provided
+	var varDefs, args []string
+	variables := make(map[string]interface{})
+	for _, arg := range tool.Args {
+		value, ok := params[arg.Name]
+		if !ok {
+			continue
+		}
+		varDefs = append(varDefs, fmt.Sprintf("$%s: %s", arg.Name, arg.Type))
+		args = append(args, fmt.Sprintf("%s: $%s", arg.Name, arg.Name))
+		variables[arg.Name] = value
+	}
+
+	var query strings.Builder
+	query.WriteString(tool.Operation + " Willknow")
+	if len(varDefs) > 0 {
+		query.WriteString("(" + strings.Join(varDefs, ", ") + ")")
+	}
+	query.WriteString(" { " + tool.Field)
+	if len(args) > 0 {
+		query.WriteString("(" + strings.Join(args, ", ") + ")")
+	}
+	if selection != "" {
+		query.WriteString(" " + selection)
+	}
+	query.WriteString(" }")
+
+	data, err := a.post(map[string]interface{}{
+		"query":         query.String(),
+		"variables":     variables,
+		"operationName": "Willknow",
+	}, authHeader)
+	if err != nil {
+		return fmt.Sprintf("GraphQL call failed: %v", err), nil
+	}
+
+	// Pretty-print JSON response; GraphQL errors are part of the body
+	var prettyJSON interface{}
+	if json.Unmarshal(data, &prettyJSON) == nil {
+		if pretty, err := json.MarshalIndent(prettyJSON, "", "  "); err == nil {
+			return string(pretty), nil
+		}
+	}
+	return string(data), nil
+}
+
+// FindTool looks up an OperationTool by name
+func (a *API) FindTool(name string) *OperationTool {
+	if a == nil {
+		return nil
+	}
+	for _, t := range a.Tools {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
@@ -0,0 +1,420 @@
+package graphqlapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/willknow-ai/willknow-go/provider"
+)
+
+// MaxTools is the maximum number of GraphQL operation tools loaded from the schema
+const MaxTools = 50
+
+// Config configures GraphQL schema-based agent tools
+type Config struct {
+	// Endpoint is the GraphQL HTTP endpoint (e.g., "http://localhost:8080/graphql").
+	// When empty, GraphQL tools are disabled.
+	Endpoint string
+
+	// SchemaFile is an optional path to the schema in SDL form (.graphql).
+	// Use it when introspection is disabled on the endpoint.
+	// Default: "" (load the schema via an introspection query)
+	SchemaFile string
+
+	// Headers are sent with every request (e.g., an API key)
+	Headers map[string]string
+
+	// ReadOnly exposes only query fields, no mutations
+	ReadOnly bool
+}
+
+// Schema is the subset of a GraphQL schema needed to generate tools.
+// Its JSON form matches the introspection result.
+type Schema struct {
+	QueryType    *namedRef     `json:"queryType"`
+	MutationType *namedRef     `json:"mutationType"`
+	Types        []*schemaType `json:"types"`
+
+	byName map[string]*schemaType
+}
+
+type namedRef struct {
+	Name string `json:"name"`
+}
+
+type schemaType struct {
+	Kind          string        `json:"kind"` // SCALAR, OBJECT, INTERFACE, UNION, ENUM, INPUT_OBJECT
+	Name          string        `json:"name"`
+	Description   string        `json:"description"`
+	Fields        []*field      `json:"fields"`
+	InputFields   []*inputValue `json:"inputFields"`
+	EnumValues    []namedRef    `json:"enumValues"`
+	PossibleTypes []namedRef    `json:"possibleTypes"`
+}
+
+type field struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Args        []*inputValue `json:"args"`
+	Type        *typeRef      `json:"type"`
+}
+
+type inputValue struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Type        *typeRef `json:"type"`
+}
+
+// typeRef is a possibly wrapped type reference: NON_NULL and LIST wrap OfType
+type typeRef struct {
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name"`
+	OfType *typeRef `json:"ofType"`
+}
+
+// String renders the reference in GraphQL syntax, e.g. "[ID!]!"
+func (t *typeRef) String() string {
+	switch t.Kind {
+	case "NON_NULL":
+		return t.OfType.String() + "!"
+	case "LIST":
+		return "[" + t.OfType.String() + "]"
+	}
+	return t.Name
+}
+
+// named returns the innermost named type
+func (t *typeRef) named() string {
+	for t.OfType != nil {
+		t = t.OfType
+	}
+	return t.Name
+}
+
+// OperationTool represents a single query or mutation field as a callable tool
+type OperationTool struct {
+	Name        string // tool name, e.g. query_order
+	Operation   string // "query" or "mutation"
+	Field       string
+	Description string
+	Args        []*inputValue
+	ReturnType  *typeRef
+}
+
+// API holds the loaded schema and the tools generated from it
+type API struct {
+	config Config
+	schema *Schema
+	client *http.Client
+	Tools  []*OperationTool
+}
+
+// introspectionQuery fetches types with four levels of type wrapping
+const introspectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    types {
+      kind name description
+      fields(includeDeprecated: false) { name description args { name description type { ...TypeRef } } type { ...TypeRef } }
+      inputFields { name description type { ...TypeRef } }
+      enumValues(includeDeprecated: false) { name }
+      possibleTypes { name }
+    }
+  }
+}
+fragment TypeRef on __Type {
+  kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name } } } }
+}`
+
+// Load reads the schema from SchemaFile or via introspection and generates tools
+func Load(config Config) (*API, error) {
+	api := &API{
+		config: config,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+
+	var schema *Schema
+	var err error
+	if config.SchemaFile != "" {
+		data, readErr := os.ReadFile(config.SchemaFile)
+		if readErr != nil {
+			return nil, fmt.Errorf("failed to read schema file: %w", readErr)
+		}
+		schema, err = ParseSDL(string(data))
+	} else {
+		schema, err = api.introspect()
+	}
+	if err != nil {
+		return nil, err
+	}
+	schema.index()
+	api.schema = schema
+
+	totalFields := 0
+	addTools := func(operation string, root *namedRef) {
+		if root == nil {
+			return
+		}
+		t := schema.byName[root.Name]
+		if t == nil {
+			return
+		}
+		for _, f := range t.Fields {
+			if strings.HasPrefix(f.Name, "__") {
+				continue
+			}
+			totalFields++
+			if len(api.Tools) >= MaxTools {
+				continue
+			}
+			desc := f.Description
+			if desc == "" {
+				desc = fmt.Sprintf("GraphQL %s %s returning %s", operation, f.Name, f.Type)
+			}
+			api.Tools = append(api.Tools, &OperationTool{
+				Name:        operation + "_" + f.Name,
+				Operation:   operation,
+				Field:       f.Name,
+				Description: desc,
+				Args:        f.Args,
+				ReturnType:  f.Type,
+			})
+		}
+	}
+	addTools("query", schema.QueryType)
+	if !config.ReadOnly {
+		addTools("mutation", schema.MutationType)
+	}
+
+	if totalFields > MaxTools {
+		fmt.Printf("[Willknow] Warning: GraphQL schema has %d operations, only the first %d are loaded.\n", totalFields, MaxTools)
+	}
+	return api, nil
+}
+
+// introspect loads the schema from the endpoint's introspection query
+func (a *API) introspect() (*Schema, error) {
+	data, err := a.post(map[string]interface{}{"query": introspectionQuery}, "")
+	if err != nil {
+		return nil, fmt.Errorf("introspection failed: %w", err)
+	}
+	var result struct {
+		Data struct {
+			Schema *Schema `json:"__schema"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("invalid introspection response: %w", err)
+	}
+	if len(result.Errors) > 0 {
+		return nil, fmt.Errorf("introspection failed: %s (set SchemaFile if introspection is disabled)", result.Errors[0].Message)
+	}
+	if result.Data.Schema == nil {
+		return nil, fmt.Errorf("introspection returned no schema")
+	}
+	return result.Data.Schema, nil
+}
+
+// post sends a GraphQL request and returns the raw response body
+func (a *API) post(body map[string]interface{}, authHeader string) ([]byte, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, a.config.Endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	for k, v := range a.config.Headers {
+		req.Header.Set(k, v)
+	}
+	if authHeader != "" {
+		req.Header.Set("Authorization", authHeader)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode >= 300 && !json.Valid(data) {
+		return nil, fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
+
+func (s *Schema) index() {
+	s.byName = make(map[string]*schemaType, len(s.Types))
+	for _, t := range s.Types {
+		s.byName[t.Name] = t
+	}
+}
+
+// maxSchemaDepth bounds nested input object expansion
+const maxSchemaDepth = 4
+
+// ToProviderTool converts an OperationTool to a provider.Tool definition for the LLM.
+// Arguments become properties; __selection optionally overrides the selection set.
+func (a *API) ToProviderTool(t *OperationTool) provider.Tool {
+	properties := make(map[string]interface{})
+	var required []string
+	for _, arg := range t.Args {
+		prop := a.typeSchema(arg.Type, 0)
+		if arg.Description != "" {
+			prop["description"] = arg.Description
+		}
+		properties[arg.Name] = prop
+		if arg.Type.Kind == "NON_NULL" {
+			required = append(required, arg.Name)
+		}
+	}
+	if selection := a.defaultSelection(t.ReturnType); selection != "" {
+		properties["__selection"] = map[string]interface{}{
+			"type":        "string",
+			"description": fmt.Sprintf("Optional: GraphQL selection set for the %s result, e.g. '{ id name }'. Default: %s", t.ReturnType.named(), selection),
+		}
+	}
+
+	inputSchema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		inputSchema["required"] = required
+	}
+
+	return provider.Tool{
+		Name:        t.Name,
+		Description: t.Description,
+		InputSchema: inputSchema,
+	}
+}
+
+// typeSchema builds a JSON schema for an input type reference
+func (a *API) typeSchema(ref *typeRef, depth int) map[string]interface{} {
+	switch ref.Kind {
+	case "NON_NULL":
+		return a.typeSchema(ref.OfType, depth)
+	case "LIST":
+		return map[string]interface{}{"type": "array", "items": a.typeSchema(ref.OfType, depth+1)}
+	}
+
+	switch ref.Name {
+	case "Int":
+		return map[string]interface{}{"type": "integer"}
+	case "Float":
+		return map[string]interface{}{"type": "number"}
+	case "Boolean":
+		return map[string]interface{}{"type": "boolean"}
+	case "String", "ID":
+		return map[string]interface{}{"type": "string"}
+	}
+
+	t := a.schema.byName[ref.Name]
+	if t == nil {
+		return map[string]interface{}{"type": "string"}
+	}
+	switch t.Kind {
+	case "ENUM":
+		var values []string
+		for _, v := range t.EnumValues {
+			values = append(values, v.Name)
+		}
+		return map[string]interface{}{"type": "string", "enum": values}
+	case "INPUT_OBJECT":
+		schema := map[string]interface{}{"type": "object"}
+		if depth >= maxSchemaDepth {
+			return schema
+		}
+		properties := make(map[string]interface{})
+		var required []string
+		for _, f := range t.InputFields {
+			prop := a.typeSchema(f.Type, depth+1)
+			if f.Description != "" {
+				prop["description"] = f.Description
+			}
+			properties[f.Name] = prop
+			if f.Type.Kind == "NON_NULL" {
+				required = append(required, f.Name)
+			}
+		}
+		schema["properties"] = properties
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	}
+	// Custom scalars (DateTime, JSON, ...) are passed through as strings
+	return map[string]interface{}{"type": "string", "description": "Custom scalar " + t.Name}
+}
+
+// maxSelectionDepth bounds how deep the generated default selection set goes
+const maxSelectionDepth = 2
+
+// defaultSelection generates a selection set for a return type: all scalar
+// fields plus nested objects up to maxSelectionDepth. Empty for leaf types.
+func (a *API) defaultSelection(ref *typeRef) string {
+	return a.selection(ref.named(), 0)
+}
+
+func (a *API) selection(typeName string, depth int) string {
+	t := a.schema.byName[typeName]
+	if t == nil {
+		return ""
+	}
+	switch t.Kind {
+	case "UNION":
+		return "{ __typename }"
+	case "OBJECT", "INTERFACE":
+	default:
+		return ""
+	}
+
+	var parts []string
+	for _, f := range t.Fields {
+		if hasRequiredArgs(f) {
+			continue
+		}
+		child := a.schema.byName[f.Type.named()]
+		if child == nil || child.Kind == "SCALAR" || child.Kind == "ENUM" {
+			parts = append(parts, f.Name)
+			continue
+		}
+		if depth+1 >= maxSelectionDepth {
+			continue
+		}
+		if sub := a.selection(child.Name, depth+1); sub != "" {
+			parts = append(parts, f.Name+" "+sub)
+		}
+	}
+	if len(parts) == 0 {
+		return "{ __typename }"
+	}
+	return "{ " + strings.Join(parts, " ") + " }"
+}
+
+func hasRequiredArgs(f *field) bool {
+	for _, arg := range f.Args {
+		if arg.Type.Kind == "NON_NULL" {
+			return true
+		}
+	}
+	return false
+}
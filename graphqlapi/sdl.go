@@ -0,0 +1,446 @@
+package graphqlapi
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ParseSDL parses a schema in GraphQL schema definition language.
+// Directives, default values and directive definitions are skipped.
+func ParseSDL(src string) (*Schema, error) {
+	p := &sdlParser{lex: sdlLexer{src: strings.TrimPrefix(src, "\ufeff")}}
+	p.next()
+
+	schema := &Schema{}
+	types := make(map[string]*schemaType)
+	var order []string
+	getType := func(kind, name string) *schemaType {
+		t, ok := types[name]
+		if !ok {
+			t = &schemaType{Kind: kind, Name: name}
+			types[name] = t
+			order = append(order, name)
+		}
+		return t
+	}
+
+	for p.tok.kind != tokEOF {
+		desc := p.description()
+		p.keyword("extend") // extensions merge into the existing definition
+		keyword := p.expectName()
+
+		switch keyword {
+		case "schema":
+			p.directives()
+			if p.tok.value == "{" {
+				p.next()
+				for p.tok.value != "}" && p.tok.kind != tokEOF {
+					op := p.expectName()
+					p.expect(":")
+					name := p.expectName()
+					switch op {
+					case "query":
+						schema.QueryType = &namedRef{Name: name}
+					case "mutation":
+						schema.MutationType = &namedRef{Name: name}
+					}
+				}
+				p.expect("}")
+			}
+		case "scalar":
+			t := getType("SCALAR", p.expectName())
+			t.Description = pick(t.Description, desc)
+			p.directives()
+		case "type", "interface":
+			kind := "OBJECT"
+			if keyword == "interface" {
+				kind = "INTERFACE"
+			}
+			t := getType(kind, p.expectName())
+			t.Description = pick(t.Description, desc)
+			if p.keyword("implements") {
+				p.skip("&")
+				for p.tok.kind == tokName {
+					p.next()
+					p.skip("&")
+				}
+			}
+			p.directives()
+			if p.tok.value == "{" {
+				fields, err := p.fields()
+				if err != nil {
+					return nil, err
+				}
+				t.Fields = append(t.Fields, fields...)
+			}
+		case "union":
+			t := getType("UNION", p.expectName())
+			t.Description = pick(t.Description, desc)
+			p.directives()
+			if p.skip("=") {
+				p.skip("|")
+				for p.tok.kind == tokName {
+					t.PossibleTypes = append(t.PossibleTypes, namedRef{Name: p.tok.value})
+					p.next()
+					if !p.skip("|") {
+						break
+					}
+				}
+			}
+		case "enum":
+			t := getType("ENUM", p.expectName())
+			t.Description = pick(t.Description, desc)
+			p.directives()
+			if p.skip("{") {
+				for p.tok.value != "}" && p.tok.kind != tokEOF {
+					p.description()
+					t.EnumValues = append(t.EnumValues, namedRef{Name: p.expectName()})
+					p.directives()
+				}
+				p.expect("}")
+			}
+		case "input":
+			t := getType("INPUT_OBJECT", p.expectName())
+			t.Description = pick(t.Description, desc)
+			p.directives()
+			if p.skip("{") {
+				for p.tok.value != "}" && p.tok.kind != tokEOF {
+					v, err := p.inputValue()
+					if err != nil {
+						return nil, err
+					}
+					t.InputFields = append(t.InputFields, v)
+				}
+				p.expect("}")
+			}
+		case "directive":
+			// directive @name(args) repeatable on LOCATION | LOCATION
+			p.expect("@")
+			p.expectName()
+			if p.tok.value == "(" {
+				p.skipBalanced("(", ")")
+			}
+			p.keyword("repeatable")
+			p.keyword("on")
+			p.skip("|")
+			for p.tok.kind == tokName {
+				p.next()
+				if !p.skip("|") {
+					break
+				}
+			}
+		default:
+			return nil, fmt.Errorf("schema line %d: unexpected %q", p.tok.line, keyword)
+		}
+		if p.err != nil {
+			return nil, p.err
+		}
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	// Default root operation types
+	if schema.QueryType == nil && types["Query"] != nil {
+		schema.QueryType = &namedRef{Name: "Query"}
+	}
+	if schema.MutationType == nil && types["Mutation"] != nil {
+		schema.MutationType = &namedRef{Name: "Mutation"}
+	}
+	if schema.QueryType == nil {
+		return nil, fmt.Errorf("schema has no Query type")
+	}
+
+	for _, name := range order {
+		schema.Types = append(schema.Types, types[name])
+	}
+	return schema, nil
+}
+
+func pick(current, desc string) string {
+	if current != "" {
+		return current
+	}
+	return desc
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokName
+	tokPunct
+	tokString
+	tokNumber
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	line  int
+}
+
+type sdlLexer struct {
+	src  string
+	pos  int
+	line int
+}
+
+// next returns the next token, skipping whitespace, commas and comments
+func (l *sdlLexer) next() (token, error) {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		if c == '\n' {
+			l.line++
+		}
+		if c == '#' {
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+			continue
+		}
+		if c == ',' || unicode.IsSpace(rune(c)) {
+			l.pos++
+			continue
+		}
+		break
+	}
+	line := l.line + 1
+	if l.pos >= len(l.src) {
+		return token{kind: tokEOF, line: line}, nil
+	}
+
+	start := l.pos
+	c := l.src[l.pos]
+	switch {
+	case strings.HasPrefix(l.src[l.pos:], `"""`):
+		end := strings.Index(l.src[l.pos+3:], `"""`)
+		if end < 0 {
+			return token{}, fmt.Errorf("schema line %d: unterminated block string", line)
+		}
+		value := l.src[l.pos+3 : l.pos+3+end]
+		l.line += strings.Count(value, "\n")
+		l.pos += end + 6
+		return token{kind: tokString, value: dedent(value), line: line}, nil
+	case c == '"':
+		l.pos++
+		var sb strings.Builder
+		for l.pos < len(l.src) && l.src[l.pos] != '"' {
+			if l.src[l.pos] == '\\' && l.pos+1 < len(l.src) {
+				l.pos++
+			}
+			if l.src[l.pos] == '\n' {
+				return token{}, fmt.Errorf("schema line %d: unterminated string", line)
+			}
+			sb.WriteByte(l.src[l.pos])
+			l.pos++
+		}
+		l.pos++
+		return token{kind: tokString, value: sb.String(), line: line}, nil
+	case c == '_' || unicode.IsLetter(rune(c)):
+		for l.pos < len(l.src) && (l.src[l.pos] == '_' || unicode.IsLetter(rune(l.src[l.pos])) || unicode.IsDigit(rune(l.src[l.pos]))) {
+			l.pos++
+		}
+		return token{kind: tokName, value: l.src[start:l.pos], line: line}, nil
+	case c == '-' || unicode.IsDigit(rune(c)):
+		l.pos++
+		for l.pos < len(l.src) && strings.ContainsRune("0123456789.eE+-", rune(l.src[l.pos])) {
+			l.pos++
+		}
+		return token{kind: tokNumber, value: l.src[start:l.pos], line: line}, nil
+	case strings.HasPrefix(l.src[l.pos:], "..."):
+		l.pos += 3
+		return token{kind: tokPunct, value: "...", line: line}, nil
+	case strings.ContainsRune("!$&()/:=@[]{}|", rune(c)):
+		l.pos++
+		return token{kind: tokPunct, value: string(c), line: line}, nil
+	}
+	return token{}, fmt.Errorf("schema line %d: unexpected character %q", line, c)
+}
+
+// dedent strips the common indentation of a block string
+func dedent(s string) string {
+	lines := strings.Split(s, "\n")
+	indent := -1
+	for _, line := range lines[1:] {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" {
+			continue
+		}
+		if n := len(line) - len(trimmed); indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	for i := 1; i < len(lines); i++ {
+		if indent > 0 && len(lines[i]) >= indent {
+			lines[i] = lines[i][indent:]
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// sdlParser is a recursive-descent parser over sdlLexer. The first error is
+// kept in err; afterwards the parser only yields EOF so callers can unwind.
+type sdlParser struct {
+	lex sdlLexer
+	tok token
+	err error
+}
+
+func (p *sdlParser) next() {
+	if p.err != nil {
+		p.tok = token{kind: tokEOF}
+		return
+	}
+	tok, err := p.lex.next()
+	if err != nil {
+		p.err = err
+		tok = token{kind: tokEOF}
+	}
+	p.tok = tok
+}
+
+func (p *sdlParser) fail(format string, args ...interface{}) {
+	if p.err == nil {
+		p.err = fmt.Errorf("schema line %d: %s", p.tok.line, fmt.Sprintf(format, args...))
+	}
+	p.tok = token{kind: tokEOF}
+}
+
+// skip consumes the punctuator if present
+func (p *sdlParser) skip(punct string) bool {
+	if p.tok.kind == tokPunct && p.tok.value == punct {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *sdlParser) expect(punct string) {
+	if !p.skip(punct) {
+		p.fail("expected %q, got %q", punct, p.tok.value)
+	}
+}
+
+// keyword consumes the name if present
+func (p *sdlParser) keyword(name string) bool {
+	if p.tok.kind == tokName && p.tok.value == name {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *sdlParser) expectName() string {
+	if p.tok.kind != tokName {
+		p.fail("expected name, got %q", p.tok.value)
+		return ""
+	}
+	name := p.tok.value
+	p.next()
+	return name
+}
+
+func (p *sdlParser) description() string {
+	if p.tok.kind == tokString {
+		desc := p.tok.value
+		p.next()
+		return desc
+	}
+	return ""
+}
+
+// directives skips any @directive(args) annotations
+func (p *sdlParser) directives() {
+	for p.skip("@") {
+		p.expectName()
+		if p.tok.value == "(" {
+			p.skipBalanced("(", ")")
+		}
+	}
+}
+
+// skipBalanced skips from an opening punctuator to its matching close
+func (p *sdlParser) skipBalanced(open, close string) {
+	depth := 0
+	for p.tok.kind != tokEOF {
+		if p.tok.kind == tokPunct {
+			switch p.tok.value {
+			case open:
+				depth++
+			case close:
+				depth--
+			}
+		}
+		p.next()
+		if depth == 0 {
+			return
+		}
+	}
+	p.fail("unbalanced %q", open)
+}
+
+// skipValue skips a constant value (default values and directive arguments)
+func (p *sdlParser) skipValue() {
+	switch p.tok.value {
+	case "[":
+		p.skipBalanced("[", "]")
+	case "{":
+		p.skipBalanced("{", "}")
+	default:
+		p.next()
+	}
+}
+
+// fields parses a { field(args): Type ... } block
+func (p *sdlParser) fields() ([]*field, error) {
+	p.expect("{")
+	var fields []*field
+	for p.tok.value != "}" && p.tok.kind != tokEOF {
+		f := &field{Description: p.description(), Name: p.expectName()}
+		if p.skip("(") {
+			for p.tok.value != ")" && p.tok.kind != tokEOF {
+				arg, err := p.inputValue()
+				if err != nil {
+					return nil, err
+				}
+				f.Args = append(f.Args, arg)
+			}
+			p.expect(")")
+		}
+		p.expect(":")
+		f.Type = p.typeRef()
+		p.directives()
+		fields = append(fields, f)
+	}
+	p.expect("}")
+	return fields, p.err
+}
+
+// inputValue parses an argument or input field: name: Type = default @directives
+func (p *sdlParser) inputValue() (*inputValue, error) {
+	v := &inputValue{Description: p.description(), Name: p.expectName()}
+	p.expect(":")
+	v.Type = p.typeRef()
+	if p.skip("=") {
+		p.skipValue()
+	}
+	p.directives()
+	return v, p.err
+}
+
+// typeRef parses Name, [Type] and their non-null forms
+func (p *sdlParser) typeRef() *typeRef {
+	var ref *typeRef
+	if p.skip("[") {
+		ref = &typeRef{Kind: "LIST", OfType: p.typeRef()}
+		p.expect("]")
+	} else {
+		ref = &typeRef{Kind: "NAMED", Name: p.expectName()}
+	}
+	if p.skip("!") {
+		ref = &typeRef{Kind: "NON_NULL", OfType: ref}
+	}
+	return ref
+}
@@ -0,0 +1,89 @@
+package aiassistant
+
+import (
+	"fmt"
+	"strings"
+)
+
+// defaultGreeting is the welcome message without GreetingConfig.Message
+const defaultGreeting = "Connected to AI Assistant. How can I help you?"
+
+// maxGreetingLogSources is the number of log sources named in the greeting
+const maxGreetingLogSources = 5
+
+// GreetingConfig configures the welcome message shown when a chat starts in
+// the web UI
+type GreetingConfig struct {
+	// Message is the welcome text, in Markdown; use it to describe the
+	// application and what the assistant is for
+	// Default: "Connected to AI Assistant. How can I help you?"
+	Message string
+
+	// ExampleQuestions are shown below the message; clicking one asks it
+	// (e.g. "Why did request abc123 fail?")
+	ExampleQuestions []string
+
+	// HideCapabilities omits the capabilities detected at startup (log
+	// sources, API tools, code index status) from the greeting
+	HideCapabilities bool
+}
+
+// Greeting is the welcome message of a new session, sent with session_info
+type Greeting struct {
+	Message      string   `json:"message"`
+	Examples     []string `json:"examples,omitempty"`
+	Capabilities []string `json:"capabilities,omitempty"` // one line each, in Markdown
+}
+
+// greeting builds the welcome message of a new session on a target
+func (a *Assistant) greeting(t *target) *Greeting {
+	config := a.config.Greeting
+	g := &Greeting{Message: config.Message, Examples: config.ExampleQuestions}
+	if g.Message == "" {
+		g.Message = defaultGreeting
+	}
+	if !config.HideCapabilities {
+		g.Capabilities = a.capabilities(t)
+	}
+	return g
+}
+
+// capabilities describes what the assistant can use on a target
+func (a *Assistant) capabilities(t *target) []string {
+	var list []string
+	if names := t.toolRegistry.LogSourceNames(); len(names) > 0 {
+		shown := names
+		if len(shown) > maxGreetingLogSources {
+			shown = shown[:maxGreetingLogSources]
+		}
+		line := "**Logs**: " + strings.Join(shown, ", ")
+		if more := len(names) - len(shown); more > 0 {
+			line += fmt.Sprintf(" and %d more", more)
+		}
+		list = append(list, line)
+	} else {
+		list = append(list, "**Logs**: none configured")
+	}
+
+	var apis []string
+	if len(t.apiTools) > 0 {
+		apis = append(apis, fmt.Sprintf("%d REST operations", len(t.apiTools)))
+	}
+	if a.grpcService != nil {
+		apis = append(apis, fmt.Sprintf("%d gRPC methods", len(a.grpcService.Tools)))
+	}
+	if a.graphqlAPI != nil {
+		apis = append(apis, fmt.Sprintf("%d GraphQL operations", len(a.graphqlAPI.Tools)))
+	}
+	if len(apis) > 0 {
+		list = append(list, "**API tools**: "+strings.Join(apis, ", "))
+	}
+
+	switch {
+	case t.codeIndex != nil:
+		list = append(list, fmt.Sprintf("**Code index**: %d files, built %s", len(t.codeIndex.Files), t.codeIndex.CreatedAt.Format("2006-01-02 15:04")))
+	case t.config.EnableCodeIndex:
+		list = append(list, "**Code index**: unavailable; the source is searched with grep")
+	}
+	return list
+}
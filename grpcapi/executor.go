@@ -0,0 +1,61 @@
+package grpcapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// ExecuteTool calls a gRPC method, transcoding the JSON params to the request
+// message and the response message back to JSON
+func (s *Service) ExecuteTool(tool *MethodTool, params map[string]interface{}, authHeader string) (string, error) {
+	paramsJSON, err := json.Marshal(params)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req := dynamicpb.NewMessage(tool.Input)
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(paramsJSON, req); err != nil {
+		return "", fmt.Errorf("invalid request for %s: %w", tool.FullMethod, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	md := metadata.New(s.config.Metadata)
+	if authHeader != "" {
+		md.Set("authorization", authHeader)
+	}
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	resp := dynamicpb.NewMessage(tool.Output)
+	if err := s.conn.Invoke(ctx, tool.FullMethod, req, resp); err != nil {
+		st := status.Convert(err)
+		return fmt.Sprintf("gRPC call failed with status %s: %s", st.Code(), st.Message()), nil
+	}
+
+	out, err := (protojson.MarshalOptions{Multiline: true, Indent: "  ", EmitUnpopulated: true}).Marshal(resp)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode response: %w", err)
+	}
+	return string(out), nil
+}
+
+// FindTool looks up a MethodTool by name
+func (s *Service) FindTool(name string) *MethodTool {
+	if s == nil {
+		return nil
+	}
+	for _, t := range s.Tools {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
@@ -0,0 +1,359 @@
+package grpcapi
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/willknow-ai/willknow-go/provider"
+)
+
+// MaxTools is the maximum number of gRPC method tools loaded via reflection
+const MaxTools = 50
+
+// Config configures gRPC reflection-based agent tools
+type Config struct {
+	// Target is the gRPC server address (e.g., "localhost:9090").
+	// The server must have the reflection service enabled.
+	// When empty, gRPC tools are disabled.
+	Target string
+
+	// TLS enables transport security. Default: plaintext.
+	TLS bool
+
+	// Services limits the exposed services to these fully-qualified names
+	// (e.g., "shop.v1.OrderService"). Default: all services except the
+	// reflection and health services.
+	Services []string
+
+	// Metadata is sent with every call (e.g., an API key header)
+	Metadata map[string]string
+}
+
+// MethodTool represents a single unary gRPC method as a callable tool
+type MethodTool struct {
+	Name        string // tool name, e.g. OrderService_GetOrder
+	FullMethod  string // e.g. /shop.v1.OrderService/GetOrder
+	Description string
+	Input       protoreflect.MessageDescriptor
+	Output      protoreflect.MessageDescriptor
+	ReadOnly    bool // the method's idempotency_level is NO_SIDE_EFFECTS
+}
+
+// Service holds a connection to a gRPC server and the tools generated from it
+type Service struct {
+	config Config
+	conn   *grpc.ClientConn
+	Tools  []*MethodTool
+}
+
+// Load connects to a gRPC server and generates tools from its reflection service
+func Load(config Config) (*Service, error) {
+	creds := insecure.NewCredentials()
+	if config.TLS {
+		creds = credentials.NewTLS(&tls.Config{})
+	}
+	conn, err := grpc.NewClient(config.Target, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", config.Target, err)
+	}
+
+	files, err := fetchDescriptors(conn, config)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	svc := &Service{config: config, conn: conn}
+	totalMethods := 0
+	for _, sd := range files {
+		methods := sd.Methods()
+		for i := 0; i < methods.Len(); i++ {
+			m := methods.Get(i)
+			if m.IsStreamingClient() || m.IsStreamingServer() {
+				continue // only unary methods can be called as tools
+			}
+			totalMethods++
+			if len(svc.Tools) >= MaxTools {
+				continue
+			}
+			svc.Tools = append(svc.Tools, newMethodTool(sd, m))
+		}
+	}
+
+	if totalMethods > MaxTools {
+		fmt.Printf("[Willknow] Warning: gRPC server has %d unary methods, only the first %d are loaded. Use Services to narrow them down.\n", totalMethods, MaxTools)
+	}
+	return svc, nil
+}
+
+// Close closes the connection to the gRPC server
+func (s *Service) Close() error {
+	return s.conn.Close()
+}
+
+// fetchDescriptors lists the server's services and resolves their descriptors
+func fetchDescriptors(conn *grpc.ClientConn, config Config) ([]protoreflect.ServiceDescriptor, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	stream, err := rpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reflection unavailable: %w", err)
+	}
+	defer stream.CloseSend()
+
+	// ask sends a reflection request and waits for its response
+	ask := func(req *rpb.ServerReflectionRequest) (*rpb.ServerReflectionResponse, error) {
+		if err := stream.Send(req); err != nil {
+			return nil, err
+		}
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return nil, fmt.Errorf("reflection stream closed")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if e := resp.GetErrorResponse(); e != nil {
+			return nil, fmt.Errorf("reflection error: %s", e.ErrorMessage)
+		}
+		return resp, nil
+	}
+
+	resp, err := ask(&rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_ListServices{ListServices: "*"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+
+	allowed := make(map[string]bool)
+	for _, name := range config.Services {
+		allowed[name] = true
+	}
+	var serviceNames []string
+	for _, s := range resp.GetListServicesResponse().GetService() {
+		if len(allowed) > 0 {
+			if !allowed[s.Name] {
+				continue
+			}
+		} else if strings.HasPrefix(s.Name, "grpc.reflection.") || s.Name == "grpc.health.v1.Health" {
+			continue
+		}
+		serviceNames = append(serviceNames, s.Name)
+	}
+
+	// Collect file descriptors for each service, then any missing dependencies
+	fdps := make(map[string]*descriptorpb.FileDescriptorProto)
+	addFiles := func(resp *rpb.ServerReflectionResponse) error {
+		for _, raw := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			fdp := &descriptorpb.FileDescriptorProto{}
+			if err := proto.Unmarshal(raw, fdp); err != nil {
+				return fmt.Errorf("invalid file descriptor: %w", err)
+			}
+			fdps[fdp.GetName()] = fdp
+		}
+		return nil
+	}
+	for _, name := range serviceNames {
+		resp, err := ask(&rpb.ServerReflectionRequest{
+			MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: name},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", name, err)
+		}
+		if err := addFiles(resp); err != nil {
+			return nil, err
+		}
+	}
+	for {
+		var missing []string
+		for _, fdp := range fdps {
+			for _, dep := range fdp.GetDependency() {
+				if _, ok := fdps[dep]; !ok {
+					missing = append(missing, dep)
+				}
+			}
+		}
+		if len(missing) == 0 {
+			break
+		}
+		for _, dep := range missing {
+			if _, ok := fdps[dep]; ok {
+				continue
+			}
+			resp, err := ask(&rpb.ServerReflectionRequest{
+				MessageRequest: &rpb.ServerReflectionRequest_FileByFilename{FileByFilename: dep},
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve %s: %w", dep, err)
+			}
+			if err := addFiles(resp); err != nil {
+				return nil, err
+			}
+			if _, ok := fdps[dep]; !ok {
+				return nil, fmt.Errorf("server did not return descriptor for %s", dep)
+			}
+		}
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, fdp := range fdps {
+		set.File = append(set.File, fdp)
+	}
+	registry, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build descriptors: %w", err)
+	}
+
+	var services []protoreflect.ServiceDescriptor
+	for _, name := range serviceNames {
+		d, err := registry.FindDescriptorByName(protoreflect.FullName(name))
+		if err != nil {
+			return nil, fmt.Errorf("service %s not found in descriptors: %w", name, err)
+		}
+		if sd, ok := d.(protoreflect.ServiceDescriptor); ok {
+			services = append(services, sd)
+		}
+	}
+	return services, nil
+}
+
+func newMethodTool(sd protoreflect.ServiceDescriptor, m protoreflect.MethodDescriptor) *MethodTool {
+	desc := strings.TrimSpace(sd.ParentFile().SourceLocations().ByDescriptor(m).LeadingComments)
+	if desc == "" {
+		desc = fmt.Sprintf("Call %s.%s (%s → %s)", sd.FullName(), m.Name(), m.Input().FullName(), m.Output().FullName())
+	}
+	opts, _ := m.Options().(*descriptorpb.MethodOptions)
+	readOnly := opts.GetIdempotencyLevel() == descriptorpb.MethodOptions_NO_SIDE_EFFECTS
+	return &MethodTool{
+		Name:        string(sd.Name()) + "_" + string(m.Name()),
+		FullMethod:  fmt.Sprintf("/%s/%s", sd.FullName(), m.Name()),
+		Description: desc,
+		Input:       m.Input(),
+		Output:      m.Output(),
+		ReadOnly:    readOnly,
+	}
+}
+
+// ToProviderTool converts a MethodTool to a provider.Tool definition for the LLM.
+// The input schema follows the protobuf JSON mapping of the request message.
+func (t *MethodTool) ToProviderTool() provider.Tool {
+	schema := messageSchema(t.Input, 0)
+	return provider.Tool{
+		Name:        t.Name,
+		Description: t.Description,
+		InputSchema: schema,
+	}
+}
+
+// maxSchemaDepth bounds nested and recursive message expansion
+const maxSchemaDepth = 4
+
+// messageSchema builds a JSON schema for a message using protojson field names
+func messageSchema(md protoreflect.MessageDescriptor, depth int) map[string]interface{} {
+	if wk := wellKnownSchema(md); wk != nil {
+		return wk
+	}
+	schema := map[string]interface{}{"type": "object"}
+	if depth >= maxSchemaDepth {
+		return schema
+	}
+
+	properties := make(map[string]interface{})
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		var prop map[string]interface{}
+		switch {
+		case fd.IsMap():
+			prop = map[string]interface{}{
+				"type":                 "object",
+				"additionalProperties": fieldSchema(fd.MapValue(), depth+1),
+			}
+		case fd.IsList():
+			prop = map[string]interface{}{
+				"type":  "array",
+				"items": fieldSchema(fd, depth+1),
+			}
+		default:
+			prop = fieldSchema(fd, depth+1)
+		}
+		comment := strings.TrimSpace(md.ParentFile().SourceLocations().ByDescriptor(fd).LeadingComments)
+		if comment != "" {
+			prop["description"] = comment
+		}
+		properties[fd.JSONName()] = prop
+	}
+	schema["properties"] = properties
+	return schema
+}
+
+// fieldSchema builds a JSON schema for a single (non-repeated) field value
+func fieldSchema(fd protoreflect.FieldDescriptor, depth int) map[string]interface{} {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return map[string]interface{}{"type": "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return map[string]interface{}{"type": "integer"}
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return map[string]interface{}{"type": "number"}
+	case protoreflect.BytesKind:
+		return map[string]interface{}{"type": "string", "description": "base64-encoded bytes"}
+	case protoreflect.EnumKind:
+		values := fd.Enum().Values()
+		var names []string
+		for i := 0; i < values.Len(); i++ {
+			names = append(names, string(values.Get(i).Name()))
+		}
+		return map[string]interface{}{"type": "string", "enum": names}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return messageSchema(fd.Message(), depth)
+	default:
+		return map[string]interface{}{"type": "string"}
+	}
+}
+
+// wellKnownSchema returns the JSON schema of well-known types with special JSON mappings
+func wellKnownSchema(md protoreflect.MessageDescriptor) map[string]interface{} {
+	switch md.FullName() {
+	case "google.protobuf.Timestamp":
+		return map[string]interface{}{"type": "string", "description": "RFC3339 timestamp, e.g. 2024-01-02T15:04:05Z"}
+	case "google.protobuf.Duration":
+		return map[string]interface{}{"type": "string", "description": "Duration in seconds with 's' suffix, e.g. 1.5s"}
+	case "google.protobuf.FieldMask":
+		return map[string]interface{}{"type": "string", "description": "Comma-separated field paths"}
+	case "google.protobuf.StringValue", "google.protobuf.BytesValue":
+		return map[string]interface{}{"type": "string"}
+	case "google.protobuf.BoolValue":
+		return map[string]interface{}{"type": "boolean"}
+	case "google.protobuf.Int32Value", "google.protobuf.UInt32Value",
+		"google.protobuf.Int64Value", "google.protobuf.UInt64Value":
+		return map[string]interface{}{"type": "integer"}
+	case "google.protobuf.FloatValue", "google.protobuf.DoubleValue":
+		return map[string]interface{}{"type": "number"}
+	case "google.protobuf.Struct", "google.protobuf.Any":
+		return map[string]interface{}{"type": "object"}
+	case "google.protobuf.ListValue":
+		return map[string]interface{}{"type": "array"}
+	case "google.protobuf.Value":
+		return map[string]interface{}{}
+	}
+	return nil
+}
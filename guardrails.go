@@ -0,0 +1,279 @@
+package aiassistant
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/willknow-ai/willknow-go/openapi"
+	"github.com/willknow-ai/willknow-go/tools"
+)
+
+// DefaultSecretPaths are glob patterns of files that commonly hold secrets,
+// for use in GuardrailsConfig.DeniedPaths
+var DefaultSecretPaths = []string{
+	".env", ".env.*", "*.pem", "*.key", "*.p12", "*.pfx", "*.jks", "*.keystore",
+	"id_rsa*", "id_ecdsa*", "id_ed25519*", ".netrc", ".npmrc", ".pypirc",
+	"credentials", "credentials.json", "*.tfstate", "secrets", ".git",
+}
+
+// GuardrailsConfig defines policies checked before every tool call. A blocked
+// call is not executed: the model receives the reason instead of a result,
+// and the violation is logged.
+type GuardrailsConfig struct {
+	// DeniedPaths are glob patterns of source files the file tools may not
+	// read. A pattern matches any run of path components, so "*.pem" matches
+	// "certs/server.pem" and "secrets" matches everything under a secrets
+	// directory. grep, glob and compare_deployed_source results in
+	// denied files are hidden.
+	// See DefaultSecretPaths.
+	DeniedPaths []string
+
+	// AllowedHosts limits the hosts tools may reach: host API, gRPC and GraphQL
+	// calls are blocked unless their endpoint is allowlisted, and so is any
+	// http(s) URL passed as a tool argument. Entries are host names or
+	// "*.suffix" patterns. Default: no restriction
+	AllowedHosts []string
+
+	// MaxLogRange limits the time range of log and metric queries (read_logs,
+	// query_metrics). read_logs calls without a start_time are blocked, since
+	// they search the whole history. Default: no limit
+	MaxLogRange time.Duration
+
+	// Check is a custom policy called before every tool call, after the rules
+	// above. Return an error to block the call; its message is shown to the model.
+	Check func(call ToolCall) error
+}
+
+// ToolCall describes a tool call inspected by guardrails
+type ToolCall struct {
+	Name   string
+	Input  map[string]interface{}
+	User   *User  // nil for automated analyses
+	Target string // target the session debugs
+}
+
+// guardrails enforces GuardrailsConfig
+type guardrails struct {
+	config GuardrailsConfig
+}
+
+// newGuardrails returns nil if no policy is configured
+func newGuardrails(config GuardrailsConfig) *guardrails {
+	if len(config.DeniedPaths) == 0 && len(config.AllowedHosts) == 0 && config.MaxLogRange <= 0 && config.Check == nil {
+		return nil
+	}
+	return &guardrails{config: config}
+}
+
+// check returns an error explaining the violation if the call is not allowed
+func (g *guardrails) check(a *Assistant, session *Session, name string, input map[string]interface{}) error {
+	if g == nil {
+		return nil
+	}
+	t := a.sessionTarget(session)
+	reason := g.violation(a, t, name, input)
+	if reason == "" && g.config.Check != nil {
+		if err := g.config.Check(ToolCall{Name: name, Input: input, User: session.User, Target: t.config.Name}); err != nil {
+			reason = err.Error()
+		}
+	}
+	if reason == "" {
+		return nil
+	}
+
+	userID := ""
+	if session.User != nil {
+		userID = session.User.ID
+	}
+	log.Printf("[Guardrails] Blocked %s (session %s, user %s): %s", name, session.ID, userID, reason)
+	if a.ops != nil {
+		fields := a.ops.sessionFields(session)
+		fields["tool"] = name
+		fields["reason"] = reason
+		a.ops.record("warn", "tool_blocked", fields)
+	}
+	session.logEvent("policy_violation", map[string]interface{}{
+		"tool_name": name,
+		"input":     input,
+		"reason":    reason,
+	})
+	return fmt.Errorf("blocked by policy: %s. This is an administrator policy, not a transient failure: do not retry the same call; work within the policy or tell the user what you could not access", reason)
+}
+
+// violation applies the configured rules and returns why the call is not allowed, or ""
+func (g *guardrails) violation(a *Assistant, t *target, name string, input map[string]interface{}) string {
+	// Paths of the file tools
+	switch name {
+	case "read_file":
+		p, _ := input["file_path"].(string)
+		if pattern := g.deniedPath(p); pattern != "" {
+			return fmt.Sprintf("reading %s is not allowed (matches %q)", p, pattern)
+		}
+	case "grep":
+		p, _ := input["file_pattern"].(string)
+		if pattern := g.deniedPath(p); pattern != "" {
+			return fmt.Sprintf("searching %s is not allowed (matches %q)", p, pattern)
+		}
+	case "glob":
+		p, _ := input["pattern"].(string)
+		if pattern := g.deniedPath(p); pattern != "" {
+			return fmt.Sprintf("listing %s is not allowed (matches %q)", p, pattern)
+		}
+	case "compare_deployed_source":
+		p, _ := input["path"].(string)
+		if pattern := g.deniedPath(p); pattern != "" {
+			return fmt.Sprintf("comparing %s is not allowed (matches %q)", p, pattern)
+		}
+	}
+
+	// Time ranges of log and metric queries
+	if g.config.MaxLogRange > 0 && (name == "read_logs" || name == "query_metrics") {
+		now := time.Now()
+		startStr, _ := input["start_time"].(string)
+		endStr, _ := input["end_time"].(string)
+		start, err1 := tools.ParseTimeParam(startStr, now)
+		end, err2 := tools.ParseTimeParam(endStr, now)
+		if err1 == nil && err2 == nil {
+			if end.IsZero() {
+				end = now
+			}
+			switch {
+			case start.IsZero() && name == "read_logs":
+				return fmt.Sprintf("log queries must set start_time, covering at most %s", g.config.MaxLogRange)
+			case !start.IsZero() && end.Sub(start) > g.config.MaxLogRange:
+				return fmt.Sprintf("the queried time range (%s) exceeds the maximum of %s; narrow start_time/end_time", end.Sub(start).Round(time.Second), g.config.MaxLogRange)
+			}
+		}
+	}
+
+	// Hosts reached by the call
+	if len(g.config.AllowedHosts) > 0 {
+		endpoint := ""
+		switch {
+		case openapi.FindTool(t.apiTools, name) != nil:
+			endpoint = t.config.HostBaseURL
+		case a.grpcService.FindTool(name) != nil:
+			endpoint = "grpc://" + a.config.GRPC.Target
+		case a.graphqlAPI.FindTool(name) != nil:
+			endpoint = a.config.GraphQL.Endpoint
+		case name == replayRequestToolName && a.captures != nil:
+			endpoint = a.captures.baseURL(t)
+		case name == callAgentToolName:
+			agentName, _ := input["agent"].(string)
+			if peer := a.peers.find(agentName); peer != nil {
+				endpoint = peer.chatURL
+			}
+		}
+		if endpoint != "" {
+			if host := urlHost(endpoint); !g.hostAllowed(host) {
+				return fmt.Sprintf("calls to %s are not allowed (host not in the allowlist)", host)
+			}
+		}
+		for _, raw := range stringValues(input) {
+			if !strings.HasPrefix(raw, "http://") && !strings.HasPrefix(raw, "https://") {
+				continue
+			}
+			if host := urlHost(raw); !g.hostAllowed(host) {
+				return fmt.Sprintf("the URL %s points at %s, which is not in the allowlist", raw, host)
+			}
+		}
+	}
+	return ""
+}
+
+// deniedPath returns the DeniedPaths pattern matching p, or "".
+// A pattern matches any run of consecutive path components.
+func (g *guardrails) deniedPath(p string) string {
+	if g == nil || p == "" {
+		return ""
+	}
+	components := strings.Split(path.Clean(strings.ReplaceAll(p, "\\", "/")), "/")
+	for _, pattern := range g.config.DeniedPaths {
+		for i := range components {
+			for j := i + 1; j <= len(components); j++ {
+				if ok, _ := path.Match(pattern, strings.Join(components[i:j], "/")); ok {
+					return pattern
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// filterResult hides grep matches and glob results in denied files
+func (g *guardrails) filterResult(name, result string) string {
+	if g == nil || len(g.config.DeniedPaths) == 0 || (name != "grep" && name != "glob") {
+		return result
+	}
+	lines := strings.Split(result, "\n")
+	kept := lines[:0]
+	hidden := 0
+	for _, line := range lines {
+		file := line
+		if name == "grep" {
+			file, _, _ = strings.Cut(line, ":")
+		}
+		if strings.TrimSpace(file) != "" && g.deniedPath(strings.TrimSpace(file)) != "" {
+			hidden++
+			continue
+		}
+		kept = append(kept, line)
+	}
+	if hidden == 0 {
+		return result
+	}
+	return strings.Join(kept, "\n") + fmt.Sprintf("\n(%d results in files protected by policy were hidden)", hidden)
+}
+
+// hostAllowed reports whether host matches AllowedHosts
+func (g *guardrails) hostAllowed(host string) bool {
+	host = strings.ToLower(host)
+	for _, entry := range g.config.AllowedHosts {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if host == entry {
+			return true
+		}
+		if strings.HasPrefix(entry, "*.") && strings.HasSuffix(host, entry[1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// urlHost returns the host name of a URL
+func urlHost(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	if host, _, err := net.SplitHostPort(u.Host); err == nil {
+		return host
+	}
+	return u.Host
+}
+
+// stringValues returns all string values in nested tool arguments
+func stringValues(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case map[string]interface{}:
+		var out []string
+		for _, item := range v {
+			out = append(out, stringValues(item)...)
+		}
+		return out
+	case []interface{}:
+		var out []string
+		for _, item := range v {
+			out = append(out, stringValues(item)...)
+		}
+		return out
+	}
+	return nil
+}
@@ -3,8 +3,9 @@ package indexer
 import (
 	"encoding/json"
 	"fmt"
+	"io/fs"
 	"os"
-	"path/filepath"
+	"path"
 	"strings"
 	"time"
 
@@ -28,7 +29,13 @@ type FileSummary struct {
 
 // BuildCodeIndex scans the source directory and generates summaries using LLM
 func BuildCodeIndex(sourcePath string, llm provider.Provider) (*CodeIndex, error) {
-	files, err := scanGoFiles(sourcePath)
+	return BuildCodeIndexFS(os.DirFS(sourcePath), sourcePath, llm)
+}
+
+// BuildCodeIndexFS scans the source code in source and generates summaries
+// using LLM. sourcePath describes where the source came from.
+func BuildCodeIndexFS(source fs.FS, sourcePath string, llm provider.Provider) (*CodeIndex, error) {
+	files, err := scanGoFiles(source)
 	if err != nil {
 		return nil, fmt.Errorf("failed to scan files: %w", err)
 	}
@@ -41,20 +48,17 @@ func BuildCodeIndex(sourcePath string, llm provider.Provider) (*CodeIndex, error
 
 	// Summarize each file using LLM
 	for _, file := range files {
-		summary, err := summarizeFile(file, llm)
+		summary, size, err := summarizeFile(source, file, llm)
 		if err != nil {
 			// Log error but continue with other files
 			fmt.Printf("[Code Index] Warning: failed to summarize %s: %v\n", file, err)
 			continue
 		}
 
-		fileInfo, _ := os.Stat(file)
-		relativePath := strings.TrimPrefix(file, sourcePath+"/")
-
-		index.Files[relativePath] = FileSummary{
-			Path:        relativePath,
+		index.Files[file] = FileSummary{
+			Path:        file,
 			Summary:     summary,
-			Size:        fileInfo.Size(),
+			Size:        size,
 			LastIndexed: time.Now().Format(time.RFC3339),
 		}
 	}
@@ -62,27 +66,27 @@ func BuildCodeIndex(sourcePath string, llm provider.Provider) (*CodeIndex, error
 	return index, nil
 }
 
-// scanGoFiles recursively scans for .go files in the source directory
-func scanGoFiles(sourcePath string) ([]string, error) {
+// scanGoFiles recursively scans for .go files in the source tree
+func scanGoFiles(source fs.FS) ([]string, error) {
 	var files []string
 
-	err := filepath.Walk(sourcePath, func(path string, info os.FileInfo, err error) error {
+	err := fs.WalkDir(source, ".", func(p string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
 		// Skip vendor and hidden directories
-		if info.IsDir() {
-			name := info.Name()
-			if name == "vendor" || name == ".git" || strings.HasPrefix(name, ".") {
-				return filepath.SkipDir
+		if d.IsDir() {
+			name := d.Name()
+			if p != "." && (name == "vendor" || name == ".git" || strings.HasPrefix(name, ".")) {
+				return fs.SkipDir
 			}
 			return nil
 		}
 
 		// Only index .go files
-		if filepath.Ext(path) == ".go" {
-			files = append(files, path)
+		if path.Ext(p) == ".go" {
+			files = append(files, p)
 		}
 
 		return nil
@@ -91,11 +95,12 @@ func scanGoFiles(sourcePath string) ([]string, error) {
 	return files, err
 }
 
-// summarizeFile reads a file and asks LLM to summarize its purpose
-func summarizeFile(filePath string, llm provider.Provider) (string, error) {
-	content, err := os.ReadFile(filePath)
+// summarizeFile reads a file and asks LLM to summarize its purpose.
+// Returns the summary and the file size.
+func summarizeFile(source fs.FS, filePath string, llm provider.Provider) (string, int64, error) {
+	content, err := fs.ReadFile(source, filePath)
 	if err != nil {
-		return "", err
+		return "", 0, err
 	}
 
 	// Truncate very large files to avoid token limits
@@ -123,7 +128,7 @@ func summarizeFile(filePath string, llm provider.Provider) (string, error) {
 
 	response, err := llm.SendMessage(messages, nil, "")
 	if err != nil {
-		return "", err
+		return "", 0, err
 	}
 
 	// Extract text from response
@@ -134,7 +139,7 @@ func summarizeFile(filePath string, llm provider.Provider) (string, error) {
 		}
 	}
 
-	return strings.TrimSpace(summary), nil
+	return strings.TrimSpace(summary), int64(len(content)), nil
 }
 
 // LoadIndex loads an existing index from a file
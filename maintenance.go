@@ -0,0 +1,172 @@
+package aiassistant
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/willknow-ai/willknow-go/provider"
+)
+
+// maintenancePath is the admin API of maintenance mode
+const maintenancePath = "/willknow/maintenance"
+
+// defaultMaintenanceMessage is shown to users while the assistant is paused
+const defaultMaintenanceMessage = "The AI assistant is paused for maintenance. Please try again later."
+
+// ErrMaintenance is returned instead of calling the provider or running
+// tools while the assistant is paused
+var ErrMaintenance = errors.New("the AI assistant is paused for maintenance")
+
+// maintenanceError carries the message shown to users
+type maintenanceError struct {
+	message string
+}
+
+func (e *maintenanceError) Error() string { return e.message }
+func (e *maintenanceError) Unwrap() error { return ErrMaintenance }
+
+// MaintenanceStatus is the state of maintenance mode
+type MaintenanceStatus struct {
+	Enabled bool       `json:"enabled"`
+	Message string     `json:"message,omitempty"`
+	Since   *time.Time `json:"since,omitempty"`
+}
+
+// maintenanceSwitch pauses every LLM call and tool execution
+type maintenanceSwitch struct {
+	mu      sync.Mutex
+	enabled bool
+	message string
+	since   time.Time
+}
+
+// newMaintenanceSwitch starts paused if Config.Maintenance is set
+func newMaintenanceSwitch(config Config) *maintenanceSwitch {
+	m := &maintenanceSwitch{}
+	if config.Maintenance {
+		m.set(true, config.MaintenanceMessage)
+	}
+	return m
+}
+
+// set turns maintenance mode on or off
+func (m *maintenanceSwitch) set(enabled bool, message string) {
+	if message == "" {
+		message = defaultMaintenanceMessage
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if enabled && !m.enabled {
+		m.since = time.Now()
+	}
+	m.enabled = enabled
+	m.message = message
+}
+
+// status returns the state of maintenance mode
+func (m *maintenanceSwitch) status() MaintenanceStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.enabled {
+		return MaintenanceStatus{}
+	}
+	since := m.since
+	return MaintenanceStatus{Enabled: true, Message: m.message, Since: &since}
+}
+
+// check returns ErrMaintenance, with the message for users, while paused
+func (m *maintenanceSwitch) check() error {
+	if status := m.status(); status.Enabled {
+		return &maintenanceError{message: status.Message}
+	}
+	return nil
+}
+
+// SetMaintenance pauses the assistant (enabled) or resumes it. While paused,
+// no LLM call is made and no tool runs, including in conversations already
+// under way, and users are shown message (or a default notice).
+func (a *Assistant) SetMaintenance(enabled bool, message string) {
+	a.maintenance.set(enabled, message)
+	if enabled {
+		log.Printf("[AI Assistant] Maintenance mode enabled: LLM calls and tools are paused")
+	} else {
+		log.Printf("[AI Assistant] Maintenance mode disabled")
+	}
+	if a.ops != nil {
+		a.ops.record("warn", "maintenance", map[string]interface{}{"enabled": enabled})
+	}
+}
+
+// Maintenance returns the state of maintenance mode
+func (a *Assistant) Maintenance() MaintenanceStatus {
+	return a.maintenance.status()
+}
+
+// maintenanceProvider refuses to call the provider while paused
+type maintenanceProvider struct {
+	base        provider.Provider
+	maintenance *maintenanceSwitch
+}
+
+// SendMessage calls the provider unless the assistant is paused
+func (p *maintenanceProvider) SendMessage(messages []provider.Message, tools []provider.Tool, system string) (*provider.Response, error) {
+	if err := p.maintenance.check(); err != nil {
+		return nil, err
+	}
+	return p.base.SendMessage(messages, tools, system)
+}
+
+// SendMessageStream calls the provider unless the assistant is paused
+func (p *maintenanceProvider) SendMessageStream(messages []provider.Message, tools []provider.Tool, system string) (io.ReadCloser, error) {
+	if err := p.maintenance.check(); err != nil {
+		return nil, err
+	}
+	return p.base.SendMessageStream(messages, tools, system)
+}
+
+// GetName returns the wrapped provider's name
+func (p *maintenanceProvider) GetName() string {
+	return p.base.GetName()
+}
+
+// handleMaintenanceStatus serves GET /api/maintenance, polled by the web UI
+// to show the maintenance banner
+func (a *Assistant) handleMaintenanceStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(a.Maintenance())
+}
+
+// handleMaintenance serves the admin API: GET returns the state, POST
+// {"enabled": true, "message": "..."} changes it. Requests must carry
+// Config.AdminToken as a bearer token.
+func (a *Assistant) handleMaintenance(w http.ResponseWriter, r *http.Request) {
+	token := a.config.AdminToken
+	if token == "" || subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+token)) != 1 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var req struct {
+			Enabled bool   `json:"enabled"`
+			Message string `json:"message"`
+		}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64*1024)).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		a.SetMaintenance(req.Enabled, req.Message)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(a.Maintenance())
+}
@@ -0,0 +1,235 @@
+package aiassistant
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/willknow-ai/willknow-go/provider"
+)
+
+const (
+	// memoryToolName is the tool the assistant uses to read and write memories
+	memoryToolName = "memory"
+	// defaultMaxMemories is the number of memories kept per user
+	defaultMaxMemories = 100
+	// maxMemoryChars limits the length of a single memory
+	maxMemoryChars = 1000
+)
+
+// MemoryConfig configures the per-user memory store. Memories are facts the
+// assistant saves with the memory tool ("our DB is RDS Postgres in eu-west-1",
+// "error X was fixed by PR #42") and sees again in every later session of the
+// same user. In open mode all visitors share the "anonymous" user.
+type MemoryConfig struct {
+	// Dir is the folder where each user's memories are stored as a JSON file.
+	// When empty, the memory tool is disabled.
+	Dir string
+
+	// MaxEntries caps the memories kept per user; the oldest are dropped first.
+	// Default: 100
+	MaxEntries int
+}
+
+// memoryEntry is a single remembered fact
+type memoryEntry struct {
+	ID        string    `json:"id"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// memoryStore keeps memories in one JSON file per user
+type memoryStore struct {
+	config MemoryConfig
+	mu     sync.Mutex
+}
+
+// newMemoryStore creates the store, or returns nil if no Dir is configured
+func newMemoryStore(config MemoryConfig) (*memoryStore, error) {
+	if config.Dir == "" {
+		return nil, nil
+	}
+	if config.MaxEntries <= 0 {
+		config.MaxEntries = defaultMaxMemories
+	}
+	if err := os.MkdirAll(config.Dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create memory folder: %w", err)
+	}
+	return &memoryStore{config: config}, nil
+}
+
+// path returns the user's memory file. User IDs are hashed since they may
+// contain characters that are not valid in file names.
+func (m *memoryStore) path(userID string) string {
+	sum := sha256.Sum256([]byte(userID))
+	return filepath.Join(m.config.Dir, hex.EncodeToString(sum[:16])+".json")
+}
+
+// load reads the user's memories, oldest first
+func (m *memoryStore) load(userID string) ([]memoryEntry, error) {
+	data, err := os.ReadFile(m.path(userID))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []memoryEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("corrupt memory file: %w", err)
+	}
+	return entries, nil
+}
+
+// store writes the user's memories
+func (m *memoryStore) store(userID string, entries []memoryEntry) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(m.path(userID), data, 0600)
+}
+
+// remember saves a new memory for the user
+func (m *memoryStore) remember(userID, content string) (memoryEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entries, err := m.load(userID)
+	if err != nil {
+		return memoryEntry{}, err
+	}
+	id := make([]byte, 4)
+	rand.Read(id)
+	entry := memoryEntry{ID: hex.EncodeToString(id), Content: content, CreatedAt: time.Now()}
+	entries = append(entries, entry)
+	if len(entries) > m.config.MaxEntries {
+		entries = entries[len(entries)-m.config.MaxEntries:]
+	}
+	return entry, m.store(userID, entries)
+}
+
+// forget deletes one of the user's memories
+func (m *memoryStore) forget(userID, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entries, err := m.load(userID)
+	if err != nil {
+		return err
+	}
+	for i, e := range entries {
+		if e.ID == id {
+			return m.store(userID, append(entries[:i], entries[i+1:]...))
+		}
+	}
+	return fmt.Errorf("no memory with id %q", id)
+}
+
+// list returns the user's memories, oldest first
+func (m *memoryStore) list(userID string) ([]memoryEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.load(userID)
+}
+
+// execute runs the memory tool for the session's user
+func (m *memoryStore) execute(session *Session, params map[string]interface{}) (string, error) {
+	if session.User == nil || session.User.ID == "" {
+		return "", fmt.Errorf("memory is only available to identified users")
+	}
+	userID := session.User.ID
+
+	action, _ := params["action"].(string)
+	switch action {
+	case "save":
+		content, _ := params["content"].(string)
+		content = strings.TrimSpace(content)
+		if content == "" {
+			return "", fmt.Errorf("content parameter is required")
+		}
+		if len(content) > maxMemoryChars {
+			return "", fmt.Errorf("memory is too long (%d characters, max %d)", len(content), maxMemoryChars)
+		}
+		entry, err := m.remember(userID, content)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Saved memory %s.", entry.ID), nil
+	case "delete":
+		id, _ := params["id"].(string)
+		if id == "" {
+			return "", fmt.Errorf("id parameter is required")
+		}
+		if err := m.forget(userID, id); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Deleted memory %s.", id), nil
+	case "list", "":
+		entries, err := m.list(userID)
+		if err != nil {
+			return "", err
+		}
+		if len(entries) == 0 {
+			return "No memories saved for this user.", nil
+		}
+		return formatMemories(entries), nil
+	default:
+		return "", fmt.Errorf("unknown action %q (use list, save or delete)", action)
+	}
+}
+
+// formatMemories renders memories as a list with their IDs and dates
+func formatMemories(entries []memoryEntry) string {
+	var b strings.Builder
+	for _, e := range entries {
+		fmt.Fprintf(&b, "- [%s] (%s) %s\n", e.ID, e.CreatedAt.Format("2006-01-02"), e.Content)
+	}
+	return b.String()
+}
+
+// promptSection returns the user's memories for the system prompt, or ""
+func (m *memoryStore) promptSection(session *Session) string {
+	if m == nil || session.User == nil || session.User.ID == "" {
+		return ""
+	}
+	entries, err := m.list(session.User.ID)
+	if err != nil || len(entries) == 0 {
+		return ""
+	}
+	return "\n\nWhat you remember about this user and their systems (saved with the memory tool in earlier sessions):\n" + formatMemories(entries)
+}
+
+// toolDefinition describes the memory tool to the model
+func (m *memoryStore) toolDefinition() provider.Tool {
+	return provider.Tool{
+		Name:        memoryToolName,
+		Description: "Read and write long-term memories for the current user, kept across sessions. Save durable facts worth knowing next time (infrastructure details such as \"the DB is RDS Postgres in eu-west-1\", past fixes such as \"error X was fixed by PR #42\", team conventions) when the user states them or asks you to remember something. Do not save secrets, credentials or one-off details. Saved memories are already included in your instructions; use list to see their IDs before deleting outdated ones.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"action": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{"list", "save", "delete"},
+					"description": "list memories, save a new one, or delete one by id",
+				},
+				"content": map[string]interface{}{
+					"type":        "string",
+					"description": "The fact to remember, as a self-contained sentence (for save)",
+				},
+				"id": map[string]interface{}{
+					"type":        "string",
+					"description": "ID of the memory to delete (for delete)",
+				},
+			},
+			"required": []string{"action"},
+		},
+	}
+}
@@ -0,0 +1,169 @@
+package aiassistant
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// toolStats counts the calls of one tool since the assistant started
+type toolStats struct {
+	calls       int64
+	errors      int64
+	resultBytes int64
+	answers     int64 // answers with evidence from the tool
+	cited       int64 // answers citing evidence from the tool
+}
+
+// ToolMetrics reports how useful a tool is, for GET /willknow/metrics?format=json
+type ToolMetrics struct {
+	Tool           string  `json:"tool"`
+	Calls          int64   `json:"calls"`
+	Errors         int64   `json:"errors"`
+	ErrorRate      float64 `json:"error_rate"`
+	ResultBytes    int64   `json:"result_bytes"`
+	AvgResultBytes float64 `json:"avg_result_bytes"`
+	Answers        int64   `json:"answers"`   // answers with evidence from the tool
+	Cited          int64   `json:"cited"`     // answers citing that evidence
+	CiteRate       float64 `json:"cite_rate"` // Cited / Answers
+}
+
+// toolMetrics tracks per-tool efficacy, so operators can tell which tools
+// are worth enabling
+type toolMetrics struct {
+	mu      sync.Mutex
+	started time.Time
+	tools   map[string]*toolStats
+}
+
+func newToolMetrics() *toolMetrics {
+	return &toolMetrics{started: time.Now(), tools: make(map[string]*toolStats)}
+}
+
+// stats returns the counters of a tool. Callers hold m.mu.
+func (m *toolMetrics) stats(name string) *toolStats {
+	s := m.tools[name]
+	if s == nil {
+		s = &toolStats{}
+		m.tools[name] = s
+	}
+	return s
+}
+
+// recordCall counts a tool call and the size of its result
+func (m *toolMetrics) recordCall(name string, result string, err error) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.stats(name)
+	s.calls++
+	s.resultBytes += int64(len(result))
+	if err != nil {
+		s.errors++
+	}
+}
+
+// recordAnswer counts, for each tool that produced evidence for a final
+// answer, whether the answer cited it
+func (m *toolMetrics) recordAnswer(session *Session, answer string) {
+	if m == nil {
+		return
+	}
+	cited := make(map[int]bool)
+	for _, match := range citationRef.FindAllStringSubmatch(answer, -1) {
+		id, _ := strconv.Atoi(match[1])
+		cited[id] = true
+	}
+	used := make(map[string]bool)
+	citedBy := make(map[string]bool)
+	for _, c := range session.answerCitations() {
+		used[c.Tool] = true
+		if cited[c.ID] {
+			citedBy[c.Tool] = true
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for name := range used {
+		s := m.stats(name)
+		s.answers++
+		if citedBy[name] {
+			s.cited++
+		}
+	}
+}
+
+// snapshot returns the metrics of every tool, by name
+func (m *toolMetrics) snapshot() []ToolMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	list := make([]ToolMetrics, 0, len(m.tools))
+	for name, s := range m.tools {
+		tm := ToolMetrics{Tool: name, Calls: s.calls, Errors: s.errors, ResultBytes: s.resultBytes, Answers: s.answers, Cited: s.cited}
+		if s.calls > 0 {
+			tm.ErrorRate = float64(s.errors) / float64(s.calls)
+			tm.AvgResultBytes = float64(s.resultBytes) / float64(s.calls)
+		}
+		if s.answers > 0 {
+			tm.CiteRate = float64(s.cited) / float64(s.answers)
+		}
+		list = append(list, tm)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Tool < list[j].Tool })
+	return list
+}
+
+// handleMetrics serves GET /willknow/metrics: per-tool counters since startup
+// in the Prometheus text format, or as JSON with ?format=json
+func (m *toolMetrics) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	list := m.snapshot()
+
+	if r.URL.Query().Get("format") == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"since": m.started.UTC().Format(time.RFC3339),
+			"tools": list,
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	metric := func(name, help string, value func(ToolMetrics) int64) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+		for _, tm := range list {
+			fmt.Fprintf(w, "%s{tool=%q} %d\n", name, tm.Tool, value(tm))
+		}
+	}
+	metric("willknow_tool_calls_total", "Tool calls.", func(tm ToolMetrics) int64 { return tm.Calls })
+	metric("willknow_tool_errors_total", "Tool calls that failed.", func(tm ToolMetrics) int64 { return tm.Errors })
+	metric("willknow_tool_result_bytes_total", "Bytes of tool results.", func(tm ToolMetrics) int64 { return tm.ResultBytes })
+	metric("willknow_tool_answers_total", "Final answers with evidence from the tool.", func(tm ToolMetrics) int64 { return tm.Answers })
+	metric("willknow_tool_cited_answers_total", "Final answers citing evidence from the tool.", func(tm ToolMetrics) int64 { return tm.Cited })
+}
+
+// metricsAuth lets scrapers presenting MetricsToken as a bearer token
+// through; everyone else must authenticate like any other user
+func metricsAuth(next http.HandlerFunc, a *Assistant) http.HandlerFunc {
+	authenticated := authMiddleware(next, a)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token := a.config.MetricsToken; token != "" {
+			if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+token)) == 1 {
+				next(w, r)
+				return
+			}
+		}
+		authenticated(w, r)
+	}
+}
@@ -0,0 +1,102 @@
+package openapi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxSummaryChars limits an operation summary in the capabilities overview
+const maxSummaryChars = 100
+
+// untaggedGroup names the group of operations without tags
+const untaggedGroup = "Other"
+
+// Capabilities describes the operations of the spec grouped by tag, one
+// line per operation with its short summary, for use in a system prompt:
+//
+//	## Orders: Manage customer orders
+//	- listOrders (GET /orders): List orders
+//
+// Groups follow the spec's tag declarations, then undeclared tags
+// alphabetically, then untagged operations. An operation with several tags
+// is listed under its first one.
+func (s *ParsedSpec) Capabilities() string {
+	if s == nil || len(s.Tools) == 0 {
+		return ""
+	}
+
+	groups := make(map[string][]*APITool)
+	for _, tool := range s.Tools {
+		group := untaggedGroup
+		if len(tool.Tags) > 0 && tool.Tags[0] != "" {
+			group = tool.Tags[0]
+		}
+		groups[group] = append(groups[group], tool)
+	}
+
+	// Declared tags first, in spec order
+	var order []string
+	seen := make(map[string]bool)
+	descriptions := make(map[string]string)
+	for _, tag := range s.Tags {
+		if _, ok := groups[tag.Name]; ok && !seen[tag.Name] {
+			order = append(order, tag.Name)
+			seen[tag.Name] = true
+			descriptions[tag.Name] = tag.Description
+		}
+	}
+	var rest []string
+	for group := range groups {
+		if !seen[group] && group != untaggedGroup {
+			rest = append(rest, group)
+		}
+	}
+	sort.Strings(rest)
+	order = append(order, rest...)
+	if _, ok := groups[untaggedGroup]; ok && !seen[untaggedGroup] {
+		order = append(order, untaggedGroup)
+	}
+
+	var b strings.Builder
+	for i, group := range order {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("## " + group)
+		if desc := strings.TrimSpace(descriptions[group]); desc != "" {
+			b.WriteString(": " + shortSummary(desc))
+		}
+		b.WriteString("\n")
+
+		tools := groups[group]
+		sort.Slice(tools, func(i, j int) bool {
+			if tools[i].Path != tools[j].Path {
+				return tools[i].Path < tools[j].Path
+			}
+			return tools[i].Method < tools[j].Method
+		})
+		for _, tool := range tools {
+			fmt.Fprintf(&b, "- %s (%s %s)", tool.Name, tool.Method, tool.Path)
+			summary := tool.Summary
+			if summary == "" {
+				summary = tool.Description
+			}
+			if summary = shortSummary(summary); summary != "" && summary != tool.Method+" "+tool.Path {
+				b.WriteString(": " + summary)
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// shortSummary returns the first line of text, truncated to maxSummaryChars
+func shortSummary(text string) string {
+	text, _, _ = strings.Cut(strings.TrimSpace(text), "\n")
+	text = strings.TrimSpace(text)
+	if runes := []rune(text); len(runes) > maxSummaryChars {
+		text = string(runes[:maxSummaryChars]) + "…"
+	}
+	return text
+}
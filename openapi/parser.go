@@ -24,6 +24,7 @@ type APITool struct {
 	Path        string // e.g., /users/{userId}
 	Parameters  []Parameter
 	RequestBody *RequestBody
+	Tags        []string // groups the operation belongs to
 }
 
 // Parameter represents a path or query parameter
@@ -54,6 +55,13 @@ type ParsedSpec struct {
 	Description string
 	ServerURL   string
 	Tools       []*APITool
+	Tags        []Tag // top-level tag declarations, in spec order
+}
+
+// Tag describes a group of operations
+type Tag struct {
+	Name        string
+	Description string
 }
 
 // ParseSpec reads and parses an OpenAPI spec file (YAML or JSON)
@@ -94,6 +102,15 @@ func extractSpec(raw map[string]interface{}) (*ParsedSpec, error) {
 		}
 	}
 
+	// Extract tag declarations
+	if tags, ok := raw["tags"].([]interface{}); ok {
+		for _, t := range tags {
+			if tag, ok := t.(map[string]interface{}); ok && getString(tag, "name") != "" {
+				spec.Tags = append(spec.Tags, Tag{Name: getString(tag, "name"), Description: getString(tag, "description")})
+			}
+		}
+	}
+
 	// Extract paths
 	paths, ok := raw["paths"].(map[string]interface{})
 	if !ok {
@@ -145,6 +162,7 @@ func extractTool(path, method string, op map[string]interface{}) *APITool {
 		Method:  method,
 		Path:    path,
 		Summary: getString(op, "summary"),
+		Tags:    getStringSlice(op, "tags"),
 	}
 
 	// Name from operationId, fallback to generated name
@@ -0,0 +1,119 @@
+package aiassistant
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/willknow-ai/willknow-go/provider"
+)
+
+// opsLogPrompt tells the model about the assistant's own operational log
+const opsLogPrompt = `
+
+Your own operational log (%s) records your provider failures, tool errors, blocked tool calls and authentication denials as JSON lines with an "event" field. When a user asks why you failed, answered incompletely or denied access, search it with read_logs (e.g. by session ID, tool name or "provider_error").`
+
+// opsLog is the assistant's structured operational log: one JSON object
+// per line, searchable with read_logs like the application's logs
+type opsLog struct {
+	path string
+	mu   sync.Mutex
+	file *os.File
+}
+
+// newOpsLog opens the operational log for appending; nil if path is empty
+func newOpsLog(path string) (*opsLog, error) {
+	if path == "" {
+		return nil, nil
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open operational log: %w", err)
+	}
+	return &opsLog{path: path, file: file}, nil
+}
+
+// record appends an event with the given fields
+func (o *opsLog) record(level, event string, fields map[string]interface{}) {
+	if o == nil {
+		return
+	}
+	entry := map[string]interface{}{
+		"time":  time.Now().UTC().Format(time.RFC3339Nano),
+		"level": level,
+		"event": event,
+	}
+	for k, v := range fields {
+		entry[k] = v
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("[AI Assistant] Failed to marshal operational log entry: %v", err)
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.file.Write(append(line, '\n'))
+}
+
+// sessionFields returns the fields identifying a session and its user
+func (o *opsLog) sessionFields(session *Session) map[string]interface{} {
+	fields := map[string]interface{}{"session_id": session.ID}
+	if session.User != nil {
+		fields["user_id"] = session.User.ID
+	}
+	if v := session.experimentVariant(); v != nil {
+		fields["variant"] = v.config.Name
+	}
+	return fields
+}
+
+// promptSection describes the operational log in the system prompt
+func (o *opsLog) promptSection() string {
+	if o == nil {
+		return ""
+	}
+	return fmt.Sprintf(opsLogPrompt, o.path)
+}
+
+// opsLogProvider records failed provider calls in the operational log
+type opsLogProvider struct {
+	base provider.Provider
+	ops  *opsLog
+}
+
+// SendMessage sends the conversation and records a failure
+func (p *opsLogProvider) SendMessage(messages []provider.Message, tools []provider.Tool, system string) (*provider.Response, error) {
+	start := time.Now()
+	response, err := p.base.SendMessage(messages, tools, system)
+	if err != nil {
+		p.ops.record("error", "provider_error", map[string]interface{}{
+			"provider":    p.base.GetName(),
+			"error":       err.Error(),
+			"duration_ms": time.Since(start).Milliseconds(),
+		})
+	}
+	return response, err
+}
+
+// SendMessageStream sends the conversation and records a failure
+func (p *opsLogProvider) SendMessageStream(messages []provider.Message, tools []provider.Tool, system string) (io.ReadCloser, error) {
+	stream, err := p.base.SendMessageStream(messages, tools, system)
+	if err != nil {
+		p.ops.record("error", "provider_error", map[string]interface{}{
+			"provider": p.base.GetName(),
+			"error":    err.Error(),
+			"stream":   true,
+		})
+	}
+	return stream, err
+}
+
+// GetName returns the wrapped provider's name
+func (p *opsLogProvider) GetName() string {
+	return p.base.GetName()
+}
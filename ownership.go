@@ -0,0 +1,138 @@
+package aiassistant
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ownershipPromptText tells the model to name the owners of faulty code
+const ownershipPromptText = `
+
+Code ownership:
+Files read with read_file end with the team or people who own them ("Owned by: ..."), and find_owners looks up the owners of other files. When your diagnosis locates the cause in a file, say who owns it, e.g. "The nil dereference is in payments/refund.go (owned by @payments-team)", so the user knows whom to involve.`
+
+// citationLineRange matches the line range of a file citation label
+var citationLineRange = regexp.MustCompile(`:\d+(?:-\d+)?$`)
+
+// ownershipPrompt returns the ownership section of the system prompt
+func (a *Assistant) ownershipPrompt() string {
+	if !a.config.Ownership.Enabled {
+		return ""
+	}
+	return ownershipPromptText
+}
+
+// isGitCheckout reports whether dir is the root of a git checkout
+func isGitCheckout(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ".git"))
+	return dir != "" && err == nil
+}
+
+// ownerChannels delivers diagnoses to the channels of the owners of the
+// code they cite
+type ownerChannels struct {
+	channels map[string]WebhookConfig // by owner, e.g. "@payments-team"
+	notifier *webhookNotifier
+}
+
+// newOwnerChannels returns nil if no owner has a channel
+func newOwnerChannels(channels map[string]WebhookConfig) *ownerChannels {
+	if len(channels) == 0 {
+		return nil
+	}
+	return &ownerChannels{
+		channels: channels,
+		notifier: &webhookNotifier{client: &http.Client{Timeout: 10 * time.Second}},
+	}
+}
+
+// notifyOwners sends the answer to the channels of the owners of the files
+// it cites. start is the index of the user's question in the session.
+func (a *Assistant) notifyOwners(session *Session, start int, answer string) {
+	if a.owners == nil {
+		return
+	}
+	t := a.sessionTarget(session)
+
+	cited := make(map[int]bool)
+	for _, m := range citationRef.FindAllStringSubmatch(answer, -1) {
+		id, _ := strconv.Atoi(m[1])
+		cited[id] = true
+	}
+	filesByOwner := make(map[string][]string)
+	seen := make(map[string]bool)
+	for _, c := range session.answerCitations() {
+		if c.Kind != "file" || !cited[c.ID] {
+			continue
+		}
+		file := citationLineRange.ReplaceAllString(c.Label, "")
+		if seen[file] {
+			continue
+		}
+		seen[file] = true
+		ownership, ok := t.toolRegistry.Owners(file)
+		if !ok {
+			return
+		}
+		for _, owner := range ownership.Owners {
+			if _, ok := a.owners.channels[owner]; ok {
+				filesByOwner[owner] = append(filesByOwner[owner], file)
+			}
+		}
+	}
+	if len(filesByOwner) == 0 {
+		return
+	}
+
+	var question string
+	session.mu.Lock()
+	if start < len(session.messages) {
+		for _, block := range session.messages[start].Content {
+			if block.Type == "text" {
+				question += block.Text
+			}
+		}
+	}
+	session.mu.Unlock()
+	var userID string
+	if session.User != nil {
+		userID = session.User.ID
+	}
+
+	owners := make([]string, 0, len(filesByOwner))
+	for owner := range filesByOwner {
+		owners = append(owners, owner)
+	}
+	sort.Strings(owners)
+	for _, owner := range owners {
+		files := filesByOwner[owner]
+		summary := truncate(answer, maxWebhookSummaryChars)
+		payload := WebhookPayload{
+			Event:           WebhookEventOwnerNotified,
+			Timestamp:       time.Now(),
+			SessionID:       session.ID,
+			UserID:          userID,
+			Question:        question,
+			Summary:         summary,
+			ReferencedFiles: files,
+			Owners:          []string{owner},
+			Text:            fmt.Sprintf("A diagnosis points to code owned by %s (%s).\n\nQuestion: %s\n\n%s", owner, strings.Join(files, ", "), question, summary),
+		}
+		body, err := json.Marshal(payload)
+		if err != nil {
+			log.Printf("[Ownership] Failed to marshal payload: %v", err)
+			return
+		}
+		log.Printf("[Ownership] Notifying %s of session %s", owner, session.ID)
+		go a.owners.notifier.deliver(a.owners.channels[owner], body)
+	}
+}
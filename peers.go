@@ -0,0 +1,296 @@
+package aiassistant
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/willknow-ai/willknow-go/provider"
+)
+
+const (
+	// callAgentToolName is the tool that delegates a question to a peer agent
+	callAgentToolName = "call_agent"
+	// peerHopsHeader carries the number of agent-to-agent calls that led to a
+	// request, so peers that call each other cannot loop forever
+	peerHopsHeader = "X-Willknow-Hops"
+	// maxPeerHops is the longest chain of delegated calls
+	maxPeerHops = 3
+	// maxPeerAnswerChars limits the peer answer passed back to the model
+	maxPeerAnswerChars = 20000
+)
+
+// PeerAgentConfig registers another willknow instance, typically the
+// assistant of an upstream service, that this assistant may delegate
+// questions to with the call_agent tool.
+type PeerAgentConfig struct {
+	// InfoURL is the peer's discovery endpoint
+	// (e.g., "http://payments-service:8888/willknow/info")
+	InfoURL string
+
+	// Token is sent as a bearer token to peers that require authentication.
+	// The caller's own credentials are never forwarded.
+	Token string
+
+	// Name is how the model addresses the peer
+	// Default: the name reported by the peer
+	Name string
+
+	// Description tells the model what the peer knows about
+	// Default: the description reported by the peer
+	Description string
+
+	// Timeout bounds a single call; peers may take several tool calls to answer
+	// Default: 5m
+	Timeout time.Duration
+}
+
+// peerAgent is a discovered peer
+type peerAgent struct {
+	config      PeerAgentConfig
+	name        string
+	description string
+	chatURL     string
+	client      *http.Client
+}
+
+// peerAgents delegates questions to peer agents and keeps one peer
+// conversation per local session and peer, so follow-up questions keep context
+type peerAgents struct {
+	agents []*peerAgent
+
+	mu       sync.Mutex
+	sessions map[string]string // local session ID + "/" + peer name → peer session ID
+}
+
+// newPeerAgents discovers the configured peers. Unreachable peers are kept,
+// addressed by their configured name and the default chat endpoint, so a
+// peer that starts later is still usable. Returns nil without peers.
+func newPeerAgents(configs []PeerAgentConfig) (*peerAgents, error) {
+	if len(configs) == 0 {
+		return nil, nil
+	}
+	p := &peerAgents{sessions: make(map[string]string)}
+	for _, config := range configs {
+		info, err := url.Parse(config.InfoURL)
+		if err != nil || info.Host == "" {
+			return nil, fmt.Errorf("invalid peer InfoURL %q", config.InfoURL)
+		}
+		if config.Timeout <= 0 {
+			config.Timeout = 5 * time.Minute
+		}
+		agent := &peerAgent{
+			config:      config,
+			name:        config.Name,
+			description: config.Description,
+			chatURL:     info.ResolveReference(&url.URL{Path: "/willknow/chat"}).String(),
+			client:      &http.Client{Timeout: config.Timeout},
+		}
+		if err := agent.discover(info); err != nil {
+			log.Printf("[Peer Agents] Warning: %s: %v", config.InfoURL, err)
+		}
+		if agent.name == "" {
+			agent.name = info.Hostname()
+		}
+		if p.find(agent.name) != nil {
+			return nil, fmt.Errorf("duplicate peer agent name %q; set PeerAgentConfig.Name", agent.name)
+		}
+		p.agents = append(p.agents, agent)
+	}
+	return p, nil
+}
+
+// discover reads the peer's /willknow/info
+func (pa *peerAgent) discover(info *url.URL) error {
+	req, err := http.NewRequest(http.MethodGet, info.String(), nil)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("discovery returned HTTP %d", resp.StatusCode)
+	}
+
+	var body AgentInfoResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return fmt.Errorf("invalid discovery response: %w", err)
+	}
+	if pa.name == "" {
+		pa.name = body.Name
+	}
+	if pa.description == "" {
+		pa.description = body.Description
+	}
+	if body.ChatEndpoint != "" {
+		if chat, err := url.Parse(body.ChatEndpoint); err == nil {
+			pa.chatURL = info.ResolveReference(chat).String()
+		}
+	}
+	if body.Auth.Required && pa.config.Token == "" {
+		log.Printf("[Peer Agents] Warning: %s requires authentication but no Token is configured", pa.name)
+	}
+	return nil
+}
+
+// find returns the peer with the given name (case-insensitive), or nil
+func (p *peerAgents) find(name string) *peerAgent {
+	if p == nil {
+		return nil
+	}
+	for _, agent := range p.agents {
+		if strings.EqualFold(agent.name, name) {
+			return agent
+		}
+	}
+	return nil
+}
+
+// execute runs the call_agent tool
+func (p *peerAgents) execute(session *Session, params map[string]interface{}) (string, error) {
+	name, _ := params["agent"].(string)
+	question, _ := params["question"].(string)
+	if strings.TrimSpace(question) == "" {
+		return "", fmt.Errorf("question parameter is required")
+	}
+	agent := p.find(name)
+	if agent == nil {
+		return "", fmt.Errorf("unknown agent %q", name)
+	}
+	if session.hops >= maxPeerHops {
+		return "", fmt.Errorf("this question was already delegated %d times; answer with what you know instead of calling another agent", session.hops)
+	}
+
+	key := session.ID + "/" + agent.name
+	p.mu.Lock()
+	peerSession := p.sessions[key]
+	p.mu.Unlock()
+	if newConversation, _ := params["new_conversation"].(bool); newConversation {
+		peerSession = ""
+	}
+
+	log.Printf("[Peer Agents] Session %s asking %s", session.ID, agent.name)
+	answer, err := agent.chat(question, peerSession, session.hops+1)
+	if err != nil {
+		return "", fmt.Errorf("%s did not answer: %w", agent.name, err)
+	}
+
+	p.mu.Lock()
+	p.sessions[key] = answer.SessionID
+	p.mu.Unlock()
+
+	message := answer.Message
+	if len(message) > maxPeerAnswerChars {
+		message = message[:maxPeerAnswerChars] + "\n... (answer truncated)"
+	}
+	header := fmt.Sprintf("Answer from %s", agent.name)
+	if answer.Revision != "" {
+		header += fmt.Sprintf(" (based on its source revision %s)", shortRevision(answer.Revision))
+	}
+	return header + ":\n\n" + message, nil
+}
+
+// chat sends a question to the peer's /willknow/chat
+func (pa *peerAgent) chat(question, sessionID string, hops int) (*AgentChatResponse, error) {
+	body, err := json.Marshal(AgentChatRequest{Message: question, SessionID: sessionID})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, pa.chatURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(peerHopsHeader, strconv.Itoa(hops))
+	if pa.config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+pa.config.Token)
+	}
+
+	resp, err := pa.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	var answer AgentChatResponse
+	if err := json.Unmarshal(data, &answer); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	return &answer, nil
+}
+
+// peerHops reads the delegation depth of an incoming agent request
+func peerHops(r *http.Request) int {
+	hops, _ := strconv.Atoi(r.Header.Get(peerHopsHeader))
+	if hops < 0 {
+		return 0
+	}
+	return hops
+}
+
+// promptSection lists the peers in the system prompt, or ""
+func (p *peerAgents) promptSection() string {
+	if p == nil {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\n\nPeer agents: other services have their own assistant with access to their code and logs. When a problem leads into one of these services (e.g. an upstream call fails with a 5xx), ask its agent with call_agent instead of guessing, then combine its answer with your own findings and say which parts came from which agent:\n")
+	for _, agent := range p.agents {
+		fmt.Fprintf(&b, "- %s", agent.name)
+		if agent.description != "" {
+			fmt.Fprintf(&b, ": %s", agent.description)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// toolDefinition describes the call_agent tool to the model
+func (p *peerAgents) toolDefinition() provider.Tool {
+	var names []string
+	for _, agent := range p.agents {
+		names = append(names, agent.name)
+	}
+	return provider.Tool{
+		Name:        callAgentToolName,
+		Description: "Ask the assistant of another service a question and get its answer. That assistant investigates with its own service's source code, logs and APIs. Ask a specific, self-contained question and include the details it needs (timestamps, request IDs, error messages, the endpoint you called). Follow-up questions to the same agent continue the same conversation.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"agent": map[string]interface{}{
+					"type":        "string",
+					"enum":        names,
+					"description": "Name of the agent to ask",
+				},
+				"question": map[string]interface{}{
+					"type":        "string",
+					"description": "The question, with the context the agent needs to investigate it",
+				},
+				"new_conversation": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Start a fresh conversation with the agent instead of continuing the previous one",
+				},
+			},
+			"required": []string{"agent", "question"},
+		},
+	}
+}
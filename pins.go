@@ -0,0 +1,224 @@
+package aiassistant
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// pinsFile stores the pinned answers of all sessions
+	pinsFile = "./sessions/pins.json"
+	// maxPinTitleChars limits the title of a pin
+	maxPinTitleChars = 200
+)
+
+// Pin is an answer pinned as a key finding, e.g. a root-cause summary
+type Pin struct {
+	ID        string    `json:"id"`
+	SessionID string    `json:"session_id"`
+	Target    string    `json:"target,omitempty"`
+	UserID    string    `json:"user_id"`
+	UserName  string    `json:"user_name,omitempty"`
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// pinStore keeps the pins of all sessions in one JSON file, encrypted like
+// the session logs the pinned answers come from
+type pinStore struct {
+	transcripts *transcriptPolicy // decrypts session logs and seals the pins file
+	mu          sync.Mutex
+}
+
+// load reads all pins, oldest first
+func (p *pinStore) load() ([]Pin, error) {
+	data, err := os.ReadFile(pinsFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if data, err = p.transcripts.open(bytes.TrimSpace(data)); err != nil {
+		return nil, err
+	}
+	var pins []Pin
+	if err := json.Unmarshal(data, &pins); err != nil {
+		return nil, fmt.Errorf("corrupt pins file: %w", err)
+	}
+	return pins, nil
+}
+
+// store writes all pins
+func (p *pinStore) store(pins []Pin) error {
+	data, err := json.MarshalIndent(pins, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(pinsFile), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(pinsFile, p.transcripts.seal(data), 0600)
+}
+
+// handle serves /api/pins: GET lists pins across sessions (newest first,
+// optionally filtered by session_id and target), POST pins an answer of the
+// user's own session and DELETE ?id= unpins one of the user's pins
+func (p *pinStore) handle(w http.ResponseWriter, r *http.Request) {
+	user, _ := r.Context().Value(userContextKey).(*User)
+	userID := ""
+	if user != nil {
+		userID = user.ID
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		p.mu.Lock()
+		pins, err := p.load()
+		p.mu.Unlock()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		sessionID, target := r.URL.Query().Get("session_id"), r.URL.Query().Get("target")
+		list := []Pin{}
+		for _, pin := range pins {
+			if (sessionID == "" || pin.SessionID == sessionID) && (target == "" || pin.Target == target) {
+				list = append(list, pin)
+			}
+		}
+		sort.SliceStable(list, func(i, j int) bool { return list[i].CreatedAt.After(list[j].CreatedAt) })
+		json.NewEncoder(w).Encode(list)
+
+	case http.MethodPost:
+		var req struct {
+			SessionID string `json:"session_id"`
+			Content   string `json:"content"`
+			Title     string `json:"title"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || !shareSessionID.MatchString(req.SessionID) {
+			http.Error(w, "session_id is required", http.StatusBadRequest)
+			return
+		}
+		content := strings.TrimSpace(req.Content)
+		if content == "" {
+			http.Error(w, "content is required", http.StatusBadRequest)
+			return
+		}
+		events, err := readSessionEvents(req.SessionID, p.transcripts)
+		if err != nil {
+			http.Error(w, "session not found", http.StatusNotFound)
+			return
+		}
+
+		// Only the user who held the conversation may pin its answers, and
+		// only text the assistant actually wrote
+		if owner := sessionOwner(events); owner != "" && owner != userID {
+			http.Error(w, "you can only pin answers of your own conversations", http.StatusForbidden)
+			return
+		}
+		var answers strings.Builder
+		target := ""
+		for _, event := range events {
+			switch event.Type {
+			case "session_start":
+				target, _ = event.Data["target"].(string)
+			case "assistant_message":
+				text, _ := event.Data["content"].(string)
+				answers.WriteString(text)
+			}
+		}
+		if !strings.Contains(answers.String(), content) {
+			http.Error(w, "content is not an answer of this session", http.StatusBadRequest)
+			return
+		}
+
+		pin := Pin{
+			ID:        newPinID(),
+			SessionID: req.SessionID,
+			Target:    target,
+			UserID:    userID,
+			Title:     pinTitle(req.Title, content),
+			Content:   content,
+			CreatedAt: time.Now(),
+		}
+		if user != nil {
+			pin.UserName = user.Name
+		}
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		pins, err := p.load()
+		if err == nil {
+			err = p.store(append(pins, pin))
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		log.Printf("[Pins] %s pinned %q in session %s", userID, pin.Title, pin.SessionID)
+		json.NewEncoder(w).Encode(pin)
+
+	case http.MethodDelete:
+		id := r.URL.Query().Get("id")
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		pins, err := p.load()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for i, pin := range pins {
+			if pin.ID != id {
+				continue
+			}
+			if pin.UserID != userID {
+				http.Error(w, "you can only unpin your own pins", http.StatusForbidden)
+				return
+			}
+			if err := p.store(append(pins[:i], pins[i+1:]...)); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		http.Error(w, "pin not found", http.StatusNotFound)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// pinTitle returns the given title, or the first line of the content
+func pinTitle(title, content string) string {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		title, _, _ = strings.Cut(content, "\n")
+		title = strings.TrimSpace(strings.TrimLeft(title, "#*- "))
+	}
+	if runes := []rune(title); len(runes) > maxPinTitleChars {
+		title = string(runes[:maxPinTitleChars]) + "..."
+	}
+	return title
+}
+
+// newPinID returns a random pin identifier
+func newPinID() string {
+	id := make([]byte, 6)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,156 @@
+package aiassistant
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/websocket"
+)
+
+// WebSocket protocol versions of /api/ws, selected with ?v=. Version 1 (the
+// default, for existing clients) sends ChatResponse messages; version 2
+// wraps every message in a ClientEvent envelope with a typed payload.
+const (
+	ProtocolV1 = 1
+	ProtocolV2 = 2
+
+	// LatestProtocolVersion is the newest protocol version the server speaks
+	LatestProtocolVersion = ProtocolV2
+)
+
+// Event names of protocol version 2
+const (
+	EventSession    = "session"     // SessionEvent: the session started or was resumed
+	EventAck        = "ack"         // AckEvent: a client message was queued
+	EventStatus     = "status"      // StatusEvent: progress while the assistant works
+	EventDelta      = "delta"       // DeltaEvent: more text of the answer
+	EventToolStart  = "tool_start"  // ToolStartEvent: a tool call begins
+	EventToolResult = "tool_result" // ToolResultEvent: a tool call finished
+	EventDone       = "done"        // DoneEvent: the answer is complete
+	EventError      = "error"       // ErrorEvent: the message could not be answered
+	EventConsent    = "consent"     // ConsentRequest: the user is asked to allow a capability
+	EventPaused     = "paused"      // PausedEvent: the assistant is paused for maintenance
+)
+
+// ClientEvent is the envelope of every protocol version 2 message. Data
+// holds the payload type documented for the event name; clients must ignore
+// events and payload fields they do not know, which new server versions may add.
+type ClientEvent struct {
+	V     int             `json:"v"`
+	Event string          `json:"event"`
+	Seq   int64           `json:"seq,omitempty"` // for replay after reconnection (?session=&after=)
+	Data  json.RawMessage `json:"data"`
+}
+
+// SessionEvent is the payload of EventSession
+type SessionEvent struct {
+	SessionID string    `json:"session_id"`
+	Revision  string    `json:"revision,omitempty"` // source revision analyzed
+	Resumed   bool      `json:"resumed,omitempty"`
+	Greeting  *Greeting `json:"greeting,omitempty"` // welcome message of a new session
+	Actions   []string  `json:"actions,omitempty"`  // actions offered under answers
+}
+
+// AckEvent is the payload of EventAck
+type AckEvent struct {
+	MessageID int64 `json:"message_id"`
+}
+
+// StatusEvent is the payload of EventStatus
+type StatusEvent struct {
+	Text string `json:"text"`
+}
+
+// DeltaEvent is the payload of EventDelta
+type DeltaEvent struct {
+	Text string `json:"text"`
+}
+
+// ToolStartEvent is the payload of EventToolStart
+type ToolStartEvent struct {
+	Tool  string                 `json:"tool"`
+	Input map[string]interface{} `json:"input,omitempty"`
+}
+
+// ToolResultEvent is the payload of EventToolResult
+type ToolResultEvent struct {
+	Tool    string `json:"tool"`
+	Output  string `json:"output"` // truncated for display
+	IsError bool   `json:"is_error,omitempty"`
+}
+
+// DoneEvent is the payload of EventDone
+type DoneEvent struct {
+	Citations   []Citation        `json:"citations,omitempty"`
+	Suggestions []SuggestedAction `json:"suggestions,omitempty"` // follow-up actions offered with the answer
+}
+
+// PausedEvent is the payload of EventPaused; the message was not answered
+type PausedEvent struct {
+	Message string `json:"message"`
+}
+
+// ErrorEvent is the payload of EventError
+type ErrorEvent struct {
+	Message string `json:"message"`
+}
+
+// clientEvent converts a response to its protocol version 2 envelope
+func (r ChatResponse) clientEvent() ClientEvent {
+	var event string
+	var data interface{}
+	switch r.Type {
+	case "session_info":
+		event, data = EventSession, SessionEvent{SessionID: r.SessionID, Revision: r.Revision, Resumed: r.Resumed, Greeting: r.Greeting, Actions: r.Actions}
+	case "ack":
+		event, data = EventAck, AckEvent{MessageID: r.MessageID}
+	case "status":
+		event, data = EventStatus, StatusEvent{Text: r.Content}
+	case "text":
+		event, data = EventDelta, DeltaEvent{Text: r.Content}
+	case "tool_use":
+		event, data = EventToolStart, ToolStartEvent{Tool: r.ToolName, Input: r.ToolInput}
+	case "tool_result":
+		event, data = EventToolResult, ToolResultEvent{Tool: r.ToolName, Output: r.Content, IsError: r.IsError}
+	case "done":
+		event, data = EventDone, DoneEvent{Citations: r.Citations, Suggestions: r.Suggestions}
+	case "consent":
+		event, data = EventConsent, r.Consent
+	case "maintenance":
+		event, data = EventPaused, PausedEvent{Message: r.Content}
+	default:
+		event, data = EventError, ErrorEvent{Message: r.Content}
+	}
+	raw, _ := json.Marshal(data)
+	return ClientEvent{V: ProtocolV2, Event: event, Seq: r.Seq, Data: raw}
+}
+
+// clientConn is a WebSocket connection that sends responses in the protocol
+// version the client asked for
+type clientConn struct {
+	*websocket.Conn
+	version int
+}
+
+// WriteJSON sends a ChatResponse in the connection's protocol version
+func (c *clientConn) WriteJSON(v interface{}) error {
+	if resp, ok := v.(ChatResponse); ok && c.version >= ProtocolV2 {
+		return c.Conn.WriteJSON(resp.clientEvent())
+	}
+	return c.Conn.WriteJSON(v)
+}
+
+// protocolVersion returns the protocol version requested with ?v=
+func protocolVersion(r *http.Request) (int, error) {
+	v := r.URL.Query().Get("v")
+	if v == "" {
+		return ProtocolV1, nil
+	}
+	version, err := strconv.Atoi(v)
+	if err != nil || version < ProtocolV1 || version > LatestProtocolVersion {
+		return 0, fmt.Errorf("unsupported protocol version %q (supported: %d-%d)", v, ProtocolV1, LatestProtocolVersion)
+	}
+	return version, nil
+}
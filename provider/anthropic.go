@@ -6,14 +6,16 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 )
 
-const anthropicAPIURL = "https://api.anthropic.com/v1/messages"
+const anthropicBaseURL = "https://api.anthropic.com"
 
 // AnthropicProvider implements the Provider interface for Anthropic/Claude
 type AnthropicProvider struct {
 	apiKey     string
 	model      string
+	baseURL    string // serves /v1/messages, e.g. an internal gateway
 	httpClient *http.Client
 }
 
@@ -25,10 +27,22 @@ func NewAnthropicProvider(apiKey, model string) *AnthropicProvider {
 	return &AnthropicProvider{
 		apiKey:     apiKey,
 		model:      model,
+		baseURL:    anthropicBaseURL,
 		httpClient: &http.Client{},
 	}
 }
 
+// NewAnthropicProviderWithBaseURL creates an Anthropic provider that sends
+// requests to baseURL instead of the public API, e.g. an internal gateway
+// serving /v1/messages
+func NewAnthropicProviderWithBaseURL(apiKey, model, baseURL string) *AnthropicProvider {
+	p := NewAnthropicProvider(apiKey, model)
+	if baseURL != "" {
+		p.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+	return p
+}
+
 // GetName returns the provider name
 func (p *AnthropicProvider) GetName() string {
 	return "Anthropic"
@@ -54,7 +68,7 @@ func (p *AnthropicProvider) SendMessage(messages []Message, tools []Tool, system
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequest("POST", anthropicAPIURL, bytes.NewBuffer(reqBody))
+	httpReq, err := http.NewRequest("POST", p.baseURL+"/v1/messages", bytes.NewBuffer(reqBody))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -107,7 +121,7 @@ func (p *AnthropicProvider) SendMessageStream(messages []Message, tools []Tool,
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequest("POST", anthropicAPIURL, bytes.NewBuffer(reqBody))
+	httpReq, err := http.NewRequest("POST", p.baseURL+"/v1/messages", bytes.NewBuffer(reqBody))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -14,7 +14,7 @@ const (
 func NewProvider(providerType ProviderType, apiKey, model, baseURL string) (Provider, error) {
 	// Special handling for Anthropic (non-OpenAI compatible)
 	if providerType == ProviderAnthropic {
-		return NewAnthropicProvider(apiKey, model), nil
+		return NewAnthropicProviderWithBaseURL(apiKey, model, baseURL), nil
 	}
 
 	// Get preset configuration
@@ -0,0 +1,190 @@
+package aiassistant
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/willknow-ai/willknow-go/provider"
+)
+
+// UserQuotaConfig limits how much each authenticated user may use the
+// assistant per day (local time), so one person cannot use up a deployment
+// shared by a whole organization. Users see their remaining quota in the
+// UI header. Automated analyses (alerts, schedules, anomalies and the
+// analysis API) are not counted against any user.
+type UserQuotaConfig struct {
+	// DailyMessages caps the questions a user may ask per day (0 = no limit)
+	DailyMessages int
+
+	// DailyTokens caps the input + output tokens spent answering a user's
+	// questions per day (0 = no limit). The question that crosses the limit
+	// is still answered.
+	DailyTokens int64
+
+	// ExemptRoles are roles (see User.Roles) without quotas, e.g. "admin"
+	ExemptRoles []string
+
+	// StateFile persists usage so restarts do not reset quotas
+	// Default: "" (usage is kept in memory)
+	StateFile string
+}
+
+// ErrQuotaExceeded is returned instead of answering once a user's daily quota is used up
+var ErrQuotaExceeded = errors.New("you have used up your daily quota")
+
+// userUsage is a user's usage of the current day
+type userUsage struct {
+	Day      string `json:"day"` // 2006-01-02
+	Messages int    `json:"messages"`
+	Tokens   int64  `json:"tokens"`
+}
+
+// QuotaStatus is a user's usage and limits, served by GET /api/quota
+type QuotaStatus struct {
+	Enabled       bool      `json:"enabled"`
+	Exempt        bool      `json:"exempt,omitempty"`
+	Messages      int       `json:"messages"`
+	MessagesLimit int       `json:"messages_limit,omitempty"`
+	Tokens        int64     `json:"tokens"`
+	TokensLimit   int64     `json:"tokens_limit,omitempty"`
+	ResetsAt      time.Time `json:"resets_at"`
+}
+
+// userQuotas tracks per-user usage and enforces UserQuotaConfig
+type userQuotas struct {
+	config UserQuotaConfig
+
+	mu    sync.Mutex
+	usage map[string]*userUsage // by user ID
+}
+
+// newUserQuotas returns nil if no quota is configured
+func newUserQuotas(config UserQuotaConfig) *userQuotas {
+	if config.DailyMessages <= 0 && config.DailyTokens <= 0 {
+		return nil
+	}
+	q := &userQuotas{config: config, usage: make(map[string]*userUsage)}
+	if config.StateFile != "" {
+		if data, err := os.ReadFile(config.StateFile); err == nil {
+			if err := json.Unmarshal(data, &q.usage); err != nil {
+				log.Printf("[Quota] Ignoring unreadable state file %s: %v", config.StateFile, err)
+			}
+		}
+	}
+	return q
+}
+
+// counted reports whether a session's questions count against its user's quota
+func (q *userQuotas) counted(session *Session) bool {
+	return q != nil && !session.unattended && session.User != nil && !q.exempt(session.User)
+}
+
+// exempt reports whether a user has one of the ExemptRoles
+func (q *userQuotas) exempt(user *User) bool {
+	for _, role := range user.Roles {
+		for _, exempt := range q.config.ExemptRoles {
+			if role == exempt {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// current returns a user's usage of today. Callers hold q.mu.
+func (q *userQuotas) current(userID string) *userUsage {
+	day := time.Now().Format("2006-01-02")
+	u := q.usage[userID]
+	if u == nil || u.Day != day {
+		u = &userUsage{Day: day}
+		q.usage[userID] = u
+		// Drop the users inactive since an earlier day
+		for id, other := range q.usage {
+			if other.Day != day {
+				delete(q.usage, id)
+			}
+		}
+	}
+	return u
+}
+
+// admit counts the session's latest question against its user's quota, or
+// explains which quota is used up
+func (q *userQuotas) admit(session *Session) error {
+	if !q.counted(session) {
+		return nil
+	}
+	q.mu.Lock()
+	u := q.current(session.User.ID)
+	var err error
+	switch {
+	case q.config.DailyMessages > 0 && u.Messages >= q.config.DailyMessages:
+		err = fmt.Errorf("%w of %d questions; it resets at midnight. Please contact the administrator if this is urgent", ErrQuotaExceeded, q.config.DailyMessages)
+	case q.config.DailyTokens > 0 && u.Tokens >= q.config.DailyTokens:
+		err = fmt.Errorf("%w of %d tokens; it resets at midnight. Please contact the administrator if this is urgent", ErrQuotaExceeded, q.config.DailyTokens)
+	default:
+		u.Messages++
+		q.save()
+	}
+	q.mu.Unlock()
+
+	if err != nil {
+		session.logEvent("quota_exceeded", map[string]interface{}{"reason": err.Error()})
+	}
+	return err
+}
+
+// record adds the tokens of a response to the session's user
+func (q *userQuotas) record(session *Session, usage provider.Usage) {
+	if !q.counted(session) {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.current(session.User.ID).Tokens += int64(usage.InputTokens + usage.OutputTokens)
+	q.save()
+}
+
+// status returns a user's usage and limits
+func (q *userQuotas) status(user *User) QuotaStatus {
+	now := time.Now()
+	status := QuotaStatus{ResetsAt: time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())}
+	if q == nil || user == nil {
+		return status
+	}
+	status.Enabled = true
+	status.Exempt = q.exempt(user)
+	status.MessagesLimit = q.config.DailyMessages
+	status.TokensLimit = q.config.DailyTokens
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	u := q.current(user.ID)
+	status.Messages = u.Messages
+	status.Tokens = u.Tokens
+	return status
+}
+
+// save persists usage to the state file. Callers hold q.mu.
+func (q *userQuotas) save() {
+	if q.config.StateFile == "" {
+		return
+	}
+	data, _ := json.Marshal(q.usage)
+	if err := os.WriteFile(q.config.StateFile, data, 0600); err != nil {
+		log.Printf("[Quota] Failed to save state: %v", err)
+	}
+}
+
+// handleStatus serves GET /api/quota: the current user's usage and limits
+func (q *userQuotas) handleStatus(w http.ResponseWriter, r *http.Request) {
+	user, _ := r.Context().Value(userContextKey).(*User)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(q.status(user))
+}
@@ -0,0 +1,172 @@
+package aiassistant
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/willknow-ai/willknow-go/provider"
+)
+
+// RedactionConfig masks sensitive data in everything sent to the LLM provider:
+// tool results (file contents, log excerpts, API responses), chat messages and
+// the system prompt. Session logs on local disk keep the original content.
+type RedactionConfig struct {
+	// Enabled turns on redaction with the built-in patterns: private keys,
+	// cloud and SaaS API keys, JWTs, bearer tokens, password/secret/token
+	// assignments, credentials in connection strings and email addresses.
+	Enabled bool
+
+	// DisableBuiltins lists built-in patterns to skip by name: "private_key",
+	// "aws_key", "github_token", "slack_token", "secret_key", "google_api_key",
+	// "jwt", "bearer", "credential", "url_password", "email"
+	DisableBuiltins []string
+
+	// Patterns are additional regular expressions to mask, e.g. customer
+	// IDs or internal hostnames. If a pattern has a capture group named
+	// "secret", only that group is masked.
+	Patterns []string
+
+	// Values are literal strings to mask wherever they appear (e.g. the
+	// application's own credentials)
+	Values []string
+}
+
+// redactionRule masks the matches of a pattern
+type redactionRule struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// builtinRedactions are masked unless disabled. Patterns with a "secret" group
+// keep the surrounding text (e.g. "password=") for context.
+var builtinRedactions = []redactionRule{
+	{"private_key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)},
+	{"aws_key", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"github_token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{20,}|glpat-[A-Za-z0-9_-]{20,})\b`)},
+	{"slack_token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	{"secret_key", regexp.MustCompile(`\b(?:sk|rk|pk)[-_](?:live[-_]|test[-_]|proj-|ant-)?[A-Za-z0-9_-]{20,}`)},
+	{"google_api_key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{"jwt", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`)},
+	{"bearer", regexp.MustCompile(`(?i)\b(?:bearer|basic|token)\s+(?P<secret>[A-Za-z0-9._~+/-]{16,}=*)`)},
+	{"credential", regexp.MustCompile(`(?i)\b[\w.-]*(?:password|passwd|pwd|secret|api[_-]?key|apikey|access[_-]?token|auth[_-]?token|refresh[_-]?token|client[_-]?secret|private[_-]?key)["']?\s*[:=]\s*["']?(?P<secret>[^\s"'&,;}]{4,})`)},
+	{"url_password", regexp.MustCompile(`://[^:/\s@]+:(?P<secret>[^@/\s]+)@`)},
+	{"email", regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`)},
+}
+
+// redactor masks sensitive data in text
+type redactor struct {
+	rules  []redactionRule
+	values []string
+}
+
+// newRedactor compiles the configured rules, or returns nil if redaction is disabled
+func newRedactor(config RedactionConfig) (*redactor, error) {
+	if !config.Enabled {
+		return nil, nil
+	}
+	disabled := make(map[string]bool)
+	for _, name := range config.DisableBuiltins {
+		disabled[name] = true
+	}
+
+	r := &redactor{}
+	for _, rule := range builtinRedactions {
+		if !disabled[rule.name] {
+			r.rules = append(r.rules, rule)
+		}
+	}
+	for i, p := range config.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", p, err)
+		}
+		r.rules = append(r.rules, redactionRule{name: fmt.Sprintf("pattern_%d", i+1), pattern: re})
+	}
+	for _, v := range config.Values {
+		if len(v) >= 4 {
+			r.values = append(r.values, v)
+		}
+	}
+	return r, nil
+}
+
+// redact returns text with every match masked as [REDACTED:<rule>]
+func (r *redactor) redact(text string) string {
+	if r == nil || text == "" {
+		return text
+	}
+	for _, v := range r.values {
+		text = strings.ReplaceAll(text, v, "[REDACTED:value]")
+	}
+	for _, rule := range r.rules {
+		mask := "[REDACTED:" + rule.name + "]"
+		group := rule.pattern.SubexpIndex("secret")
+		if group < 0 {
+			text = rule.pattern.ReplaceAllLiteralString(text, mask)
+			continue
+		}
+		// Only mask the secret group, keeping e.g. "password=" for context
+		text = rule.pattern.ReplaceAllStringFunc(text, func(match string) string {
+			loc := rule.pattern.FindStringSubmatchIndex(match)
+			if loc == nil || loc[2*group] < 0 {
+				return mask
+			}
+			return match[:loc[2*group]] + mask + match[loc[2*group+1]:]
+		})
+	}
+	return text
+}
+
+// redactingProvider masks sensitive data in every request before it leaves the process
+type redactingProvider struct {
+	base     provider.Provider
+	redactor *redactor
+}
+
+// SendMessage sends a redacted copy of the conversation
+func (p *redactingProvider) SendMessage(messages []provider.Message, tools []provider.Tool, system string) (*provider.Response, error) {
+	return p.base.SendMessage(p.redactor.redactMessages(messages), tools, p.redactor.redact(system))
+}
+
+// SendMessageStream sends a redacted copy of the conversation
+func (p *redactingProvider) SendMessageStream(messages []provider.Message, tools []provider.Tool, system string) (io.ReadCloser, error) {
+	return p.base.SendMessageStream(p.redactor.redactMessages(messages), tools, p.redactor.redact(system))
+}
+
+// GetName returns the wrapped provider's name
+func (p *redactingProvider) GetName() string {
+	return p.base.GetName()
+}
+
+// redactMessages copies the messages with text, tool results and tool inputs
+// redacted, leaving the session's history untouched
+func (r *redactor) redactMessages(messages []provider.Message) []provider.Message {
+	out := make([]provider.Message, len(messages))
+	for i, msg := range messages {
+		blocks := make([]provider.ContentBlock, len(msg.Content))
+		for j, block := range msg.Content {
+			block.Text = r.redact(block.Text)
+			block.Content = r.redact(block.Content)
+			if block.Input != nil {
+				block.Input = r.redactInput(block.Input)
+			}
+			blocks[j] = block
+		}
+		out[i] = provider.Message{Role: msg.Role, Content: blocks}
+	}
+	return out
+}
+
+// redactInput copies tool call arguments with string values redacted
+func (r *redactor) redactInput(input map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(input))
+	for k, v := range input {
+		if s, ok := v.(string); ok {
+			v = r.redact(s)
+		}
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,226 @@
+package aiassistant
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/willknow-ai/willknow-go/provider"
+)
+
+// defaultRequestIDFields are the error response fields searched for a request
+// ID when RequestIDConfig.JSONFields is empty
+var defaultRequestIDFields = []string{
+	"request_id", "requestId", "requestID", "x-request-id", "correlation_id", "correlationId", "trace_id", "traceId",
+}
+
+// RequestIDConfig registers how the host application identifies requests.
+// When a user pastes an error response, a header dump or a log line holding
+// a request ID, the assistant's first step is a read_logs lookup of that ID.
+// Enabled when Header or Pattern is set.
+type RequestIDConfig struct {
+	// Header is the HTTP header carrying the request ID (e.g. "X-Request-ID").
+	// RequestIDMiddleware sets it on every response. Default: "X-Request-ID"
+	Header string
+
+	// Pattern is a regular expression matching the ID format, used to find
+	// IDs in free text (e.g. `req_[0-9a-f]{16}`). If it has a group, the first
+	// group is the ID. Default: "" (IDs are only taken from JSON and headers)
+	Pattern string
+
+	// JSONFields are the fields of error response bodies holding the ID,
+	// searched at any depth. Default: defaultRequestIDFields
+	JSONFields []string
+}
+
+// requestIDContextKey holds the request ID set by RequestIDMiddleware
+type requestIDContextKey struct{}
+
+// requestIDExtractor finds request IDs in chat messages
+type requestIDExtractor struct {
+	header  string
+	pattern *regexp.Regexp
+	fields  map[string]bool // lower-case field names
+}
+
+// newRequestIDExtractor returns nil if no request ID format is registered
+func newRequestIDExtractor(config RequestIDConfig) (*requestIDExtractor, error) {
+	if config.Header == "" && config.Pattern == "" {
+		return nil, nil
+	}
+	e := &requestIDExtractor{header: config.Header, fields: make(map[string]bool)}
+	if e.header == "" {
+		e.header = "X-Request-ID"
+	}
+	if config.Pattern != "" {
+		pattern, err := regexp.Compile(config.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RequestID.Pattern: %w", err)
+		}
+		e.pattern = pattern
+	}
+	fields := config.JSONFields
+	if len(fields) == 0 {
+		fields = defaultRequestIDFields
+	}
+	for _, f := range fields {
+		e.fields[strings.ToLower(f)] = true
+	}
+	e.fields[strings.ToLower(e.header)] = true
+	return e, nil
+}
+
+// extract returns the request ID in text, or "". JSON bodies are tried
+// first, then "Header: value" lines, then Pattern.
+func (e *requestIDExtractor) extract(text string) string {
+	if e == nil {
+		return ""
+	}
+	if start, end := strings.Index(text, "{"), strings.LastIndex(text, "}"); start >= 0 && end > start {
+		var body interface{}
+		if json.Unmarshal([]byte(text[start:end+1]), &body) == nil {
+			if id := e.findField(body); id != "" {
+				return id
+			}
+		}
+	}
+	for _, line := range strings.Split(text, "\n") {
+		name, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if ok && strings.EqualFold(strings.TrimSpace(name), e.header) {
+			if id := strings.TrimSpace(value); id != "" {
+				return id
+			}
+		}
+	}
+	if e.pattern != nil {
+		if m := e.pattern.FindStringSubmatch(text); m != nil {
+			if len(m) > 1 {
+				return m[1]
+			}
+			return m[0]
+		}
+	}
+	return ""
+}
+
+// findField searches a decoded JSON value for a request ID field
+func (e *requestIDExtractor) findField(v interface{}) string {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if !e.fields[strings.ToLower(key)] {
+				continue
+			}
+			switch id := value.(type) {
+			case string:
+				if id != "" {
+					return id
+				}
+			case float64:
+				return fmt.Sprintf("%.0f", id)
+			}
+		}
+		for _, value := range v {
+			if id := e.findField(value); id != "" {
+				return id
+			}
+		}
+	case []interface{}:
+		for _, value := range v {
+			if id := e.findField(value); id != "" {
+				return id
+			}
+		}
+	}
+	return ""
+}
+
+// requestIDLookup returns a response calling read_logs for the request ID in
+// the session's latest question, to be handled as the model's first turn.
+// Returns nil if the question holds no request ID or logs are unavailable.
+func (a *Assistant) requestIDLookup(session *Session) *provider.Response {
+	if a.requestIDs == nil {
+		return nil
+	}
+	session.mu.Lock()
+	var question string
+	if n := len(session.messages); n > 0 && session.messages[n-1].Role == "user" {
+		for _, block := range session.messages[n-1].Content {
+			if block.Type == "text" {
+				question += block.Text
+			}
+		}
+	}
+	session.mu.Unlock()
+
+	id := a.requestIDs.extract(question)
+	if id == "" {
+		return nil
+	}
+	hasLogs := false
+	for _, tool := range a.getAllToolDefinitions(a.sessionTarget(session)) {
+		hasLogs = hasLogs || tool.Name == "read_logs"
+	}
+	if !hasLogs {
+		return nil
+	}
+
+	input := map[string]interface{}{"query": id}
+	if len(a.sessionTarget(session).toolRegistry.LogSourceNames()) > 1 {
+		input["correlate"] = true
+	}
+	if g := a.targetGuardrails(a.sessionTarget(session)); g != nil && g.config.MaxLogRange > 0 {
+		input["start_time"] = g.config.MaxLogRange.String()
+	}
+	toolID := make([]byte, 8)
+	rand.Read(toolID)
+	return &provider.Response{
+		Role: "assistant",
+		Content: []provider.ContentBlock{{
+			Type:  "tool_use",
+			ID:    "toolu_requestid_" + hex.EncodeToString(toolID),
+			Name:  "read_logs",
+			Input: input,
+		}},
+		StopReason: "tool_use",
+	}
+}
+
+// RequestIDMiddleware makes sure every request of the host application has
+// a request ID in the RequestIDConfig.Header header: an incoming ID is kept,
+// otherwise one is generated. The ID is echoed in the response header and
+// available to handlers and loggers through RequestIDFromContext, so the
+// IDs users copy from error responses can be found in the logs.
+func (a *Assistant) RequestIDMiddleware(next http.Handler) http.Handler {
+	header := a.requestIDHeader()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(header)
+		if id == "" {
+			b := make([]byte, 8)
+			rand.Read(b)
+			id = hex.EncodeToString(b)
+			r.Header.Set(header, id)
+		}
+		w.Header().Set(header, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDContextKey{}, id)))
+	})
+}
+
+// requestIDHeader returns the header carrying the application's request IDs
+func (a *Assistant) requestIDHeader() string {
+	if a.requestIDs != nil {
+		return a.requestIDs.header
+	}
+	return "X-Request-ID"
+}
+
+// RequestIDFromContext returns the request ID set by RequestIDMiddleware, or ""
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey{}).(string)
+	return id
+}
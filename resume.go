@@ -0,0 +1,253 @@
+package aiassistant
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/willknow-ai/willknow-go/provider"
+)
+
+const (
+	// wsResumeWindow is how long a disconnected chat session can be resumed
+	wsResumeWindow = 10 * time.Minute
+	// maxReplayEvents bounds the output kept for replay to a reconnecting client
+	maxReplayEvents = 1000
+	// maxQueuedMessages bounds the user messages waiting to be processed
+	maxQueuedMessages = 20
+	// wsWriteTimeout keeps a dead connection from blocking the session
+	wsWriteTimeout = 10 * time.Second
+)
+
+// chatWriter receives the responses of processChat
+type chatWriter interface {
+	WriteJSON(v interface{}) error
+}
+
+// wsSession is a WebSocket chat session that outlives its connection.
+// Messages are processed one at a time by a worker, so a reply is produced
+// even if the connection drops mid-answer; a client reconnecting within
+// wsResumeWindow attaches to the session again and is sent the responses
+// it missed.
+type wsSession struct {
+	session *Session
+	inbox   chan ChatMessage
+
+	mu         sync.Mutex
+	conn       *clientConn    // nil while disconnected
+	seq        int64          // sequence number of the last response
+	history    []ChatResponse // last maxReplayEvents responses, for replay
+	lastMsgID  int64          // highest client message ID received, to drop resent duplicates
+	detachedAt time.Time
+	closed     bool
+	consents   map[string]chan bool // pending consent requests by ID
+}
+
+// wsSessionStore holds the resumable WebSocket sessions
+type wsSessionStore struct {
+	a        *Assistant
+	mu       sync.Mutex
+	sessions map[string]*wsSession
+}
+
+func newWSSessionStore(a *Assistant) *wsSessionStore {
+	return &wsSessionStore{a: a, sessions: make(map[string]*wsSession)}
+}
+
+// start registers a new session and runs its worker
+func (s *wsSessionStore) start(session *Session, conn *clientConn) *wsSession {
+	ws := &wsSession{
+		session: session,
+		inbox:   make(chan ChatMessage, maxQueuedMessages),
+		conn:    conn,
+	}
+	session.askConsent = ws.askConsent
+	s.mu.Lock()
+	s.sessions[session.ID] = ws
+	s.mu.Unlock()
+	go ws.run(s)
+	return ws
+}
+
+// resume attaches conn to the user's session with the given ID and replays
+// the responses after sequence number after. Returns nil if the session does
+// not exist, has expired or belongs to another user.
+func (s *wsSessionStore) resume(id string, after int64, user *User, conn *clientConn) *wsSession {
+	s.mu.Lock()
+	ws := s.sessions[id]
+	s.mu.Unlock()
+	if ws == nil || user == nil || ws.session.User == nil || ws.session.User.ID != user.ID {
+		return nil
+	}
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if ws.closed {
+		return nil
+	}
+	if ws.conn != nil {
+		ws.conn.Close() // a stale connection the server has not noticed yet
+	}
+	ws.conn = conn
+	conn.WriteJSON(ChatResponse{
+		Type:      "session_info",
+		SessionID: id,
+		Content:   "Session " + id + " resumed",
+		Revision:  s.a.analyzedRevision(s.a.sessionTarget(ws.session)),
+		Resumed:   true,
+		Actions:   s.a.answerActions(),
+	})
+	if len(ws.history) > 0 && ws.history[0].Seq > after+1 {
+		conn.WriteJSON(ChatResponse{Type: "error", Content: "Some output was lost while you were disconnected."})
+	}
+	for _, resp := range ws.history {
+		if resp.Seq > after {
+			conn.WriteJSON(resp)
+		}
+	}
+	log.Printf("[Session %s] Resumed", id)
+	return ws
+}
+
+// WriteJSON numbers a response, keeps it for replay and sends it to the
+// connected client, if any
+func (ws *wsSession) WriteJSON(v interface{}) error {
+	resp, ok := v.(ChatResponse)
+	if !ok {
+		return fmt.Errorf("unsupported response type %T", v)
+	}
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.seq++
+	resp.Seq = ws.seq
+	ws.history = append(ws.history, resp)
+	if len(ws.history) > maxReplayEvents {
+		ws.history = ws.history[len(ws.history)-maxReplayEvents:]
+	}
+	if ws.conn != nil {
+		// On failure the reader notices the broken connection and detaches
+		// it; the response is replayed on reconnection
+		ws.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+		ws.conn.WriteJSON(resp)
+	}
+	return nil
+}
+
+// receive acknowledges a client message and queues it for processing
+func (ws *wsSession) receive(conn *clientConn, msg ChatMessage) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if msg.Consent != nil {
+		ws.answerConsent(*msg.Consent)
+		return
+	}
+	if msg.ID != 0 && msg.ID <= ws.lastMsgID {
+		conn.WriteJSON(ChatResponse{Type: "ack", MessageID: msg.ID}) // resent after a reconnection
+		return
+	}
+	select {
+	case ws.inbox <- msg:
+		if msg.ID != 0 {
+			ws.lastMsgID = msg.ID
+			conn.WriteJSON(ChatResponse{Type: "ack", MessageID: msg.ID})
+		}
+	default:
+		conn.WriteJSON(ChatResponse{Type: "error", Content: "Too many queued messages; wait for the current answer."})
+	}
+}
+
+// detach marks the session disconnected if conn is still its connection,
+// and ends the session unless a client resumes it within wsResumeWindow
+func (ws *wsSession) detach(s *wsSessionStore, conn *clientConn, err error) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if ws.conn != conn {
+		return // replaced by a newer connection
+	}
+	ws.conn = nil
+	ws.detachedAt = time.Now()
+	detachedAt := ws.detachedAt
+	log.Printf("[Session %s] Disconnected: %v", ws.session.ID, err)
+
+	time.AfterFunc(wsResumeWindow, func() {
+		ws.mu.Lock()
+		if ws.conn != nil || !ws.detachedAt.Equal(detachedAt) || ws.closed {
+			ws.mu.Unlock()
+			return
+		}
+		ws.closed = true
+		ws.mu.Unlock()
+
+		s.mu.Lock()
+		delete(s.sessions, ws.session.ID)
+		s.mu.Unlock()
+		ws.session.logEvent("session_end", map[string]interface{}{
+			"reason": "connection_closed",
+			"error":  err.Error(),
+		})
+		close(ws.inbox)
+	})
+}
+
+// run processes queued messages until the session ends
+func (ws *wsSession) run(s *wsSessionStore) {
+	session := ws.session
+	for msg := range ws.inbox {
+		// While paused, messages are turned away before they join the conversation
+		if err := s.a.maintenance.check(); err != nil {
+			ws.WriteJSON(ChatResponse{Type: "maintenance", Content: err.Error()})
+			ws.WriteJSON(ChatResponse{Type: "done"})
+			continue
+		}
+
+		// An answer action asks its predefined question
+		if msg.Action != "" {
+			prompt, err := s.a.actionPrompt(session, msg)
+			if err != nil {
+				ws.WriteJSON(ChatResponse{Type: "error", Content: fmt.Sprintf("Error: %v", err)})
+				ws.WriteJSON(ChatResponse{Type: "done"})
+				continue
+			}
+			msg.Content = prompt
+		}
+
+		// Log user message
+		session.logEvent("user_message", map[string]interface{}{
+			"content": msg.Content,
+		})
+
+		// Add user message to session
+		session.mu.Lock()
+		session.messages = append(session.messages, provider.Message{
+			Role: "user",
+			Content: []provider.ContentBlock{
+				{Type: "text", Text: msg.Content},
+			},
+		})
+		session.mu.Unlock()
+
+		log.Printf("[Session %s] User: %s", session.ID, msg.Content)
+
+		// Process with AI (allow multiple tool use turns)
+		if err := processChat(ws, s.a, session); err != nil {
+			log.Printf("[Session %s] Error: %v", session.ID, err)
+			session.logEvent("error", map[string]interface{}{
+				"error": err.Error(),
+			})
+			ws.WriteJSON(ChatResponse{
+				Type:    "error",
+				Content: fmt.Sprintf("Error: %v", err),
+			})
+		}
+
+		// Send done signal
+		ws.WriteJSON(ChatResponse{Type: "done", Citations: session.answerCitations(), Suggestions: session.answerSuggestions()})
+	}
+
+	if session.logFile != nil {
+		session.logFile.Close()
+	}
+	log.Printf("[Session %s] Ended", session.ID)
+}
@@ -0,0 +1,126 @@
+package aiassistant
+
+import (
+	"bufio"
+	"io/fs"
+	"log"
+	"runtime/debug"
+	"strings"
+)
+
+// minRevisionLength is the shortest revision prefix compared with a full SHA
+const minRevisionLength = 7
+
+// buildRevision returns the VCS revision the running binary was built from,
+// and whether the build had uncommitted changes
+func buildRevision() (string, bool) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "", false
+	}
+	var revision string
+	var modified bool
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			revision = s.Value
+		case "vcs.modified":
+			modified = s.Value == "true"
+		}
+	}
+	return revision, modified
+}
+
+// gitRevision returns the commit checked out in a git working tree at the
+// root of source, or "" if source is not a git checkout
+func gitRevision(source fs.FS) string {
+	head, err := fs.ReadFile(source, ".git/HEAD")
+	if err != nil {
+		return ""
+	}
+	ref, isRef := strings.CutPrefix(strings.TrimSpace(string(head)), "ref: ")
+	if !isRef {
+		return strings.TrimSpace(string(head)) // detached HEAD
+	}
+	if data, err := fs.ReadFile(source, ".git/"+ref); err == nil {
+		return strings.TrimSpace(string(data))
+	}
+
+	// The ref may only be in packed-refs
+	packed, err := source.Open(".git/packed-refs")
+	if err != nil {
+		return ""
+	}
+	defer packed.Close()
+	scanner := bufio.NewScanner(packed)
+	for scanner.Scan() {
+		if sha, name, ok := strings.Cut(scanner.Text(), " "); ok && name == ref {
+			return sha
+		}
+	}
+	return ""
+}
+
+// sameRevision reports whether two revisions name the same commit, allowing
+// one of them to be abbreviated
+func sameRevision(a, b string) bool {
+	if len(a) < minRevisionLength || len(b) < minRevisionLength {
+		return false
+	}
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	return strings.HasPrefix(strings.ToLower(b), strings.ToLower(a))
+}
+
+// analyzedRevision returns the revision of the source the target's tools read
+func (a *Assistant) analyzedRevision(t *target) string {
+	if t == a.targets[0] && a.gitSource != nil {
+		return a.gitSource.Revision()
+	}
+	return t.revision
+}
+
+// checkRevision warns when the default target's source does not match the running build
+func (a *Assistant) checkRevision() {
+	running := a.config.BuildRevision
+	analyzed := a.analyzedRevision(a.targets[0])
+	switch {
+	case running == "":
+		log.Println("[AI Assistant] Warning: the running build has no VCS revision (set Config.BuildRevision); cannot verify the source matches the deployed code")
+	case analyzed == "":
+		log.Printf("[AI Assistant] Running revision %s; the source has no git metadata, so it cannot be verified", shortRevision(running))
+	case !sameRevision(running, analyzed):
+		log.Printf("[AI Assistant] Warning: source is at %s but the running build is %s; answers may refer to code that is not deployed", shortRevision(analyzed), shortRevision(running))
+	default:
+		log.Printf("[AI Assistant] Source matches the running build (%s)", shortRevision(running))
+	}
+	if a.dirtyBuild {
+		log.Println("[AI Assistant] Warning: the running build had uncommitted changes")
+	}
+}
+
+// revisionPrompt tells the model which revision it is reading and whether
+// it matches the deployed code
+func (a *Assistant) revisionPrompt(t *target) string {
+	analyzed := a.analyzedRevision(t)
+	if analyzed == "" {
+		return ""
+	}
+	prompt := "\n\nSource revision: the source code tools read commit " + shortRevision(analyzed) + "."
+	if t != a.targets[0] || a.config.BuildRevision == "" {
+		return prompt + " When you suggest a fix, state the revision it applies to."
+	}
+	if !sameRevision(a.config.BuildRevision, analyzed) {
+		return prompt + " The running build is commit " + shortRevision(a.config.BuildRevision) + ", so the deployed code may differ from what you read. Warn the user when a finding depends on code that may have changed, and state the analyzed revision when you suggest a fix."
+	}
+	return prompt + " It matches the running build. When you suggest a fix, state the revision it applies to."
+}
+
+// shortRevision abbreviates a commit SHA for display
+func shortRevision(revision string) string {
+	if len(revision) > 12 {
+		return revision[:12]
+	}
+	return revision
+}
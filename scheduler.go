@@ -0,0 +1,390 @@
+package aiassistant
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/willknow-ai/willknow-go/provider"
+)
+
+// maxStoredScheduledResults is how many recent scheduled analyses are kept for /api/scheduled
+const maxStoredScheduledResults = 50
+
+// SchedulerConfig configures analyses that run unattended on a schedule
+type SchedulerConfig struct {
+	// Jobs are the scheduled analyses
+	Jobs []ScheduledAnalysis
+
+	// ResultsDir, if set, stores every result as a Markdown file
+	// Default: "" (results are only kept in memory)
+	ResultsDir string
+}
+
+// ScheduledAnalysis is an analysis prompt run on a cron schedule.
+// Results are delivered to webhooks subscribed to scheduled_analysis_completed
+// and, if Email is set, emailed with the Digest SMTP settings.
+type ScheduledAnalysis struct {
+	// Name identifies the job in logs, results and notifications
+	Name string
+
+	// Schedule is a standard 5-field cron expression in local time
+	// ("minute hour day-of-month month day-of-week", e.g. "0 2 * * *"),
+	// or one of @hourly, @daily, @midnight, @weekly, @monthly.
+	Schedule string
+
+	// Prompt is the question the assistant answers,
+	// e.g. "Summarize all ERROR logs of the last 24 hours and group them by cause"
+	Prompt string
+
+	// Target runs the analysis against a target from Config.Targets.
+	// Default: the default target
+	Target string
+
+	// Email sends the result by email using the Digest SMTP settings
+	Email bool
+
+	// EmailTo overrides the recipients (default: Digest.To)
+	EmailTo []string
+}
+
+// ScheduledResult is the outcome of one scheduled analysis run
+type ScheduledResult struct {
+	Job       string    `json:"job"`
+	SessionID string    `json:"session_id"`
+	Result    string    `json:"result"`
+	Failed    bool      `json:"failed,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+	Duration  string    `json:"duration"`
+}
+
+// scheduledJob is a job with its parsed schedule and target
+type scheduledJob struct {
+	ScheduledAnalysis
+	schedule *cronSchedule
+	target   *target
+}
+
+// scheduler runs scheduled analyses and keeps their recent results
+type scheduler struct {
+	a    *Assistant
+	jobs []*scheduledJob
+	dir  string
+
+	mu      sync.Mutex
+	results []ScheduledResult
+}
+
+// newScheduler validates the jobs, or returns nil if none are configured
+func newScheduler(a *Assistant, config SchedulerConfig) (*scheduler, error) {
+	if len(config.Jobs) == 0 {
+		return nil, nil
+	}
+	s := &scheduler{a: a, dir: config.ResultsDir}
+	for _, job := range config.Jobs {
+		if job.Name == "" || strings.TrimSpace(job.Prompt) == "" {
+			return nil, fmt.Errorf("scheduled analyses require a Name and a Prompt")
+		}
+		schedule, err := parseCron(job.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("job %s: %w", job.Name, err)
+		}
+		t := a.findTarget(job.Target)
+		if t == nil {
+			return nil, fmt.Errorf("job %s: unknown target %q", job.Name, job.Target)
+		}
+		if job.Email && a.digest == nil {
+			return nil, fmt.Errorf("job %s: Email requires the Digest SMTP settings", job.Name)
+		}
+		s.jobs = append(s.jobs, &scheduledJob{ScheduledAnalysis: job, schedule: schedule, target: t})
+	}
+	if s.dir != "" {
+		if err := os.MkdirAll(s.dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create results folder: %w", err)
+		}
+	}
+	return s, nil
+}
+
+// start runs every job on its schedule in the background
+func (s *scheduler) start() {
+	for _, job := range s.jobs {
+		go func(job *scheduledJob) {
+			for {
+				next := job.schedule.next(time.Now())
+				if next.IsZero() {
+					log.Printf("[Scheduler] Job %s: schedule never matches, stopping", job.Name)
+					return
+				}
+				time.Sleep(time.Until(next))
+				s.run(job)
+			}
+		}(job)
+	}
+}
+
+// run executes one job in a fresh session and publishes the result
+func (s *scheduler) run(job *scheduledJob) {
+	sessionID := generateSessionID()
+	logFile, _ := initSessionLog(sessionID, s.a.transcripts)
+	session := &Session{
+		ID:         sessionID,
+		User:       &User{ID: "schedule:" + job.Name, Name: "Scheduled " + job.Name},
+		messages:   []provider.Message{},
+		logFile:    logFile,
+		unattended: true,
+		target:     job.target,
+	}
+	defer func() {
+		if logFile != nil {
+			logFile.Close()
+		}
+	}()
+
+	log.Printf("[Scheduler] Running %s in session %s", job.Name, sessionID)
+	session.logEvent("session_start", map[string]interface{}{
+		"channel": "schedule",
+		"job":     job.Name,
+	})
+	prompt := fmt.Sprintf("This is an unattended scheduled analysis (%q, run at %s); nobody can answer follow-up questions, so complete the task with the tools available and reply with the final report.\n\n%s",
+		job.Name, time.Now().Format(time.RFC3339), job.Prompt)
+	session.messages = append(session.messages, provider.Message{
+		Role: "user",
+		Content: []provider.ContentBlock{
+			{Type: "text", Text: prompt},
+		},
+	})
+	session.logEvent("user_message", map[string]interface{}{"content": prompt})
+
+	started := time.Now()
+	result := ScheduledResult{Job: job.Name, SessionID: sessionID, StartedAt: started}
+	if err := processChatHTTP(s.a, session, &result.Result); err != nil {
+		log.Printf("[Scheduler] %s failed: %v", job.Name, err)
+		result.Result = fmt.Sprintf("Scheduled analysis failed: %v", err)
+		result.Failed = true
+	}
+	result.Duration = time.Since(started).Round(time.Second).String()
+
+	s.mu.Lock()
+	s.results = append(s.results, result)
+	if len(s.results) > maxStoredScheduledResults {
+		s.results = s.results[len(s.results)-maxStoredScheduledResults:]
+	}
+	s.mu.Unlock()
+
+	s.publish(job, result)
+	log.Printf("[Scheduler] %s complete (%s)", job.Name, result.Duration)
+}
+
+// publish stores the result on disk and sends the configured notifications
+func (s *scheduler) publish(job *scheduledJob, result ScheduledResult) {
+	title := fmt.Sprintf("%s (%s)", job.Name, result.StartedAt.Format("2006-01-02 15:04"))
+	if result.Failed {
+		title = "FAILED: " + title
+	}
+
+	if s.dir != "" {
+		name := result.StartedAt.Format("2006-01-02-150405") + "-" + slugify(job.Name) + ".md"
+		body := fmt.Sprintf("# %s\n\n- Session: %s\n- Duration: %s\n\n## Prompt\n\n%s\n\n## Result\n\n%s\n",
+			title, result.SessionID, result.Duration, job.Prompt, result.Result)
+		if err := os.WriteFile(filepath.Join(s.dir, name), []byte(body), 0644); err != nil {
+			log.Printf("[Scheduler] Failed to store %s result: %v", job.Name, err)
+		}
+	}
+
+	s.a.webhooks.notify(WebhookPayload{
+		Event:     WebhookEventScheduledAnalysis,
+		Timestamp: time.Now(),
+		SessionID: result.SessionID,
+		UserID:    "schedule:" + job.Name,
+		Question:  job.Prompt,
+		Summary:   truncate(result.Result, maxWebhookSummaryChars),
+	})
+
+	if job.Email {
+		to := job.EmailTo
+		if len(to) == 0 {
+			to = s.a.digest.config.To
+		}
+		subject := fmt.Sprintf("[%s] Scheduled analysis: %s", s.a.digest.name, title)
+		body := fmt.Sprintf("%s\n\nPrompt:\n%s\n\n%s\n", title, job.Prompt, result.Result)
+		if err := s.a.digest.deliver(to, subject, body); err != nil {
+			log.Printf("[Scheduler] Failed to email %s result: %v", job.Name, err)
+		}
+	}
+}
+
+// handleList returns the recent results as JSON for /api/scheduled
+func (s *scheduler) handleList(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	results := make([]ScheduledResult, len(s.results))
+	copy(results, s.results)
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+// slugify turns a name into a lowercase file name fragment
+func slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// --- Cron expressions ---
+
+// cronSchedule is a parsed 5-field cron expression
+type cronSchedule struct {
+	minute, hour, dom, month, dow []bool
+	// domAny and dowAny record "*" fields: when both day fields are
+	// restricted, a day matches if either one does (standard cron behavior)
+	domAny, dowAny bool
+}
+
+var cronMacros = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+}
+
+// parseCron parses "minute hour day-of-month month day-of-week"
+func parseCron(expr string) (*cronSchedule, error) {
+	expr = strings.TrimSpace(expr)
+	if macro, ok := cronMacros[expr]; ok {
+		expr = macro
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 fields", expr)
+	}
+
+	var c cronSchedule
+	var err error
+	if c.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("minute: %w", err)
+	}
+	if c.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("hour: %w", err)
+	}
+	if c.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("day of month: %w", err)
+	}
+	if c.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("month: %w", err)
+	}
+	// Day of week accepts 0-7, where both 0 and 7 are Sunday
+	if c.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("day of week: %w", err)
+	}
+	c.dow[0] = c.dow[0] || c.dow[7]
+	c.domAny = fields[2] == "*"
+	c.dowAny = fields[4] == "*"
+	return &c, nil
+}
+
+// parseCronField parses a comma-separated list of "*", "n", "a-b" with optional "/step"
+func parseCronField(field string, min, max int) ([]bool, error) {
+	set := make([]bool, max+1)
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid step in %q", part)
+			}
+			rangePart, step = part[:i], n
+		}
+
+		lo, hi := min, max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			a, err1 := strconv.Atoi(bounds[0])
+			b, err2 := strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil || a > b {
+				return nil, fmt.Errorf("invalid range %q", rangePart)
+			}
+			lo, hi = a, b
+		default:
+			n, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q", rangePart)
+			}
+			lo, hi = n, n
+			if step > 1 {
+				// "n/step" means from n to the maximum
+				hi = max
+			}
+		}
+		if lo < min || hi > max {
+			return nil, fmt.Errorf("value out of range %d-%d in %q", min, max, part)
+		}
+		for v := lo; v <= hi; v += step {
+			set[v] = true
+		}
+	}
+	return set, nil
+}
+
+// next returns the first matching minute after t, or the zero time if the
+// schedule does not match within five years (e.g. "0 0 31 2 *")
+func (c *cronSchedule) next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if !c.month[t.Month()] {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.hour[t.Hour()] {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.minute[t.Minute()] {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches applies the day-of-month / day-of-week rules
+func (c *cronSchedule) dayMatches(t time.Time) bool {
+	dom := c.dom[t.Day()]
+	dow := c.dow[int(t.Weekday())]
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dow
+	case c.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
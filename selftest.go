@@ -0,0 +1,214 @@
+package aiassistant
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/willknow-ai/willknow-go/provider"
+	"github.com/willknow-ai/willknow-go/tools"
+)
+
+const (
+	// selfTestStaleLog is the age after which an unchanged log file is reported
+	selfTestStaleLog = 24 * time.Hour
+	// selfTestMaxFiles bounds the source files counted per target
+	selfTestMaxFiles = 100000
+)
+
+// Self-test check statuses
+const (
+	CheckOK   = "ok"
+	CheckWarn = "warn"
+	CheckFail = "fail"
+)
+
+// SelfTestCheck is the result of one self-test check
+type SelfTestCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"` // CheckOK, CheckWarn or CheckFail
+	Detail string `json:"detail"`
+}
+
+// SelfTestReport is the diagnostic report of Assistant.SelfTest
+type SelfTestReport struct {
+	Checks []SelfTestCheck `json:"checks"`
+}
+
+// OK reports whether no check failed
+func (r *SelfTestReport) OK() bool {
+	for _, c := range r.Checks {
+		if c.Status == CheckFail {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *SelfTestReport) add(name, status, format string, args ...interface{}) {
+	r.Checks = append(r.Checks, SelfTestCheck{Name: name, Status: status, Detail: fmt.Sprintf(format, args...)})
+}
+
+// WriteText writes the report as a plain-text checklist
+func (r *SelfTestReport) WriteText(w io.Writer) {
+	marks := map[string]string{CheckOK: "[ OK ]", CheckWarn: "[WARN]", CheckFail: "[FAIL]"}
+	for _, c := range r.Checks {
+		fmt.Fprintf(w, "%s %s: %s\n", marks[c.Status], c.Name, c.Detail)
+	}
+	if r.OK() {
+		fmt.Fprintln(w, "\nAll checks passed.")
+	} else {
+		fmt.Fprintln(w, "\nSome checks failed; the assistant may not answer until they are fixed.")
+	}
+}
+
+// SelfTest verifies that the assistant can work: the AI provider answers,
+// log files are readable, source paths contain files, code indexes are
+// loaded and OpenAPI specs yield tools. It sends one short message to the
+// provider. Run it when "the assistant doesn't answer" (see "willknow doctor").
+func (a *Assistant) SelfTest() *SelfTestReport {
+	report := &SelfTestReport{}
+	a.selfTestProvider(report)
+	for _, t := range a.targets {
+		prefix := ""
+		if len(a.targets) > 1 {
+			prefix = t.config.Name + ": "
+		}
+		a.selfTestSource(report, t, prefix)
+		a.selfTestLogs(report, t, prefix)
+		a.selfTestIndex(report, t, prefix)
+		a.selfTestSpec(report, t, prefix)
+	}
+	return report
+}
+
+// selfTestProvider sends a minimal message to the AI provider
+func (a *Assistant) selfTestProvider(report *SelfTestReport) {
+	name := "provider " + a.config.Provider
+	if a.config.Model != "" {
+		name += "/" + a.config.Model
+	}
+	start := time.Now()
+	response, err := a.provider.SendMessage([]provider.Message{{
+		Role:    "user",
+		Content: []provider.ContentBlock{{Type: "text", Text: "Reply with OK."}},
+	}}, nil, "You are a connectivity check. Reply with OK.")
+	switch {
+	case err != nil:
+		report.add(name, CheckFail, "%v", err)
+	case len(response.Content) == 0:
+		report.add(name, CheckWarn, "empty response after %s", time.Since(start).Round(time.Millisecond))
+	default:
+		report.add(name, CheckOK, "answered in %s", time.Since(start).Round(time.Millisecond))
+	}
+}
+
+// selfTestSource counts the files of a target's source tree
+func (a *Assistant) selfTestSource(report *SelfTestReport, t *target, prefix string) {
+	name := prefix + "source " + t.config.SourcePath
+	source := t.config.SourceFS
+	if source == nil {
+		var err error
+		if source, err = tools.OpenSource(t.config.SourcePath); err != nil {
+			report.add(name, CheckFail, "%v", err)
+			return
+		}
+	}
+	files := 0
+	err := fs.WalkDir(source, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && (d.Name() == ".git" || d.Name() == "node_modules" || d.Name() == "vendor") {
+			return fs.SkipDir
+		}
+		if !d.IsDir() {
+			files++
+		}
+		if files >= selfTestMaxFiles {
+			return fs.SkipAll
+		}
+		return nil
+	})
+	switch {
+	case err != nil:
+		report.add(name, CheckFail, "cannot read the source: %v", err)
+	case files == 0:
+		report.add(name, CheckFail, "no files; mount or copy the application source there")
+	default:
+		detail := fmt.Sprintf("%d files", files)
+		if t.revision != "" {
+			detail += ", revision " + t.revision
+		}
+		report.add(name, CheckOK, "%s", detail)
+	}
+}
+
+// selfTestLogs checks that a target's log files exist and are readable
+func (a *Assistant) selfTestLogs(report *SelfTestReport, t *target, prefix string) {
+	if len(t.config.LogFiles) == 0 {
+		report.add(prefix+"log files", CheckWarn, "none configured or detected; read_logs only searches remote log sources")
+		return
+	}
+	for _, path := range t.config.LogFiles {
+		name := prefix + "log " + path
+		file, err := os.Open(path)
+		if err != nil {
+			report.add(name, CheckFail, "%v", err)
+			continue
+		}
+		info, err := file.Stat()
+		if err == nil {
+			_, err = file.Read(make([]byte, 1))
+		}
+		file.Close()
+		switch {
+		case info == nil || info.IsDir():
+			report.add(name, CheckFail, "not a file")
+		case info.Size() == 0:
+			report.add(name, CheckWarn, "empty")
+		case err != nil && err != io.EOF:
+			report.add(name, CheckFail, "not readable: %v", err)
+		case time.Since(info.ModTime()) > selfTestStaleLog:
+			report.add(name, CheckWarn, "%d bytes, not written since %s; is the application logging elsewhere?", info.Size(), info.ModTime().Format(time.RFC3339))
+		default:
+			report.add(name, CheckOK, "%d bytes, last written %s", info.Size(), info.ModTime().Format(time.RFC3339))
+		}
+	}
+}
+
+// selfTestIndex checks the code index of a target that enables one
+func (a *Assistant) selfTestIndex(report *SelfTestReport, t *target, prefix string) {
+	if !t.config.EnableCodeIndex {
+		return
+	}
+	name := prefix + "code index"
+	switch {
+	case t.codeIndex == nil:
+		report.add(name, CheckFail, "not available; check the startup log for indexing errors")
+	case len(t.codeIndex.Files) == 0:
+		report.add(name, CheckWarn, "empty index built %s", t.codeIndex.CreatedAt.Format(time.RFC3339))
+	default:
+		report.add(name, CheckOK, "%d files, built %s", len(t.codeIndex.Files), t.codeIndex.CreatedAt.Format(time.RFC3339))
+	}
+}
+
+// selfTestSpec checks the OpenAPI spec of a target that configures one
+func (a *Assistant) selfTestSpec(report *SelfTestReport, t *target, prefix string) {
+	if t.config.APISpec == "" {
+		return
+	}
+	name := prefix + "OpenAPI spec " + t.config.APISpec
+	switch {
+	case t.apiSpec == nil:
+		report.add(name, CheckFail, "not loaded")
+	case len(t.apiTools) == 0:
+		report.add(name, CheckWarn, "no operations found")
+	case t.config.HostBaseURL == "":
+		report.add(name, CheckWarn, "%d operations, but no HostBaseURL or server URL to call them", len(t.apiTools))
+	default:
+		report.add(name, CheckOK, "%d operations, calling %s", len(t.apiTools), t.config.HostBaseURL)
+	}
+}
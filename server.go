@@ -5,13 +5,16 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gorilla/websocket"
 	"github.com/willknow-ai/willknow-go/provider"
@@ -42,7 +45,7 @@ When a user reports an error:
 2. Use search_code_index to find relevant files based on the error context
 3. Use read_file to examine the code where the error occurred
 4. Analyze the root cause
-5. Suggest a fix with specific file and line numbers
+5. Suggest a fix with specific file and line numbers, written as a unified diff in a diff code block
 
 When exploring the codebase:
 1. Start with search_code_index to find files related to the feature or concept
@@ -51,6 +54,16 @@ When exploring the codebase:
 
 Be concise, technical, and focus on solving the problem quickly. Always reference specific files and line numbers when suggesting fixes.`
 
+// runbookGuidance is appended to the system prompt when runbooks are configured
+const runbookGuidance = `
+
+Runbooks:
+The team documents procedures for known failure modes in runbooks (search_runbooks).
+- As soon as you know the error, alert or symptom, search the runbooks for it
+- If a runbook covers the problem, prefer its documented diagnosis and remediation steps over your own, and cite the runbook and section
+- Point out when the evidence contradicts a runbook or a runbook looks outdated
+- Only fall back to open-ended investigation when no runbook applies`
+
 var upgrader = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool {
 		return true // Allow all origins for MVP
@@ -59,14 +72,38 @@ var upgrader = websocket.Upgrader{
 
 // ChatMessage represents a chat message from the client
 type ChatMessage struct {
-	Content string `json:"content"`
+	Content    string           `json:"content"`
+	ID         int64            `json:"id,omitempty"`         // client message ID, acknowledged and deduplicated across reconnections
+	Consent    *ConsentReply    `json:"consent,omitempty"`    // answer to a "consent" response instead of a question
+	Action     string           `json:"action,omitempty"`     // answer action (e.g. "generate_test") asked instead of Content
+	Suggestion *SuggestedAction `json:"suggestion,omitempty"` // suggested action run by the "suggestion" action
 }
 
 // ChatResponse represents a response to the client
 type ChatResponse struct {
-	Type      string `json:"type"`    // "text", "error", "done", "session_info"
-	Content   string `json:"content"` // text content
-	SessionID string `json:"sessionId,omitempty"` // session identifier
+	Type      string    `json:"type"`                // "text", "error", "done", "session_info", "tool_use", "tool_result", "status", "ack", "consent", "maintenance"
+	Content   string    `json:"content"`             // text content
+	SessionID string    `json:"sessionId,omitempty"` // session identifier
+	Revision  string    `json:"revision,omitempty"`  // source revision analyzed (session_info)
+	Resumed   bool      `json:"resumed,omitempty"`   // session_info of a resumed session
+	Greeting  *Greeting `json:"greeting,omitempty"`  // welcome message (session_info of a new session)
+	Actions   []string  `json:"actions,omitempty"`   // actions offered under answers (session_info)
+	Seq       int64     `json:"seq,omitempty"`       // response sequence number, for replay after reconnection
+	MessageID int64     `json:"messageId,omitempty"` // acknowledged client message ID ("ack")
+
+	// For tool_use and tool_result traces
+	ToolName  string                 `json:"toolName,omitempty"`
+	ToolInput map[string]interface{} `json:"toolInput,omitempty"`
+	IsError   bool                   `json:"isError,omitempty"`
+
+	// Evidence the final answer cites ("done")
+	Citations []Citation `json:"citations,omitempty"`
+
+	// Follow-up actions offered with the answer ("done")
+	Suggestions []SuggestedAction `json:"suggestions,omitempty"`
+
+	// Capability the user is asked to allow ("consent")
+	Consent *ConsentRequest `json:"consent,omitempty"`
 }
 
 // Session manages a chat session
@@ -74,9 +111,33 @@ type Session struct {
 	ID         string
 	User       *User
 	messages   []provider.Message
-	logFile    *os.File
+	logFile    *sessionLog
 	mu         sync.Mutex
-	authHeader string // original Authorization header for API forwarding
+	authHeader string  // original Authorization header for API forwarding
+	target     *target // nil routes tool calls to the default target
+	hops       int     // agent-to-agent calls that led to this session
+
+	// Host API calls that failed in the current answer, by tool and arguments
+	failedCalls map[string]*failedCall
+	unattended  bool // automated analysis, not counted against user quotas
+
+	// Consent decisions by capability, and the prompt of interactive sessions
+	consents   map[string]bool
+	askConsent func(req ConsentRequest, timeout time.Duration) (approved, answered bool)
+
+	// Evidence gathered for the current answer, numbered across answers
+	citations []Citation
+	evidence  int
+
+	// Follow-up actions offered with the current answer
+	suggestions []SuggestedAction
+
+	// Experiment variant, assigned before the first question
+	variant *variant
+
+	// onToolUse, if set, is called before each tool call of processChatHTTP
+	// so HTTP callers can report progress (e.g. A2A streaming)
+	onToolUse func(name string, input map[string]interface{})
 }
 
 // generateSessionID creates a unique session identifier
@@ -86,8 +147,8 @@ func generateSessionID() string {
 	return hex.EncodeToString(bytes)
 }
 
-// initSessionLog creates a log file for the session
-func initSessionLog(sessionID string) (*os.File, error) {
+// initSessionLog creates a log file for the session, encrypted and filtered by policy
+func initSessionLog(sessionID string, policy *transcriptPolicy) (*sessionLog, error) {
 	// Create sessions directory
 	logDir := "./sessions"
 	os.MkdirAll(logDir, 0755)
@@ -102,7 +163,7 @@ func initSessionLog(sessionID string) (*os.File, error) {
 		return nil, err
 	}
 
-	return file, nil
+	return &sessionLog{file: file, policy: policy}, nil
 }
 
 // logSessionEvent logs an event to the session log file
@@ -124,8 +185,7 @@ func (s *Session) logEvent(eventType string, data interface{}) {
 		return
 	}
 
-	s.logFile.Write(jsonData)
-	s.logFile.WriteString("\n")
+	s.logFile.write(eventType, jsonData)
 }
 
 func startServer(a *Assistant) error {
@@ -135,6 +195,9 @@ func startServer(a *Assistant) error {
 	// HTTP session store for /willknow/chat (external AI agents)
 	httpSessions := &httpSessionStore{sessions: make(map[string]*Session)}
 
+	// Resumable WebSocket sessions of the web UI
+	wsSessions := newWSSessionStore(a)
+
 	// Auth routes (no authentication required)
 	mux.HandleFunc("/auth/login", func(w http.ResponseWriter, r *http.Request) {
 		handleLogin(w, r, a)
@@ -153,11 +216,99 @@ func startServer(a *Assistant) error {
 		handleAgentChat(w, r, a, httpSessions)
 	}, a))
 
+	// A2A protocol: public agent card, JSON-RPC endpoint sharing /willknow/chat sessions
+	a2a := newA2AServer(a, httpSessions)
+	mux.HandleFunc(a2aAgentCardPath, a2a.handleAgentCard)
+	mux.HandleFunc("/.well-known/agent.json", a2a.handleAgentCard)
+	mux.HandleFunc("/willknow/a2a", authMiddleware(a2a.handleRPC, a))
+
+	// Microsoft Teams bot endpoint (authenticated via Bot Framework JWT)
+	if a.config.Teams.AppID != "" {
+		teams := newTeamsConnector(a)
+		mux.HandleFunc("/willknow/teams/messages", teams.handleMessages)
+	}
+
+	// Alert webhooks (authenticated via signature / shared secret)
+	var alerts *alertHandler
+	if a.config.Alerts.Secret != "" {
+		alerts = newAlertHandler(a)
+		mux.HandleFunc("/willknow/alerts/pagerduty", alerts.handlePagerDuty)
+		mux.HandleFunc("/willknow/alerts/opsgenie", alerts.handleOpsgenie)
+	}
+
+	// Headless analysis jobs (authenticated via bearer token)
+	if a.config.AnalyzeAPI.Token != "" {
+		analyze := newAnalyzeAPI(a)
+		mux.HandleFunc(analyzePath, analyze.handle)
+		mux.HandleFunc(analyzePath+"/", analyze.handle)
+	}
+
 	// Protected routes
+	mux.HandleFunc("/api/alerts", authMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		if alerts == nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte("[]"))
+			return
+		}
+		alerts.handleList(w, r)
+	}, a))
+	mux.HandleFunc("/api/anomalies", authMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		if a.anomalies == nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte("[]"))
+			return
+		}
+		a.anomalies.handleList(w, r)
+	}, a))
+	mux.HandleFunc("/api/scheduled", authMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		if a.scheduler == nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte("[]"))
+			return
+		}
+		a.scheduler.handleList(w, r)
+	}, a))
+	mux.HandleFunc("/api/targets", authMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		handleTargets(w, r, a)
+	}, a))
+	mux.HandleFunc("/api/capabilities", authMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		handleCapabilities(w, r, a)
+	}, a))
+	mux.HandleFunc("/api/share", authMiddleware(a.sharer.handleCreate, a))
+	mux.HandleFunc("/api/editor", authMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		handleEditor(w, r, a)
+	}, a))
+	mux.HandleFunc("/api/source", authMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		handleSource(w, r, a)
+	}, a))
+
+	// Pinned answers across sessions
+	pins := &pinStore{transcripts: a.transcripts}
+	mux.HandleFunc("/api/pins", authMiddleware(pins.handle, a))
+
+	// Shared transcripts (authenticated via the link signature)
+	mux.HandleFunc(sharePath, a.sharer.handleView)
 	mux.HandleFunc("/", authMiddleware(serveHome, a))
 	mux.HandleFunc("/api/ws", authMiddleware(func(w http.ResponseWriter, r *http.Request) {
-		handleWebSocket(w, r, a)
+		handleWebSocket(w, r, a, wsSessions)
+	}, a))
+	mux.HandleFunc("/api/fork", authMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		handleFork(w, r, wsSessions)
+	}, a))
+	mux.HandleFunc("/api/logs/export", authMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		handleLogExport(w, r, a)
 	}, a))
+	mux.HandleFunc(workspacePath, authMiddleware(a.workspaces.handleDownload, a))
+	mux.HandleFunc("/willknow/metrics", metricsAuth(a.metrics.handleMetrics, a))
+	mux.HandleFunc("/api/quota", authMiddleware(a.quotas.handleStatus, a))
+	mux.HandleFunc("/willknow/dead-letters", metricsAuth(a.deadLetters.handleList, a))
+	mux.HandleFunc("/willknow/experiments", metricsAuth(a.experiment.handleExperiments, a))
+	mux.HandleFunc("/api/maintenance", authMiddleware(a.handleMaintenanceStatus, a))
+
+	// Admin API (authenticated via bearer token)
+	if a.config.AdminToken != "" {
+		mux.HandleFunc(maintenancePath, a.handleMaintenance)
+	}
 
 	addr := fmt.Sprintf(":%d", a.config.Port)
 	return http.ListenAndServe(addr, mux)
@@ -171,6 +322,13 @@ func authMiddleware(next http.HandlerFunc, a *Assistant) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		user, err := a.authManager.authenticateRequest(r)
 		if err != nil {
+			if !a.authManager.isPasswordMode() {
+				a.ops.record("warn", "auth_denied", map[string]interface{}{
+					"path":        r.URL.Path,
+					"remote_addr": r.RemoteAddr,
+					"error":       err.Error(),
+				})
+			}
 			if a.authManager.isPasswordMode() {
 				http.Redirect(w, r, "/auth/login", http.StatusFound)
 			} else {
@@ -196,6 +354,7 @@ func handleLogin(w http.ResponseWriter, r *http.Request, a *Assistant) {
 		password := r.FormValue("password")
 		token, err := a.authManager.verifyPassword(password)
 		if err != nil {
+			a.ops.record("warn", "login_failed", map[string]interface{}{"remote_addr": r.RemoteAddr})
 			serveLoginPage(w, "Incorrect password. Please try again.")
 			return
 		}
@@ -363,10 +522,56 @@ func serveHome(w http.ResponseWriter, r *http.Request) {
             background: #f5f5f5;
             margin-right: 20%;
         }
+        .message.alert {
+            background: #fff8e1;
+            border-left: 4px solid #ffa000;
+            margin-right: 20%;
+        }
+        .message.greeting {
+            background: #f3f0ff;
+            margin-right: 20%;
+        }
+        .examples { display: flex; flex-wrap: wrap; gap: 8px; margin-top: 10px; }
+        .example-question {
+            padding: 6px 12px;
+            border: 1px solid #c5b9f5;
+            border-radius: 16px;
+            background: white;
+            color: #5a4fcf;
+            font-size: 13px;
+            cursor: pointer;
+        }
+        .message.consent {
+            background: #fff3e0;
+            border-left: 4px solid #fb8c00;
+            margin-right: 20%;
+        }
+        .consent-actions { display: flex; gap: 8px; margin-top: 10px; }
+        .consent-actions button {
+            padding: 6px 14px;
+            border: 1px solid #fb8c00;
+            border-radius: 4px;
+            background: white;
+            cursor: pointer;
+        }
+        .consent-actions button.allow { background: #fb8c00; color: white; }
+        .consent-actions button:disabled { opacity: 0.5; cursor: default; }
         .message.error {
             background: #ffebee;
             color: #c62828;
         }
+        #maintenanceBanner {
+            display: none;
+            padding: 10px 20px;
+            background: #fff8e1;
+            color: #8d6e00;
+            border-bottom: 1px solid #ffe082;
+            text-align: center;
+            font-size: 14px;
+        }
+        .message.queued {
+            opacity: 0.6;
+        }
         .message strong {
             display: block;
             margin-bottom: 8px;
@@ -453,11 +658,119 @@ func serveHome(w http.ResponseWriter, r *http.Request) {
             opacity: 0.5;
             cursor: not-allowed;
         }
+        #shareButton {
+            margin-top: 8px;
+            padding: 4px 12px;
+            border: 1px solid rgba(255,255,255,0.6);
+            border-radius: 4px;
+            background: transparent;
+            color: white;
+            font-size: 13px;
+            cursor: pointer;
+        }
+        #pinsButton {
+            margin-top: 8px;
+            padding: 4px 12px;
+            border: 1px solid rgba(255,255,255,0.6);
+            border-radius: 4px;
+            background: transparent;
+            color: white;
+            font-size: 13px;
+            cursor: pointer;
+        }
+        .pin-button {
+            margin-top: 8px;
+            padding: 2px 10px;
+            border: 1px solid #ccc;
+            border-radius: 4px;
+            background: white;
+            font-size: 12px;
+            cursor: pointer;
+        }
+        .suggestions { display: flex; flex-wrap: wrap; gap: 6px; margin-top: 8px; }
+        .suggestion-button {
+            padding: 4px 12px;
+            border: 1px solid #90caf9;
+            border-radius: 14px;
+            background: #e3f2fd;
+            color: #1565c0;
+            font-size: 12px;
+            cursor: pointer;
+        }
+        .citations { margin-top: 8px; font-size: 12px; }
+        .citations summary { cursor: pointer; color: #555; }
+        .citations ol { margin: 6px 0 0 20px; padding: 0; }
+        .citations li { margin-bottom: 6px; }
+        .citations li.highlight { background: #fff8c5; }
+        .citations .citation-kind { color: #888; font-size: 11px; text-transform: uppercase; margin-left: 4px; }
+        .citations pre { margin: 4px 0 0; max-height: 160px; overflow: auto; font-size: 11px; background: #f6f8fa; padding: 6px; border-radius: 4px; white-space: pre-wrap; }
+        .citation-ref { font-size: 0.75em; vertical-align: super; text-decoration: none; }
+        .pin-item { padding: 12px 15px; border-bottom: 1px solid #eee; font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; font-size: 13px; }
+        .pin-item .pin-meta { color: #888; font-size: 11px; margin: 4px 0; }
+        #targetSelect {
+            margin-top: 8px;
+            padding: 4px 8px;
+            border: none;
+            border-radius: 4px;
+            font-size: 13px;
+        }
         .typing {
             color: #666;
             font-style: italic;
             padding: 15px;
         }
+        a.source-link { color: #5a4fcf; }
+        .diff { margin: 10px 0; border: 1px solid #ddd; border-radius: 6px; overflow: hidden; background: white; }
+        .diff-actions { display: flex; gap: 8px; align-items: center; padding: 6px 10px; background: #f6f8fa; border-bottom: 1px solid #ddd; }
+        .diff-file { flex: 1; font-family: monospace; font-size: 12px; }
+        .diff-actions button { padding: 2px 10px; border: 1px solid #ccc; border-radius: 4px; background: white; font-size: 12px; cursor: pointer; }
+        .diff table { width: 100%; border-collapse: collapse; table-layout: fixed; font-family: monospace; font-size: 12px; }
+        .diff td.diff-num { width: 40px; padding: 0 6px; text-align: right; color: #999; user-select: none; }
+        .diff td.diff-code { padding: 0 6px; white-space: pre-wrap; word-break: break-all; }
+        .diff td.diff-del { background: #ffeef0; }
+        .diff td.diff-add { background: #e6ffed; }
+        .diff td.diff-empty { background: #fafbfc; }
+        .diff td.diff-hunk { padding: 2px 6px; color: #666; background: #f1f8ff; }
+        #sourcePane {
+            position: fixed;
+            top: 0;
+            right: 0;
+            bottom: 0;
+            width: min(50%, 900px);
+            display: none;
+            flex-direction: column;
+            background: #fafafa;
+            box-shadow: -4px 0 20px rgba(0,0,0,0.15);
+            z-index: 10;
+        }
+        #sourcePane.open { display: flex; }
+        #sourceHeader {
+            display: flex;
+            align-items: center;
+            gap: 12px;
+            padding: 10px 15px;
+            border-bottom: 1px solid #ddd;
+            font-size: 13px;
+        }
+        #sourcePath { flex: 1; font-family: monospace; overflow: hidden; text-overflow: ellipsis; white-space: nowrap; }
+        #sourceClose { border: none; background: none; font-size: 18px; cursor: pointer; }
+        #sourceBody { flex: 1; overflow: auto; font-family: monospace; font-size: 12px; line-height: 1.5; }
+        #sourceBody table { border-collapse: collapse; width: 100%; }
+        #sourceBody td.ln {
+            width: 1%;
+            padding: 0 10px;
+            text-align: right;
+            color: #999;
+            user-select: none;
+            white-space: nowrap;
+        }
+        #sourceBody td.ln a { color: inherit; text-decoration: none; }
+        #sourceBody td.code { padding: 0 10px; white-space: pre; }
+        #sourceBody tr.highlight { background: #fff3c4; }
+        .tok-comment { color: #6a737d; font-style: italic; }
+        .tok-string { color: #032f62; }
+        .tok-number { color: #005cc5; }
+        .tok-keyword { color: #d73a49; }
     </style>
 </head>
 <body>
@@ -465,6 +778,19 @@ func serveHome(w http.ResponseWriter, r *http.Request) {
         <h1>🤖 AI Assistant</h1>
         <p>Your intelligent debugging companion</p>
         <p id="sessionInfo" style="font-size: 12px; opacity: 0.8; margin-top: 5px;"></p>
+        <p id="quotaInfo" style="font-size: 12px; opacity: 0.8; display: none;"></p>
+        <select id="targetSelect" title="Service" style="display: none;"></select>
+        <button id="shareButton" title="Create a read-only link to this conversation" style="display: none;">Share</button>
+        <button id="pinsButton" title="Answers pinned as key findings, across sessions">Pinned</button>
+    </div>
+    <div id="maintenanceBanner"></div>
+    <div id="sourcePane">
+        <div id="sourceHeader">
+            <span id="sourcePath"></span>
+            <a id="sourceEditor" style="display: none;">Open in editor</a>
+            <button id="sourceClose" title="Close">×</button>
+        </div>
+        <div id="sourceBody"></div>
     </div>
     <div class="container">
         <div id="messages"></div>
@@ -479,26 +805,89 @@ func serveHome(w http.ResponseWriter, r *http.Request) {
         const messageInput = document.getElementById('messageInput');
         const sendButton = document.getElementById('sendButton');
         const sessionInfo = document.getElementById('sessionInfo');
+        const targetSelect = document.getElementById('targetSelect');
+        const shareButton = document.getElementById('shareButton');
 
         let ws;
         let isProcessing = false;
         let currentSessionId = '';
+        let currentTarget = '';
+
+        // Reconnection state: responses are numbered so a resumed session
+        // replays only what was missed, and messages typed while
+        // disconnected wait in the outbox until the server acknowledges them
+        let lastSeq = 0;
+        let nextMessageId = 1;
+        let outbox = [];
+        let reconnectDelay = 1000;
+        let reconnectTimer = null;
+        let disconnected = false;
+
+        // Questions asked and answered in this session, the fork points
+        let questionCount = 0;
+        let answeredCount = 0;
+        // Actions offered under completed answers (e.g. 'generate_test')
+        let answerActions = [];
 
         function connect() {
             const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
-            ws = new WebSocket(protocol + '//' + window.location.host + '/api/ws');
+            const params = new URLSearchParams();
+            if (currentTarget) params.set('target', currentTarget);
+            if (currentSessionId) {
+                params.set('session', currentSessionId);
+                params.set('after', lastSeq);
+            }
+            const query = params.toString() ? '?' + params.toString() : '';
+            const socket = new WebSocket(protocol + '//' + window.location.host + '/api/ws' + query);
+            ws = socket;
 
             ws.onopen = () => {
-                addMessage('system', 'Connected to AI Assistant. How can I help you?');
+                if (socket !== ws) return;
+                reconnectDelay = 1000;
             };
 
             ws.onmessage = (event) => {
+                // Ignore a connection replaced by a target switch
+                if (socket !== ws) return;
                 const response = JSON.parse(event.data);
 
+                // Skip responses already shown before a reconnection
+                if (response.seq) {
+                    if (response.seq <= lastSeq) return;
+                    lastSeq = response.seq;
+                }
+
                 if (response.type === 'session_info') {
+                    if (response.greeting && !response.resumed && !disconnected) {
+                        showGreeting(response.greeting);
+                    }
+                    if (disconnected) {
+                        addMessage('system', response.resumed ? 'Reconnected.' :
+                            'Reconnected, but the previous session had expired; a new session was started.');
+                        disconnected = false;
+                    }
+                    if (!response.resumed) lastSeq = 0;
+                    answerActions = response.actions || [];
                     // Store and display session ID
                     currentSessionId = response.sessionId;
-                    sessionInfo.textContent = 'Session ID: ' + currentSessionId;
+                    sessionInfo.textContent = 'Session ID: ' + currentSessionId +
+                        (response.revision ? ' · Source revision: ' + response.revision.substring(0, 12) : '');
+                    flushOutbox();
+                } else if (response.type === 'ack') {
+                    outbox = outbox.filter(m => m.id !== response.messageId);
+                    const queued = messagesDiv.querySelector('[data-message-id="' + response.messageId + '"]');
+                    if (queued) queued.classList.remove('queued');
+                } else if (response.type === 'status') {
+                    // Show progress in the typing indicator, below any text so far
+                    let typing = document.querySelector('.typing');
+                    if (!typing && isProcessing) {
+                        typing = document.createElement('div');
+                        typing.className = 'typing';
+                    }
+                    if (typing) {
+                        typing.textContent = response.content;
+                        messagesDiv.appendChild(typing);
+                    }
                 } else if (response.type === 'text') {
                     // Remove typing indicator
                     const typing = document.querySelector('.typing');
@@ -519,30 +908,407 @@ func serveHome(w http.ResponseWriter, r *http.Request) {
                         addMessage('assistant', response.content);
                     }
                 } else if (response.type === 'done') {
+                    const typing = document.querySelector('.typing');
+                    if (typing) typing.remove();
+                    answeredCount++;
                     const lastMsg = messagesDiv.lastElementChild;
                     if (lastMsg && lastMsg.classList.contains('assistant')) {
                         lastMsg.dataset.complete = 'true';
+                        addCitations(lastMsg, response.citations || []);
+                        addSuggestions(lastMsg, response.suggestions || []);
+                        addPinButton(lastMsg);
+                        addForkButton(lastMsg, 'Fork from here', answeredCount, '');
+                        if (answerActions.includes('generate_test')) addTestButton(lastMsg);
                     }
                     isProcessing = false;
                     sendButton.disabled = false;
+                    loadQuota();
+                } else if (response.type === 'consent') {
+                    showConsent(response.content, response.consent);
                 } else if (response.type === 'error') {
                     addMessage('error', response.content);
                     isProcessing = false;
                     sendButton.disabled = false;
+                } else if (response.type === 'maintenance') {
+                    const typing = document.querySelector('.typing');
+                    if (typing) typing.remove();
+                    showMaintenance(response.content);
+                    addMessage('error', response.content);
                 }
 
                 messagesDiv.scrollTop = messagesDiv.scrollHeight;
             };
 
-            ws.onerror = () => {
-                addMessage('error', 'Connection error. Please refresh the page.');
+            ws.onclose = () => {
+                if (socket !== ws) return;
+                scheduleReconnect();
             };
+        }
 
-            ws.onclose = () => {
-                addMessage('error', 'Connection closed. Please refresh the page.');
+        // Reconnect with exponential backoff, capped at 30 seconds
+        function scheduleReconnect() {
+            if (reconnectTimer) return;
+            if (!disconnected) {
+                disconnected = true;
+                addMessage('system', 'Connection lost. Reconnecting... Messages you send meanwhile are queued.');
+            }
+            const delay = reconnectDelay * (0.75 + Math.random() / 2);
+            reconnectDelay = Math.min(reconnectDelay * 2, 30000);
+            reconnectTimer = setTimeout(() => {
+                reconnectTimer = null;
+                connect();
+            }, delay);
+        }
+
+        // Reconnect right away when the browser comes back online
+        window.addEventListener('online', () => {
+            if (!disconnected || !reconnectTimer) return;
+            clearTimeout(reconnectTimer);
+            reconnectTimer = null;
+            reconnectDelay = 1000;
+            connect();
+        });
+
+        // Send queued messages; unacknowledged ones are sent again after a
+        // reconnection and the server drops duplicates
+        function flushOutbox() {
+            if (!ws || ws.readyState !== WebSocket.OPEN) return;
+            outbox.forEach(m => ws.send(JSON.stringify({ id: m.id, content: m.content, action: m.action, suggestion: m.suggestion })));
+        }
+
+        // Show the target selector when the assistant serves several services
+        function loadTargets() {
+            fetch('/api/targets')
+                .then(r => r.json())
+                .then(list => {
+                    if (!list || list.length < 2) return;
+                    list.forEach(t => {
+                        const option = document.createElement('option');
+                        option.value = t.default ? '' : t.name;
+                        option.textContent = t.description ? t.name + ' - ' + t.description : t.name;
+                        targetSelect.appendChild(option);
+                    });
+                    targetSelect.style.display = '';
+                })
+                .catch(() => {});
+        }
+
+        // Link file:line references to the configured editor
+        let editorLinks = { url: '', source_root: '' };
+        function loadEditorLinks() {
+            fetch('/api/editor' + (currentTarget ? '?target=' + encodeURIComponent(currentTarget) : ''))
+                .then(r => r.json())
+                .then(info => { editorLinks = info; })
+                .catch(() => {});
+        }
+
+        // linkSourceRefs turns path/to/file.go:42 references in escaped text into
+        // links that open the source viewer (or, with a modifier key, the editor)
+        function linkSourceRefs(text) {
+            const refPattern = /(^|[\s(\[>:,;"']|\x60)((?:\.{0,2}\/)?(?:[\w.-]+\/)*[\w-]+(?:\.[\w-]+)*\.[A-Za-z][\w]*):(\d+)(?::\d+)?/g;
+            return text.replace(refPattern, (match, lead, path, line) => {
+                let rel = path;
+                const root = (editorLinks.source_root || '').replace(/\/+$/, '');
+                if (root && rel.startsWith(root + '/')) rel = rel.substring(root.length + 1);
+                rel = rel.replace(/^(\.\/)+/, '').replace(/^\//, '');
+                if (rel.startsWith('../')) return match;
+                const href = editorURL(rel, line) || '#source=' + rel + ':' + line;
+                return lead + '<a class="source-link" href="' + href + '" data-path="' + rel + '" data-line="' + line + '">' +
+                    match.substring(lead.length) + '</a>';
+            });
+        }
+
+        function editorURL(path, line) {
+            if (!editorLinks.url) return '';
+            return editorLinks.url.split('{path}').join(encodeURI(path)).split('{line}').join(line);
+        }
+
+        // Source viewer
+        const sourcePane = document.getElementById('sourcePane');
+        const sourcePath = document.getElementById('sourcePath');
+        const sourceEditor = document.getElementById('sourceEditor');
+        const sourceBody = document.getElementById('sourceBody');
+        document.getElementById('sourceClose').onclick = () => {
+            sourcePane.classList.remove('open');
+            history.replaceState(null, '', window.location.pathname + window.location.search);
+        };
+
+        messagesDiv.addEventListener('click', (e) => {
+            const link = e.target.closest('a.source-link');
+            if (!link || e.metaKey || e.ctrlKey) return;
+            e.preventDefault();
+            openSource(link.dataset.path, parseInt(link.dataset.line, 10));
+        });
+
+        function openSource(path, line) {
+            const params = new URLSearchParams({ path });
+            if (currentTarget) params.set('target', currentTarget);
+            sourcePath.textContent = path + (line ? ':' + line : '');
+            sourceBody.textContent = 'Loading...';
+            sourcePane.classList.add('open');
+            fetch('/api/source?' + params.toString())
+                .then(r => r.ok ? r.json() : r.text().then(t => { throw new Error(t.trim()); }))
+                .then(file => {
+                    sourcePath.textContent = file.path + (file.revision ? ' @ ' + file.revision.substring(0, 12) : '');
+                    const href = editorURL(file.path, line || 1);
+                    sourceEditor.style.display = href ? '' : 'none';
+                    sourceEditor.href = href;
+                    renderSource(file, line);
+                    history.replaceState(null, '', '#source=' + file.path + (line ? ':' + line : ''));
+                })
+                .catch(err => { sourceBody.textContent = 'Cannot open ' + path + ': ' + err.message; });
+        }
+
+        function renderSource(file, line) {
+            const lines = highlightSource(file.content, file.language);
+            const rows = lines.map((html, i) => {
+                const n = i + 1;
+                return '<tr id="L' + n + '"' + (n === line ? ' class="highlight"' : '') + '>' +
+                    '<td class="ln"><a href="#source=' + file.path + ':' + n + '">' + n + '</a></td>' +
+                    '<td class="code">' + (html || ' ') + '</td></tr>';
+            });
+            sourceBody.innerHTML = '<table>' + rows.join('') + '</table>';
+            sourceBody.querySelectorAll('td.ln a').forEach(a => {
+                a.onclick = (e) => {
+                    e.preventDefault();
+                    const n = parseInt(a.textContent, 10);
+                    sourceBody.querySelectorAll('tr.highlight').forEach(tr => tr.classList.remove('highlight'));
+                    a.closest('tr').classList.add('highlight');
+                    sourcePath.textContent = file.path + ':' + n;
+                    history.replaceState(null, '', '#source=' + file.path + ':' + n);
+                    if (editorLinks.url) sourceEditor.href = editorURL(file.path, n);
+                };
+            });
+            const target = line && document.getElementById('L' + line);
+            if (target) target.scrollIntoView({ block: 'center' });
+        }
+
+        // highlightSource returns the HTML of each line with comments, strings,
+        // numbers and keywords marked; tokens spanning lines are split per line
+        const hashComments = ['python', 'ruby', 'shell', 'yaml', 'toml', 'hcl'];
+        const sourceKeywords = new Set(('break case catch class const continue def default defer do elif else enum ' +
+            'except export extends false finally fn for func function go if impl import in interface let match ' +
+            'module mut new nil none null package private protected pub public raise return select self static ' +
+            'struct super switch this throw true try type typeof use var void while with yield async await ' +
+            'from as lambda pass end begin rescue require val object trait select where and or not').split(' '));
+        function highlightSource(content, language) {
+            const lines = [''];
+            const emit = (text, cls) => {
+                text.split('\n').forEach((part, i) => {
+                    if (i > 0) lines.push('');
+                    if (part === '') return;
+                    const html = escapeHtml(part);
+                    lines[lines.length - 1] += cls ? '<span class="tok-' + cls + '">' + html + '</span>' : html;
+                });
+            };
+            if (!language) {
+                emit(content, '');
+                return lines;
+            }
+            const bt = String.fromCharCode(96);
+            const comment = hashComments.includes(language) ? '#[^\n]*' :
+                language === 'sql' ? '--[^\n]*|/\\*[\\s\\S]*?\\*/' : '//[^\n]*|/\\*[\\s\\S]*?\\*/';
+            const strings = '"(?:[^"\\\\\n]|\\\\.)*"|\'(?:[^\'\\\\\n]|\\\\.)*\'|' + bt + '[^' + bt + ']*' + bt;
+            const pattern = new RegExp('(' + comment + ')|(' + strings + ')|(\\b\\d[\\d_.xXa-fA-F]*\\b)|([A-Za-z_]\\w*)', 'g');
+            let last = 0;
+            let m;
+            while ((m = pattern.exec(content)) !== null) {
+                emit(content.substring(last, m.index), '');
+                if (m[1]) emit(m[1], 'comment');
+                else if (m[2]) emit(m[2], 'string');
+                else if (m[3]) emit(m[3], 'number');
+                else emit(m[4], sourceKeywords.has(m[4]) ? 'keyword' : '');
+                last = pattern.lastIndex;
+            }
+            emit(content.substring(last), '');
+            return lines;
+        }
+
+        // Open the file of a #source=path:line link, e.g. after a reload
+        function openSourceFromHash() {
+            const m = window.location.hash.match(/^#source=(.+?)(?::(\d+))?$/);
+            if (m) openSource(decodeURIComponent(m[1]), m[2] ? parseInt(m[2], 10) : 0);
+        }
+
+        // Citations: the evidence an answer rests on is listed as expandable
+        // footnotes, and [n] markers in the answer link to them
+        function addCitations(msg, citations) {
+            if (!citations.length) return;
+            const contentDiv = msg.querySelector('.message-content');
+            const ids = new Set(citations.map(c => String(c.id)));
+            contentDiv.innerHTML = contentDiv.innerHTML.replace(/(?<=^|[\s>\](])\[(\d+)\]/g, (match, id) =>
+                ids.has(id) ? '<a class="citation-ref" href="#" data-citation="' + id + '">[' + id + ']</a>' : match);
+
+            const details = document.createElement('details');
+            details.className = 'citations';
+            details.innerHTML = '<summary>Evidence (' + citations.length + ')</summary><ol>' +
+                citations.map(c => {
+                    const label = c.kind === 'file' ? linkSourceRefs(escapeHtml(c.label)) : escapeHtml(c.label);
+                    return '<li value="' + c.id + '" data-citation="' + c.id + '">' + label +
+                        '<span class="citation-kind">' + escapeHtml(c.kind) + '</span>' +
+                        (c.excerpt ? '<pre>' + escapeHtml(c.excerpt) + '</pre>' : '') + '</li>';
+                }).join('') + '</ol>';
+            msg.appendChild(details);
+
+            contentDiv.addEventListener('click', (e) => {
+                const ref = e.target.closest('a.citation-ref');
+                if (!ref) return;
+                e.preventDefault();
+                details.open = true;
+                details.querySelectorAll('li.highlight').forEach(li => li.classList.remove('highlight'));
+                const item = details.querySelector('li[data-citation="' + ref.dataset.citation + '"]');
+                if (item) {
+                    item.classList.add('highlight');
+                    item.scrollIntoView({ block: 'nearest' });
+                }
+            });
+        }
+
+        // Forking: a conversation can branch at any question or answer into a
+        // new session with a copy of the earlier history, so an alternative
+        // hypothesis can be explored while the original thread is kept
+        function addForkButton(msg, label, before, prefill) {
+            const button = document.createElement('button');
+            button.className = 'pin-button';
+            button.textContent = label;
+            button.onclick = () => forkConversation(msg, before, prefill);
+            msg.appendChild(button);
+        }
+
+        function forkConversation(msg, before, prefill) {
+            if (isProcessing) {
+                addMessage('error', 'Wait for the current answer before forking.');
+                return;
+            }
+            const originalId = currentSessionId;
+            fetch('/api/fork', {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ session_id: originalId, before })
+            })
+                .then(r => r.ok ? r.json() : r.text().then(t => { throw new Error(t.trim()); }))
+                .then(fork => {
+                    // Keep the history up to the fork point: an edited question
+                    // is dropped, a forked answer is kept
+                    let node = prefill ? msg : msg.nextElementSibling;
+                    while (node) {
+                        const next = node.nextElementSibling;
+                        node.remove();
+                        node = next;
+                    }
+                    questionCount = before;
+                    answeredCount = before;
+                    currentSessionId = fork.session_id;
+                    lastSeq = 0;
+                    outbox = [];
+                    const old = ws;
+                    connect();
+                    old.close();
+                    addMessage('system', 'Forked into a new conversation. The original thread is kept as session ' + originalId + '.');
+                    if (prefill) {
+                        messageInput.value = prefill;
+                        messageInput.focus();
+                    }
+                })
+                .catch(err => addMessage('error', 'Failed to fork: ' + err.message));
+        }
+
+        // Pinning: completed answers can be pinned as key findings, and the
+        // pins of all sessions are listed in the side pane for handoffs
+        function addPinButton(msg) {
+            const button = document.createElement('button');
+            button.className = 'pin-button';
+            button.textContent = 'Pin';
+            button.onclick = () => {
+                const content = msg.querySelector('.message-content').dataset.rawText;
+                fetch('/api/pins', {
+                    method: 'POST',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify({ session_id: currentSessionId, content })
+                })
+                    .then(r => r.ok ? r.json() : r.text().then(t => { throw new Error(t.trim()); }))
+                    .then(() => {
+                        button.textContent = 'Pinned';
+                        button.disabled = true;
+                    })
+                    .catch(err => addMessage('error', 'Failed to pin: ' + err.message));
             };
+            msg.appendChild(button);
+        }
+
+        document.getElementById('pinsButton').onclick = () => {
+            sourcePath.textContent = 'Pinned findings';
+            sourceEditor.style.display = 'none';
+            sourceBody.textContent = 'Loading...';
+            sourcePane.classList.add('open');
+            fetch('/api/pins')
+                .then(r => r.json())
+                .then(pins => {
+                    if (!pins.length) {
+                        sourceBody.textContent = 'Nothing pinned yet. Use the Pin button under an answer.';
+                        return;
+                    }
+                    sourceBody.innerHTML = pins.map(pin =>
+                        '<div class="pin-item"><strong>' + escapeHtml(pin.title) + '</strong>' +
+                        '<div class="pin-meta">' + escapeHtml(pin.user_name || pin.user_id || 'anonymous') +
+                        ' · ' + new Date(pin.created_at).toLocaleString() +
+                        ' · session ' + escapeHtml(pin.session_id) + (pin.target ? ' · ' + escapeHtml(pin.target) : '') + '</div>' +
+                        '<div class="message-content">' + formatMarkdown(pin.content) + '</div></div>').join('');
+                })
+                .catch(() => { sourceBody.textContent = 'Failed to load pins.'; });
+        };
+
+        // Show the share button when sharing is enabled
+        function loadSharing() {
+            fetch('/api/share')
+                .then(r => r.json())
+                .then(info => {
+                    if (info.enabled) shareButton.style.display = '';
+                })
+                .catch(() => {});
         }
 
+        // Create a read-only link to the current conversation and copy it
+        shareButton.onclick = () => {
+            if (!currentSessionId) return;
+            fetch('/api/share', {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ session_id: currentSessionId })
+            })
+                .then(r => r.ok ? r.json() : r.text().then(t => { throw new Error(t.trim()); }))
+                .then(share => {
+                    const expires = new Date(share.expires_at).toLocaleString();
+                    const copied = navigator.clipboard ? navigator.clipboard.writeText(share.url) : Promise.reject();
+                    copied
+                        .then(() => addMessage('system', 'Share link copied to clipboard (valid until ' + expires + '): ' + share.url))
+                        .catch(() => addMessage('system', 'Share link (valid until ' + expires + '): ' + share.url));
+                })
+                .catch(err => addMessage('error', 'Could not create share link: ' + err.message));
+        };
+
+        // Switching targets starts a new session
+        targetSelect.onchange = () => {
+            currentTarget = targetSelect.value;
+            const old = ws;
+            messagesDiv.innerHTML = '';
+            isProcessing = false;
+            sendButton.disabled = false;
+            currentSessionId = '';
+            lastSeq = 0;
+            outbox = [];
+            questionCount = 0;
+            answeredCount = 0;
+            loadEditorLinks();
+            disconnected = false;
+            if (reconnectTimer) {
+                clearTimeout(reconnectTimer);
+                reconnectTimer = null;
+            }
+            connect();
+            old.close();
+        };
+
         function addMessage(type, content) {
             const div = document.createElement('div');
             div.className = 'message ' + type;
@@ -557,6 +1323,12 @@ func serveHome(w http.ResponseWriter, r *http.Request) {
                 
                 div.innerHTML = '<strong>AI Assistant</strong>';
                 div.appendChild(contentDiv);
+            } else if (type === 'alert') {
+                const contentDiv = document.createElement('div');
+                contentDiv.className = 'message-content';
+                contentDiv.innerHTML = formatMarkdown(content);
+                div.innerHTML = '<strong>Alert Analysis</strong>';
+                div.appendChild(contentDiv);
             } else if (type === 'system') {
                 div.innerHTML = '<strong>System</strong><div class="message-content">' + escapeHtml(content) + '</div>';
             } else if (type === 'error') {
@@ -567,8 +1339,157 @@ func serveHome(w http.ResponseWriter, r *http.Request) {
             messagesDiv.scrollTop = messagesDiv.scrollHeight;
         }
 
+        // Suggested changes: unified diffs and "Before"/"After" code block
+        // pairs are rendered side by side with copy and download actions
+        const maxDiffLines = 2000;
+        function extractDiffs(text) {
+            const fence = String.fromCharCode(96).repeat(3);
+            const block = fence + '(\\w+)?\\n([\\s\\S]*?)' + fence;
+            const label = (word) => '(?:^|\\n)[ \\t]*(?:[#*_ ]*)' + word + '\\b[^\\n]*\\n+';
+            const diffs = [];
+            const placeholder = (diff) => '\nXDIFFBLOCK' + (diffs.push(diff) - 1) + 'X\n';
+
+            // Before/after pairs
+            const pairRegex = new RegExp(label('Before') + block + '\\s*' + label('After') + block, 'gi');
+            text = text.replace(pairRegex, (match, langA, before, langB, after) => {
+                const ops = diffLines(before.replace(/\n$/, '').split('\n'), after.replace(/\n$/, '').split('\n'));
+                if (!ops) return match;
+                return placeholder({ ops, copy: after, patch: unifiedPatch(ops, 'file') });
+            });
+
+            // Unified diffs
+            const blockRegex = new RegExp(block, 'g');
+            text = text.replace(blockRegex, (match, lang, body) => {
+                const isDiff = lang === 'diff' || lang === 'patch' || /^@@ -\d+/m.test(body);
+                if (!isDiff) return match;
+                const parsed = parseUnifiedDiff(body);
+                if (!parsed.ops.length) return match;
+                return placeholder({ ops: parsed.ops, copy: body, patch: body, file: parsed.file });
+            });
+            return { text, diffs };
+        }
+
+        // diffLines computes a line diff with a longest common subsequence
+        function diffLines(a, b) {
+            if (a.length > maxDiffLines || b.length > maxDiffLines) return null;
+            const lcs = Array.from({ length: a.length + 1 }, () => new Array(b.length + 1).fill(0));
+            for (let i = a.length - 1; i >= 0; i--) {
+                for (let j = b.length - 1; j >= 0; j--) {
+                    lcs[i][j] = a[i] === b[j] ? lcs[i + 1][j + 1] + 1 : Math.max(lcs[i + 1][j], lcs[i][j + 1]);
+                }
+            }
+            const ops = [];
+            let i = 0, j = 0;
+            while (i < a.length || j < b.length) {
+                if (i < a.length && j < b.length && a[i] === b[j]) {
+                    ops.push({ type: 'ctx', old: i + 1, new: j + 1, text: a[i] });
+                    i++; j++;
+                } else if (j < b.length && (i >= a.length || lcs[i][j + 1] > lcs[i + 1][j])) {
+                    ops.push({ type: 'add', new: j + 1, text: b[j] });
+                    j++;
+                } else {
+                    ops.push({ type: 'del', old: i + 1, text: a[i] });
+                    i++;
+                }
+            }
+            return ops;
+        }
+
+        function parseUnifiedDiff(body) {
+            const ops = [];
+            let file = '';
+            let oldLine = 1, newLine = 1;
+            body.replace(/\n$/, '').split('\n').forEach(line => {
+                let m;
+                if (line.startsWith('+++ ')) {
+                    file = line.substring(4).replace(/^b\//, '').split('\t')[0];
+                } else if (line.startsWith('--- ') || line.startsWith('diff ') || line.startsWith('index ')) {
+                    // file headers
+                } else if ((m = line.match(/^@@ -(\d+)(?:,\d+)? \+(\d+)(?:,\d+)? @@(.*)/))) {
+                    oldLine = parseInt(m[1], 10);
+                    newLine = parseInt(m[2], 10);
+                    ops.push({ type: 'hunk', text: line });
+                } else if (line.startsWith('+')) {
+                    ops.push({ type: 'add', new: newLine++, text: line.substring(1) });
+                } else if (line.startsWith('-')) {
+                    ops.push({ type: 'del', old: oldLine++, text: line.substring(1) });
+                } else if (!line.startsWith('\\')) {
+                    ops.push({ type: 'ctx', old: oldLine++, new: newLine++, text: line.substring(1) });
+                }
+            });
+            return { ops: ops.some(op => op.type === 'add' || op.type === 'del') ? ops : [], file };
+        }
+
+        function unifiedPatch(ops, file) {
+            const oldCount = ops.filter(op => op.type !== 'add').length;
+            const newCount = ops.filter(op => op.type !== 'del').length;
+            const sign = { ctx: ' ', add: '+', del: '-' };
+            return '--- a/' + file + '\n+++ b/' + file + '\n@@ -1,' + oldCount + ' +1,' + newCount + ' @@\n' +
+                ops.map(op => sign[op.type] + op.text).join('\n') + '\n';
+        }
+
+        // renderDiff pairs deleted and added runs row by row
+        function renderDiff(diff) {
+            const cell = (num, text, cls) => '<td class="diff-num">' + (num || '') + '</td>' +
+                '<td class="diff-code ' + cls + '">' + (text === undefined ? '' : escapeHtml(text)) + '</td>';
+            const rows = [];
+            const ops = diff.ops;
+            for (let i = 0; i < ops.length;) {
+                const op = ops[i];
+                if (op.type === 'hunk') {
+                    rows.push('<tr><td colspan="4" class="diff-hunk">' + escapeHtml(op.text) + '</td></tr>');
+                    i++;
+                } else if (op.type === 'ctx') {
+                    rows.push('<tr>' + cell(op.old, op.text, '') + cell(op.new, op.text, '') + '</tr>');
+                    i++;
+                } else {
+                    const dels = [], adds = [];
+                    while (i < ops.length && ops[i].type === 'del') dels.push(ops[i++]);
+                    while (i < ops.length && ops[i].type === 'add') adds.push(ops[i++]);
+                    for (let k = 0; k < Math.max(dels.length, adds.length); k++) {
+                        const d = dels[k], a = adds[k];
+                        rows.push('<tr>' +
+                            cell(d && d.old, d && d.text, d ? 'diff-del' : 'diff-empty') +
+                            cell(a && a.new, a && a.text, a ? 'diff-add' : 'diff-empty') + '</tr>');
+                    }
+                }
+            }
+            const name = (diff.file || 'suggested-change').split('/').pop() + '.patch';
+            return '<div class="diff">' +
+                '<div class="diff-actions">' +
+                (diff.file ? '<span class="diff-file">' + escapeHtml(diff.file) + '</span>' : '') +
+                '<button class="diff-copy" data-copy="' + escapeAttr(diff.copy) + '">Copy</button>' +
+                '<button class="diff-download" data-name="' + escapeAttr(name) + '" data-patch="' + escapeAttr(diff.patch) + '">Download patch</button>' +
+                '</div><table>' + rows.join('') + '</table></div>';
+        }
+
+        function escapeAttr(text) {
+            return escapeHtml(text).replace(/"/g, '&quot;');
+        }
+
+        messagesDiv.addEventListener('click', (e) => {
+            const copy = e.target.closest('button.diff-copy');
+            if (copy) {
+                navigator.clipboard.writeText(copy.dataset.copy).then(() => {
+                    copy.textContent = 'Copied';
+                    setTimeout(() => { copy.textContent = 'Copy'; }, 1500);
+                });
+                return;
+            }
+            const download = e.target.closest('button.diff-download');
+            if (download) {
+                const url = URL.createObjectURL(new Blob([download.dataset.patch], { type: 'text/x-diff' }));
+                const a = document.createElement('a');
+                a.href = url;
+                a.download = download.dataset.name;
+                a.click();
+                URL.revokeObjectURL(url);
+            }
+        });
+
         function formatMarkdown(text) {
-            text = escapeHtml(text);
+            const extracted = extractDiffs(text);
+            text = escapeHtml(extracted.text);
             
             var backtick = String.fromCharCode(96);
             var tripleBacktick = backtick + backtick + backtick;
@@ -581,6 +1502,13 @@ func serveHome(w http.ResponseWriter, r *http.Request) {
             var inlineCodeRegex = new RegExp(inlineCodePattern, 'g');
             text = text.replace(inlineCodeRegex, '<code>$1</code>');
             
+            // Download links to the assistant's own endpoints, e.g. log exports.
+            // The path is limited to URL characters (& is escaped as &amp;) so
+            // it cannot close the href attribute.
+            text = text.replace(/\[([^\]]+)\]\((\/api\/[A-Za-z0-9\/_.?=&;%-]+)\)/g, '<a href="$2" download>$1</a>');
+
+            text = linkSourceRefs(text);
+
             text = text.replace(/\*\*([^\*]+)\*\*/g, '<strong>$1</strong>');
             text = text.replace(/\*([^\*]+)\*/g, '<em>$1</em>');
             
@@ -600,6 +1528,7 @@ func serveHome(w http.ResponseWriter, r *http.Request) {
                 text = '<p>' + text + '</p>';
             }
             
+            text = text.replace(/XDIFFBLOCK(\d+)X/g, (match, i) => renderDiff(extracted.diffs[i]));
             return text;
         }
 
@@ -609,12 +1538,150 @@ func serveHome(w http.ResponseWriter, r *http.Request) {
             return div.innerHTML;
         }
 
-        function sendMessage() {
-            const content = messageInput.value.trim();
+        // The user's remaining daily quota, shown in the header
+        const quotaInfo = document.getElementById('quotaInfo');
+        function formatCount(n) {
+            return n >= 10000 ? Math.round(n / 1000) + 'k' : String(n);
+        }
+        function loadQuota() {
+            fetch('/api/quota')
+                .then(r => r.ok ? r.json() : null)
+                .then(quota => {
+                    if (!quota || !quota.enabled || quota.exempt) return;
+                    const parts = [];
+                    if (quota.messages_limit) {
+                        parts.push(Math.max(0, quota.messages_limit - quota.messages) + ' of ' + quota.messages_limit + ' questions');
+                    }
+                    if (quota.tokens_limit) {
+                        parts.push(formatCount(Math.max(0, quota.tokens_limit - quota.tokens)) + ' of ' + formatCount(quota.tokens_limit) + ' tokens');
+                    }
+                    quotaInfo.textContent = 'Left today: ' + parts.join(' · ');
+                    quotaInfo.style.display = '';
+                })
+                .catch(() => {});
+        }
+        loadQuota();
+
+        // The maintenance banner, shown while the assistant is paused
+        const maintenanceBanner = document.getElementById('maintenanceBanner');
+        function showMaintenance(message) {
+            maintenanceBanner.textContent = '⏸ ' + message;
+            maintenanceBanner.style.display = 'block';
+        }
+        function pollMaintenance() {
+            fetch('/api/maintenance')
+                .then(r => r.ok ? r.json() : null)
+                .then(status => {
+                    if (!status) return;
+                    if (status.enabled) {
+                        showMaintenance(status.message);
+                    } else {
+                        maintenanceBanner.style.display = 'none';
+                    }
+                })
+                .catch(() => {});
+        }
+        pollMaintenance();
+        setInterval(pollMaintenance, 30000);
+
+        // The welcome message of a new session, with clickable example questions
+        function showGreeting(greeting) {
+            let text = greeting.message;
+            if (greeting.capabilities && greeting.capabilities.length) {
+                text += '\n\n' + greeting.capabilities.map(c => '- ' + c).join('\n');
+            }
+            const div = document.createElement('div');
+            div.className = 'message system greeting';
+            div.innerHTML = '<strong>AI Assistant</strong><div class="message-content">' + formatMarkdown(text) + '</div>';
+            if (greeting.examples && greeting.examples.length) {
+                const examples = document.createElement('div');
+                examples.className = 'examples';
+                greeting.examples.forEach(question => {
+                    const button = document.createElement('button');
+                    button.className = 'example-question';
+                    button.textContent = question;
+                    button.onclick = () => {
+                        messageInput.value = question;
+                        sendMessage();
+                    };
+                    examples.appendChild(button);
+                });
+                div.appendChild(examples);
+            }
+            messagesDiv.appendChild(div);
+            messagesDiv.scrollTop = messagesDiv.scrollHeight;
+        }
+
+        // Ask the user to allow a sensitive capability for this session
+        function showConsent(question, consent) {
+            const div = document.createElement('div');
+            div.className = 'message consent';
+            div.innerHTML = '<strong>Permission needed</strong><div class="message-content"></div>';
+            div.querySelector('.message-content').textContent = question + ' (' + consent.tool + ')';
+            const actions = document.createElement('div');
+            actions.className = 'consent-actions';
+            [[consent.once ? 'Allow' : 'Allow for this session', true], ['Deny', false]].forEach(([label, approved]) => {
+                const button = document.createElement('button');
+                button.textContent = label;
+                if (approved) button.className = 'allow';
+                button.onclick = () => {
+                    if (!ws || ws.readyState !== WebSocket.OPEN) return;
+                    ws.send(JSON.stringify({consent: {id: consent.id, approved: approved}}));
+                    actions.querySelectorAll('button').forEach(b => b.disabled = true);
+                    actions.appendChild(document.createTextNode(approved ? 'Allowed' : 'Denied'));
+                };
+                actions.appendChild(button);
+            });
+            div.appendChild(actions);
+            const typing = document.querySelector('.typing');
+            messagesDiv.insertBefore(div, typing);
+        }
+
+        // Suggested actions: follow-ups the assistant offers with an answer.
+        // open_file opens the source viewer; the others are sent back and run
+        // with the assistant's tools
+        function addSuggestions(msg, suggestions) {
+            if (!suggestions.length) return;
+            const div = document.createElement('div');
+            div.className = 'suggestions';
+            suggestions.forEach(s => {
+                const button = document.createElement('button');
+                button.className = 'suggestion-button';
+                button.textContent = s.label;
+                button.title = s.type.replace(/_/g, ' ');
+                button.onclick = () => {
+                    if (s.type === 'open_file') {
+                        openSource(s.file_path, s.line || 0);
+                    } else {
+                        sendMessage(s.type === 'follow_up' ? s.question : s.label, 'suggestion', s);
+                    }
+                };
+                div.appendChild(button);
+            });
+            msg.appendChild(div);
+        }
+
+        // Ask the assistant for a Go test reproducing the bug it diagnosed
+        function addTestButton(msg) {
+            const button = document.createElement('button');
+            button.className = 'pin-button';
+            button.textContent = 'Write failing test';
+            button.onclick = () => sendMessage('Write a failing Go test that reproduces this bug.', 'generate_test');
+            msg.appendChild(button);
+        }
+
+        // sendMessage sends the input, or the text of an answer action
+        function sendMessage(text, action, suggestion) {
+            const content = text || messageInput.value.trim();
             if (!content || isProcessing) return;
 
+            const id = nextMessageId++;
             addMessage('user', content);
-            messageInput.value = '';
+            const div = messagesDiv.lastElementChild;
+            div.dataset.messageId = id;
+            div.classList.add('queued');
+            addForkButton(div, 'Edit in a fork', questionCount++, content);
+            if (!text) messageInput.value = '';
 
             // Add typing indicator
             const typing = document.createElement('div');
@@ -625,14 +1692,62 @@ func serveHome(w http.ResponseWriter, r *http.Request) {
             isProcessing = true;
             sendButton.disabled = true;
 
-            ws.send(JSON.stringify({ content }));
+            outbox.push({ id, content, action, suggestion });
+            flushOutbox();
         }
 
-        sendButton.onclick = sendMessage;
+        sendButton.onclick = () => sendMessage();
         messageInput.onkeypress = (e) => {
             if (e.key === 'Enter') sendMessage();
         };
 
+        // Show automated alert analyses as they complete
+        const seenAlerts = new Set();
+        let alertsLoaded = false;
+        function pollAlerts() {
+            fetch('/api/alerts')
+                .then(r => r.json())
+                .then(list => {
+                    (list || []).forEach(item => {
+                        const key = item.source + ':' + item.alert_id + ':' + item.session_id;
+                        if (seenAlerts.has(key)) return;
+                        seenAlerts.add(key);
+                        // Only surface analyses that finish while the page is open
+                        if (!alertsLoaded) return;
+                        addMessage('alert', '**' + item.title + '** (' + item.source + ' ' + item.alert_id + ')\n\n' + item.analysis);
+                    });
+                    alertsLoaded = true;
+                })
+                .catch(() => {});
+        }
+        pollAlerts();
+        setInterval(pollAlerts, 30000);
+
+        // Show log anomaly explanations as they complete
+        const seenAnomalies = new Set();
+        let anomaliesLoaded = false;
+        function pollAnomalies() {
+            fetch('/api/anomalies')
+                .then(r => r.json())
+                .then(list => {
+                    (list || []).forEach(item => {
+                        if (seenAnomalies.has(item.session_id)) return;
+                        seenAnomalies.add(item.session_id);
+                        if (!anomaliesLoaded) return;
+                        const findings = (item.findings || []).map(f => '- ' + f).join('\n');
+                        addMessage('alert', '**Log anomaly** (' + item.target + ')\n\n' + findings + '\n\n' + item.explanation);
+                    });
+                    anomaliesLoaded = true;
+                })
+                .catch(() => {});
+        }
+        pollAnomalies();
+        setInterval(pollAnomalies, 30000);
+
+        loadTargets();
+        loadSharing();
+        loadEditorLinks();
+        openSourceFromHash();
         connect();
     </script>
 </body>
@@ -642,107 +1757,116 @@ func serveHome(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(html))
 }
 
-func handleWebSocket(w http.ResponseWriter, r *http.Request, a *Assistant) {
-	conn, err := upgrader.Upgrade(w, r, nil)
+func handleWebSocket(w http.ResponseWriter, r *http.Request, a *Assistant, store *wsSessionStore) {
+	// Clients choose the protocol version with ?v= (default 1)
+	version, err := protocolVersion(r)
 	if err != nil {
-		log.Printf("WebSocket upgrade error: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	defer conn.Close()
-
-	// Generate unique session ID
-	sessionID := generateSessionID()
-
-	// Initialize session log
-	logFile, err := initSessionLog(sessionID)
+	wsConn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		log.Printf("Failed to create session log: %v", err)
-		// Continue without logging
+		log.Printf("WebSocket upgrade error: %v", err)
+		return
 	}
-	defer func() {
-		if logFile != nil {
-			logFile.Close()
-		}
-	}()
-
-	session := &Session{
-		ID:       sessionID,
-		User:     r.Context().Value(userContextKey).(*User),
-		messages: []provider.Message{},
-		logFile:  logFile,
+	defer wsConn.Close()
+	conn := &clientConn{Conn: wsConn, version: version}
+	user := r.Context().Value(userContextKey).(*User)
+
+	// A reconnecting client resumes its session with ?session=&after=
+	var ws *wsSession
+	if id := r.URL.Query().Get("session"); id != "" {
+		after, _ := strconv.ParseInt(r.URL.Query().Get("after"), 10, 64)
+		ws = store.resume(id, after, user, conn)
 	}
 
-	// Log session start with user info
-	userID := session.User.ID
-	userName := session.User.Name
-	session.logEvent("session_start", map[string]interface{}{
-		"timestamp":   time.Now().Format(time.RFC3339),
-		"remote_addr": r.RemoteAddr,
-		"user_id":     userID,
-		"user_name":   userName,
-	})
-
-	// Send session info to client
-	conn.WriteJSON(ChatResponse{
-		Type:      "session_info",
-		SessionID: sessionID,
-		Content:   fmt.Sprintf("Session %s started", sessionID),
-	})
+	if ws == nil {
+		// The UI selects a target per connection with ?target=
+		target := a.findTarget(r.URL.Query().Get("target"))
+		if target == nil {
+			conn.WriteJSON(ChatResponse{
+				Type:    "error",
+				Content: fmt.Sprintf("Unknown target %q", r.URL.Query().Get("target")),
+			})
+			return
+		}
 
-	log.Printf("[Session %s] Started (user: %s)", sessionID, userID)
+		// Generate unique session ID
+		sessionID := generateSessionID()
 
-	for {
-		var msg ChatMessage
-		err := conn.ReadJSON(&msg)
+		// Initialize session log; it is closed when the session ends
+		logFile, err := initSessionLog(sessionID, a.transcripts)
 		if err != nil {
-			log.Printf("[Session %s] WebSocket read error: %v", sessionID, err)
-			session.logEvent("session_end", map[string]interface{}{
-				"reason": "connection_closed",
-				"error": err.Error(),
-			})
-			break
+			log.Printf("Failed to create session log: %v", err)
+			// Continue without logging
+		}
+
+		session := &Session{
+			ID:       sessionID,
+			User:     user,
+			messages: []provider.Message{},
+			logFile:  logFile,
+			target:   target,
 		}
 
-		// Log user message
-		session.logEvent("user_message", map[string]interface{}{
-			"content": msg.Content,
+		// Log session start with user info
+		userID := session.User.ID
+		userName := session.User.Name
+		session.logEvent("session_start", map[string]interface{}{
+			"timestamp":   time.Now().Format(time.RFC3339),
+			"remote_addr": r.RemoteAddr,
+			"user_id":     userID,
+			"user_name":   userName,
+			"target":      target.config.Name,
 		})
 
-		// Add user message to session
-		session.mu.Lock()
-		session.messages = append(session.messages, provider.Message{
-			Role: "user",
-			Content: []provider.ContentBlock{
-				{Type: "text", Text: msg.Content},
-			},
+		// Send session info to client
+		conn.WriteJSON(ChatResponse{
+			Type:      "session_info",
+			SessionID: sessionID,
+			Content:   fmt.Sprintf("Session %s started", sessionID),
+			Revision:  a.analyzedRevision(target),
+			Greeting:  a.greeting(target),
+			Actions:   a.answerActions(),
 		})
-		session.mu.Unlock()
 
-		log.Printf("[Session %s] User: %s", sessionID, msg.Content)
+		log.Printf("[Session %s] Started (user: %s, target: %s)", sessionID, userID, target.config.Name)
+		ws = store.start(session, conn)
+	}
 
-		// Process with AI (allow multiple tool use turns)
-		err = processChat(conn, a, session)
-		if err != nil {
-			log.Printf("[Session %s] Error: %v", sessionID, err)
-			session.logEvent("error", map[string]interface{}{
-				"error": err.Error(),
-			})
-			conn.WriteJSON(ChatResponse{
-				Type:    "error",
-				Content: fmt.Sprintf("Error: %v", err),
-			})
+	// Messages are processed by the session's worker, which keeps running
+	// if this connection drops
+	for {
+		var msg ChatMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			ws.detach(store, conn, err)
+			return
 		}
-
-		// Send done signal
-		conn.WriteJSON(ChatResponse{Type: "done"})
+		ws.receive(conn, msg)
 	}
-
-	log.Printf("[Session %s] Ended", sessionID)
 }
 
-func processChat(conn *websocket.Conn, a *Assistant, session *Session) error {
+func processChat(conn chatWriter, a *Assistant, session *Session) error {
 	maxTurns := 10 // Allow multiple tool use turns
 
+	// Index of the user's question, used for the completion webhook
+	session.mu.Lock()
+	start := len(session.messages) - 1
+	session.mu.Unlock()
+	if err := a.maintenance.check(); err != nil {
+		return err
+	}
+	if err := a.budget.checkAvailable(); err != nil {
+		return err
+	}
+	if err := a.quotas.admit(session); err != nil {
+		return err
+	}
+	a.experiment.assign(session)
+	session.resetCitations()
+	var answer string
+	evidenceRequired := false
+
 	for turn := 0; turn < maxTurns; turn++ {
 		// Call AI API
 		session.mu.Lock()
@@ -750,10 +1874,38 @@ func processChat(conn *websocket.Conn, a *Assistant, session *Session) error {
 		copy(messages, session.messages)
 		session.mu.Unlock()
 
-		tools := a.getAllToolDefinitions()
-		response, err := a.provider.SendMessage(messages, tools, buildSystemPrompt(a))
-		if err != nil {
-			return err
+		// Tell the user what the assistant is doing during long turns
+		status := "Thinking…"
+		if turn > 0 {
+			status = "Analyzing the results…"
+		}
+		conn.WriteJSON(ChatResponse{Type: "status", Content: status})
+
+		// A pasted request ID is looked up in the logs before asking the model
+		var response *provider.Response
+		if turn == 0 {
+			response = a.requestIDLookup(session)
+		}
+		if response == nil {
+			tools := a.getAllToolDefinitions(a.sessionTarget(session))
+			var err error
+			system := buildSystemPrompt(a, session)
+			if evidenceRequired {
+				system += evidenceRequiredPrompt
+			}
+			if response, err = a.sessionProvider(session).SendMessage(a.fitContext(a.sessionTarget(session), messages, tools, system), tools, system); err != nil {
+				return err
+			}
+			a.quotas.record(session, response.Usage)
+			a.experiment.record(session, response)
+		}
+
+		// In strict evidence mode, a conclusion without evidence is discarded
+		// once and the model is told to gather evidence first
+		if !evidenceRequired && a.lacksEvidence(session, response) {
+			evidenceRequired = true
+			session.logEvent("evidence_required", map[string]interface{}{"turn": turn})
+			continue
 		}
 
 		// Process response content
@@ -768,6 +1920,7 @@ func processChat(conn *websocket.Conn, a *Assistant, session *Session) error {
 					Content: block.Text,
 				})
 				assistantContent = append(assistantContent, block)
+				answer += block.Text
 
 				// Log AI text response
 				session.logEvent("assistant_message", map[string]interface{}{
@@ -784,13 +1937,34 @@ func processChat(conn *websocket.Conn, a *Assistant, session *Session) error {
 					"input":     block.Input,
 				})
 
+				// Send tool call trace and progress to client
+				conn.WriteJSON(ChatResponse{
+					Type:      "tool_use",
+					ToolName:  block.Name,
+					ToolInput: block.Input,
+				})
+				conn.WriteJSON(ChatResponse{Type: "status", Content: toolStatus(a, session, block.Name, block.Input)})
+
 				// Execute tool
 				log.Printf("Executing tool: %s", block.Name)
-				result, err := a.executeToolCall(block.Name, block.Input, session.authHeader)
+				result, err := a.executeToolCall(session, block.Name, block.Input)
 				if err != nil {
 					result = fmt.Sprintf("Error: %v", err)
 				}
 
+				// Number evidence for the answer to cite
+				content := result
+				if err == nil {
+					content = a.citeToolResult(session, block.Name, block.Input, result)
+				}
+
+				conn.WriteJSON(ChatResponse{
+					Type:     "tool_result",
+					ToolName: block.Name,
+					Content:  truncate(result, maxTraceChars),
+					IsError:  err != nil,
+				})
+
 				// Log tool result
 				session.logEvent("tool_result", map[string]interface{}{
 					"tool_name": block.Name,
@@ -811,7 +1985,7 @@ func processChat(conn *websocket.Conn, a *Assistant, session *Session) error {
 						{
 							Type:      "tool_result",
 							ToolUseID: block.ID,
-							Content:   result,
+							Content:   content,
 						},
 					},
 				})
@@ -827,6 +2001,9 @@ func processChat(conn *websocket.Conn, a *Assistant, session *Session) error {
 				Content: assistantContent,
 			})
 			session.mu.Unlock()
+			a.metrics.recordAnswer(session, answer)
+			a.notifyAnalysisCompleted(session, start, answer)
+			a.notifyOwners(session, start, answer)
 			break
 		}
 	}
@@ -834,14 +2011,55 @@ func processChat(conn *websocket.Conn, a *Assistant, session *Session) error {
 	return nil
 }
 
-// buildSystemPrompt returns the appropriate system prompt based on configuration
-func buildSystemPrompt(a *Assistant) string {
-	if a.config.APISpec != "" {
+// maxTraceChars limits tool results shown in client-side tool traces
+const maxTraceChars = 500
+
+// truncate shortens s to at most max bytes followed by "...", cutting on a
+// character boundary so the result stays valid UTF-8 in JSON and HTML
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+	return s[:max] + "..."
+}
+
+// buildSystemPrompt returns the appropriate system prompt based on configuration,
+// the session's target, the user's memories and the session's experiment variant
+func buildSystemPrompt(a *Assistant, session *Session) string {
+	t := a.sessionTarget(session)
+	return basePrompt(a, t) + a.revisionPrompt(t) + citationPrompt + a.ownershipPrompt() + a.peers.promptSection() + a.ops.promptSection() + a.memory.promptSection(session) + session.variantPrompt()
+}
+
+// apiCapabilitiesPrompt describes the operations of a target's OpenAPI spec
+// grouped by tag, so the model knows what it can do before reading every tool
+func apiCapabilitiesPrompt(t *target) string {
+	capabilities := t.apiSpec.Capabilities()
+	if capabilities == "" {
+		return ""
+	}
+	intro := "\n\nAvailable API capabilities"
+	if t.apiSpec.Title != "" {
+		intro += " of " + t.apiSpec.Title
+	}
+	return intro + ", grouped by area (operation name, HTTP method and path, summary). " +
+		"Use them to plan which operations a request needs and in which order; " +
+		"if no operation fits, say so instead of guessing:\n\n" + capabilities
+}
+
+// basePrompt returns the system prompt for a target
+func basePrompt(a *Assistant, t *target) string {
+	if a.isAgentMode(t) {
 		name := a.config.AgentInfo.Name
+		desc := a.config.AgentInfo.Description
+		if t != a.targets[0] {
+			name, desc = t.config.Name, t.config.Description
+		}
 		if name == "" {
 			name = "this application"
 		}
-		desc := a.config.AgentInfo.Description
 		if desc != "" {
 			desc = "\n\nAbout this system: " + desc
 		}
@@ -859,10 +2077,33 @@ When a user makes a request:
 3. Report the results in a clear, human-readable format
 4. If an API call fails, explain what went wrong and suggest alternatives
 
-Be helpful, concise, and always confirm when actions are completed successfully.`
+Be helpful, concise, and always confirm when actions are completed successfully.` + apiCapabilitiesPrompt(t)
+	}
+
+	prompt := systemPrompt
+	if a.config.Runbooks.Dir != "" || len(a.config.Runbooks.URLs) > 0 {
+		prompt += runbookGuidance
 	}
+	if t.environment != nil {
+		desc := ""
+		if t.config.Description != "" {
+			desc = " (" + t.config.Description + ")"
+		}
+		prompt += `
+
+You are debugging the "` + t.config.Name + `" environment of the application` + desc + `.
+The log tools and API calls only reach this environment.`
+	} else if len(a.targets) > 1 {
+		desc := ""
+		if t.config.Description != "" {
+			desc = " (" + t.config.Description + ")"
+		}
+		prompt += `
 
-	return systemPrompt
+This assistant serves several services. You are debugging the "` + t.config.Name + `" service` + desc + `.
+The source code, log and code index tools only see this service.`
+	}
+	return prompt
 }
 
 // --- HTTP Session Store for /willknow/chat ---
@@ -889,11 +2130,13 @@ func (s *httpSessionStore) set(id string, session *Session) {
 
 // AgentInfoResponse is the JSON response for /willknow/info
 type AgentInfoResponse struct {
-	Name         string             `json:"name"`
-	Description  string             `json:"description"`
-	ChatEndpoint string             `json:"chat_endpoint"`
-	Auth         AgentInfoAuth      `json:"authentication"`
-	Capabilities []AgentCapability  `json:"capabilities"`
+	Name         string            `json:"name"`
+	Description  string            `json:"description"`
+	ChatEndpoint string            `json:"chat_endpoint"`
+	A2AAgentCard string            `json:"a2a_agent_card"` // A2A protocol agent card URL
+	Auth         AgentInfoAuth     `json:"authentication"`
+	Capabilities []AgentCapability `json:"capabilities"`
+	AirGapped    bool              `json:"air_gapped"` // outbound traffic restricted to allowlisted internal hosts
 }
 
 // AgentInfoAuth describes authentication requirements
@@ -915,27 +2158,8 @@ func handleAgentInfo(w http.ResponseWriter, r *http.Request, a *Assistant) {
 		return
 	}
 
-	name := a.config.AgentInfo.Name
-	if name == "" {
-		name = "Willknow AI Assistant"
-	}
-	desc := a.config.AgentInfo.Description
-	if desc == "" {
-		desc = "AI-powered assistant for debugging and application interaction"
-	}
-
-	// Build capabilities list
-	var capabilities []AgentCapability
-	for _, tool := range a.apiTools {
-		desc := tool.Description
-		if desc == "" {
-			desc = tool.Summary
-		}
-		capabilities = append(capabilities, AgentCapability{
-			Name:        tool.Name,
-			Description: desc,
-		})
-	}
+	name, desc := agentIdentity(a)
+	capabilities := agentCapabilities(a)
 
 	// Determine auth requirement
 	authRequired := !a.authManager.isOpenMode()
@@ -951,27 +2175,79 @@ func handleAgentInfo(w http.ResponseWriter, r *http.Request, a *Assistant) {
 		Name:         name,
 		Description:  desc,
 		ChatEndpoint: chatEndpoint,
+		A2AAgentCard: a2aAgentCardPath,
 		Auth: AgentInfoAuth{
 			Required: authRequired,
 			Type:     authType,
 		},
 		Capabilities: capabilities,
+		AirGapped:    a.config.AirGapped,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
 }
 
+// agentIdentity returns the agent's display name and description
+func agentIdentity(a *Assistant) (string, string) {
+	name := a.config.AgentInfo.Name
+	if name == "" {
+		name = "Willknow AI Assistant"
+	}
+	desc := a.config.AgentInfo.Description
+	if desc == "" {
+		desc = "AI-powered assistant for debugging and application interaction"
+	}
+	return name, desc
+}
+
+// agentCapabilities lists the host API operations the agent can perform
+func agentCapabilities(a *Assistant) []AgentCapability {
+	// Build capabilities list
+	var capabilities []AgentCapability
+	for _, tool := range a.apiTools {
+		desc := tool.Description
+		if desc == "" {
+			desc = tool.Summary
+		}
+		capabilities = append(capabilities, AgentCapability{
+			Name:        tool.Name,
+			Description: desc,
+		})
+	}
+	if a.grpcService != nil {
+		for _, tool := range a.grpcService.Tools {
+			capabilities = append(capabilities, AgentCapability{
+				Name:        tool.Name,
+				Description: tool.Description,
+			})
+		}
+	}
+	if a.graphqlAPI != nil {
+		for _, tool := range a.graphqlAPI.Tools {
+			capabilities = append(capabilities, AgentCapability{
+				Name:        tool.Name,
+				Description: tool.Description,
+			})
+		}
+	}
+	return capabilities
+}
+
 // AgentChatRequest is the JSON body for POST /willknow/chat
 type AgentChatRequest struct {
 	Message   string `json:"message"`
 	SessionID string `json:"session_id"`
+	Target    string `json:"target,omitempty"` // target for new sessions (default: "default")
 }
 
 // AgentChatResponse is the JSON response for POST /willknow/chat
 type AgentChatResponse struct {
-	Message   string `json:"message"`
-	SessionID string `json:"session_id"`
+	Message     string            `json:"message"`
+	SessionID   string            `json:"session_id"`
+	Revision    string            `json:"revision,omitempty"`    // source revision the answer is based on
+	Citations   []Citation        `json:"citations,omitempty"`   // evidence the answer cites
+	Suggestions []SuggestedAction `json:"suggestions,omitempty"` // follow-up actions offered with the answer
 }
 
 // handleAgentChat handles POST /willknow/chat for external AI callers
@@ -997,8 +2273,13 @@ func handleAgentChat(w http.ResponseWriter, r *http.Request, a *Assistant, store
 		session = store.get(req.SessionID)
 	}
 	if session == nil {
+		target := a.findTarget(req.Target)
+		if target == nil {
+			http.Error(w, "unknown target", http.StatusBadRequest)
+			return
+		}
 		sessionID := generateSessionID()
-		logFile, _ := initSessionLog(sessionID)
+		logFile, _ := initSessionLog(sessionID, a.transcripts)
 
 		user, _ := r.Context().Value(userContextKey).(*User)
 		session = &Session{
@@ -1007,6 +2288,8 @@ func handleAgentChat(w http.ResponseWriter, r *http.Request, a *Assistant, store
 			messages:   []provider.Message{},
 			logFile:    logFile,
 			authHeader: r.Header.Get("Authorization"),
+			target:     target,
+			hops:       peerHops(r),
 		}
 		store.set(sessionID, session)
 		log.Printf("[Agent Session %s] Created", sessionID)
@@ -1027,6 +2310,14 @@ func handleAgentChat(w http.ResponseWriter, r *http.Request, a *Assistant, store
 	// Collect AI response text
 	var responseText string
 	err := processChatHTTP(a, session, &responseText)
+	if errors.Is(err, ErrBudgetExceeded) || errors.Is(err, ErrQuotaExceeded) {
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+		return
+	}
+	if errors.Is(err, ErrMaintenance) {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
 	if err != nil {
 		log.Printf("[Agent Session %s] Error: %v", session.ID, err)
 		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusInternalServerError)
@@ -1035,8 +2326,11 @@ func handleAgentChat(w http.ResponseWriter, r *http.Request, a *Assistant, store
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(AgentChatResponse{
-		Message:   responseText,
-		SessionID: session.ID,
+		Message:     responseText,
+		SessionID:   session.ID,
+		Revision:    a.analyzedRevision(a.sessionTarget(session)),
+		Citations:   session.answerCitations(),
+		Suggestions: session.answerSuggestions(),
 	})
 }
 
@@ -1044,16 +2338,52 @@ func handleAgentChat(w http.ResponseWriter, r *http.Request, a *Assistant, store
 func processChatHTTP(a *Assistant, session *Session, responseText *string) error {
 	maxTurns := 10
 
+	session.mu.Lock()
+	start := len(session.messages) - 1
+	session.mu.Unlock()
+	if err := a.maintenance.check(); err != nil {
+		return err
+	}
+	if err := a.budget.checkAvailable(); err != nil {
+		return err
+	}
+	if err := a.quotas.admit(session); err != nil {
+		return err
+	}
+	a.experiment.assign(session)
+	session.resetCitations()
+	evidenceRequired := false
+
 	for turn := 0; turn < maxTurns; turn++ {
 		session.mu.Lock()
 		messages := make([]provider.Message, len(session.messages))
 		copy(messages, session.messages)
 		session.mu.Unlock()
 
-		tools := a.getAllToolDefinitions()
-		response, err := a.provider.SendMessage(messages, tools, buildSystemPrompt(a))
-		if err != nil {
-			return err
+		var response *provider.Response
+		if turn == 0 {
+			response = a.requestIDLookup(session)
+		}
+		if response == nil {
+			tools := a.getAllToolDefinitions(a.sessionTarget(session))
+			var err error
+			system := buildSystemPrompt(a, session)
+			if evidenceRequired {
+				system += evidenceRequiredPrompt
+			}
+			if response, err = a.sessionProvider(session).SendMessage(a.fitContext(a.sessionTarget(session), messages, tools, system), tools, system); err != nil {
+				return err
+			}
+			a.quotas.record(session, response.Usage)
+			a.experiment.record(session, response)
+		}
+
+		// In strict evidence mode, a conclusion without evidence is discarded
+		// once and the model is told to gather evidence first
+		if !evidenceRequired && a.lacksEvidence(session, response) {
+			evidenceRequired = true
+			session.logEvent("evidence_required", map[string]interface{}{"turn": turn})
+			continue
 		}
 
 		var assistantContent []provider.ContentBlock
@@ -1071,8 +2401,11 @@ func processChatHTTP(a *Assistant, session *Session, responseText *string) error
 					"tool_name": block.Name,
 					"input":     block.Input,
 				})
+				if session.onToolUse != nil {
+					session.onToolUse(block.Name, block.Input)
+				}
 
-				result, err := a.executeToolCall(block.Name, block.Input, session.authHeader)
+				result, err := a.executeToolCall(session, block.Name, block.Input)
 				if err != nil {
 					result = fmt.Sprintf("Error: %v", err)
 				}
@@ -1081,6 +2414,9 @@ func processChatHTTP(a *Assistant, session *Session, responseText *string) error
 					"result":    result,
 					"error":     err != nil,
 				})
+				if err == nil {
+					result = a.citeToolResult(session, block.Name, block.Input, result)
+				}
 
 				session.mu.Lock()
 				session.messages = append(session.messages, provider.Message{
@@ -1109,6 +2445,9 @@ func processChatHTTP(a *Assistant, session *Session, responseText *string) error
 				Content: assistantContent,
 			})
 			session.mu.Unlock()
+			a.metrics.recordAnswer(session, *responseText)
+			a.notifyAnalysisCompleted(session, start, *responseText)
+			a.notifyOwners(session, start, *responseText)
 			break
 		}
 	}
@@ -0,0 +1,378 @@
+package aiassistant
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sharePath is the public prefix of share links
+const sharePath = "/willknow/share/"
+
+// SharingConfig lets users create read-only links to a conversation
+// transcript. A link is signed and expires, so it can be passed to a
+// teammate who has no assistant credentials. The transcript is rendered
+// server-side from the session log.
+type SharingConfig struct {
+	// Enabled shows the Share button in the chat UI and serves share links
+	Enabled bool
+
+	// Secret signs share links. When empty, a random secret is generated at
+	// startup, so links stop working when the process restarts.
+	Secret string
+
+	// TTL is how long a share link stays valid
+	// Default: 24h
+	TTL time.Duration
+
+	// BaseURL is the externally reachable URL of the assistant, used to build
+	// absolute links (e.g. "https://assistant.internal.example.com").
+	// Default: derived from the request's Host header
+	BaseURL string
+}
+
+// shareSessionID matches the session IDs of share links
+var shareSessionID = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// sharer signs and serves transcript share links
+type sharer struct {
+	config      SharingConfig
+	secret      []byte
+	redactor    *redactor         // nil without redaction
+	transcripts *transcriptPolicy // decrypts session logs
+}
+
+// newSharer returns nil if sharing is disabled
+func newSharer(config SharingConfig, redactor *redactor, transcripts *transcriptPolicy) *sharer {
+	if !config.Enabled {
+		return nil
+	}
+	if config.TTL <= 0 {
+		config.TTL = 24 * time.Hour
+	}
+	secret := []byte(config.Secret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		rand.Read(secret)
+	}
+	return &sharer{config: config, secret: secret, redactor: redactor, transcripts: transcripts}
+}
+
+// sign returns the signature of a share link
+func (s *sharer) sign(sessionID string, expires int64) string {
+	mac := hmac.New(sha256.New, s.secret)
+	fmt.Fprintf(mac, "%s|%d", sessionID, expires)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// ShareResponse is the JSON response for POST /api/share
+type ShareResponse struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// handleCreate serves /api/share: GET reports whether sharing is enabled,
+// POST {"session_id": "..."} creates a link for one of the user's sessions
+func (s *sharer) handleCreate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method == http.MethodGet {
+		json.NewEncoder(w).Encode(map[string]bool{"enabled": s != nil})
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s == nil {
+		http.Error(w, "sharing is disabled", http.StatusNotFound)
+		return
+	}
+
+	var req struct {
+		SessionID string `json:"session_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || !shareSessionID.MatchString(req.SessionID) {
+		http.Error(w, "session_id is required", http.StatusBadRequest)
+		return
+	}
+	events, err := readSessionEvents(req.SessionID, s.transcripts)
+	if err != nil {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+
+	// Only the user who held the conversation may share it
+	user, _ := r.Context().Value(userContextKey).(*User)
+	if owner := sessionOwner(events); owner != "" && (user == nil || user.ID != owner) {
+		http.Error(w, "you can only share your own conversations", http.StatusForbidden)
+		return
+	}
+
+	expiresAt := time.Now().Add(s.config.TTL).Truncate(time.Second)
+	expires := expiresAt.Unix()
+	base := strings.TrimSuffix(s.config.BaseURL, "/")
+	if base == "" {
+		scheme := "http"
+		if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+			scheme = "https"
+		}
+		base = scheme + "://" + r.Host
+	}
+	link := fmt.Sprintf("%s%s%s?exp=%d&sig=%s", base, sharePath, req.SessionID, expires, s.sign(req.SessionID, expires))
+
+	userID := ""
+	if user != nil {
+		userID = user.ID
+	}
+	log.Printf("[Share] Session %s shared by %s until %s", req.SessionID, userID, expiresAt.Format(time.RFC3339))
+	json.NewEncoder(w).Encode(ShareResponse{URL: link, ExpiresAt: expiresAt})
+}
+
+// handleView serves a shared transcript. It requires no authentication:
+// the signature proves the link was created by a user of the assistant.
+func (s *sharer) handleView(w http.ResponseWriter, r *http.Request) {
+	if s == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sessionID := strings.TrimPrefix(r.URL.Path, sharePath)
+	expires, err := strconv.ParseInt(r.URL.Query().Get("exp"), 10, 64)
+	sig := r.URL.Query().Get("sig")
+	if err != nil || !shareSessionID.MatchString(sessionID) ||
+		!hmac.Equal([]byte(sig), []byte(s.sign(sessionID, expires))) {
+		http.Error(w, "Invalid share link", http.StatusNotFound)
+		return
+	}
+	if time.Now().Unix() > expires {
+		http.Error(w, "This share link has expired", http.StatusGone)
+		return
+	}
+
+	events, err := readSessionEvents(sessionID, s.transcripts)
+	if err != nil {
+		http.Error(w, "Conversation not found", http.StatusNotFound)
+		return
+	}
+
+	page := s.transcript(sessionID, events)
+	page.ExpiresAt = time.Unix(expires, 0)
+
+	// Keep the transcript out of caches, search engines and Referer headers
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Robots-Tag", "noindex")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	if err := shareTemplate.Execute(w, page); err != nil {
+		log.Printf("[Share] Failed to render session %s: %v", sessionID, err)
+	}
+}
+
+// sessionEvent is one line of a session log
+type sessionEvent struct {
+	Timestamp string                 `json:"timestamp"`
+	Type      string                 `json:"type"`
+	Data      map[string]interface{} `json:"data"`
+}
+
+// readSessionEvents reads the log of a session
+func readSessionEvents(sessionID string, policy *transcriptPolicy) ([]sessionEvent, error) {
+	matches, _ := filepath.Glob(filepath.Join("./sessions", "*_"+sessionID+".jsonl"))
+	if len(matches) == 0 {
+		return nil, os.ErrNotExist
+	}
+	return readSessionLog(matches[0], policy)
+}
+
+// readSessionLog reads a session log file, decrypting encrypted lines and
+// skipping malformed ones
+func readSessionLog(path string, policy *transcriptPolicy) ([]sessionEvent, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var events []sessionEvent
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line, err := policy.open(scanner.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		var event sessionEvent
+		if json.Unmarshal(line, &event) == nil {
+			events = append(events, event)
+		}
+	}
+	return events, scanner.Err()
+}
+
+// sessionOwner returns the user recorded at session start ("" for sessions
+// without one, e.g. automated analyses)
+func sessionOwner(events []sessionEvent) string {
+	for _, event := range events {
+		if event.Type == "session_start" {
+			owner, _ := event.Data["user_id"].(string)
+			return owner
+		}
+	}
+	return ""
+}
+
+// sharePage is the data of the transcript template
+type sharePage struct {
+	SessionID string
+	Started   string
+	UserName  string
+	Target    string
+	ExpiresAt time.Time
+	Entries   []shareEntry
+}
+
+// shareEntry is one rendered transcript entry
+type shareEntry struct {
+	Kind  string // "user", "assistant", "tool", "error"
+	Title string
+	Text  string
+	Time  string
+}
+
+// transcript converts session events to the template data. Tool results are
+// truncated and all text is redacted when redaction is configured.
+func (s *sharer) transcript(sessionID string, events []sessionEvent) sharePage {
+	const maxToolResult = 4000
+
+	page := sharePage{SessionID: sessionID}
+	str := func(data map[string]interface{}, key string) string {
+		v, _ := data[key].(string)
+		return s.redactor.redact(v)
+	}
+	for _, event := range events {
+		clock := event.Timestamp
+		if t, err := time.Parse(time.RFC3339, event.Timestamp); err == nil {
+			clock = t.Format("15:04:05")
+		}
+		switch event.Type {
+		case "session_start":
+			page.Started = event.Timestamp
+			page.UserName = str(event.Data, "user_name")
+			page.Target = str(event.Data, "target")
+		case "user_message":
+			page.Entries = append(page.Entries, shareEntry{Kind: "user", Title: "User", Text: str(event.Data, "content"), Time: clock})
+		case "assistant_message":
+			// Consecutive text blocks belong to the same reply
+			if n := len(page.Entries); n > 0 && page.Entries[n-1].Kind == "assistant" {
+				page.Entries[n-1].Text += "\n\n" + str(event.Data, "content")
+				continue
+			}
+			page.Entries = append(page.Entries, shareEntry{Kind: "assistant", Title: "AI Assistant", Text: str(event.Data, "content"), Time: clock})
+		case "tool_use":
+			input, _ := json.MarshalIndent(event.Data["input"], "", "  ")
+			page.Entries = append(page.Entries, shareEntry{Kind: "tool", Title: "Tool call: " + str(event.Data, "tool_name"), Text: s.redactor.redact(string(input)), Time: clock})
+		case "tool_result":
+			result := str(event.Data, "result")
+			if len(result) > maxToolResult {
+				result = result[:maxToolResult] + "\n... (truncated)"
+			}
+			title := "Tool result: " + str(event.Data, "tool_name")
+			if failed, _ := event.Data["error"].(bool); failed {
+				title = "Tool error: " + str(event.Data, "tool_name")
+			}
+			page.Entries = append(page.Entries, shareEntry{Kind: "tool", Title: title, Text: result, Time: clock})
+		case "error":
+			page.Entries = append(page.Entries, shareEntry{Kind: "error", Title: "Error", Text: str(event.Data, "error"), Time: clock})
+		}
+	}
+	return page
+}
+
+// shareTemplate renders a read-only transcript
+var shareTemplate = template.Must(template.New("share").Parse(`<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <meta name="robots" content="noindex">
+    <title>Shared conversation {{.SessionID}}</title>
+    <style>
+        * { margin: 0; padding: 0; box-sizing: border-box; }
+        body {
+            font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif;
+            background: #f5f5f5;
+        }
+        .header {
+            background: linear-gradient(135deg, #667eea 0%, #764ba2 100%);
+            color: white;
+            padding: 20px;
+        }
+        .header h1 { font-size: 22px; margin-bottom: 5px; }
+        .header p { font-size: 13px; opacity: 0.9; }
+        .container { max-width: 1000px; margin: 0 auto; padding: 20px; }
+        .message {
+            margin-bottom: 15px;
+            padding: 15px;
+            border-radius: 8px;
+            background: white;
+            line-height: 1.6;
+        }
+        .message.user { background: #e3f2fd; margin-left: 15%; }
+        .message.assistant { margin-right: 15%; }
+        .message.error { background: #ffebee; color: #c62828; }
+        .message strong {
+            display: block;
+            margin-bottom: 8px;
+            font-size: 12px;
+            text-transform: uppercase;
+            opacity: 0.7;
+        }
+        .message .time { float: right; font-size: 12px; opacity: 0.5; }
+        .text { white-space: pre-wrap; word-wrap: break-word; }
+        details.tool { margin: 0 15% 15px 0; font-size: 13px; color: #555; }
+        details.tool summary { cursor: pointer; padding: 6px 0; }
+        details.tool pre {
+            background: #282c34;
+            color: #abb2bf;
+            padding: 12px;
+            border-radius: 5px;
+            overflow-x: auto;
+            white-space: pre-wrap;
+        }
+    </style>
+</head>
+<body>
+    <div class="header">
+        <h1>🤖 Shared conversation</h1>
+        <p>{{if .UserName}}{{.UserName}} · {{end}}{{if .Target}}{{.Target}} · {{end}}{{.Started}}</p>
+        <p>Read-only link, valid until {{.ExpiresAt.Format "2006-01-02 15:04 MST"}}</p>
+    </div>
+    <div class="container">
+        {{range .Entries}}{{if eq .Kind "tool"}}
+        <details class="tool"><summary>🔧 {{.Title}} <span class="time">{{.Time}}</span></summary><pre>{{.Text}}</pre></details>
+        {{else}}
+        <div class="message {{.Kind}}"><span class="time">{{.Time}}</span><strong>{{.Title}}</strong><div class="text">{{.Text}}</div></div>
+        {{end}}{{else}}
+        <p>This conversation has no messages.</p>
+        {{end}}
+    </div>
+</body>
+</html>
+`))
@@ -0,0 +1,104 @@
+package aiassistant
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"unicode/utf8"
+)
+
+// sourceLanguages maps file extensions to the languages the viewer highlights
+var sourceLanguages = map[string]string{
+	".go": "go", ".js": "javascript", ".mjs": "javascript", ".jsx": "javascript",
+	".ts": "typescript", ".tsx": "typescript", ".py": "python", ".rb": "ruby",
+	".java": "java", ".kt": "kotlin", ".rs": "rust", ".c": "c", ".h": "c",
+	".cc": "cpp", ".cpp": "cpp", ".hpp": "cpp", ".cs": "csharp", ".php": "php",
+	".swift": "swift", ".scala": "scala", ".sh": "shell", ".bash": "shell",
+	".sql": "sql", ".yaml": "yaml", ".yml": "yaml", ".json": "json",
+	".toml": "toml", ".proto": "protobuf", ".graphql": "graphql",
+	".html": "html", ".css": "css", ".md": "markdown", ".tf": "hcl",
+}
+
+// sourceFile is the JSON response of /api/source
+type sourceFile struct {
+	Path     string `json:"path"`
+	Content  string `json:"content"`
+	Language string `json:"language"`           // "" if not highlighted
+	Revision string `json:"revision,omitempty"` // source revision the file was read from
+}
+
+// handleSource serves GET /api/source?path=&target=, the read-only source
+// of the files the assistant references. Guardrail-denied files are refused,
+// as are secret files unless Config.SourceViewerSecrets is set, and the
+// content is redacted like what the model sees.
+func handleSource(w http.ResponseWriter, r *http.Request, a *Assistant) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	t := a.findTarget(r.URL.Query().Get("target"))
+	if t == nil {
+		http.Error(w, "unknown target", http.StatusBadRequest)
+		return
+	}
+
+	// References may be absolute paths inside the source tree
+	p := r.URL.Query().Get("path")
+	if root := strings.TrimRight(t.config.SourcePath, "/"); root != "" {
+		p = strings.TrimPrefix(p, root+"/")
+	}
+	if p == "" {
+		http.Error(w, "path is required", http.StatusBadRequest)
+		return
+	}
+	if a.targetGuardrails(t).deniedPath(p) != "" || a.secretSourceFile(p) {
+		http.Error(w, "this file is protected by policy", http.StatusForbidden)
+		return
+	}
+
+	name, data, err := t.toolRegistry.ReadSourceFile(p)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		http.Error(w, "file not found", http.StatusNotFound)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if a.targetGuardrails(t).deniedPath(name) != "" || a.secretSourceFile(name) {
+		http.Error(w, "this file is protected by policy", http.StatusForbidden)
+		return
+	}
+	if bytes.IndexByte(data, 0) >= 0 || !utf8.Valid(data) {
+		http.Error(w, "binary files cannot be displayed", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	content := string(data)
+	if a.redactor != nil {
+		content = a.redactor.redact(content)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(sourceFile{
+		Path:     name,
+		Content:  content,
+		Language: sourceLanguages[strings.ToLower(path.Ext(name))],
+		Revision: a.analyzedRevision(t),
+	})
+}
+
+// secretSourceFile reports whether the viewer must refuse a file that
+// commonly holds secrets
+func (a *Assistant) secretSourceFile(p string) bool {
+	if a.config.SourceViewerSecrets {
+		return false
+	}
+	secrets := newGuardrails(GuardrailsConfig{DeniedPaths: DefaultSecretPaths})
+	return isEnvFile(p) || secrets.deniedPath(p) != ""
+}
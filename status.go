@@ -0,0 +1,101 @@
+package aiassistant
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/willknow-ai/willknow-go/openapi"
+)
+
+// maxStatusArgChars limits a tool argument quoted in a status message
+const maxStatusArgChars = 60
+
+// toolStatus describes a tool call as a short progress message for the user,
+// e.g. "Reading auth.go…" or "Calling listOrders API…"
+func toolStatus(a *Assistant, session *Session, name string, input map[string]interface{}) string {
+	arg := func(key string) string {
+		s, _ := input[key].(string)
+		s = strings.Join(strings.Fields(s), " ")
+		if runes := []rune(s); len(runes) > maxStatusArgChars {
+			s = string(runes[:maxStatusArgChars]) + "…"
+		}
+		return s
+	}
+
+	switch name {
+	case "read_file":
+		return fmt.Sprintf("Reading %s…", path.Base(arg("file_path")))
+	case "grep":
+		return fmt.Sprintf("Searching code for %q…", arg("pattern"))
+	case "glob":
+		return fmt.Sprintf("Listing files matching %s…", arg("pattern"))
+	case "read_logs":
+		if q := arg("query"); q != "" {
+			return fmt.Sprintf("Searching logs for %q…", q)
+		}
+		return "Searching logs…"
+	case "search_code_index":
+		return "Searching the code index…"
+	case "search_runbooks":
+		return "Searching runbooks…"
+	case "run_snippet":
+		return "Running a Go snippet…"
+	case suggestActionsToolName:
+		return "Preparing suggested actions…"
+	case writeTestFileToolName:
+		return "Writing a test file…"
+	case runGoTestToolName:
+		return "Running Go tests…"
+	case "find_owners":
+		return "Looking up code owners…"
+	case "compare_deployed_source":
+		return "Comparing the deployed source with main…"
+	case "search_knowledge_base":
+		return "Searching past incidents…"
+	case "get_sentry_issue":
+		return "Fetching the Sentry issue…"
+	case "get_trace":
+		return "Fetching the trace…"
+	case "query_metrics":
+		return "Querying metrics…"
+	case "describe_schema":
+		return "Inspecting the database schema…"
+	case "inspect_redis":
+		return "Inspecting Redis…"
+	case "inspect_kafka":
+		return "Inspecting Kafka…"
+	case "inspect_kubernetes":
+		return "Inspecting Kubernetes…"
+	case "create_github_issue", "create_gitlab_issue":
+		return "Creating an issue…"
+	case "create_github_pull_request":
+		return "Opening a pull request…"
+	case "comment_gitlab_merge_request":
+		return "Commenting on the merge request…"
+	case "record_root_cause":
+		return "Recording the root cause…"
+	case memoryToolName:
+		return "Checking memories…"
+	case callAgentToolName:
+		return fmt.Sprintf("Asking %s…", arg("agent"))
+	case exportLogsToolName:
+		return "Preparing a log export…"
+	case listWorkspaceToolName:
+		return "Listing workspace files…"
+	case writeWorkspaceToolName:
+		return fmt.Sprintf("Saving %s…", arg("name"))
+	case replayRequestToolName:
+		if id := arg("request_id"); id != "" {
+			return fmt.Sprintf("Replaying request %s…", id)
+		}
+		return "Listing captured requests…"
+	}
+
+	// Host API, gRPC and GraphQL calls
+	if openapi.FindTool(a.sessionTarget(session).apiTools, name) != nil ||
+		a.grpcService.FindTool(name) != nil || a.graphqlAPI.FindTool(name) != nil {
+		return fmt.Sprintf("Calling %s API…", name)
+	}
+	return fmt.Sprintf("Running %s…", name)
+}
@@ -0,0 +1,195 @@
+package aiassistant
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/willknow-ai/willknow-go/provider"
+)
+
+const (
+	// suggestActionsToolName offers follow-up actions with the answer
+	suggestActionsToolName = "suggest_actions"
+	// suggestionAction is the ChatMessage action running a suggested action
+	suggestionAction = "suggestion"
+	// maxSuggestedActions limits the actions offered with one answer
+	maxSuggestedActions = 4
+)
+
+// Suggested action types
+const (
+	// SuggestionOpenFile opens a file in the UI's source viewer
+	SuggestionOpenFile = "open_file"
+	// SuggestionRerunLogsQuery searches the logs again, e.g. to check a fix
+	SuggestionRerunLogsQuery = "rerun_logs_query"
+	// SuggestionCreateTicket files an issue with the GitHub or GitLab tool
+	SuggestionCreateTicket = "create_ticket"
+	// SuggestionFollowUp asks a follow-up question
+	SuggestionFollowUp = "follow_up"
+)
+
+// SuggestedAction is a follow-up the assistant offers with an answer,
+// rendered as a button by the UI. open_file runs in the UI; the others are
+// sent back as the suggestion of a chat message and run with the tools.
+type SuggestedAction struct {
+	Type  string `json:"type"`
+	Label string `json:"label"` // button text
+
+	// open_file
+	FilePath string `json:"file_path,omitempty"`
+	Line     int    `json:"line,omitempty"`
+
+	// rerun_logs_query
+	Query     string `json:"query,omitempty"`
+	Filter    string `json:"filter,omitempty"`
+	StartTime string `json:"start_time,omitempty"`
+	EndTime   string `json:"end_time,omitempty"`
+
+	// create_ticket
+	Title string `json:"title,omitempty"`
+	Body  string `json:"body,omitempty"`
+
+	// follow_up
+	Question string `json:"question,omitempty"`
+}
+
+// validate checks that the action has what its type needs and can run for t
+func (s SuggestedAction) validate(a *Assistant, t *target) error {
+	if strings.TrimSpace(s.Label) == "" {
+		return fmt.Errorf("label is required")
+	}
+	switch s.Type {
+	case SuggestionOpenFile:
+		if s.FilePath == "" {
+			return fmt.Errorf("open_file needs file_path")
+		}
+	case SuggestionRerunLogsQuery:
+		if s.Query == "" {
+			return fmt.Errorf("rerun_logs_query needs query")
+		}
+		if !t.toolRegistry.HasLogs() {
+			return fmt.Errorf("no logs are configured")
+		}
+	case SuggestionCreateTicket:
+		if s.Title == "" || s.Body == "" {
+			return fmt.Errorf("create_ticket needs title and body")
+		}
+		if a.ticketTool(t) == "" {
+			return fmt.Errorf("no GitHub or GitLab integration is configured")
+		}
+	case SuggestionFollowUp:
+		if s.Question == "" {
+			return fmt.Errorf("follow_up needs question")
+		}
+	default:
+		return fmt.Errorf("unknown action type %q", s.Type)
+	}
+	return nil
+}
+
+// ticketTool returns the tool that files tickets for t, or ""
+func (a *Assistant) ticketTool(t *target) string {
+	for _, tool := range t.toolRegistry.GetToolDefinitions() {
+		if tool.Name == "create_github_issue" || tool.Name == "create_gitlab_issue" {
+			return tool.Name
+		}
+	}
+	return ""
+}
+
+// suggestActions records the actions offered with the current answer
+func (a *Assistant) suggestActions(session *Session, params map[string]interface{}) (string, error) {
+	raw, _ := json.Marshal(params["actions"])
+	var actions []SuggestedAction
+	if err := json.Unmarshal(raw, &actions); err != nil || len(actions) == 0 {
+		return "", fmt.Errorf("actions must be a non-empty list of actions")
+	}
+	if len(actions) > maxSuggestedActions {
+		return "", fmt.Errorf("offer at most %d actions", maxSuggestedActions)
+	}
+	t := a.sessionTarget(session)
+	for i, action := range actions {
+		if err := action.validate(a, t); err != nil {
+			return "", fmt.Errorf("action %d: %w", i+1, err)
+		}
+	}
+
+	session.mu.Lock()
+	session.suggestions = actions
+	session.mu.Unlock()
+	return fmt.Sprintf("%d actions will be shown as buttons under your answer. Do not repeat them in the answer text.", len(actions)), nil
+}
+
+// answerSuggestions returns the actions offered with the latest answer
+func (s *Session) answerSuggestions() []SuggestedAction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]SuggestedAction(nil), s.suggestions...)
+}
+
+// suggestionPrompt returns the question that runs a suggested action
+func (a *Assistant) suggestionPrompt(session *Session, s *SuggestedAction) (string, error) {
+	if s == nil {
+		return "", fmt.Errorf("the suggestion is missing")
+	}
+	t := a.sessionTarget(session)
+	if err := s.validate(a, t); err != nil {
+		return "", err
+	}
+	switch s.Type {
+	case SuggestionRerunLogsQuery:
+		params, _ := json.Marshal(struct {
+			Query     string `json:"query"`
+			Filter    string `json:"filter,omitempty"`
+			StartTime string `json:"start_time,omitempty"`
+			EndTime   string `json:"end_time,omitempty"`
+		}{s.Query, s.Filter, s.StartTime, s.EndTime})
+		return fmt.Sprintf("Run the suggested action %q: search the logs again with read_logs using exactly these parameters: %s. Then say what the results show, and what changed compared with the earlier search.", s.Label, params), nil
+	case SuggestionCreateTicket:
+		return fmt.Sprintf("Run the suggested action %q: create the ticket with %s, using this title and body as they are.\n\nTitle: %s\n\nBody:\n%s\n\nReply with the link to the ticket.", s.Label, a.ticketTool(t), s.Title, s.Body), nil
+	case SuggestionFollowUp:
+		return s.Question, nil
+	}
+	return "", fmt.Errorf("%s actions run in the UI", s.Type)
+}
+
+// suggestActionsToolDefinition describes suggest_actions to the model
+func suggestActionsToolDefinition() provider.Tool {
+	return provider.Tool{
+		Name:        suggestActionsToolName,
+		Description: fmt.Sprintf("Offer up to %d follow-up actions as buttons under your answer, so the user can continue in one click: open_file (open the faulty code in the source viewer), rerun_logs_query (search the logs again, e.g. to check whether a fix worked), create_ticket (file an issue with the diagnosis, if GitHub or GitLab is configured) and follow_up (ask a natural next question). Call it at most once, just before your final answer, and only when there are obvious next steps.", maxSuggestedActions),
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"actions": map[string]interface{}{
+					"type": "array",
+					"items": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"type": map[string]interface{}{
+								"type": "string",
+								"enum": []string{SuggestionOpenFile, SuggestionRerunLogsQuery, SuggestionCreateTicket, SuggestionFollowUp},
+							},
+							"label": map[string]interface{}{
+								"type":        "string",
+								"description": "Short button text, e.g. 'Open refund.go:42' or 'Check the logs again'",
+							},
+							"file_path":  map[string]interface{}{"type": "string", "description": "open_file: path relative to the source directory"},
+							"line":       map[string]interface{}{"type": "integer", "description": "open_file: optional line to show"},
+							"query":      map[string]interface{}{"type": "string", "description": "rerun_logs_query: read_logs query"},
+							"filter":     map[string]interface{}{"type": "string", "description": "rerun_logs_query: optional read_logs filter"},
+							"start_time": map[string]interface{}{"type": "string", "description": "rerun_logs_query: optional start time (RFC3339 or relative, e.g. '-1h')"},
+							"end_time":   map[string]interface{}{"type": "string", "description": "rerun_logs_query: optional end time"},
+							"title":      map[string]interface{}{"type": "string", "description": "create_ticket: issue title"},
+							"body":       map[string]interface{}{"type": "string", "description": "create_ticket: issue body in Markdown, with the diagnosis and evidence"},
+							"question":   map[string]interface{}{"type": "string", "description": "follow_up: the question to ask"},
+						},
+						"required": []string{"type", "label"},
+					},
+				},
+			},
+			"required": []string{"actions"},
+		},
+	}
+}
@@ -0,0 +1,215 @@
+package aiassistant
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/willknow-ai/willknow-go/analyzer"
+	"github.com/willknow-ai/willknow-go/indexer"
+	"github.com/willknow-ai/willknow-go/openapi"
+	"github.com/willknow-ai/willknow-go/provider"
+	"github.com/willknow-ai/willknow-go/tools"
+)
+
+// defaultTargetName names the target built from the top-level Config fields
+const defaultTargetName = "default"
+
+// TargetConfig describes one additional service served by the assistant.
+// Each target has its own source tree, log files, API spec and code index;
+// all other integrations (GitHub, Sentry, tracing, remote log sources, ...)
+// are shared with the top-level configuration.
+type TargetConfig struct {
+	// Name identifies the target in the UI and the chat API (e.g., "billing").
+	// Must be unique and must not be "default".
+	Name string
+
+	// Description is shown in the target selector and the system prompt
+	Description string
+
+	// SourcePath is the path to the target's source code: a directory or a
+	// .zip, .tar, .tar.gz or .tgz archive
+	SourcePath string
+
+	// SourceFS serves the target's source code instead of SourcePath,
+	// e.g. an embed.FS compiled into the binary
+	SourceFS fs.FS
+
+	// LogFiles are the paths to the target's log files.
+	// If empty, the assistant will try to auto-detect them on startup.
+	LogFiles []string
+
+	// APISpec is the path to the target's OpenAPI spec file (YAML or JSON)
+	// Default: "" (no API tools)
+	APISpec string
+
+	// HostBaseURL is the base URL for the target's API calls.
+	// Defaults to the first server URL in APISpec.
+	HostBaseURL string
+
+	// EnableCodeIndex builds a code index for the target.
+	// The index is cached to ./code_index_<name>.json with 24-hour TTL.
+	EnableCodeIndex bool
+}
+
+// target holds the per-service tools a session is routed to
+type target struct {
+	config       TargetConfig
+	toolRegistry *tools.Registry
+	codeIndex    *indexer.CodeIndex
+	apiTools     []*openapi.APITool
+	apiSpec      *openapi.ParsedSpec
+	revision     string // git commit of the source, if known
+
+	// Set for the targets of environments selectable per conversation
+	environment *EnvironmentConfig
+	provider    provider.Provider // the environment's model; nil uses the assistant's
+	guardrails  *guardrails       // the environment's policies, if it sets Guardrails
+}
+
+var targetNameValid = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// newTarget builds a target whose registry shares every integration of base
+// but reads its own source tree, logs and code index
+func newTarget(config TargetConfig, base *tools.Registry, logSources []tools.LogSource, aiProvider provider.Provider, buildIndex bool) (*target, error) {
+	source := config.SourceFS
+	if source == nil {
+		if config.SourcePath == "" {
+			return nil, fmt.Errorf("target %s: SourcePath or SourceFS is required", config.Name)
+		}
+		var err error
+		if source, err = tools.OpenSource(config.SourcePath); err != nil {
+			return nil, fmt.Errorf("target %s: %w", config.Name, err)
+		}
+	}
+
+	t := &target{
+		config:       config,
+		toolRegistry: base.ForSource(source),
+		revision:     gitRevision(source),
+	}
+	for _, source := range logSources {
+		t.toolRegistry.RegisterLogSource(source)
+	}
+
+	// Auto-detect log files if not provided
+	if len(config.LogFiles) == 0 && len(logSources) == 0 {
+		log.Printf("[AI Assistant] Target %s: no log files configured, attempting auto-detection...", config.Name)
+		logFiles, err := analyzer.DetectLogFiles(aiProvider, t.toolRegistry, config.SourcePath)
+		if err != nil {
+			log.Printf("[AI Assistant] Target %s: Warning: Failed to auto-detect log files: %v", config.Name, err)
+		} else {
+			t.config.LogFiles = logFiles
+			log.Printf("[AI Assistant] Target %s: auto-detected log files: %v", config.Name, logFiles)
+		}
+	}
+	if len(t.config.LogFiles) > 0 || len(logSources) == 0 {
+		t.toolRegistry.RegisterLogTool(t.config.LogFiles)
+	}
+
+	if config.EnableCodeIndex {
+		t.codeIndex = loadCodeIndex("./code_index_"+config.Name+".json", source, config.SourcePath, aiProvider, buildIndex)
+		if t.codeIndex != nil {
+			t.toolRegistry.RegisterCodeIndexTool(t.codeIndex)
+		}
+	}
+
+	if config.APISpec != "" {
+		log.Printf("[AI Assistant] Target %s: loading OpenAPI spec: %s", config.Name, config.APISpec)
+		spec, err := openapi.ParseSpec(config.APISpec)
+		if err != nil {
+			return nil, fmt.Errorf("target %s: failed to parse OpenAPI spec: %w", config.Name, err)
+		}
+		t.apiSpec = spec
+		t.apiTools = spec.Tools
+		if t.config.HostBaseURL == "" {
+			t.config.HostBaseURL = spec.ServerURL
+		}
+		log.Printf("[AI Assistant] Target %s: loaded %d API tools from OpenAPI spec", config.Name, len(spec.Tools))
+	}
+
+	return t, nil
+}
+
+// loadCodeIndex loads the cached code index at indexPath if it is recent,
+// otherwise builds and caches a new one from source if build is set (false
+// when the budget is running low). Returns nil if indexing fails.
+func loadCodeIndex(indexPath string, source fs.FS, sourcePath string, aiProvider provider.Provider, build bool) *indexer.CodeIndex {
+	const maxAge = 24 * time.Hour
+
+	if indexer.IsIndexRecent(indexPath, maxAge) {
+		log.Println("[AI Assistant] Loading existing code index...")
+		codeIndex, err := indexer.LoadIndex(indexPath)
+		if err == nil {
+			log.Printf("[AI Assistant] Code index loaded: %d files indexed", len(codeIndex.Files))
+			return codeIndex
+		}
+		log.Printf("[AI Assistant] Warning: Failed to load code index: %v", err)
+		log.Println("[AI Assistant] Will build new index...")
+	}
+
+	if !build {
+		log.Println("[AI Assistant] Budget running low; skipping code index build")
+		return nil
+	}
+	log.Println("[AI Assistant] Building code index (this may take a few minutes)...")
+	codeIndex, err := indexer.BuildCodeIndexFS(source, sourcePath, aiProvider)
+	if err != nil {
+		log.Printf("[AI Assistant] Warning: Failed to build code index: %v", err)
+		return nil
+	}
+	log.Printf("[AI Assistant] Code index built: %d files indexed", len(codeIndex.Files))
+
+	// Save index for future use
+	if err := indexer.SaveIndex(indexPath, codeIndex); err != nil {
+		log.Printf("[AI Assistant] Warning: Failed to save code index: %v", err)
+	}
+	return codeIndex
+}
+
+// findTarget returns the target with the given name ("" selects the default),
+// or nil if there is none
+func (a *Assistant) findTarget(name string) *target {
+	if name == "" {
+		return a.targets[0]
+	}
+	for _, t := range a.targets {
+		if t.config.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// sessionTarget returns the target a session's tool calls are routed to
+func (a *Assistant) sessionTarget(session *Session) *target {
+	if session.target != nil {
+		return session.target
+	}
+	return a.targets[0]
+}
+
+// TargetInfo describes a target for GET /api/targets
+type TargetInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Default     bool   `json:"default,omitempty"`
+}
+
+// handleTargets lists the targets sessions can select
+func handleTargets(w http.ResponseWriter, r *http.Request, a *Assistant) {
+	var list []TargetInfo
+	for i, t := range a.targets {
+		list = append(list, TargetInfo{
+			Name:        t.config.Name,
+			Description: t.config.Description,
+			Default:     i == 0,
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
@@ -0,0 +1,495 @@
+package aiassistant
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/willknow-ai/willknow-go/provider"
+)
+
+const (
+	teamsOpenIDConfigURL = "https://login.botframework.com/v1/.well-known/openidconfiguration"
+	teamsTokenURL        = "https://login.microsoftonline.com/botframework.com/oauth2/v2.0/token"
+	teamsTokenScope      = "https://api.botframework.com/.default"
+	teamsIssuer          = "https://api.botframework.com"
+)
+
+// TeamsConfig configures the Microsoft Teams connector (Bot Framework).
+//
+// When AppID is set, the assistant exposes POST /willknow/teams/messages as the
+// bot messaging endpoint. Register that URL in the Azure Bot resource.
+type TeamsConfig struct {
+	// AppID is the Microsoft App ID of the Azure Bot registration.
+	// Default: "" (Teams connector disabled)
+	AppID string
+
+	// AppPassword is the client secret of the Azure Bot registration.
+	// Used to obtain tokens for replying to Teams conversations.
+	AppPassword string
+
+	// MapUser maps the Teams (Azure AD) identity of the sender to a User.
+	// Return an error to reject the user.
+	// Default: User{ID: AADObjectID, Name: Name}
+	MapUser func(identity TeamsIdentity) (*User, error)
+}
+
+// TeamsIdentity is the SSO identity of a Teams user as sent by Bot Framework
+type TeamsIdentity struct {
+	ID          string // Teams user ID (29:...)
+	AADObjectID string // Azure AD object ID
+	Name        string // display name
+	TenantID    string // Azure AD tenant ID
+}
+
+// teamsActivity is the subset of a Bot Framework activity used by the connector
+type teamsActivity struct {
+	Type       string `json:"type"`
+	ID         string `json:"id"`
+	Text       string `json:"text"`
+	ServiceURL string `json:"serviceUrl"`
+	From       struct {
+		ID          string `json:"id"`
+		Name        string `json:"name"`
+		AADObjectID string `json:"aadObjectId"`
+	} `json:"from"`
+	Recipient struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"recipient"`
+	Conversation struct {
+		ID       string `json:"id"`
+		TenantID string `json:"tenantId"`
+	} `json:"conversation"`
+	ChannelData struct {
+		Tenant struct {
+			ID string `json:"id"`
+		} `json:"tenant"`
+	} `json:"channelData"`
+}
+
+// teamsConnector handles Bot Framework traffic for Microsoft Teams
+type teamsConnector struct {
+	a        *Assistant
+	config   TeamsConfig
+	sessions *httpSessionStore
+	client   *http.Client
+
+	mu          sync.Mutex
+	keys        map[string]*rsa.PublicKey // kid → signing key
+	keysFetched time.Time
+	token       string
+	tokenExpiry time.Time
+
+	turnsMu sync.Mutex
+	turns   map[string]*sync.Mutex // session key → lock held during a turn
+}
+
+// newTeamsConnector creates a Teams connector for the assistant
+func newTeamsConnector(a *Assistant) *teamsConnector {
+	return &teamsConnector{
+		a:        a,
+		config:   a.config.Teams,
+		sessions: &httpSessionStore{sessions: make(map[string]*Session)},
+		client:   &http.Client{Timeout: 30 * time.Second},
+		turns:    make(map[string]*sync.Mutex),
+	}
+}
+
+// handleMessages handles POST /willknow/teams/messages from Bot Framework
+func (tc *teamsConnector) handleMessages(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	serviceURL, err := tc.verifyRequest(r)
+	if err != nil {
+		log.Printf("[Teams] Rejected request: %v", err)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var activity teamsActivity
+	if err := json.NewDecoder(r.Body).Decode(&activity); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	// The reply carries the bot token, so it may only go to the service
+	// URL Bot Framework signed
+	if !strings.EqualFold(strings.TrimRight(activity.ServiceURL, "/"), strings.TrimRight(serviceURL, "/")) {
+		log.Printf("[Teams] Rejected request: service URL %q does not match the token", activity.ServiceURL)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Bot Framework expects a quick acknowledgement; the answer is sent asynchronously
+	w.WriteHeader(http.StatusOK)
+
+	if activity.Type != "message" {
+		return
+	}
+	go tc.handleActivity(activity)
+}
+
+// handleActivity runs a chat turn for an incoming Teams message and posts the reply
+func (tc *teamsConnector) handleActivity(activity teamsActivity) {
+	text := cleanTeamsText(activity.Text)
+	if text == "" {
+		return
+	}
+
+	tenantID := activity.Conversation.TenantID
+	if tenantID == "" {
+		tenantID = activity.ChannelData.Tenant.ID
+	}
+	identity := TeamsIdentity{
+		ID:          activity.From.ID,
+		AADObjectID: activity.From.AADObjectID,
+		Name:        activity.From.Name,
+		TenantID:    tenantID,
+	}
+
+	user, err := tc.mapUser(identity)
+	if err != nil {
+		log.Printf("[Teams] User %s (%s) rejected: %v", identity.Name, identity.AADObjectID, err)
+		tc.reply(activity, "Sorry, you are not authorized to use this assistant.")
+		return
+	}
+
+	// One session per Teams conversation and user, one turn at a time
+	key := activity.Conversation.ID + "|" + identity.ID
+	unlock := tc.lockTurn(key)
+	defer unlock()
+	session := tc.sessions.get(key)
+	if session == nil {
+		sessionID := generateSessionID()
+		logFile, _ := initSessionLog(sessionID, tc.a.transcripts)
+		session = &Session{
+			ID:       sessionID,
+			User:     user,
+			messages: []provider.Message{},
+			logFile:  logFile,
+		}
+		tc.sessions.set(key, session)
+		session.logEvent("session_start", map[string]interface{}{
+			"channel":   "teams",
+			"user_id":   user.ID,
+			"user_name": user.Name,
+			"tenant_id": identity.TenantID,
+		})
+		log.Printf("[Teams Session %s] Started (user: %s)", sessionID, user.ID)
+	}
+
+	session.mu.Lock()
+	session.messages = append(session.messages, provider.Message{
+		Role: "user",
+		Content: []provider.ContentBlock{
+			{Type: "text", Text: text},
+		},
+	})
+	session.mu.Unlock()
+	session.logEvent("user_message", map[string]interface{}{"content": text})
+
+	var responseText string
+	if err := processChatHTTP(tc.a, session, &responseText); err != nil {
+		log.Printf("[Teams Session %s] Error: %v", session.ID, err)
+		responseText = fmt.Sprintf("Error: %v", err)
+	}
+	if responseText == "" {
+		return
+	}
+
+	if err := tc.reply(activity, responseText); err != nil {
+		log.Printf("[Teams Session %s] Failed to send reply: %v", session.ID, err)
+	}
+}
+
+// lockTurn waits for the previous turn of a session to finish, so quick
+// messages do not interleave their turns in the conversation
+func (tc *teamsConnector) lockTurn(key string) (unlock func()) {
+	tc.turnsMu.Lock()
+	turn := tc.turns[key]
+	if turn == nil {
+		turn = &sync.Mutex{}
+		tc.turns[key] = turn
+	}
+	tc.turnsMu.Unlock()
+	turn.Lock()
+	return turn.Unlock
+}
+
+// mapUser converts a Teams identity into a User via the configured callback
+func (tc *teamsConnector) mapUser(identity TeamsIdentity) (*User, error) {
+	if tc.config.MapUser != nil {
+		user, err := tc.config.MapUser(identity)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, fmt.Errorf("no user returned")
+		}
+		return user, nil
+	}
+
+	id := identity.AADObjectID
+	if id == "" {
+		id = identity.ID
+	}
+	return &User{ID: id, Name: identity.Name}, nil
+}
+
+// reply posts a message into the conversation the activity came from
+func (tc *teamsConnector) reply(activity teamsActivity, text string) error {
+	token, err := tc.getToken()
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"type":       "message",
+		"text":       text,
+		"textFormat": "markdown",
+		"from":       activity.Recipient,
+		"recipient":  activity.From,
+		"replyToId":  activity.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal reply: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/v3/conversations/%s/activities/%s",
+		strings.TrimRight(activity.ServiceURL, "/"),
+		url.PathEscape(activity.Conversation.ID),
+		url.PathEscape(activity.ID))
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(string(body)))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := tc.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send reply: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("reply failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+// getToken returns a cached Bot Framework access token, refreshing it when expired
+func (tc *teamsConnector) getToken() (string, error) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	if tc.token != "" && time.Now().Before(tc.tokenExpiry) {
+		return tc.token, nil
+	}
+
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {tc.config.AppID},
+		"client_secret": {tc.config.AppPassword},
+		"scope":         {teamsTokenScope},
+	}
+	resp, err := tc.client.PostForm(teamsTokenURL, form)
+	if err != nil {
+		return "", fmt.Errorf("failed to request token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var tokenResp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return "", fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || tokenResp.AccessToken == "" {
+		return "", fmt.Errorf("token request failed with status %d", resp.StatusCode)
+	}
+
+	tc.token = tokenResp.AccessToken
+	// Refresh a little early to avoid using a token right at expiry
+	tc.tokenExpiry = time.Now().Add(time.Duration(tokenResp.ExpiresIn)*time.Second - time.Minute)
+	return tc.token, nil
+}
+
+// verifyRequest validates the Bot Framework JWT in the Authorization header
+// and returns the service URL it was issued for
+func (tc *teamsConnector) verifyRequest(r *http.Request) (string, error) {
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		return "", fmt.Errorf("missing bearer token")
+	}
+	parts := strings.Split(strings.TrimPrefix(authHeader, "Bearer "), ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeJWTSegment(parts[0], &header); err != nil {
+		return "", fmt.Errorf("invalid token header: %w", err)
+	}
+	if header.Alg != "RS256" {
+		return "", fmt.Errorf("unsupported signing algorithm: %s", header.Alg)
+	}
+
+	key, err := tc.signingKey(header.Kid)
+	if err != nil {
+		return "", err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", fmt.Errorf("invalid token signature: %w", err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return "", fmt.Errorf("signature verification failed: %w", err)
+	}
+
+	var claims struct {
+		Iss        string `json:"iss"`
+		Aud        string `json:"aud"`
+		Exp        int64  `json:"exp"`
+		Nbf        int64  `json:"nbf"`
+		ServiceURL string `json:"serviceurl"`
+	}
+	if err := decodeJWTSegment(parts[1], &claims); err != nil {
+		return "", fmt.Errorf("invalid token claims: %w", err)
+	}
+	if claims.Iss != teamsIssuer {
+		return "", fmt.Errorf("unexpected issuer: %s", claims.Iss)
+	}
+	if claims.Aud != tc.config.AppID {
+		return "", fmt.Errorf("unexpected audience: %s", claims.Aud)
+	}
+	// Allow five minutes of clock skew as recommended by Bot Framework
+	now := time.Now()
+	if now.After(time.Unix(claims.Exp, 0).Add(5 * time.Minute)) {
+		return "", fmt.Errorf("token expired")
+	}
+	if claims.Nbf != 0 && now.Add(5*time.Minute).Before(time.Unix(claims.Nbf, 0)) {
+		return "", fmt.Errorf("token not valid yet")
+	}
+	if claims.ServiceURL == "" {
+		return "", fmt.Errorf("token has no serviceurl claim")
+	}
+	return claims.ServiceURL, nil
+}
+
+// signingKey returns the Bot Framework signing key with the given key ID,
+// refreshing the key set once a day or when an unknown key ID is seen
+func (tc *teamsConnector) signingKey(kid string) (*rsa.PublicKey, error) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	if key, ok := tc.keys[kid]; ok && time.Since(tc.keysFetched) < 24*time.Hour {
+		return key, nil
+	}
+
+	keys, err := tc.fetchSigningKeys()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch signing keys: %w", err)
+	}
+	tc.keys = keys
+	tc.keysFetched = time.Now()
+
+	key, ok := keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key: %s", kid)
+	}
+	return key, nil
+}
+
+// fetchSigningKeys downloads the Bot Framework JWKS via its OpenID configuration
+func (tc *teamsConnector) fetchSigningKeys() (map[string]*rsa.PublicKey, error) {
+	var openIDConfig struct {
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := tc.getJSON(teamsOpenIDConfigURL, &openIDConfig); err != nil {
+		return nil, err
+	}
+
+	var jwks struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := tc.getJSON(openIDConfig.JWKSURI, &jwks); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range jwks.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	return keys, nil
+}
+
+// getJSON fetches a URL and decodes the JSON response into v
+func (tc *teamsConnector) getJSON(u string, v interface{}) error {
+	resp, err := tc.client.Get(u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s failed with status %d", u, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// decodeJWTSegment base64url-decodes a JWT segment and unmarshals it into v
+func decodeJWTSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+var teamsMentionRegex = regexp.MustCompile(`(?s)<at>.*?</at>`)
+
+// cleanTeamsText removes bot @mentions and surrounding whitespace from a Teams message
+func cleanTeamsText(text string) string {
+	return strings.TrimSpace(teamsMentionRegex.ReplaceAllString(text, ""))
+}
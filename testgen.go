@@ -0,0 +1,353 @@
+package aiassistant
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/willknow-ai/willknow-go/provider"
+	"github.com/willknow-ai/willknow-go/tools"
+)
+
+const (
+	// generateTestAction asks for a failing test reproducing the diagnosed bug
+	generateTestAction = "generate_test"
+	// writeTestFileToolName writes a test file into the source tree
+	writeTestFileToolName = "write_test_file"
+	// runGoTestToolName runs a test of the source with go test
+	runGoTestToolName = "run_go_test"
+	// defaultGoTestTimeout limits a go test run
+	defaultGoTestTimeout = 2 * time.Minute
+	// maxGoTestOutput limits the go test output returned to the model
+	maxGoTestOutput = 8000
+	// goTestMemoryMB limits the memory of the compiler and test processes
+	goTestMemoryMB = 2048
+	// goTestFileMB limits the size of files they may write
+	goTestFileMB = 512
+)
+
+// TestGenerationConfig adds a "Write failing test" action to answers: the
+// assistant writes a Go test reproducing the bug it diagnosed, saves it to
+// the session workspace (or into the source tree with WriteToSource) and,
+// with RunTests, runs it to confirm that it fails.
+type TestGenerationConfig struct {
+	// Enabled shows the action. The test is saved to the session workspace,
+	// so Workspace.Dir is required unless WriteToSource is set.
+	Enabled bool
+
+	// ModuleDir is the Go module the tests belong to, with its go.mod
+	// Default: SourcePath
+	ModuleDir string
+
+	// WriteToSource adds the write_test_file tool, which writes new
+	// _test.go files into ModuleDir instead of the workspace. Existing
+	// files are never overwritten.
+	WriteToSource bool
+
+	// RunTests adds the run_go_test tool, which runs go test in ModuleDir
+	// with the generated test. go test runs without the assistant's
+	// environment variables and with CPU, memory and file size limits (they
+	// need a Unix shell), but the test code is written by the model and can
+	// still reach the network and the file system; only enable it where
+	// that is acceptable (e.g. a sandboxed checkout), or together with
+	// Consent.
+	RunTests bool
+
+	// Timeout limits a test run
+	// Default: 2 minutes
+	Timeout time.Duration
+}
+
+// testGenerator runs the tools of the test generation action
+type testGenerator struct {
+	config     TestGenerationConfig
+	workspaces *workspaceStore
+	env        []string // environment of go test runs
+	shell      string   // for resource limits; empty if unavailable
+}
+
+// newTestGenerator returns nil unless test generation is enabled
+func newTestGenerator(config TestGenerationConfig, sourcePath string, workspaces *workspaceStore) (*testGenerator, error) {
+	if !config.Enabled {
+		return nil, nil
+	}
+	if config.ModuleDir == "" {
+		config.ModuleDir = sourcePath
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultGoTestTimeout
+	}
+	if !config.WriteToSource && workspaces == nil {
+		return nil, fmt.Errorf("TestGeneration needs Workspace.Dir unless WriteToSource is set")
+	}
+	if config.WriteToSource || config.RunTests {
+		if _, err := os.Stat(filepath.Join(config.ModuleDir, "go.mod")); err != nil {
+			return nil, fmt.Errorf("TestGeneration.ModuleDir %q is not a Go module: %w", config.ModuleDir, err)
+		}
+	}
+	g := &testGenerator{config: config, workspaces: workspaces}
+	if config.RunTests {
+		env, err := goTestEnv()
+		if err != nil {
+			return nil, err
+		}
+		g.env = env
+		if runtime.GOOS != "windows" {
+			g.shell, _ = exec.LookPath("sh")
+		}
+	}
+	return g, nil
+}
+
+// goTestEnv returns the environment of go test runs: only what go needs to
+// find its caches and tools, so the model's tests do not see the
+// assistant's credentials
+func goTestEnv() ([]string, error) {
+	out, err := exec.Command("go", "env", "GOPATH", "GOCACHE", "GOMODCACHE").Output()
+	if err != nil {
+		return nil, fmt.Errorf("TestGeneration.RunTests needs the go command: %w", err)
+	}
+	values := strings.Split(strings.TrimRight(string(out), "\n"), "\n")
+	if len(values) != 3 {
+		return nil, fmt.Errorf("unexpected go env output %q", out)
+	}
+	home, _ := os.UserHomeDir()
+	return []string{
+		"PATH=" + os.Getenv("PATH"),
+		"HOME=" + home,
+		"GOPATH=" + values[0],
+		"GOCACHE=" + values[1],
+		"GOMODCACHE=" + values[2],
+	}, nil
+}
+
+// prompt is the question the action asks
+func (g *testGenerator) prompt() string {
+	var b strings.Builder
+	b.WriteString("Write a Go test that reproduces the bug you diagnosed in this conversation. It must fail on the current code for the reason you found, and pass once the bug is fixed.\n\n")
+	b.WriteString("- Put it in the package of the faulty code (read a file of the package for its package clause and existing test helpers) and name the file after the bug, ending in _repro_test.go.\n")
+	b.WriteString("- Keep it minimal: one test function, only the standard library and packages the module already uses, no network or external services unless the bug needs them.\n")
+	b.WriteString("- Make the failure message state the expected and the actual behavior.\n")
+	if g.config.WriteToSource {
+		fmt.Fprintf(&b, "- Save it with %s.\n", writeTestFileToolName)
+	} else {
+		fmt.Fprintf(&b, "- Save it with %s.\n", writeWorkspaceToolName)
+	}
+	if g.config.RunTests {
+		fmt.Fprintf(&b, "- Run it with %s. If it does not compile, or passes, fix it and run it again (at most three attempts); a test that passes does not reproduce the bug.\n", runGoTestToolName)
+	}
+	b.WriteString("\nReply with the test code, where it was saved (with the download link, if any)")
+	if g.config.RunTests {
+		b.WriteString(", and the result of the run: whether it fails as expected, quoting the failure")
+	}
+	b.WriteString(".")
+	return b.String()
+}
+
+// actionPrompt returns the question an answer action asks
+func (a *Assistant) actionPrompt(session *Session, msg ChatMessage) (string, error) {
+	switch {
+	case msg.Action == generateTestAction && a.testGen != nil:
+		return a.testGen.prompt(), nil
+	case msg.Action == suggestionAction:
+		return a.suggestionPrompt(session, msg.Suggestion)
+	}
+	return "", fmt.Errorf("unknown action %q", msg.Action)
+}
+
+// answerActions lists the actions the UI offers under answers
+func (a *Assistant) answerActions() []string {
+	if a.testGen == nil {
+		return nil
+	}
+	return []string{generateTestAction}
+}
+
+// isTool reports whether name is an enabled test generation tool
+func (g *testGenerator) isTool(name string) bool {
+	if g == nil {
+		return false
+	}
+	return (name == writeTestFileToolName && g.config.WriteToSource) || (name == runGoTestToolName && g.config.RunTests)
+}
+
+// execute runs the write_test_file and run_go_test tools
+func (g *testGenerator) execute(session *Session, name string, params map[string]interface{}) (string, error) {
+	if name == writeTestFileToolName {
+		return g.writeTestFile(params)
+	}
+	return g.runTest(session, params)
+}
+
+// modulePath resolves a path relative to ModuleDir, refusing paths outside it
+func (g *testGenerator) modulePath(rel string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(rel))
+	if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the module", rel)
+	}
+	return filepath.Join(g.config.ModuleDir, clean), nil
+}
+
+// writeTestFile writes a new _test.go file into the module
+func (g *testGenerator) writeTestFile(params map[string]interface{}) (string, error) {
+	rel, _ := params["file_path"].(string)
+	content, _ := params["content"].(string)
+	if !strings.HasSuffix(rel, "_test.go") {
+		return "", fmt.Errorf("file_path must name a _test.go file")
+	}
+	if content == "" {
+		return "", fmt.Errorf("content is required")
+	}
+	path, err := g.modulePath(rel)
+	if err != nil {
+		return "", err
+	}
+	if info, err := os.Stat(filepath.Dir(path)); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("package directory %s does not exist", filepath.Dir(rel))
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if errors.Is(err, os.ErrExist) {
+		return "", fmt.Errorf("%s already exists; choose another name", rel)
+	}
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Wrote %s (%d bytes) into the source tree.", rel, len(content)), nil
+}
+
+// runTest runs go test for a package, adding a test file of the session's
+// workspace to the package through an overlay so the source is not modified
+func (g *testGenerator) runTest(session *Session, params map[string]interface{}) (string, error) {
+	pkg, _ := params["package"].(string)
+	run, _ := params["run"].(string)
+	testFile, _ := params["test_file"].(string)
+	if pkg == "" || run == "" {
+		return "", fmt.Errorf("package and run are required")
+	}
+	pkgDir, err := g.modulePath(pkg)
+	if err != nil {
+		return "", err
+	}
+
+	args := []string{"test", "-count=1", "-run", run}
+	if testFile != "" {
+		if g.workspaces == nil {
+			return "", fmt.Errorf("there is no workspace; omit test_file for tests written into the source tree")
+		}
+		if !workspaceFileName.MatchString(testFile) || !strings.HasSuffix(testFile, "_test.go") {
+			return "", fmt.Errorf("test_file must name a _test.go file of the workspace")
+		}
+		src, err := filepath.Abs(filepath.Join(g.workspaces.dir(session.ID), testFile))
+		if err != nil {
+			return "", err
+		}
+		if _, err := os.Stat(src); err != nil {
+			return "", fmt.Errorf("%s is not in the workspace; save it with %s first", testFile, writeWorkspaceToolName)
+		}
+		dst, err := filepath.Abs(filepath.Join(pkgDir, testFile))
+		if err != nil {
+			return "", err
+		}
+		overlay, err := os.CreateTemp("", "willknow-overlay-*.json")
+		if err != nil {
+			return "", err
+		}
+		defer os.Remove(overlay.Name())
+		err = json.NewEncoder(overlay).Encode(map[string]map[string]string{"Replace": {dst: src}})
+		overlay.Close()
+		if err != nil {
+			return "", err
+		}
+		args = append(args, "-overlay", overlay.Name())
+	}
+	rel, _ := filepath.Rel(g.config.ModuleDir, pkgDir)
+	args = append(args, "./"+filepath.ToSlash(rel))
+
+	ctx, cancel := context.WithTimeout(context.Background(), g.config.Timeout)
+	defer cancel()
+	cmd := tools.LimitedCommand(ctx, g.shell, tools.ResourceLimits{
+		CPU:      g.config.Timeout,
+		MemoryMB: goTestMemoryMB,
+		FileMB:   goTestFileMB,
+	}, "go", args...)
+	cmd.Dir = g.config.ModuleDir
+	cmd.Env = g.env
+	cmd.WaitDelay = time.Second
+	out := &tools.LimitedBuffer{Limit: maxGoTestOutput}
+	cmd.Stdout, cmd.Stderr = out, out
+	err = cmd.Run()
+
+	status := "PASS: the test passes, so it does not reproduce the bug"
+	switch {
+	case ctx.Err() != nil:
+		status = fmt.Sprintf("TIMEOUT: the test did not finish within %s", g.config.Timeout)
+	case err != nil:
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return "", fmt.Errorf("failed to run go test: %w", err)
+		}
+		status = fmt.Sprintf("FAIL (exit status %d): check below that it fails for the diagnosed reason and not a build error", exitErr.ExitCode())
+	}
+	return fmt.Sprintf("$ go %s\n%s\n\n%s", strings.Join(args, " "), status, out.Truncated()), nil
+}
+
+// toolDefinitions describes the enabled test generation tools to the model
+func (g *testGenerator) toolDefinitions() []provider.Tool {
+	var tools []provider.Tool
+	if g.config.WriteToSource {
+		tools = append(tools, provider.Tool{
+			Name:        writeTestFileToolName,
+			Description: "Write a new Go test file into the source tree, e.g. a test reproducing a diagnosed bug. Only new _test.go files can be written; existing files are never overwritten.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"file_path": map[string]interface{}{
+						"type":        "string",
+						"description": "Path of the new file relative to the module root, in the package under test (e.g., 'payments/refund_repro_test.go')",
+					},
+					"content": map[string]interface{}{
+						"type":        "string",
+						"description": "The Go source of the test file",
+					},
+				},
+				"required": []string{"file_path", "content"},
+			},
+		})
+	}
+	if g.config.RunTests {
+		tools = append(tools, provider.Tool{
+			Name:        runGoTestToolName,
+			Description: fmt.Sprintf("Run Go tests of a package with go test (limited to %s) and return the output. A test file saved to the session workspace can be added to the package for the run without changing the source.", g.config.Timeout),
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"package": map[string]interface{}{
+						"type":        "string",
+						"description": "Package directory relative to the module root (e.g., './payments')",
+					},
+					"run": map[string]interface{}{
+						"type":        "string",
+						"description": "Regular expression selecting the tests to run (e.g., '^TestRefundRepro$')",
+					},
+					"test_file": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional: Name of a _test.go file in the session workspace to add to the package for this run",
+					},
+				},
+				"required": []string{"package", "run"},
+			},
+		})
+	}
+	return tools
+}
@@ -0,0 +1,142 @@
+package aiassistant
+
+import (
+	"fmt"
+	"log"
+	"unicode/utf8"
+
+	"github.com/willknow-ai/willknow-go/provider"
+)
+
+const (
+	// defaultMaxToolResultTokens limits a tool result in the conversation
+	defaultMaxToolResultTokens = 20000
+	// exactCountThreshold is the fraction of MaxContextTokens above which an
+	// estimated request is counted exactly, where the provider can
+	exactCountThreshold = 0.9
+)
+
+// tokenCounter counts tokens for the context window, tool result limits and
+// cost estimates
+type tokenCounter struct {
+	tokenizer     provider.Tokenizer
+	exact         provider.TokenCounter // nil where counts are only estimated
+	redactor      *redactor             // applied before exact counts
+	maxContext    int
+	maxToolResult int
+}
+
+// newTokenCounter counts with the configured tokenizer, and exactly with
+// base where it implements provider.TokenCounter. Exact counts send the
+// request to the provider, so they see the redacted conversation like every
+// other call, and are never made in air-gapped mode.
+func newTokenCounter(config Config, base provider.Provider, redactor *redactor) *tokenCounter {
+	c := &tokenCounter{
+		tokenizer:     config.Tokenizer,
+		redactor:      redactor,
+		maxContext:    config.MaxContextTokens,
+		maxToolResult: config.MaxToolResultTokens,
+	}
+	if counter, ok := base.(provider.TokenCounter); ok && !config.AirGapped {
+		c.exact = counter
+	}
+	return c
+}
+
+// countRequest counts the tokens of a request exactly where possible,
+// otherwise estimated. An estimate is returned along with the error if the
+// exact count fails.
+func (c *tokenCounter) countRequest(messages []provider.Message, tools []provider.Tool, system string) (int, error) {
+	if c.exact == nil {
+		return provider.CountMessageTokens(c.tokenizer, messages, tools, system), nil
+	}
+	if c.redactor != nil {
+		messages, system = c.redactor.redactMessages(messages), c.redactor.redact(system)
+	}
+	n, err := c.exact.CountTokens(messages, tools, system)
+	if err != nil {
+		return provider.CountMessageTokens(c.tokenizer, messages, tools, system), err
+	}
+	return n, nil
+}
+
+// CountTokens counts the tokens of a text with the configured tokenizer, so
+// host applications can budget their own prompts
+func (a *Assistant) CountTokens(text string) int {
+	return a.tokens.tokenizer.Count(text)
+}
+
+// CountRequestTokens counts the input tokens of a request to the configured
+// model: exactly where the provider supports it (Anthropic, outside
+// air-gapped mode), otherwise estimated with the configured tokenizer
+func (a *Assistant) CountRequestTokens(messages []provider.Message, tools []provider.Tool, system string) (int, error) {
+	return a.tokens.countRequest(messages, tools, system)
+}
+
+// truncate shortens a tool result longer than MaxToolResultTokens
+func (c *tokenCounter) truncate(result string) string {
+	if c.maxToolResult <= 0 {
+		return result
+	}
+	total := c.tokenizer.Count(result)
+	if total <= c.maxToolResult {
+		return result
+	}
+	// Keep the share of the text that fits, on a character boundary
+	keep := int(int64(len(result)) * int64(c.maxToolResult) / int64(total))
+	for keep > 0 && !utf8.RuneStart(result[keep]) {
+		keep--
+	}
+	return result[:keep] + fmt.Sprintf("\n... (truncated: the result has about %d tokens, only the first %d are shown; narrow the request to see the rest)", total, c.maxToolResult)
+}
+
+// fitContext elides the oldest tool results of a request to target t until
+// it fits in MaxContextTokens. The results of the latest turn are kept. The
+// messages are copied where they change, so the session is not modified.
+func (a *Assistant) fitContext(t *target, messages []provider.Message, tools []provider.Tool, system string) []provider.Message {
+	c := a.tokens
+	if c.maxContext <= 0 {
+		return messages
+	}
+	total := provider.CountMessageTokens(c.tokenizer, messages, tools, system)
+	if total < int(float64(c.maxContext)*exactCountThreshold) {
+		return messages
+	}
+	// Count exactly near the limit, unless the target has its own model
+	if t.provider == nil {
+		if n, err := c.countRequest(messages, tools, system); err == nil {
+			total = n
+		} else {
+			log.Printf("[AI Assistant] Failed to count tokens, using the estimate: %v", err)
+		}
+	}
+
+	elided := 0
+	fitted := append([]provider.Message(nil), messages...)
+	for i := 0; i < len(fitted)-1 && total > c.maxContext; i++ {
+		var content []provider.ContentBlock
+		for j, block := range fitted[i].Content {
+			if block.Type != "tool_result" || total <= c.maxContext {
+				continue
+			}
+			n := c.tokenizer.Count(block.Content)
+			placeholder := fmt.Sprintf("[elided to fit the context window: %d tokens; call the tool again if you need it]", n)
+			if n <= c.tokenizer.Count(placeholder) {
+				continue
+			}
+			if content == nil {
+				content = append([]provider.ContentBlock(nil), fitted[i].Content...)
+			}
+			content[j].Content = placeholder
+			total -= n - c.tokenizer.Count(placeholder)
+			elided++
+		}
+		if content != nil {
+			fitted[i] = provider.Message{Role: fitted[i].Role, Content: content}
+		}
+	}
+	if elided > 0 {
+		log.Printf("[AI Assistant] Elided %d tool results to fit the context window (%d tokens)", elided, c.maxContext)
+	}
+	return fitted
+}
@@ -0,0 +1,344 @@
+package tools
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	cloudLoggingEntriesURL = "https://logging.googleapis.com/v2/entries:list"
+	cloudLoggingScope      = "https://www.googleapis.com/auth/logging.read"
+	gcpMetadataURL         = "http://metadata.google.internal/computeMetadata/v1"
+)
+
+// CloudLoggingConfig configures the Google Cloud Logging log source
+type CloudLoggingConfig struct {
+	// ProjectID is the GCP project whose logs are searched.
+	// Default: the project of the credentials file or the metadata server.
+	ProjectID string
+
+	// Filter is a Logging query language expression always applied to searches,
+	// e.g. `resource.type="cloud_run_revision" AND resource.labels.service_name="api"`.
+	Filter string
+
+	// CredentialsFile is the path to a service account JSON key.
+	// Default: "" (use the metadata server on Cloud Run, GKE and GCE)
+	CredentialsFile string
+
+	// DefaultLookback is the time range searched when no start time is given.
+	// Default: 1 hour
+	DefaultLookback time.Duration
+
+	// Enabled turns the source on. It is required because all other fields
+	// may be left empty when running on GCP.
+	Enabled bool
+}
+
+// CloudLoggingSource searches logs stored in Google Cloud Logging
+type CloudLoggingSource struct {
+	config CloudLoggingConfig
+	client *http.Client
+
+	// Service account credentials (nil when using the metadata server)
+	clientEmail string
+	privateKey  *rsa.PrivateKey
+	tokenURI    string
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+// NewCloudLoggingSource creates a LogSource backed by Google Cloud Logging
+func NewCloudLoggingSource(config CloudLoggingConfig) (*CloudLoggingSource, error) {
+	if config.DefaultLookback == 0 {
+		config.DefaultLookback = time.Hour
+	}
+	s := &CloudLoggingSource{
+		config: config,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+
+	if config.CredentialsFile != "" {
+		if err := s.loadCredentials(config.CredentialsFile); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.config.ProjectID == "" {
+		projectID, err := s.metadata("/project/project-id")
+		if err != nil {
+			return nil, fmt.Errorf("ProjectID is required when not running on GCP: %w", err)
+		}
+		s.config.ProjectID = projectID
+	}
+	return s, nil
+}
+
+// Name identifies the source in tool output
+func (s *CloudLoggingSource) Name() string {
+	return "cloud-logging " + s.config.ProjectID
+}
+
+// Search lists log entries matching the query text, filter and time range.
+// Filter is a Logging query language expression (e.g., `severity>=ERROR`).
+func (s *CloudLoggingSource) Search(query LogQuery) ([]string, error) {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultLogLimit
+	}
+
+	token, err := s.accessToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to authenticate to Cloud Logging: %w", err)
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"resourceNames": []string{"projects/" + s.config.ProjectID},
+		"filter":        s.buildFilter(query),
+		"orderBy":       "timestamp desc",
+		"pageSize":      limit,
+	})
+	req, err := http.NewRequest(http.MethodPost, cloudLoggingEntriesURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Cloud Logging query failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Cloud Logging returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	var result struct {
+		Entries []struct {
+			Timestamp   string                 `json:"timestamp"`
+			Severity    string                 `json:"severity"`
+			TextPayload string                 `json:"textPayload"`
+			JSONPayload map[string]interface{} `json:"jsonPayload"`
+			Trace       string                 `json:"trace"`
+			HTTPRequest *struct {
+				RequestMethod string `json:"requestMethod"`
+				RequestURL    string `json:"requestUrl"`
+				Status        int    `json:"status"`
+				Latency       string `json:"latency"`
+			} `json:"httpRequest"`
+		} `json:"entries"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("invalid Cloud Logging response: %w", err)
+	}
+
+	entries := result.Entries
+	matches := make([]string, 0, len(entries)+1)
+	// Entries are returned newest first; print them chronologically
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		payload := e.TextPayload
+		if payload == "" && e.JSONPayload != nil {
+			data, _ := json.Marshal(e.JSONPayload)
+			payload = string(data)
+		}
+		if payload == "" && e.HTTPRequest != nil {
+			payload = fmt.Sprintf("%s %s %d (%s)", e.HTTPRequest.RequestMethod, e.HTTPRequest.RequestURL, e.HTTPRequest.Status, e.HTTPRequest.Latency)
+		}
+		line := fmt.Sprintf("%s %s %s", e.Timestamp, e.Severity, payload)
+		if e.Trace != "" {
+			line += " [trace=" + e.Trace[strings.LastIndex(e.Trace, "/")+1:] + "]"
+		}
+		matches = append(matches, line)
+	}
+	if len(entries) >= limit {
+		matches = append(matches, fmt.Sprintf("... (showing latest %d entries; narrow the time range or filter for more)", limit))
+	}
+	return matches, nil
+}
+
+// buildFilter combines the configured filter, the AI's filter, the text search and the time range
+func (s *CloudLoggingSource) buildFilter(query LogQuery) string {
+	end := query.End
+	if end.IsZero() {
+		end = time.Now()
+	}
+	start := query.Start
+	if start.IsZero() {
+		start = end.Add(-s.config.DefaultLookback)
+	}
+
+	var clauses []string
+	if s.config.Filter != "" {
+		clauses = append(clauses, "("+s.config.Filter+")")
+	}
+	if strings.TrimSpace(query.Filter) != "" {
+		clauses = append(clauses, "("+query.Filter+")")
+	}
+	if query.Text != "" {
+		// A bare quoted string searches all fields of the entry
+		clauses = append(clauses, strconv.Quote(query.Text))
+	}
+	clauses = append(clauses,
+		fmt.Sprintf("timestamp>=%q", start.UTC().Format(time.RFC3339Nano)),
+		fmt.Sprintf("timestamp<=%q", end.UTC().Format(time.RFC3339Nano)))
+	return strings.Join(clauses, " AND ")
+}
+
+// accessToken returns a cached OAuth access token, refreshing it when expired
+func (s *CloudLoggingSource) accessToken() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && time.Now().Before(s.tokenExpiry) {
+		return s.token, nil
+	}
+
+	var tokenResp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if s.privateKey != nil {
+		assertion, err := s.signJWT()
+		if err != nil {
+			return "", err
+		}
+		resp, err := s.client.PostForm(s.tokenURI, url.Values{
+			"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+			"assertion":  {assertion},
+		})
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			return "", fmt.Errorf("token exchange failed with status %d: %s", resp.StatusCode, string(body))
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+			return "", err
+		}
+	} else {
+		data, err := s.metadata("/instance/service-accounts/default/token?scopes=" + url.QueryEscape(cloudLoggingScope))
+		if err != nil {
+			return "", err
+		}
+		if err := json.Unmarshal([]byte(data), &tokenResp); err != nil {
+			return "", err
+		}
+	}
+
+	s.token = tokenResp.AccessToken
+	s.tokenExpiry = time.Now().Add(time.Duration(tokenResp.ExpiresIn)*time.Second - time.Minute)
+	return s.token, nil
+}
+
+// loadCredentials reads a service account JSON key
+func (s *CloudLoggingSource) loadCredentials(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read credentials file: %w", err)
+	}
+	var creds struct {
+		ProjectID   string `json:"project_id"`
+		ClientEmail string `json:"client_email"`
+		PrivateKey  string `json:"private_key"`
+		TokenURI    string `json:"token_uri"`
+	}
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return fmt.Errorf("invalid credentials file: %w", err)
+	}
+
+	block, _ := pem.Decode([]byte(creds.PrivateKey))
+	if block == nil {
+		return fmt.Errorf("invalid private key in credentials file")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("invalid private key in credentials file: %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return fmt.Errorf("credentials private key is not an RSA key")
+	}
+
+	s.clientEmail = creds.ClientEmail
+	s.privateKey = rsaKey
+	s.tokenURI = creds.TokenURI
+	if s.tokenURI == "" {
+		s.tokenURI = "https://oauth2.googleapis.com/token"
+	}
+	if s.config.ProjectID == "" {
+		s.config.ProjectID = creds.ProjectID
+	}
+	return nil
+}
+
+// signJWT creates a signed service account assertion for the token exchange
+func (s *CloudLoggingSource) signJWT() (string, error) {
+	now := time.Now()
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iss":   s.clientEmail,
+		"scope": cloudLoggingScope,
+		"aud":   s.tokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, s.privateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token assertion: %w", err)
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// metadata reads a value from the GCP metadata server
+func (s *CloudLoggingSource) metadata(path string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, gcpMetadataURL+path, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("metadata server unavailable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("metadata server returned status %d", resp.StatusCode)
+	}
+	return string(body), nil
+}
@@ -0,0 +1,176 @@
+package tools
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// maxAnnotatedRefs bounds the code references annotated in one result
+	maxAnnotatedRefs = 10
+	// codeRefContextLines is how many lines are shown around a reference
+	codeRefContextLines = 2
+	// maxIndexedFiles bounds the source files indexed to resolve references
+	maxIndexedFiles = 100000
+	// maxSnippetLineChars truncates long source lines in snippets
+	maxSnippetLineChars = 200
+)
+
+var (
+	// pythonFrameRe matches Python traceback frames: File "app/views.py", line 42
+	pythonFrameRe = regexp.MustCompile(`File "([^"]+)", line (\d+)`)
+	// fileLineRe matches path/to/file.ext:42 as found in Go, Node, Java and
+	// most other stack traces and log call sites
+	fileLineRe = regexp.MustCompile(`((?:[A-Za-z]:)?(?:[\w.@~+-]*[/\\])*[\w.@+-]+\.[A-Za-z]\w{0,9}):(\d+)`)
+)
+
+// codeRefResolver maps file:line references of logs and stack traces, whose
+// paths are often absolute paths of the build or deployment (e.g.
+// /app/handlers/user.go:42), to the files of the source tree
+type codeRefResolver struct {
+	source  fs.FS
+	exclude func(path string) bool // files never shown, e.g. denied by policy
+
+	once   sync.Once
+	files  map[string]bool     // every indexed file
+	byName map[string][]string // indexed files by base name
+}
+
+// newCodeRefResolver creates a resolver; the source is indexed on first use
+func newCodeRefResolver(source fs.FS) *codeRefResolver {
+	return &codeRefResolver{source: source}
+}
+
+// index lists the files of the source tree
+func (c *codeRefResolver) index() {
+	c.once.Do(func() {
+		c.files = make(map[string]bool)
+		c.byName = make(map[string][]string)
+		fs.WalkDir(c.source, ".", func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() {
+				if name := d.Name(); p != "." && (name == ".git" || name == "node_modules") {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			if len(c.files) >= maxIndexedFiles {
+				return fs.SkipAll
+			}
+			c.files[p] = true
+			c.byName[d.Name()] = append(c.byName[d.Name()], p)
+			return nil
+		})
+	})
+}
+
+// resolve returns the source file a referenced path names: the longest
+// suffix of the path that is a file of the source tree, or a base name
+// only one file has
+func (c *codeRefResolver) resolve(ref string) (string, bool) {
+	ref = strings.ReplaceAll(ref, "\\", "/")
+	if len(ref) > 1 && ref[1] == ':' {
+		ref = ref[2:] // Windows drive letter
+	}
+	components := strings.Split(strings.TrimPrefix(path.Clean("/"+ref), "/"), "/")
+
+	c.index()
+	for i := 0; i < len(components)-1; i++ {
+		if p := strings.Join(components[i:], "/"); c.files[p] {
+			return p, true
+		}
+	}
+	if matches := c.byName[components[len(components)-1]]; len(matches) == 1 {
+		return matches[0], true
+	}
+	return "", false
+}
+
+// codeRef is a resolved reference in a line
+type codeRef struct {
+	file string
+	line int
+}
+
+// refs returns the resolvable references of a line
+func (c *codeRefResolver) refs(line string) []codeRef {
+	var refs []codeRef
+	for _, re := range []*regexp.Regexp{pythonFrameRe, fileLineRe} {
+		for _, m := range re.FindAllStringSubmatch(line, -1) {
+			n, err := strconv.Atoi(m[2])
+			if err != nil || n <= 0 {
+				continue
+			}
+			file, ok := c.resolve(m[1])
+			if !ok || c.exclude != nil && c.exclude(file) {
+				continue
+			}
+			refs = append(refs, codeRef{file: file, line: n})
+		}
+	}
+	return refs
+}
+
+// annotate inserts the source lines around each file:line reference below
+// the log line containing it, so the model sees the code without calling
+// read_file. Each reference is annotated once.
+func (c *codeRefResolver) annotate(result string) string {
+	if c == nil || !strings.Contains(result, ":") && !strings.Contains(result, `File "`) {
+		return result
+	}
+
+	contents := make(map[string][]string)
+	seen := make(map[codeRef]bool)
+	var b strings.Builder
+	annotated := 0
+	for _, line := range strings.SplitAfter(result, "\n") {
+		b.WriteString(line)
+		if annotated >= maxAnnotatedRefs {
+			continue
+		}
+		unterminated := !strings.HasSuffix(line, "\n")
+		for _, ref := range c.refs(line) {
+			if seen[ref] || annotated >= maxAnnotatedRefs {
+				continue
+			}
+			seen[ref] = true
+			lines, ok := contents[ref.file]
+			if !ok {
+				data, err := fs.ReadFile(c.source, ref.file)
+				if err == nil {
+					lines = strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+				}
+				contents[ref.file] = lines
+			}
+			if ref.line > len(lines) {
+				continue // a different revision, or not this file after all
+			}
+			if unterminated {
+				b.WriteString("\n")
+				unterminated = false
+			}
+			fmt.Fprintf(&b, "    ↳ %s:%d\n", ref.file, ref.line)
+			start, end := max(ref.line-codeRefContextLines, 1), min(ref.line+codeRefContextLines, len(lines))
+			for n := start; n <= end; n++ {
+				marker := " "
+				if n == ref.line {
+					marker = ">"
+				}
+				text := strings.TrimRight(lines[n-1], "\r")
+				if runes := []rune(text); len(runes) > maxSnippetLineChars {
+					text = string(runes[:maxSnippetLineChars]) + "..."
+				}
+				fmt.Fprintf(&b, "    │ %s%5d  %s\n", marker, n, text)
+			}
+			annotated++
+		}
+	}
+	return b.String()
+}
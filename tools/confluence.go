@@ -0,0 +1,246 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// defaultConfluenceLabel marks pages created for root-cause reports
+const defaultConfluenceLabel = "willknow-root-cause"
+
+// ConfluenceKnowledgeConfig stores root-cause reports as pages in a Confluence space
+type ConfluenceKnowledgeConfig struct {
+	// URL is the Confluence base URL including the context path
+	// (e.g., "https://acme.atlassian.net/wiki").
+	// When empty, the Confluence store is disabled.
+	URL string
+
+	// Username is the account email for Confluence Cloud API tokens.
+	// Leave empty to send Token as a bearer personal access token (Server/Data Center).
+	Username string
+
+	// Token is an API token or personal access token
+	Token string
+
+	// SpaceKey is the space report pages are created in and searched
+	SpaceKey string
+
+	// ParentID is an optional page ID new reports are created under
+	ParentID string
+
+	// Label is added to every report page and scopes searches.
+	// Default: "willknow-root-cause"
+	Label string
+}
+
+// confluenceKnowledgeStore implements KnowledgeStore with the Confluence REST API
+type confluenceKnowledgeStore struct {
+	config ConfluenceKnowledgeConfig
+	client *http.Client
+}
+
+// newConfluenceKnowledgeStore creates a Confluence store with defaults applied
+func newConfluenceKnowledgeStore(config ConfluenceKnowledgeConfig) (*confluenceKnowledgeStore, error) {
+	if config.SpaceKey == "" {
+		return nil, fmt.Errorf("KnowledgeBase.Confluence.SpaceKey is required when Confluence.URL is set")
+	}
+	if config.Label == "" {
+		config.Label = defaultConfluenceLabel
+	}
+	config.URL = strings.TrimRight(config.URL, "/")
+	return &confluenceKnowledgeStore{
+		config: config,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+func (s *confluenceKnowledgeStore) Name() string {
+	return "confluence"
+}
+
+var confluenceLabelInvalid = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// Save creates a labeled page in the space
+func (s *confluenceKnowledgeStore) Save(report RootCauseReport) (string, error) {
+	labels := []map[string]string{{"prefix": "global", "name": s.config.Label}}
+	for _, tag := range report.Tags {
+		if name := strings.Trim(confluenceLabelInvalid.ReplaceAllString(strings.ToLower(tag), "-"), "-"); name != "" {
+			labels = append(labels, map[string]string{"prefix": "global", "name": name})
+		}
+	}
+
+	body := map[string]interface{}{
+		"type": "page",
+		// Page titles must be unique within a space
+		"title": fmt.Sprintf("%s (%s)", report.Title, report.CreatedAt.UTC().Format("2006-01-02 15:04")),
+		"space": map[string]string{"key": s.config.SpaceKey},
+		"body": map[string]interface{}{
+			"storage": map[string]string{
+				"value":          markdownToStorage(report.Markdown()),
+				"representation": "storage",
+			},
+		},
+		"metadata": map[string]interface{}{"labels": labels},
+	}
+	if s.config.ParentID != "" {
+		body["ancestors"] = []map[string]string{{"id": s.config.ParentID}}
+	}
+
+	var page struct {
+		Links struct {
+			Base  string `json:"base"`
+			WebUI string `json:"webui"`
+		} `json:"_links"`
+	}
+	if err := s.do(http.MethodPost, "/rest/api/content", body, &page); err != nil {
+		return "", err
+	}
+	return page.Links.Base + page.Links.WebUI, nil
+}
+
+// Search runs a CQL full-text search over labeled pages in the space
+func (s *confluenceKnowledgeStore) Search(query string, limit int) ([]RootCauseReport, error) {
+	quote := func(v string) string {
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(v) + `"`
+	}
+	cql := fmt.Sprintf("type = page AND space = %s AND label = %s AND text ~ %s",
+		quote(s.config.SpaceKey), quote(s.config.Label), quote(query))
+	params := url.Values{}
+	params.Set("cql", cql)
+	params.Set("limit", fmt.Sprint(limit))
+	params.Set("expand", "body.storage")
+
+	var result struct {
+		Results []struct {
+			Title string `json:"title"`
+			Body  struct {
+				Storage struct {
+					Value string `json:"value"`
+				} `json:"storage"`
+			} `json:"body"`
+			Links struct {
+				WebUI string `json:"webui"`
+			} `json:"_links"`
+		} `json:"results"`
+		Links struct {
+			Base string `json:"base"`
+		} `json:"_links"`
+	}
+	if err := s.do(http.MethodGet, "/rest/api/content/search?"+params.Encode(), nil, &result); err != nil {
+		return nil, err
+	}
+
+	var reports []RootCauseReport
+	for _, page := range result.Results {
+		report := parseMarkdownReport(storageToMarkdown(page.Body.Storage.Value))
+		if report.Title == "" {
+			report.Title = page.Title
+		}
+		report.Location = result.Links.Base + page.Links.WebUI
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// markdownToStorage converts the report Markdown (headings, bullets and
+// paragraphs only) to Confluence storage format
+func markdownToStorage(markdown string) string {
+	var b strings.Builder
+	inList := false
+	for _, line := range strings.Split(markdown, "\n") {
+		isItem := strings.HasPrefix(line, "- ")
+		if inList && !isItem {
+			b.WriteString("</ul>")
+			inList = false
+		}
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "# "):
+			b.WriteString("<h1>" + html.EscapeString(line[2:]) + "</h1>")
+		case strings.HasPrefix(line, "## "):
+			b.WriteString("<h2>" + html.EscapeString(line[3:]) + "</h2>")
+		case isItem:
+			if !inList {
+				b.WriteString("<ul>")
+				inList = true
+			}
+			b.WriteString("<li>" + html.EscapeString(line[2:]) + "</li>")
+		default:
+			b.WriteString("<p>" + html.EscapeString(line) + "</p>")
+		}
+	}
+	if inList {
+		b.WriteString("</ul>")
+	}
+	return b.String()
+}
+
+var (
+	storageH1    = regexp.MustCompile(`(?i)<h1[^>]*>`)
+	storageH2    = regexp.MustCompile(`(?i)<h[2-6][^>]*>`)
+	storageItem  = regexp.MustCompile(`(?i)<li[^>]*>`)
+	storageBreak = regexp.MustCompile(`(?i)</h[1-6]>|</?p[^>]*>|<br\s*/?>|</li>`)
+	storageTag   = regexp.MustCompile(`<[^>]+>`)
+)
+
+// storageToMarkdown converts Confluence storage format back to the report Markdown layout
+func storageToMarkdown(storage string) string {
+	s := storageH1.ReplaceAllString(storage, "\n# ")
+	s = storageH2.ReplaceAllString(s, "\n## ")
+	s = storageItem.ReplaceAllString(s, "\n- ")
+	s = storageBreak.ReplaceAllString(s, "\n")
+	s = storageTag.ReplaceAllString(s, "")
+	return strings.TrimSpace(html.UnescapeString(s))
+}
+
+// do sends a request to the Confluence REST API and decodes the JSON response into out
+func (s *confluenceKnowledgeStore) do(method, path string, body interface{}, out interface{}) error {
+	var bodyReader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		bodyReader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, s.config.URL+path, bodyReader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if s.config.Username != "" {
+		req.SetBasicAuth(s.config.Username, s.config.Token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+s.config.Token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Confluence API returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+	if out != nil {
+		return json.Unmarshal(respBody, out)
+	}
+	return nil
+}
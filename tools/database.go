@@ -0,0 +1,308 @@
+package tools
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DatabaseConfig registers the host application's database connection
+type DatabaseConfig struct {
+	// DB is the application's open connection pool. The assistant only runs
+	// catalog queries against it (information_schema, pg_catalog, PRAGMA).
+	// When nil, the database tools are disabled.
+	DB *sql.DB
+
+	// Dialect is "postgres", "mysql" or "sqlite".
+	// Default: detected from the driver type (lib/pq, pgx, go-sql-driver/mysql, sqlite3)
+	Dialect string
+}
+
+// maxSchemaTables limits the number of tables listed without a table filter
+const maxSchemaTables = 200
+
+// DatabaseSchemaTool describes tables, columns, indexes and foreign keys
+type DatabaseSchemaTool struct {
+	db      *sql.DB
+	dialect string
+}
+
+// newDatabaseSchemaTool creates a schema tool, detecting the dialect when not set
+func newDatabaseSchemaTool(config DatabaseConfig) (*DatabaseSchemaTool, error) {
+	dialect := strings.ToLower(config.Dialect)
+	if dialect == "" {
+		dialect = detectDialect(config.DB)
+	}
+	switch dialect {
+	case "postgres", "postgresql":
+		dialect = "postgres"
+	case "mysql", "mariadb":
+		dialect = "mysql"
+	case "sqlite", "sqlite3":
+		dialect = "sqlite"
+	case "":
+		return nil, fmt.Errorf("could not detect database dialect from driver %T; set Dialect", config.DB.Driver())
+	default:
+		return nil, fmt.Errorf("unsupported database dialect: %s", config.Dialect)
+	}
+	return &DatabaseSchemaTool{db: config.DB, dialect: dialect}, nil
+}
+
+// detectDialect guesses the dialect from the driver's package path
+func detectDialect(db *sql.DB) string {
+	driver := strings.ToLower(fmt.Sprintf("%T", db.Driver()))
+	switch {
+	case strings.Contains(driver, "pq.") || strings.Contains(driver, "pgx") || strings.Contains(driver, "stdlib.") || strings.Contains(driver, "postgres"):
+		return "postgres"
+	case strings.Contains(driver, "mysql"):
+		return "mysql"
+	case strings.Contains(driver, "sqlite"):
+		return "sqlite"
+	}
+	return ""
+}
+
+// Execute lists tables, or describes a single table when "table" is given
+func (t *DatabaseSchemaTool) Execute(params map[string]interface{}) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	table, _ := params["table"].(string)
+	table = strings.TrimSpace(table)
+	if table == "" {
+		return t.listTables(ctx)
+	}
+	return t.describeTable(ctx, table)
+}
+
+// listTables lists all user tables
+func (t *DatabaseSchemaTool) listTables(ctx context.Context) (string, error) {
+	var query string
+	switch t.dialect {
+	case "postgres":
+		query = `SELECT table_schema || '.' || table_name FROM information_schema.tables
+			WHERE table_type = 'BASE TABLE' AND table_schema NOT IN ('pg_catalog', 'information_schema')
+			ORDER BY table_schema, table_name`
+	case "mysql":
+		query = `SELECT table_name FROM information_schema.tables
+			WHERE table_schema = DATABASE() AND table_type = 'BASE TABLE' ORDER BY table_name`
+	case "sqlite":
+		query = `SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name`
+	}
+
+	rows, err := t.queryStrings(ctx, query)
+	if err != nil {
+		return "", fmt.Errorf("failed to list tables: %w", err)
+	}
+	if len(rows) == 0 {
+		return "No tables found", nil
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "Tables (%s, %d):\n", t.dialect, len(rows))
+	for i, row := range rows {
+		if i >= maxSchemaTables {
+			fmt.Fprintf(&out, "... (%d more)\n", len(rows)-maxSchemaTables)
+			break
+		}
+		fmt.Fprintf(&out, "  %s\n", row[0])
+	}
+	return out.String(), nil
+}
+
+// describeTable prints columns, indexes and foreign keys of a table
+func (t *DatabaseSchemaTool) describeTable(ctx context.Context, table string) (string, error) {
+	var columns, indexes, foreignKeys [][]string
+	var err error
+	switch t.dialect {
+	case "postgres":
+		columns, indexes, foreignKeys, err = t.describePostgres(ctx, table)
+	case "mysql":
+		columns, indexes, foreignKeys, err = t.describeMySQL(ctx, table)
+	case "sqlite":
+		columns, indexes, foreignKeys, err = t.describeSQLite(ctx, table)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to describe %s: %w", table, err)
+	}
+	if len(columns) == 0 {
+		return "", fmt.Errorf("table not found: %s (call without a table to list tables)", table)
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "Table: %s\n\nColumns:\n", table)
+	for _, c := range columns {
+		// name, type, nullable, default
+		line := "  " + strings.TrimSpace(c[0]+" "+c[1])
+		if c[2] == "NO" {
+			line += " NOT NULL"
+		}
+		if c[3] != "" {
+			line += " DEFAULT " + c[3]
+		}
+		out.WriteString(line + "\n")
+	}
+
+	out.WriteString("\nIndexes:\n")
+	if len(indexes) == 0 {
+		out.WriteString("  (none)\n")
+	}
+	for _, idx := range indexes {
+		// name, definition
+		fmt.Fprintf(&out, "  %s: %s\n", idx[0], idx[1])
+	}
+
+	out.WriteString("\nForeign keys:\n")
+	if len(foreignKeys) == 0 {
+		out.WriteString("  (none)\n")
+	}
+	for _, fk := range foreignKeys {
+		// name, from table, definition
+		fmt.Fprintf(&out, "  %s on %s: %s\n", fk[0], fk[1], fk[2])
+	}
+	return out.String(), nil
+}
+
+func (t *DatabaseSchemaTool) describePostgres(ctx context.Context, table string) (columns, indexes, foreignKeys [][]string, err error) {
+	schema, name := "", table
+	if i := strings.LastIndex(table, "."); i >= 0 {
+		schema, name = table[:i], table[i+1:]
+	}
+
+	columns, err = t.queryStrings(ctx, `SELECT column_name, data_type, is_nullable, COALESCE(column_default, '')
+		FROM information_schema.columns
+		WHERE table_name = $1 AND ($2 = '' OR table_schema = $2)
+		AND table_schema NOT IN ('pg_catalog', 'information_schema')
+		ORDER BY table_schema, ordinal_position`, name, schema)
+	if err != nil || len(columns) == 0 {
+		return
+	}
+	indexes, err = t.queryStrings(ctx, `SELECT indexname, indexdef FROM pg_indexes
+		WHERE tablename = $1 AND ($2 = '' OR schemaname = $2) ORDER BY indexname`, name, schema)
+	if err != nil {
+		return
+	}
+	// Both outgoing and incoming references, since either can explain a failing write
+	foreignKeys, err = t.queryStrings(ctx, `SELECT c.conname, c.conrelid::regclass::text, pg_get_constraintdef(c.oid)
+		FROM pg_constraint c
+		JOIN pg_class src ON src.oid = c.conrelid JOIN pg_namespace sn ON sn.oid = src.relnamespace
+		JOIN pg_class dst ON dst.oid = c.confrelid JOIN pg_namespace dn ON dn.oid = dst.relnamespace
+		WHERE c.contype = 'f' AND (
+			(src.relname = $1 AND ($2 = '' OR sn.nspname = $2)) OR
+			(dst.relname = $1 AND ($2 = '' OR dn.nspname = $2)))
+		ORDER BY c.conname`, name, schema)
+	return
+}
+
+func (t *DatabaseSchemaTool) describeMySQL(ctx context.Context, table string) (columns, indexes, foreignKeys [][]string, err error) {
+	columns, err = t.queryStrings(ctx, `SELECT column_name, column_type, is_nullable, COALESCE(column_default, '')
+		FROM information_schema.columns
+		WHERE table_schema = DATABASE() AND table_name = ? ORDER BY ordinal_position`, table)
+	if err != nil || len(columns) == 0 {
+		return
+	}
+	indexes, err = t.queryStrings(ctx, `SELECT index_name,
+		CONCAT(IF(non_unique = 0, 'UNIQUE ', ''), index_type, ' (', GROUP_CONCAT(column_name ORDER BY seq_in_index SEPARATOR ', '), ')')
+		FROM information_schema.statistics
+		WHERE table_schema = DATABASE() AND table_name = ?
+		GROUP BY index_name, non_unique, index_type ORDER BY index_name`, table)
+	if err != nil {
+		return
+	}
+	foreignKeys, err = t.queryStrings(ctx, `SELECT constraint_name, table_name,
+		CONCAT('FOREIGN KEY (', GROUP_CONCAT(column_name ORDER BY ordinal_position SEPARATOR ', '), ') REFERENCES ',
+			referenced_table_name, '(', GROUP_CONCAT(referenced_column_name ORDER BY ordinal_position SEPARATOR ', '), ')')
+		FROM information_schema.key_column_usage
+		WHERE table_schema = DATABASE() AND referenced_table_name IS NOT NULL
+		AND (table_name = ? OR referenced_table_name = ?)
+		GROUP BY constraint_name, table_name, referenced_table_name ORDER BY constraint_name`, table, table)
+	return
+}
+
+func (t *DatabaseSchemaTool) describeSQLite(ctx context.Context, table string) (columns, indexes, foreignKeys [][]string, err error) {
+	quoted := `"` + strings.ReplaceAll(table, `"`, `""`) + `"`
+
+	// cid, name, type, notnull, dflt_value, pk
+	info, err := t.queryStrings(ctx, "PRAGMA table_info("+quoted+")")
+	if err != nil || len(info) == 0 {
+		return
+	}
+	for _, c := range info {
+		typ := c[2]
+		if c[5] != "0" {
+			typ += " PRIMARY KEY"
+		}
+		nullable := "YES"
+		if c[3] == "1" {
+			nullable = "NO"
+		}
+		columns = append(columns, []string{c[1], typ, nullable, c[4]})
+	}
+
+	// seq, name, unique, origin, partial
+	list, err := t.queryStrings(ctx, "PRAGMA index_list("+quoted+")")
+	if err != nil {
+		return
+	}
+	for _, idx := range list {
+		// seqno, cid, name
+		cols, qerr := t.queryStrings(ctx, `PRAGMA index_info("`+strings.ReplaceAll(idx[1], `"`, `""`)+`")`)
+		if qerr != nil {
+			err = qerr
+			return
+		}
+		var names []string
+		for _, c := range cols {
+			names = append(names, c[2])
+		}
+		def := "(" + strings.Join(names, ", ") + ")"
+		if idx[2] == "1" {
+			def = "UNIQUE " + def
+		}
+		indexes = append(indexes, []string{idx[1], def})
+	}
+
+	// id, seq, table, from, to, on_update, on_delete, match
+	fks, err := t.queryStrings(ctx, "PRAGMA foreign_key_list("+quoted+")")
+	if err != nil {
+		return
+	}
+	for _, fk := range fks {
+		def := fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s(%s) ON DELETE %s", fk[3], fk[2], fk[4], fk[6])
+		foreignKeys = append(foreignKeys, []string{"fk_" + fk[0], table, def})
+	}
+	return
+}
+
+// queryStrings runs a query and returns every column as a string (NULL as "")
+func (t *DatabaseSchemaTool) queryStrings(ctx context.Context, query string, args ...interface{}) ([][]string, error) {
+	rows, err := t.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	var result [][]string
+	for rows.Next() {
+		values := make([]sql.NullString, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		row := make([]string, len(cols))
+		for i, v := range values {
+			row[i] = v.String
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
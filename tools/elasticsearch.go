@@ -0,0 +1,216 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ElasticsearchConfig configures the Elasticsearch/OpenSearch log source
+type ElasticsearchConfig struct {
+	// URL is the cluster base URL (e.g., "https://es.internal:9200").
+	// When empty, the Elasticsearch source is disabled.
+	URL string
+
+	// Index is the index name or pattern to search (e.g., "logs-app-*").
+	// Default: "logs-*"
+	Index string
+
+	// Username and Password enable basic auth
+	Username string
+	Password string
+
+	// APIKey enables Elasticsearch API key auth (the base64 "id:key" value)
+	APIKey string
+
+	// TimestampField is the document time field used for time filtering and sorting.
+	// Default: "@timestamp"
+	TimestampField string
+
+	// MessageField is the field printed as the log line. When empty or missing
+	// in a document, the whole document is printed as JSON.
+	// Default: "message"
+	MessageField string
+
+	// DefaultLookback is the time range searched when no start time is given.
+	// Default: 1 hour
+	DefaultLookback time.Duration
+}
+
+// ElasticsearchSource searches logs stored in Elasticsearch or OpenSearch
+type ElasticsearchSource struct {
+	config ElasticsearchConfig
+	client *http.Client
+}
+
+// NewElasticsearchSource creates a LogSource backed by Elasticsearch or OpenSearch
+func NewElasticsearchSource(config ElasticsearchConfig) (*ElasticsearchSource, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("Elasticsearch URL is required")
+	}
+	if config.Index == "" {
+		config.Index = "logs-*"
+	}
+	if config.TimestampField == "" {
+		config.TimestampField = "@timestamp"
+	}
+	if config.MessageField == "" {
+		config.MessageField = "message"
+	}
+	if config.DefaultLookback == 0 {
+		config.DefaultLookback = time.Hour
+	}
+	config.URL = strings.TrimRight(config.URL, "/")
+	return &ElasticsearchSource{
+		config: config,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// Name identifies the source in tool output
+func (s *ElasticsearchSource) Name() string {
+	return "elasticsearch " + s.config.Index
+}
+
+// Search runs a bool query built from the query text, filter and time range.
+// Filter is a Lucene query string (e.g., `level:error AND service:checkout`).
+func (s *ElasticsearchSource) Search(query LogQuery) ([]string, error) {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultLogLimit
+	}
+
+	body, err := json.Marshal(s.buildQuery(query, limit))
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := s.config.URL + "/" + url.PathEscape(s.config.Index) + "/_search"
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.config.APIKey != "" {
+		req.Header.Set("Authorization", "ApiKey "+s.config.APIKey)
+	} else if s.config.Username != "" {
+		req.SetBasicAuth(s.config.Username, s.config.Password)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Elasticsearch query failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Elasticsearch returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				Index  string                 `json:"_index"`
+				Source map[string]interface{} `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("invalid Elasticsearch response: %w", err)
+	}
+
+	hits := result.Hits.Hits
+	matches := make([]string, 0, len(hits)+1)
+	// Hits are sorted newest first; print them chronologically
+	for i := len(hits) - 1; i >= 0; i-- {
+		matches = append(matches, s.formatHit(hits[i].Source))
+	}
+	if len(hits) >= limit {
+		matches = append(matches, fmt.Sprintf("... (showing latest %d entries; narrow the time range or filter for more)", limit))
+	}
+	return matches, nil
+}
+
+// buildQuery creates the query DSL for a log search
+func (s *ElasticsearchSource) buildQuery(query LogQuery, limit int) map[string]interface{} {
+	var must []interface{}
+	if query.Text != "" {
+		must = append(must, map[string]interface{}{
+			"multi_match": map[string]interface{}{
+				"query":   query.Text,
+				"type":    "phrase",
+				"fields":  []string{"*"},
+				"lenient": true,
+			},
+		})
+	}
+	if strings.TrimSpace(query.Filter) != "" {
+		must = append(must, map[string]interface{}{
+			"query_string": map[string]interface{}{
+				"query": query.Filter,
+			},
+		})
+	}
+
+	end := query.End
+	if end.IsZero() {
+		end = time.Now()
+	}
+	start := query.Start
+	if start.IsZero() {
+		start = end.Add(-s.config.DefaultLookback)
+	}
+
+	return map[string]interface{}{
+		"size": limit,
+		"sort": []interface{}{
+			map[string]interface{}{s.config.TimestampField: map[string]string{"order": "desc"}},
+		},
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must": must,
+				"filter": []interface{}{
+					map[string]interface{}{
+						"range": map[string]interface{}{
+							s.config.TimestampField: map[string]string{
+								"gte": start.UTC().Format(time.RFC3339Nano),
+								"lte": end.UTC().Format(time.RFC3339Nano),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// formatHit renders a document as "<timestamp> <message>" or as compact JSON
+func (s *ElasticsearchSource) formatHit(doc map[string]interface{}) string {
+	ts, _ := doc[s.config.TimestampField].(string)
+	if msg, ok := doc[s.config.MessageField].(string); ok {
+		// Include level and other small fields that help the AI correlate
+		var extras []string
+		for _, key := range []string{"level", "log.level", "service", "service.name", "trace_id", "request_id"} {
+			if v, ok := doc[key]; ok {
+				extras = append(extras, fmt.Sprintf("%s=%v", key, v))
+			}
+		}
+		line := ts + " " + msg
+		if len(extras) > 0 {
+			line += " [" + strings.Join(extras, " ") + "]"
+		}
+		return line
+	}
+
+	data, _ := json.Marshal(doc)
+	return string(data)
+}
@@ -0,0 +1,236 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const defaultGitHubAPIURL = "https://api.github.com"
+
+// GitHubConfig configures the GitHub tools
+type GitHubConfig struct {
+	// Token is a GitHub personal access token or app token with issues and
+	// contents/pull request write permissions.
+	// When empty, the GitHub tools are disabled.
+	Token string
+
+	// Repo is the target repository in "owner/name" form
+	Repo string
+
+	// BaseBranch is the branch draft pull requests are opened against.
+	// Default: the repository's default branch
+	BaseBranch string
+
+	// APIURL is the GitHub API base URL (for GitHub Enterprise).
+	// Default: https://api.github.com
+	APIURL string
+}
+
+// GitHubTool implements issue and draft pull request creation on GitHub
+type GitHubTool struct {
+	config GitHubConfig
+	client *http.Client
+}
+
+// newGitHubTool creates a GitHub tool with defaults applied
+func newGitHubTool(config GitHubConfig) *GitHubTool {
+	if config.APIURL == "" {
+		config.APIURL = defaultGitHubAPIURL
+	}
+	config.APIURL = strings.TrimRight(config.APIURL, "/")
+	return &GitHubTool{
+		config: config,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// CreateIssue opens an issue containing a diagnosis report
+func (t *GitHubTool) CreateIssue(params map[string]interface{}) (string, error) {
+	title, ok := params["title"].(string)
+	if !ok || title == "" {
+		return "", fmt.Errorf("title parameter is required")
+	}
+	body, _ := params["body"].(string)
+
+	req := map[string]interface{}{
+		"title": title,
+		"body":  body,
+	}
+	if labels := stringList(params["labels"]); len(labels) > 0 {
+		req["labels"] = labels
+	}
+
+	var issue struct {
+		Number  int    `json:"number"`
+		HTMLURL string `json:"html_url"`
+	}
+	if err := t.do(http.MethodPost, "/repos/"+t.config.Repo+"/issues", req, &issue); err != nil {
+		return "", fmt.Errorf("failed to create issue: %w", err)
+	}
+
+	return fmt.Sprintf("Created issue #%d: %s", issue.Number, issue.HTMLURL), nil
+}
+
+// CreateDraftPullRequest commits the given file contents to a new branch and
+// opens a draft pull request for it
+func (t *GitHubTool) CreateDraftPullRequest(params map[string]interface{}) (string, error) {
+	title, ok := params["title"].(string)
+	if !ok || title == "" {
+		return "", fmt.Errorf("title parameter is required")
+	}
+	branch, ok := params["branch"].(string)
+	if !ok || branch == "" {
+		return "", fmt.Errorf("branch parameter is required")
+	}
+	body, _ := params["body"].(string)
+
+	rawFiles, ok := params["files"].([]interface{})
+	if !ok || len(rawFiles) == 0 {
+		return "", fmt.Errorf("files parameter is required")
+	}
+	type fileChange struct{ path, content string }
+	var files []fileChange
+	for _, rf := range rawFiles {
+		f, ok := rf.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("each file must be an object with path and content")
+		}
+		path, _ := f["path"].(string)
+		content, hasContent := f["content"].(string)
+		if path == "" || !hasContent {
+			return "", fmt.Errorf("each file must have path and content")
+		}
+		files = append(files, fileChange{path: strings.TrimPrefix(path, "/"), content: content})
+	}
+
+	repo := "/repos/" + t.config.Repo
+
+	base := t.config.BaseBranch
+	if base == "" {
+		var repoInfo struct {
+			DefaultBranch string `json:"default_branch"`
+		}
+		if err := t.do(http.MethodGet, repo, nil, &repoInfo); err != nil {
+			return "", fmt.Errorf("failed to get repository: %w", err)
+		}
+		base = repoInfo.DefaultBranch
+	}
+
+	// Create the branch from the tip of the base branch
+	var baseRef struct {
+		Object struct {
+			SHA string `json:"sha"`
+		} `json:"object"`
+	}
+	if err := t.do(http.MethodGet, repo+"/git/ref/heads/"+base, nil, &baseRef); err != nil {
+		return "", fmt.Errorf("failed to resolve base branch %s: %w", base, err)
+	}
+	if err := t.do(http.MethodPost, repo+"/git/refs", map[string]interface{}{
+		"ref": "refs/heads/" + branch,
+		"sha": baseRef.Object.SHA,
+	}, nil); err != nil {
+		return "", fmt.Errorf("failed to create branch %s: %w", branch, err)
+	}
+
+	// Commit each file to the new branch
+	for _, f := range files {
+		contentPath := repo + "/contents/" + f.path
+		update := map[string]interface{}{
+			"message": fmt.Sprintf("Update %s", f.path),
+			"content": base64.StdEncoding.EncodeToString([]byte(f.content)),
+			"branch":  branch,
+		}
+
+		// Existing files must be updated with their current blob SHA
+		var existing struct {
+			SHA string `json:"sha"`
+		}
+		if err := t.do(http.MethodGet, contentPath+"?ref="+url.QueryEscape(branch), nil, &existing); err == nil && existing.SHA != "" {
+			update["sha"] = existing.SHA
+		}
+
+		if err := t.do(http.MethodPut, contentPath, update, nil); err != nil {
+			return "", fmt.Errorf("failed to commit %s: %w", f.path, err)
+		}
+	}
+
+	var pr struct {
+		Number  int    `json:"number"`
+		HTMLURL string `json:"html_url"`
+	}
+	if err := t.do(http.MethodPost, repo+"/pulls", map[string]interface{}{
+		"title": title,
+		"body":  body,
+		"head":  branch,
+		"base":  base,
+		"draft": true,
+	}, &pr); err != nil {
+		return "", fmt.Errorf("failed to open pull request: %w", err)
+	}
+
+	return fmt.Sprintf("Opened draft pull request #%d (%s → %s) with %d file(s): %s",
+		pr.Number, branch, base, len(files), pr.HTMLURL), nil
+}
+
+// do performs a GitHub API request and decodes the JSON response into out (if non-nil)
+func (t *GitHubTool) do(method, path string, body interface{}, out interface{}) error {
+	var bodyReader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		bodyReader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, t.config.APIURL+path, bodyReader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+t.config.Token)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+	if out != nil {
+		return json.Unmarshal(respBody, out)
+	}
+	return nil
+}
+
+// stringList converts a JSON array parameter to a string slice
+func stringList(v interface{}) []string {
+	items, ok := v.([]interface{})
+	if !ok {
+		return nil
+	}
+	var result []string
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
@@ -0,0 +1,166 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const defaultGitLabAPIURL = "https://gitlab.com/api/v4"
+
+// GitLabConfig configures the GitLab tools
+type GitLabConfig struct {
+	// Token is a GitLab personal, project or group access token with api scope.
+	// When empty, the GitLab tools are disabled.
+	Token string
+
+	// Project is the numeric project ID or full path (e.g., "group/app")
+	Project string
+
+	// APIURL is the GitLab API base URL (for self-managed instances).
+	// Default: https://gitlab.com/api/v4
+	APIURL string
+}
+
+// GitLabTool implements issue creation and merge request commenting on GitLab
+type GitLabTool struct {
+	config GitLabConfig
+	client *http.Client
+}
+
+// newGitLabTool creates a GitLab tool with defaults applied
+func newGitLabTool(config GitLabConfig) *GitLabTool {
+	if config.APIURL == "" {
+		config.APIURL = defaultGitLabAPIURL
+	}
+	config.APIURL = strings.TrimRight(config.APIURL, "/")
+	return &GitLabTool{
+		config: config,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// CreateIssue opens an issue containing a diagnosis report
+func (t *GitLabTool) CreateIssue(params map[string]interface{}) (string, error) {
+	title, ok := params["title"].(string)
+	if !ok || title == "" {
+		return "", fmt.Errorf("title parameter is required")
+	}
+	description, _ := params["description"].(string)
+
+	req := map[string]interface{}{
+		"title":       title,
+		"description": description,
+	}
+	if labels := stringList(params["labels"]); len(labels) > 0 {
+		req["labels"] = strings.Join(labels, ",")
+	}
+
+	var issue struct {
+		IID    int    `json:"iid"`
+		WebURL string `json:"web_url"`
+	}
+	if err := t.do(http.MethodPost, t.projectPath()+"/issues", req, &issue); err != nil {
+		return "", fmt.Errorf("failed to create issue: %w", err)
+	}
+
+	return fmt.Sprintf("Created issue #%d: %s", issue.IID, issue.WebURL), nil
+}
+
+// CommentOnMergeRequest posts an analysis as a note on a merge request.
+// The merge request is given directly by IID or looked up from a commit SHA.
+func (t *GitLabTool) CommentOnMergeRequest(params map[string]interface{}) (string, error) {
+	body, ok := params["body"].(string)
+	if !ok || body == "" {
+		return "", fmt.Errorf("body parameter is required")
+	}
+
+	iid := 0
+	if v, ok := params["merge_request_iid"].(float64); ok {
+		iid = int(v)
+	}
+	if iid == 0 {
+		sha, _ := params["commit_sha"].(string)
+		if sha == "" {
+			return "", fmt.Errorf("merge_request_iid or commit_sha parameter is required")
+		}
+
+		var mrs []struct {
+			IID   int    `json:"iid"`
+			State string `json:"state"`
+		}
+		if err := t.do(http.MethodGet, t.projectPath()+"/repository/commits/"+url.PathEscape(sha)+"/merge_requests", nil, &mrs); err != nil {
+			return "", fmt.Errorf("failed to look up merge requests for commit %s: %w", sha, err)
+		}
+		if len(mrs) == 0 {
+			return fmt.Sprintf("No merge request found containing commit %s", sha), nil
+		}
+		// Prefer the merged MR that introduced the commit
+		iid = mrs[0].IID
+		for _, mr := range mrs {
+			if mr.State == "merged" {
+				iid = mr.IID
+				break
+			}
+		}
+	}
+
+	var note struct {
+		ID int `json:"id"`
+	}
+	path := fmt.Sprintf("%s/merge_requests/%d/notes", t.projectPath(), iid)
+	if err := t.do(http.MethodPost, path, map[string]interface{}{"body": body}, &note); err != nil {
+		return "", fmt.Errorf("failed to comment on merge request !%d: %w", iid, err)
+	}
+
+	return fmt.Sprintf("Posted analysis as comment %d on merge request !%d", note.ID, iid), nil
+}
+
+// projectPath returns the API path of the configured project
+func (t *GitLabTool) projectPath() string {
+	return "/projects/" + url.PathEscape(t.config.Project)
+}
+
+// do performs a GitLab API request and decodes the JSON response into out (if non-nil)
+func (t *GitLabTool) do(method, path string, body interface{}, out interface{}) error {
+	var bodyReader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		bodyReader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, t.config.APIURL+path, bodyReader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("PRIVATE-TOKEN", t.config.Token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("GitLab API returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+	if out != nil {
+		return json.Unmarshal(respBody, out)
+	}
+	return nil
+}
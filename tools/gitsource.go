@@ -0,0 +1,207 @@
+package tools
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultGitFetchInterval is how often the source is re-fetched
+const defaultGitFetchInterval = 10 * time.Minute
+
+// GitSourceConfig fetches the source code from a git remote instead of a
+// mounted directory. The repository is shallow-cloned at startup into a
+// cache directory and re-fetched periodically. Requires the git binary.
+type GitSourceConfig struct {
+	// URL is the repository URL (https://..., ssh://... or git@host:org/repo.git)
+	URL string
+
+	// Ref is the branch, tag or commit SHA to check out. Pin it to the
+	// revision of the running build (e.g. injected at build time).
+	// Default: the remote's default branch
+	Ref string
+
+	// Auth holds credentials for private repositories
+	Auth GitAuth
+
+	// CacheDir is the directory clones are kept in
+	// Default: ./source_cache
+	CacheDir string
+
+	// FetchInterval is how often Ref is re-fetched; negative disables
+	// fetching after startup
+	// Default: 10m
+	FetchInterval time.Duration
+
+	// CompareBranch is the branch the compare_deployed_source tool compares
+	// the deployed revision with, to find fixes not yet deployed
+	// Default: the remote's default branch
+	CompareBranch string
+}
+
+// GitAuth holds git credentials. Credentials are passed per command and are
+// never written to the clone's configuration.
+type GitAuth struct {
+	// Token is an HTTPS access token or password
+	Token string
+
+	// Username is sent with Token
+	// Default: "x-access-token" (accepted by GitHub; GitLab accepts any name)
+	Username string
+
+	// SSHKeyFile is a private key for ssh:// and git@ URLs
+	SSHKeyFile string
+}
+
+// GitSource is a shallow clone of a git remote kept at a configured ref
+type GitSource struct {
+	config  GitSourceConfig
+	dir     string
+	fetchMu sync.Mutex // serializes fetches into the clone
+
+	mu       sync.Mutex
+	revision string
+}
+
+// NewGitSource clones (or updates the cached clone of) the repository and
+// checks out the configured ref
+func NewGitSource(config GitSourceConfig) (*GitSource, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("GitSource requires the git binary: %w", err)
+	}
+	if config.CacheDir == "" {
+		config.CacheDir = "./source_cache"
+	}
+	if config.FetchInterval == 0 {
+		config.FetchInterval = defaultGitFetchInterval
+	}
+	if config.Auth.Token != "" && config.Auth.Username == "" {
+		config.Auth.Username = "x-access-token"
+	}
+
+	// One clone per repository URL
+	sum := sha256.Sum256([]byte(config.URL))
+	dir, err := filepath.Abs(filepath.Join(config.CacheDir, hex.EncodeToString(sum[:6])))
+	if err != nil {
+		return nil, err
+	}
+	g := &GitSource{config: config, dir: dir}
+
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create source cache: %w", err)
+		}
+		if _, err := g.git("init", "--quiet"); err != nil {
+			return nil, err
+		}
+		if _, err := g.git("remote", "add", "origin", config.URL); err != nil {
+			return nil, err
+		}
+	} else if _, err := g.git("remote", "set-url", "origin", config.URL); err != nil {
+		return nil, err
+	}
+
+	if err := g.Fetch(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// FS returns the checked-out source tree
+func (g *GitSource) FS() fs.FS {
+	return os.DirFS(g.dir)
+}
+
+// Dir returns the directory of the clone
+func (g *GitSource) Dir() string {
+	return g.dir
+}
+
+// Revision returns the commit SHA currently checked out
+func (g *GitSource) Revision() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.revision
+}
+
+// Fetch shallow-fetches Ref and checks it out
+func (g *GitSource) Fetch() error {
+	g.fetchMu.Lock()
+	defer g.fetchMu.Unlock()
+	ref := g.config.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	if _, err := g.git("fetch", "--quiet", "--depth", "1", "--no-tags", "origin", ref); err != nil {
+		return err
+	}
+	if _, err := g.git("checkout", "--quiet", "--force", "--detach", "FETCH_HEAD"); err != nil {
+		return err
+	}
+	out, err := g.git("rev-parse", "HEAD")
+	if err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	revision := strings.TrimSpace(out)
+	if revision != g.revision {
+		log.Printf("[Git Source] %s at %s", ref, shortRevision(revision))
+	}
+	g.revision = revision
+	return nil
+}
+
+// Run re-fetches the source every FetchInterval; it never returns
+// unless fetching is disabled
+func (g *GitSource) Run() {
+	if g.config.FetchInterval < 0 {
+		return
+	}
+	for range time.Tick(g.config.FetchInterval) {
+		if err := g.Fetch(); err != nil {
+			log.Printf("[Git Source] Fetch failed: %v", err)
+		}
+	}
+}
+
+// git runs a git command in the clone with credentials applied
+func (g *GitSource) git(args ...string) (string, error) {
+	var pre []string
+	if g.config.Auth.Token != "" {
+		basic := base64.StdEncoding.EncodeToString([]byte(g.config.Auth.Username + ":" + g.config.Auth.Token))
+		pre = append(pre, "-c", "http.extraHeader=Authorization: Basic "+basic)
+	}
+	cmd := exec.Command("git", append(pre, args...)...)
+	cmd.Dir = g.dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if g.config.Auth.SSHKeyFile != "" {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("GIT_SSH_COMMAND=ssh -i %q -o IdentitiesOnly=yes -o StrictHostKeyChecking=accept-new", g.config.Auth.SSHKeyFile))
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s failed: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// shortRevision abbreviates a commit SHA for logs
+func shortRevision(revision string) string {
+	if len(revision) > 12 {
+		return revision[:12]
+	}
+	return revision
+}
@@ -2,14 +2,14 @@ package tools
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
+	"io/fs"
+	"path"
 	"strings"
 )
 
 // GlobTool implements file pattern matching functionality
 type GlobTool struct {
-	sourcePath string
+	source fs.FS
 }
 
 // Execute finds files matching a glob pattern
@@ -22,27 +22,21 @@ func (t *GlobTool) Execute(params map[string]interface{}) (string, error) {
 	var matches []string
 
 	// Walk the source directory
-	err := filepath.Walk(t.sourcePath, func(path string, info os.FileInfo, err error) error {
+	err := fs.WalkDir(t.source, ".", func(relPath string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
 		// Skip common directories
-		if info.IsDir() {
-			if info.Name() == ".git" || info.Name() == "node_modules" || info.Name() == "vendor" {
-				return filepath.SkipDir
+		if d.IsDir() {
+			if d.Name() == ".git" || d.Name() == "node_modules" || d.Name() == "vendor" {
+				return fs.SkipDir
 			}
 			return nil
 		}
 
-		// Get relative path
-		relPath, err := filepath.Rel(t.sourcePath, path)
-		if err != nil {
-			return err
-		}
-
 		// Check if path matches the pattern
-		matched, err := filepath.Match(pattern, filepath.Base(relPath))
+		matched, err := path.Match(pattern, path.Base(relPath))
 		if err != nil {
 			return err
 		}
@@ -52,9 +46,9 @@ func (t *GlobTool) Execute(params map[string]interface{}) (string, error) {
 		if strings.Contains(pattern, "**") {
 			// Simple ** pattern support
 			simplifiedPattern := strings.ReplaceAll(pattern, "**", "*")
-			fullMatched, _ = filepath.Match(simplifiedPattern, relPath)
+			fullMatched, _ = path.Match(simplifiedPattern, relPath)
 		} else if strings.Contains(pattern, "/") {
-			fullMatched, _ = filepath.Match(pattern, relPath)
+			fullMatched, _ = path.Match(pattern, relPath)
 		}
 
 		if matched || fullMatched {
@@ -3,15 +3,15 @@ package tools
 import (
 	"bufio"
 	"fmt"
-	"os"
-	"path/filepath"
+	"io/fs"
+	"path"
 	"regexp"
 	"strings"
 )
 
 // GrepTool implements code search functionality
 type GrepTool struct {
-	sourcePath string
+	source fs.FS
 }
 
 // Execute searches for a pattern in source files
@@ -44,24 +44,23 @@ func (t *GrepTool) Execute(params map[string]interface{}) (string, error) {
 
 	// Find files to search
 	var filesToSearch []string
-	err = filepath.Walk(t.sourcePath, func(path string, info os.FileInfo, err error) error {
+	err = fs.WalkDir(t.source, ".", func(relPath string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
-		if info.IsDir() {
+		if d.IsDir() {
 			// Skip common directories
-			if info.Name() == ".git" || info.Name() == "node_modules" || info.Name() == "vendor" {
-				return filepath.SkipDir
+			if d.Name() == ".git" || d.Name() == "node_modules" || d.Name() == "vendor" {
+				return fs.SkipDir
 			}
 			return nil
 		}
 
 		// Check if file matches the file pattern
-		relPath, _ := filepath.Rel(t.sourcePath, path)
-		matched, _ := filepath.Match(filePattern, filepath.Base(path))
+		matched, _ := path.Match(filePattern, path.Base(relPath))
 		if matched || filePattern == "**/*" {
 			// Also check for common code file extensions
-			ext := filepath.Ext(path)
+			ext := path.Ext(relPath)
 			if ext == ".go" || ext == ".js" || ext == ".ts" || ext == ".py" ||
 			   ext == ".java" || ext == ".rb" || ext == ".php" || ext == ".c" ||
 			   ext == ".cpp" || ext == ".h" || ext == ".rs" || ext == ".md" ||
@@ -81,8 +80,7 @@ func (t *GrepTool) Execute(params map[string]interface{}) (string, error) {
 	matchCount := 0
 
 	for _, relPath := range filesToSearch {
-		fullPath := filepath.Join(t.sourcePath, relPath)
-		file, err := os.Open(fullPath)
+		file, err := t.source.Open(relPath)
 		if err != nil {
 			continue
 		}
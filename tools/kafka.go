@@ -0,0 +1,322 @@
+package tools
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/sasl/plain"
+	"github.com/twmb/franz-go/pkg/sasl/scram"
+)
+
+const (
+	// defaultKafkaMessages is the number of messages returned when no limit is given
+	defaultKafkaMessages = 10
+	// maxKafkaMessages caps the number of messages returned per call
+	maxKafkaMessages = 50
+)
+
+// KafkaConfig configures the Kafka inspection tool
+type KafkaConfig struct {
+	// Brokers are the seed broker addresses (e.g., "kafka-0:9092").
+	// When empty, the Kafka tool is disabled.
+	Brokers []string
+
+	// TLS enables transport security
+	TLS bool
+
+	// SASLMechanism is "PLAIN", "SCRAM-SHA-256" or "SCRAM-SHA-512".
+	// Default: "" (no SASL)
+	SASLMechanism string
+	Username      string
+	Password      string
+
+	// MaxMessageBytes truncates each message key and value.
+	// Default: 1024
+	MaxMessageBytes int
+
+	// RedactPatterns are regular expressions whose matches in message keys,
+	// values and headers are replaced with [REDACTED] (e.g., `"email":"[^"]*"`)
+	RedactPatterns []string
+
+	// HideValues returns only message metadata (offset, time, key, headers, size),
+	// for topics whose payloads must not leave the cluster
+	HideValues bool
+}
+
+// KafkaTool lists topics and consumer groups, reports lag and samples messages
+type KafkaTool struct {
+	config KafkaConfig
+	opts   []kgo.Opt
+	redact []*regexp.Regexp
+}
+
+// newKafkaTool creates a Kafka tool
+func newKafkaTool(config KafkaConfig) (*KafkaTool, error) {
+	if config.MaxMessageBytes <= 0 {
+		config.MaxMessageBytes = 1024
+	}
+
+	opts := []kgo.Opt{
+		kgo.SeedBrokers(config.Brokers...),
+		kgo.DialTimeout(5 * time.Second),
+	}
+	if config.TLS {
+		opts = append(opts, kgo.DialTLSConfig(&tls.Config{}))
+	}
+	switch strings.ToUpper(config.SASLMechanism) {
+	case "":
+	case "PLAIN":
+		opts = append(opts, kgo.SASL(plain.Auth{User: config.Username, Pass: config.Password}.AsMechanism()))
+	case "SCRAM-SHA-256":
+		opts = append(opts, kgo.SASL(scram.Auth{User: config.Username, Pass: config.Password}.AsSha256Mechanism()))
+	case "SCRAM-SHA-512":
+		opts = append(opts, kgo.SASL(scram.Auth{User: config.Username, Pass: config.Password}.AsSha512Mechanism()))
+	default:
+		return nil, fmt.Errorf("unsupported SASL mechanism: %s", config.SASLMechanism)
+	}
+
+	tool := &KafkaTool{config: config, opts: opts}
+	for _, pattern := range config.RedactPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact pattern %q: %w", pattern, err)
+		}
+		tool.redact = append(tool.redact, re)
+	}
+	return tool, nil
+}
+
+// Execute runs one of: topics, groups, lag, messages
+func (t *KafkaTool) Execute(params map[string]interface{}) (string, error) {
+	command, _ := params["command"].(string)
+	topic, _ := params["topic"].(string)
+	group, _ := params["group"].(string)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	client, err := kgo.NewClient(t.opts...)
+	if err != nil {
+		return "", fmt.Errorf("failed to create Kafka client: %w", err)
+	}
+	defer client.Close()
+	adm := kadm.NewClient(client)
+
+	switch command {
+	case "topics":
+		return t.listTopics(ctx, adm)
+	case "groups":
+		return t.listGroups(ctx, adm)
+	case "lag":
+		return t.lag(ctx, adm, group, topic)
+	case "messages":
+		if topic == "" {
+			return "", fmt.Errorf("topic parameter is required for messages")
+		}
+		limit := defaultKafkaMessages
+		if l, ok := params["limit"].(float64); ok && l > 0 {
+			limit = int(l)
+		}
+		if limit > maxKafkaMessages {
+			limit = maxKafkaMessages
+		}
+		return t.lastMessages(ctx, adm, topic, limit)
+	}
+	return "", fmt.Errorf("unknown command: %s (use topics, groups, lag or messages)", command)
+}
+
+// listTopics lists non-internal topics with partition and replica counts
+func (t *KafkaTool) listTopics(ctx context.Context, adm *kadm.Client) (string, error) {
+	topics, err := adm.ListTopics(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to list topics: %w", err)
+	}
+	if len(topics) == 0 {
+		return "No topics found", nil
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "Topics (%d):\n", len(topics))
+	for _, d := range topics.Sorted() {
+		if d.Err != nil {
+			fmt.Fprintf(&out, "  %s: error: %v\n", d.Topic, d.Err)
+			continue
+		}
+		fmt.Fprintf(&out, "  %s: %d partitions, replication %d\n", d.Topic, len(d.Partitions), d.Partitions.NumReplicas())
+	}
+	return out.String(), nil
+}
+
+// listGroups lists consumer groups with their state
+func (t *KafkaTool) listGroups(ctx context.Context, adm *kadm.Client) (string, error) {
+	groups, err := adm.ListGroups(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to list consumer groups: %w", err)
+	}
+	if len(groups) == 0 {
+		return "No consumer groups found", nil
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "Consumer groups (%d):\n", len(groups))
+	for _, g := range groups.Sorted() {
+		fmt.Fprintf(&out, "  %s: %s (%s)\n", g.Group, g.State, g.ProtocolType)
+	}
+	return out.String(), nil
+}
+
+// lag reports per-partition committed offsets, end offsets and lag.
+// Without a group, totals are reported for every group.
+func (t *KafkaTool) lag(ctx context.Context, adm *kadm.Client, group, topic string) (string, error) {
+	var groups []string
+	if group != "" {
+		groups = []string{group}
+	} else {
+		listed, err := adm.ListGroups(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to list consumer groups: %w", err)
+		}
+		groups = listed.Groups()
+	}
+	if len(groups) == 0 {
+		return "No consumer groups found", nil
+	}
+
+	lags, err := adm.Lag(ctx, groups...)
+	if err != nil {
+		return "", fmt.Errorf("failed to compute lag: %w", err)
+	}
+
+	var out strings.Builder
+	for _, l := range lags.Sorted() {
+		if err := l.Error(); err != nil {
+			fmt.Fprintf(&out, "Group %s: error: %v\n", l.Group, err)
+			continue
+		}
+		fmt.Fprintf(&out, "Group %s (%s, %d members): total lag %d\n", l.Group, l.State, len(l.Members), l.Lag.Total())
+		if group == "" {
+			continue
+		}
+		for _, m := range l.Lag.Sorted() {
+			if topic != "" && m.Topic != topic {
+				continue
+			}
+			member := "unassigned"
+			if m.Member != nil {
+				member = m.Member.ClientID + "@" + m.Member.ClientHost
+			}
+			if m.Err != nil {
+				fmt.Fprintf(&out, "  %s[%d]: error: %v\n", m.Topic, m.Partition, m.Err)
+				continue
+			}
+			committed := "none"
+			if m.Commit.At >= 0 {
+				committed = fmt.Sprint(m.Commit.At)
+			}
+			fmt.Fprintf(&out, "  %s[%d]: committed %s, end %d, lag %d (%s)\n", m.Topic, m.Partition, committed, m.End.Offset, m.Lag, member)
+		}
+	}
+	return out.String(), nil
+}
+
+// lastMessages fetches roughly the latest limit messages across all partitions of a topic
+func (t *KafkaTool) lastMessages(ctx context.Context, adm *kadm.Client, topic string, limit int) (string, error) {
+	starts, err := adm.ListStartOffsets(ctx, topic)
+	if err != nil {
+		return "", fmt.Errorf("failed to list offsets: %w", err)
+	}
+	ends, err := adm.ListEndOffsets(ctx, topic)
+	if err != nil {
+		return "", fmt.Errorf("failed to list offsets: %w", err)
+	}
+	if err := ends.Error(); err != nil {
+		return "", fmt.Errorf("failed to list offsets for %s: %w", topic, err)
+	}
+
+	// Read the last limit messages of every partition, then keep the newest overall
+	partitions := make(map[int32]kgo.Offset)
+	remaining := 0
+	for p, end := range ends[topic] {
+		from := end.Offset - int64(limit)
+		if start, ok := starts[topic][p]; ok && from < start.Offset {
+			from = start.Offset
+		}
+		if from < end.Offset {
+			partitions[p] = kgo.NewOffset().At(from)
+			remaining += int(end.Offset - from)
+		}
+	}
+	if remaining == 0 {
+		return fmt.Sprintf("Topic %s has no messages", topic), nil
+	}
+
+	consumer, err := kgo.NewClient(append(t.opts,
+		kgo.ConsumePartitions(map[string]map[int32]kgo.Offset{topic: partitions}),
+	)...)
+	if err != nil {
+		return "", fmt.Errorf("failed to create Kafka consumer: %w", err)
+	}
+	defer consumer.Close()
+
+	var records []*kgo.Record
+	for remaining > 0 && ctx.Err() == nil {
+		fetches := consumer.PollFetches(ctx)
+		fetches.EachRecord(func(r *kgo.Record) {
+			if r.Offset < ends[topic][r.Partition].Offset {
+				records = append(records, r)
+				remaining--
+			}
+		})
+		if fetches.IsClientClosed() {
+			break
+		}
+	}
+
+	sort.Slice(records, func(i, j int) bool { return records[i].Timestamp.Before(records[j].Timestamp) })
+	if len(records) > limit {
+		records = records[len(records)-limit:]
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "Last %d messages of %s:\n", len(records), topic)
+	for _, r := range records {
+		fmt.Fprintf(&out, "--- %s partition %d offset %d (%d bytes)\n", r.Timestamp.UTC().Format(time.RFC3339Nano), r.Partition, r.Offset, len(r.Value))
+		if len(r.Key) > 0 {
+			fmt.Fprintf(&out, "key: %s\n", t.formatBytes(r.Key))
+		}
+		for _, h := range r.Headers {
+			fmt.Fprintf(&out, "header %s: %s\n", h.Key, t.formatBytes(h.Value))
+		}
+		if !t.config.HideValues {
+			out.WriteString(t.formatBytes(r.Value) + "\n")
+		}
+	}
+	if ctx.Err() != nil && remaining > 0 {
+		out.WriteString("... (timed out before all messages were fetched)\n")
+	}
+	return out.String(), nil
+}
+
+// formatBytes renders a key, value or header: redacted, truncated, and
+// marked as binary when it is not valid UTF-8
+func (t *KafkaTool) formatBytes(data []byte) string {
+	if !utf8.Valid(data) {
+		return fmt.Sprintf("<binary, %d bytes>", len(data))
+	}
+	s := string(data)
+	for _, re := range t.redact {
+		s = re.ReplaceAllString(s, "[REDACTED]")
+	}
+	if len(s) > t.config.MaxMessageBytes {
+		s = s[:t.config.MaxMessageBytes] + "... (truncated)"
+	}
+	return s
+}
@@ -0,0 +1,338 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultKnowledgeResults is the number of reports returned by a search
+	defaultKnowledgeResults = 5
+	// maxKnowledgeReportChars truncates each report returned by a search
+	maxKnowledgeReportChars = 2000
+)
+
+// KnowledgeBaseConfig configures where confirmed root-cause reports are stored
+// and searched. Reports are written to every configured store.
+type KnowledgeBaseConfig struct {
+	// Dir is a local folder where reports are written as Markdown files
+	Dir string
+
+	// Notion stores reports as pages in a Notion database
+	Notion NotionKnowledgeConfig
+
+	// Confluence stores reports as pages in a Confluence space
+	Confluence ConfluenceKnowledgeConfig
+
+	// Stores are additional custom backends
+	Stores []KnowledgeStore
+}
+
+// KnowledgeStore is a backend for root-cause reports.
+// Implement it to keep the knowledge base in another system.
+type KnowledgeStore interface {
+	// Name identifies the store in tool output (e.g., "markdown", "notion")
+	Name() string
+
+	// Save stores a report and returns its location (path or URL)
+	Save(report RootCauseReport) (string, error)
+
+	// Search returns up to limit reports relevant to the query, best first
+	Search(query string, limit int) ([]RootCauseReport, error)
+}
+
+// RootCauseReport is a confirmed diagnosis of an incident
+type RootCauseReport struct {
+	Title     string
+	Summary   string
+	RootCause string
+	Fix       string
+	Files     []string
+	Tags      []string
+	CreatedAt time.Time
+
+	// Location is where the report is stored (set by stores on search)
+	Location string
+}
+
+// Markdown renders the report as a Markdown document
+func (r RootCauseReport) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", r.Title)
+	fmt.Fprintf(&b, "- Date: %s\n", r.CreatedAt.UTC().Format(time.RFC3339))
+	if len(r.Tags) > 0 {
+		fmt.Fprintf(&b, "- Tags: %s\n", strings.Join(r.Tags, ", "))
+	}
+	if len(r.Files) > 0 {
+		fmt.Fprintf(&b, "- Files: %s\n", strings.Join(r.Files, ", "))
+	}
+	for _, section := range r.sections() {
+		if section[1] != "" {
+			fmt.Fprintf(&b, "\n## %s\n\n%s\n", section[0], strings.TrimSpace(section[1]))
+		}
+	}
+	return b.String()
+}
+
+// sections returns the report's headings and bodies in display order
+func (r RootCauseReport) sections() [][2]string {
+	return [][2]string{
+		{"Summary", r.Summary},
+		{"Root Cause", r.RootCause},
+		{"Fix", r.Fix},
+	}
+}
+
+// KnowledgeBaseTool saves and searches root-cause reports
+type KnowledgeBaseTool struct {
+	stores []KnowledgeStore
+}
+
+// newKnowledgeBaseTool creates the tool with every configured store
+func newKnowledgeBaseTool(config KnowledgeBaseConfig) (*KnowledgeBaseTool, error) {
+	var stores []KnowledgeStore
+	if config.Dir != "" {
+		if err := os.MkdirAll(config.Dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create knowledge base folder: %w", err)
+		}
+		stores = append(stores, &markdownKnowledgeStore{dir: config.Dir})
+	}
+	if config.Notion.Token != "" {
+		store, err := newNotionKnowledgeStore(config.Notion)
+		if err != nil {
+			return nil, err
+		}
+		stores = append(stores, store)
+	}
+	if config.Confluence.URL != "" {
+		store, err := newConfluenceKnowledgeStore(config.Confluence)
+		if err != nil {
+			return nil, err
+		}
+		stores = append(stores, store)
+	}
+	stores = append(stores, config.Stores...)
+	if len(stores) == 0 {
+		return nil, fmt.Errorf("no knowledge base store configured")
+	}
+	return &KnowledgeBaseTool{stores: stores}, nil
+}
+
+// Record saves a root-cause report to every store
+func (t *KnowledgeBaseTool) Record(params map[string]interface{}) (string, error) {
+	report := RootCauseReport{CreatedAt: time.Now()}
+	report.Title, _ = params["title"].(string)
+	report.Summary, _ = params["summary"].(string)
+	report.RootCause, _ = params["root_cause"].(string)
+	report.Fix, _ = params["fix"].(string)
+	report.Files = stringList(params["files"])
+	report.Tags = stringList(params["tags"])
+	if report.Title == "" || report.RootCause == "" {
+		return "", fmt.Errorf("title and root_cause parameters are required")
+	}
+
+	var out strings.Builder
+	failed := 0
+	for _, store := range t.stores {
+		location, err := store.Save(report)
+		if err != nil {
+			failed++
+			fmt.Fprintf(&out, "%s: failed: %v\n", store.Name(), err)
+			continue
+		}
+		fmt.Fprintf(&out, "%s: saved to %s\n", store.Name(), location)
+	}
+	if failed == len(t.stores) {
+		return "", fmt.Errorf("failed to save report:\n%s", out.String())
+	}
+	return "Root-cause report recorded.\n" + out.String(), nil
+}
+
+// Search finds past reports relevant to the query across all stores
+func (t *KnowledgeBaseTool) Search(params map[string]interface{}) (string, error) {
+	query, _ := params["query"].(string)
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return "", fmt.Errorf("query parameter is required")
+	}
+	limit := defaultKnowledgeResults
+	if l, ok := params["limit"].(float64); ok && l > 0 && l <= 20 {
+		limit = int(l)
+	}
+
+	var out strings.Builder
+	found := 0
+	for _, store := range t.stores {
+		reports, err := store.Search(query, limit)
+		if err != nil {
+			fmt.Fprintf(&out, "[%s] search failed: %v\n\n", store.Name(), err)
+			continue
+		}
+		for _, r := range reports {
+			found++
+			text := r.Markdown()
+			if len(text) > maxKnowledgeReportChars {
+				text = text[:maxKnowledgeReportChars] + "\n... (truncated)"
+			}
+			fmt.Fprintf(&out, "[%s] %s\n%s\n\n", store.Name(), r.Location, text)
+		}
+	}
+	if found == 0 {
+		return fmt.Sprintf("No past root-cause reports found for %q\n%s", query, out.String()), nil
+	}
+	return fmt.Sprintf("Found %d past root-cause reports for %q:\n\n%s", found, query, out.String()), nil
+}
+
+// searchTerms splits a query into lowercase words worth matching
+func searchTerms(query string) []string {
+	var terms []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' || r == '.' || r > 127)
+	}) {
+		if len(word) >= 2 {
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
+
+// --- Markdown folder store ---
+
+// markdownKnowledgeStore keeps one Markdown file per report in a folder
+type markdownKnowledgeStore struct {
+	dir string
+}
+
+func (s *markdownKnowledgeStore) Name() string {
+	return "markdown"
+}
+
+var slugInvalid = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Save writes the report to <dir>/<date>-<slug>.md
+func (s *markdownKnowledgeStore) Save(report RootCauseReport) (string, error) {
+	slug := strings.Trim(slugInvalid.ReplaceAllString(strings.ToLower(report.Title), "-"), "-")
+	if len(slug) > 60 {
+		slug = strings.TrimRight(slug[:60], "-")
+	}
+	name := report.CreatedAt.Format("2006-01-02-150405") + "-" + slug + ".md"
+	path := filepath.Join(s.dir, name)
+	if err := os.WriteFile(path, []byte(report.Markdown()), 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// Search scores every report by the number of distinct query terms it
+// contains, then by total occurrences
+func (s *markdownKnowledgeStore) Search(query string, limit int) ([]RootCauseReport, error) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.md"))
+	if err != nil {
+		return nil, err
+	}
+
+	type scored struct {
+		report   RootCauseReport
+		distinct int
+		total    int
+	}
+	var matches []scored
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		lower := strings.ToLower(string(data))
+		m := scored{}
+		for _, term := range terms {
+			if n := strings.Count(lower, term); n > 0 {
+				m.distinct++
+				m.total += n
+			}
+		}
+		if m.distinct == 0 {
+			continue
+		}
+		m.report = parseMarkdownReport(string(data))
+		m.report.Location = path
+		matches = append(matches, m)
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].distinct != matches[j].distinct {
+			return matches[i].distinct > matches[j].distinct
+		}
+		if matches[i].total != matches[j].total {
+			return matches[i].total > matches[j].total
+		}
+		return matches[i].report.CreatedAt.After(matches[j].report.CreatedAt)
+	})
+	var reports []RootCauseReport
+	for i := 0; i < len(matches) && i < limit; i++ {
+		reports = append(reports, matches[i].report)
+	}
+	return reports, nil
+}
+
+// parseMarkdownReport reads a report written by RootCauseReport.Markdown.
+// Hand-edited files that do not follow the layout end up in Summary.
+func parseMarkdownReport(text string) RootCauseReport {
+	var r RootCauseReport
+	var section *string
+	var body []string
+	flush := func() {
+		if section != nil {
+			*section = strings.TrimSpace(strings.Join(body, "\n"))
+		}
+		body = nil
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		switch {
+		case strings.HasPrefix(line, "# ") && r.Title == "":
+			r.Title = strings.TrimSpace(line[2:])
+			continue
+		case strings.HasPrefix(line, "## "):
+			flush()
+			switch strings.TrimSpace(line[3:]) {
+			case "Summary":
+				section = &r.Summary
+			case "Root Cause":
+				section = &r.RootCause
+			case "Fix":
+				section = &r.Fix
+			default:
+				// Unknown headings stay in the current section
+				body = append(body, line)
+			}
+			continue
+		case section == nil && strings.HasPrefix(line, "- Date: "):
+			r.CreatedAt, _ = time.Parse(time.RFC3339, strings.TrimSpace(line[8:]))
+			continue
+		case section == nil && strings.HasPrefix(line, "- Tags: "):
+			r.Tags = strings.Split(strings.TrimSpace(line[8:]), ", ")
+			continue
+		case section == nil && strings.HasPrefix(line, "- Files: "):
+			r.Files = strings.Split(strings.TrimSpace(line[9:]), ", ")
+			continue
+		}
+		if section == nil {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			section = &r.Summary
+		}
+		body = append(body, line)
+	}
+	flush()
+	return r
+}
@@ -0,0 +1,819 @@
+package tools
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+	// maxKubeEvents limits the number of events shown
+	maxKubeEvents = 50
+)
+
+// KubernetesConfig configures the read-only Kubernetes tools.
+// They only ever read resources in the configured namespace.
+type KubernetesConfig struct {
+	// InCluster uses the pod's service account (token, CA and namespace).
+	// The service account needs get/list on pods, events, resourcequotas and
+	// limitranges in the namespace (and optionally pods.metrics.k8s.io).
+	InCluster bool
+
+	// Kubeconfig is the path to a kubeconfig file. Token, client certificate,
+	// basic auth and exec credential plugins are supported.
+	// When empty and InCluster is false, the Kubernetes tools are disabled.
+	Kubeconfig string
+
+	// Context is the kubeconfig context to use.
+	// Default: the kubeconfig's current-context
+	Context string
+
+	// Namespace is the application's namespace.
+	// Default: the service account's namespace in-cluster, else the context's namespace, else "default"
+	Namespace string
+
+	// LabelSelector selects the application's pods (e.g., "app=checkout").
+	// Default: all pods in the namespace
+	LabelSelector string
+}
+
+// KubernetesTool lists pods, describes them, and reports events and resource limits
+type KubernetesTool struct {
+	config    KubernetesConfig
+	server    string
+	namespace string
+	client    *http.Client
+
+	// Authentication: a token (re-read from tokenFile, which may be rotated),
+	// basic auth, or an exec credential plugin
+	token     string
+	tokenFile string
+	username  string
+	password  string
+	exec      *kubeExecConfig
+
+	mu          sync.Mutex
+	execToken   string
+	execExpires time.Time
+}
+
+// newKubernetesTool creates a Kubernetes tool from in-cluster or kubeconfig credentials
+func newKubernetesTool(config KubernetesConfig) (*KubernetesTool, error) {
+	t := &KubernetesTool{config: config}
+	var err error
+	if config.InCluster {
+		err = t.loadInCluster()
+	} else {
+		err = t.loadKubeconfig(config.Kubeconfig, config.Context)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if config.Namespace != "" {
+		t.namespace = config.Namespace
+	}
+	if t.namespace == "" {
+		t.namespace = "default"
+	}
+	return t, nil
+}
+
+// loadInCluster reads the mounted service account credentials
+func (t *KubernetesTool) loadInCluster() error {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return fmt.Errorf("not running in a Kubernetes cluster (KUBERNETES_SERVICE_HOST is not set)")
+	}
+	tokenFile := filepath.Join(serviceAccountDir, "token")
+	if _, err := os.Stat(tokenFile); err != nil {
+		return fmt.Errorf("failed to read service account token: %w", err)
+	}
+	ca, err := os.ReadFile(filepath.Join(serviceAccountDir, "ca.crt"))
+	if err != nil {
+		return fmt.Errorf("failed to read service account CA: %w", err)
+	}
+	if ns, err := os.ReadFile(filepath.Join(serviceAccountDir, "namespace")); err == nil {
+		t.namespace = strings.TrimSpace(string(ns))
+	}
+
+	tlsConfig, err := kubeTLSConfig(ca, nil, nil, false)
+	if err != nil {
+		return err
+	}
+	t.server = "https://" + strings.TrimSuffix(host, ".") + ":" + port
+	if strings.Contains(host, ":") {
+		t.server = "https://[" + host + "]:" + port
+	}
+	t.tokenFile = tokenFile
+	t.client = &http.Client{Timeout: 20 * time.Second, Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+	return nil
+}
+
+// kubeconfig is the subset of the kubeconfig file format used by the tool
+type kubeconfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Clusters       []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server                   string `yaml:"server"`
+			CertificateAuthority     string `yaml:"certificate-authority"`
+			CertificateAuthorityData string `yaml:"certificate-authority-data"`
+			InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Users []struct {
+		Name string `yaml:"name"`
+		User struct {
+			Token                 string          `yaml:"token"`
+			TokenFile             string          `yaml:"tokenFile"`
+			ClientCertificate     string          `yaml:"client-certificate"`
+			ClientCertificateData string          `yaml:"client-certificate-data"`
+			ClientKey             string          `yaml:"client-key"`
+			ClientKeyData         string          `yaml:"client-key-data"`
+			Username              string          `yaml:"username"`
+			Password              string          `yaml:"password"`
+			Exec                  *kubeExecConfig `yaml:"exec"`
+		} `yaml:"user"`
+	} `yaml:"users"`
+	Contexts []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster   string `yaml:"cluster"`
+			User      string `yaml:"user"`
+			Namespace string `yaml:"namespace"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+}
+
+// kubeExecConfig is an exec credential plugin (e.g., aws eks get-token, gke-gcloud-auth-plugin)
+type kubeExecConfig struct {
+	Command string   `yaml:"command"`
+	Args    []string `yaml:"args"`
+	Env     []struct {
+		Name  string `yaml:"name"`
+		Value string `yaml:"value"`
+	} `yaml:"env"`
+	APIVersion string `yaml:"apiVersion"`
+}
+
+// loadKubeconfig reads the cluster, user and namespace of a kubeconfig context
+func (t *KubernetesTool) loadKubeconfig(path, contextName string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read kubeconfig: %w", err)
+	}
+	var kc kubeconfig
+	if err := yaml.Unmarshal(data, &kc); err != nil {
+		return fmt.Errorf("invalid kubeconfig: %w", err)
+	}
+	// Relative file references are relative to the kubeconfig's folder
+	dir := filepath.Dir(path)
+	readRef := func(file, inline string) ([]byte, error) {
+		if inline != "" {
+			return base64.StdEncoding.DecodeString(inline)
+		}
+		if file == "" {
+			return nil, nil
+		}
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+		return os.ReadFile(file)
+	}
+
+	if contextName == "" {
+		contextName = kc.CurrentContext
+	}
+	var clusterName, userName string
+	found := false
+	for _, c := range kc.Contexts {
+		if c.Name == contextName {
+			clusterName, userName, t.namespace = c.Context.Cluster, c.Context.User, c.Context.Namespace
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("context %q not found in kubeconfig", contextName)
+	}
+
+	var ca []byte
+	insecure := false
+	for _, c := range kc.Clusters {
+		if c.Name != clusterName {
+			continue
+		}
+		t.server = strings.TrimRight(c.Cluster.Server, "/")
+		insecure = c.Cluster.InsecureSkipTLSVerify
+		if ca, err = readRef(c.Cluster.CertificateAuthority, c.Cluster.CertificateAuthorityData); err != nil {
+			return fmt.Errorf("failed to read cluster CA: %w", err)
+		}
+	}
+	if t.server == "" {
+		return fmt.Errorf("cluster %q not found in kubeconfig", clusterName)
+	}
+
+	var cert, key []byte
+	for _, u := range kc.Users {
+		if u.Name != userName {
+			continue
+		}
+		t.token = u.User.Token
+		if u.User.TokenFile != "" {
+			t.tokenFile = u.User.TokenFile
+			if !filepath.IsAbs(t.tokenFile) {
+				t.tokenFile = filepath.Join(dir, t.tokenFile)
+			}
+		}
+		t.username, t.password = u.User.Username, u.User.Password
+		t.exec = u.User.Exec
+		if cert, err = readRef(u.User.ClientCertificate, u.User.ClientCertificateData); err != nil {
+			return fmt.Errorf("failed to read client certificate: %w", err)
+		}
+		if key, err = readRef(u.User.ClientKey, u.User.ClientKeyData); err != nil {
+			return fmt.Errorf("failed to read client key: %w", err)
+		}
+	}
+
+	tlsConfig, err := kubeTLSConfig(ca, cert, key, insecure)
+	if err != nil {
+		return err
+	}
+	t.client = &http.Client{Timeout: 20 * time.Second, Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+	return nil
+}
+
+// kubeTLSConfig builds the TLS configuration for the API server
+func kubeTLSConfig(ca, cert, key []byte, insecure bool) (*tls.Config, error) {
+	config := &tls.Config{InsecureSkipVerify: insecure}
+	if len(ca) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("invalid cluster CA certificate")
+		}
+		config.RootCAs = pool
+	}
+	if len(cert) > 0 && len(key) > 0 {
+		pair, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{pair}
+	}
+	return config, nil
+}
+
+// Execute runs one of: pods, describe_pod, events, resources
+func (t *KubernetesTool) Execute(params map[string]interface{}) (string, error) {
+	command, _ := params["command"].(string)
+	name, _ := params["name"].(string)
+	selector, _ := params["label_selector"].(string)
+	if selector == "" {
+		selector = t.config.LabelSelector
+	}
+
+	switch command {
+	case "pods":
+		return t.listPods(selector)
+	case "describe_pod":
+		if name == "" {
+			return "", fmt.Errorf("name parameter is required for describe_pod")
+		}
+		return t.describePod(name)
+	case "events":
+		warningsOnly, _ := params["warnings_only"].(bool)
+		return t.events(name, warningsOnly)
+	case "resources":
+		return t.resources(selector)
+	}
+	return "", fmt.Errorf("unknown command: %s (use pods, describe_pod, events or resources)", command)
+}
+
+// --- API types (subset) ---
+
+type kubeMeta struct {
+	Name              string            `json:"name"`
+	CreationTimestamp time.Time         `json:"creationTimestamp"`
+	DeletionTimestamp *time.Time        `json:"deletionTimestamp"`
+	Labels            map[string]string `json:"labels"`
+	OwnerReferences   []struct {
+		Kind string `json:"kind"`
+		Name string `json:"name"`
+	} `json:"ownerReferences"`
+}
+
+type kubeContainerState struct {
+	Waiting *struct {
+		Reason  string `json:"reason"`
+		Message string `json:"message"`
+	} `json:"waiting"`
+	Running *struct {
+		StartedAt time.Time `json:"startedAt"`
+	} `json:"running"`
+	Terminated *struct {
+		Reason     string    `json:"reason"`
+		Message    string    `json:"message"`
+		ExitCode   int       `json:"exitCode"`
+		FinishedAt time.Time `json:"finishedAt"`
+	} `json:"terminated"`
+}
+
+type kubeResources struct {
+	Requests map[string]string `json:"requests"`
+	Limits   map[string]string `json:"limits"`
+}
+
+type kubePod struct {
+	Metadata kubeMeta `json:"metadata"`
+	Spec     struct {
+		NodeName   string `json:"nodeName"`
+		Containers []struct {
+			Name      string        `json:"name"`
+			Image     string        `json:"image"`
+			Resources kubeResources `json:"resources"`
+		} `json:"containers"`
+	} `json:"spec"`
+	Status struct {
+		Phase      string    `json:"phase"`
+		Reason     string    `json:"reason"`
+		Message    string    `json:"message"`
+		QOSClass   string    `json:"qosClass"`
+		StartTime  time.Time `json:"startTime"`
+		Conditions []struct {
+			Type    string `json:"type"`
+			Status  string `json:"status"`
+			Reason  string `json:"reason"`
+			Message string `json:"message"`
+		} `json:"conditions"`
+		ContainerStatuses []struct {
+			Name         string             `json:"name"`
+			Ready        bool               `json:"ready"`
+			RestartCount int                `json:"restartCount"`
+			State        kubeContainerState `json:"state"`
+			LastState    kubeContainerState `json:"lastState"`
+		} `json:"containerStatuses"`
+	} `json:"status"`
+}
+
+type kubeEvent struct {
+	Metadata       kubeMeta `json:"metadata"`
+	Type           string   `json:"type"`
+	Reason         string   `json:"reason"`
+	Message        string   `json:"message"`
+	Count          int      `json:"count"`
+	InvolvedObject struct {
+		Kind string `json:"kind"`
+		Name string `json:"name"`
+	} `json:"involvedObject"`
+	LastTimestamp time.Time `json:"lastTimestamp"`
+	EventTime     time.Time `json:"eventTime"`
+	Series        *struct {
+		Count            int       `json:"count"`
+		LastObservedTime time.Time `json:"lastObservedTime"`
+	} `json:"series"`
+}
+
+// time returns the most recent occurrence of the event
+func (e kubeEvent) time() time.Time {
+	switch {
+	case e.Series != nil && !e.Series.LastObservedTime.IsZero():
+		return e.Series.LastObservedTime
+	case !e.LastTimestamp.IsZero():
+		return e.LastTimestamp
+	case !e.EventTime.IsZero():
+		return e.EventTime
+	}
+	return e.Metadata.CreationTimestamp
+}
+
+// --- Commands ---
+
+// listPods prints a kubectl-like pod table with the last termination reason
+func (t *KubernetesTool) listPods(selector string) (string, error) {
+	var list struct {
+		Items []kubePod `json:"items"`
+	}
+	query := url.Values{}
+	if selector != "" {
+		query.Set("labelSelector", selector)
+	}
+	if err := t.get("/api/v1/namespaces/"+t.namespace+"/pods", query, &list); err != nil {
+		return "", err
+	}
+	if len(list.Items) == 0 {
+		return fmt.Sprintf("No pods found in namespace %s (selector %q)", t.namespace, selector), nil
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "Pods in namespace %s (%d):\n", t.namespace, len(list.Items))
+	for _, p := range list.Items {
+		ready, restarts := 0, 0
+		var lastTermination string
+		for _, cs := range p.Status.ContainerStatuses {
+			if cs.Ready {
+				ready++
+			}
+			restarts += cs.RestartCount
+			if term := cs.LastState.Terminated; term != nil {
+				lastTermination = fmt.Sprintf("last restart: %s %s (exit %d, %s ago)", cs.Name, term.Reason, term.ExitCode, formatAge(time.Since(term.FinishedAt)))
+			}
+		}
+		fmt.Fprintf(&out, "  %s  ready %d/%d  %s  restarts %d  node %s  age %s",
+			p.Metadata.Name, ready, len(p.Spec.Containers), podStatus(p), restarts, p.Spec.NodeName, formatAge(time.Since(p.Metadata.CreationTimestamp)))
+		if lastTermination != "" {
+			out.WriteString("  " + lastTermination)
+		}
+		out.WriteString("\n")
+	}
+	return out.String(), nil
+}
+
+// podStatus summarizes a pod's status the way kubectl get pods does
+func podStatus(p kubePod) string {
+	if p.Metadata.DeletionTimestamp != nil {
+		return "Terminating"
+	}
+	status := p.Status.Phase
+	if p.Status.Reason != "" {
+		status = p.Status.Reason
+	}
+	for _, cs := range p.Status.ContainerStatuses {
+		if w := cs.State.Waiting; w != nil && w.Reason != "" {
+			return w.Reason
+		}
+		if term := cs.State.Terminated; term != nil && term.Reason != "" {
+			return term.Reason
+		}
+	}
+	return status
+}
+
+// describePod prints a pod's containers, states, resources, conditions and events
+func (t *KubernetesTool) describePod(name string) (string, error) {
+	var p kubePod
+	if err := t.get("/api/v1/namespaces/"+t.namespace+"/pods/"+url.PathEscape(name), nil, &p); err != nil {
+		return "", err
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "Pod: %s\nNamespace: %s\nNode: %s\nStatus: %s\n", p.Metadata.Name, t.namespace, p.Spec.NodeName, podStatus(p))
+	if p.Status.Message != "" {
+		fmt.Fprintf(&out, "Message: %s\n", p.Status.Message)
+	}
+	fmt.Fprintf(&out, "QoS class: %s\n", p.Status.QOSClass)
+	if !p.Status.StartTime.IsZero() {
+		fmt.Fprintf(&out, "Started: %s\n", p.Status.StartTime.Format(time.RFC3339))
+	}
+	for _, owner := range p.Metadata.OwnerReferences {
+		fmt.Fprintf(&out, "Controlled by: %s/%s\n", owner.Kind, owner.Name)
+	}
+
+	out.WriteString("\nContainers:\n")
+	for _, c := range p.Spec.Containers {
+		fmt.Fprintf(&out, "  %s:\n    Image: %s\n", c.Name, c.Image)
+		fmt.Fprintf(&out, "    Requests: %s\n    Limits: %s\n", formatQuantities(c.Resources.Requests), formatQuantities(c.Resources.Limits))
+		for _, cs := range p.Status.ContainerStatuses {
+			if cs.Name != c.Name {
+				continue
+			}
+			fmt.Fprintf(&out, "    Ready: %v, restarts: %d\n", cs.Ready, cs.RestartCount)
+			fmt.Fprintf(&out, "    State: %s\n", formatContainerState(cs.State))
+			if cs.LastState.Terminated != nil {
+				fmt.Fprintf(&out, "    Last state: %s\n", formatContainerState(cs.LastState))
+			}
+		}
+	}
+
+	out.WriteString("\nConditions:\n")
+	for _, c := range p.Status.Conditions {
+		fmt.Fprintf(&out, "  %s=%s", c.Type, c.Status)
+		if c.Reason != "" {
+			fmt.Fprintf(&out, " (%s: %s)", c.Reason, c.Message)
+		}
+		out.WriteString("\n")
+	}
+
+	events, err := t.listEvents(url.Values{"fieldSelector": {"involvedObject.kind=Pod,involvedObject.name=" + name}})
+	out.WriteString("\nEvents:\n")
+	if err != nil {
+		fmt.Fprintf(&out, "  (failed to list events: %v)\n", err)
+	} else {
+		writeEvents(&out, events)
+	}
+	return out.String(), nil
+}
+
+// events prints recent events in the namespace, optionally for one object
+func (t *KubernetesTool) events(objectName string, warningsOnly bool) (string, error) {
+	var selectors []string
+	if objectName != "" {
+		selectors = append(selectors, "involvedObject.name="+objectName)
+	}
+	if warningsOnly {
+		selectors = append(selectors, "type=Warning")
+	}
+	query := url.Values{}
+	if len(selectors) > 0 {
+		query.Set("fieldSelector", strings.Join(selectors, ","))
+	}
+	events, err := t.listEvents(query)
+	if err != nil {
+		return "", err
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "Events in namespace %s (%d, newest last):\n", t.namespace, len(events))
+	writeEvents(&out, events)
+	return out.String(), nil
+}
+
+// listEvents fetches events sorted oldest first, keeping the latest maxKubeEvents
+func (t *KubernetesTool) listEvents(query url.Values) ([]kubeEvent, error) {
+	var list struct {
+		Items []kubeEvent `json:"items"`
+	}
+	if err := t.get("/api/v1/namespaces/"+t.namespace+"/events", query, &list); err != nil {
+		return nil, err
+	}
+	events := list.Items
+	sort.Slice(events, func(i, j int) bool { return events[i].time().Before(events[j].time()) })
+	if len(events) > maxKubeEvents {
+		events = events[len(events)-maxKubeEvents:]
+	}
+	return events, nil
+}
+
+func writeEvents(out *strings.Builder, events []kubeEvent) {
+	if len(events) == 0 {
+		out.WriteString("  (none; events are kept for about an hour)\n")
+	}
+	for _, e := range events {
+		count := e.Count
+		if e.Series != nil && e.Series.Count > count {
+			count = e.Series.Count
+		}
+		fmt.Fprintf(out, "  %s  %s  %s  %s/%s: %s", e.time().UTC().Format(time.RFC3339), e.Type, e.Reason, e.InvolvedObject.Kind, e.InvolvedObject.Name, strings.TrimSpace(e.Message))
+		if count > 1 {
+			fmt.Fprintf(out, " (x%d)", count)
+		}
+		out.WriteString("\n")
+	}
+}
+
+// resources prints quotas, limit ranges, and container requests/limits with
+// current usage when metrics-server is available
+func (t *KubernetesTool) resources(selector string) (string, error) {
+	var out strings.Builder
+	fmt.Fprintf(&out, "Resources in namespace %s:\n", t.namespace)
+
+	var quotas struct {
+		Items []struct {
+			Metadata kubeMeta `json:"metadata"`
+			Status   struct {
+				Hard map[string]string `json:"hard"`
+				Used map[string]string `json:"used"`
+			} `json:"status"`
+		} `json:"items"`
+	}
+	out.WriteString("\nResource quotas:\n")
+	if err := t.get("/api/v1/namespaces/"+t.namespace+"/resourcequotas", nil, &quotas); err != nil {
+		fmt.Fprintf(&out, "  (failed to list: %v)\n", err)
+	} else if len(quotas.Items) == 0 {
+		out.WriteString("  (none)\n")
+	}
+	for _, q := range quotas.Items {
+		fmt.Fprintf(&out, "  %s:\n", q.Metadata.Name)
+		for _, res := range sortedKeys(q.Status.Hard) {
+			fmt.Fprintf(&out, "    %s: used %s of %s\n", res, q.Status.Used[res], q.Status.Hard[res])
+		}
+	}
+
+	var limitRanges struct {
+		Items []struct {
+			Metadata kubeMeta `json:"metadata"`
+			Spec     struct {
+				Limits []struct {
+					Type           string            `json:"type"`
+					Default        map[string]string `json:"default"`
+					DefaultRequest map[string]string `json:"defaultRequest"`
+					Max            map[string]string `json:"max"`
+				} `json:"limits"`
+			} `json:"spec"`
+		} `json:"items"`
+	}
+	out.WriteString("\nLimit ranges:\n")
+	if err := t.get("/api/v1/namespaces/"+t.namespace+"/limitranges", nil, &limitRanges); err != nil {
+		fmt.Fprintf(&out, "  (failed to list: %v)\n", err)
+	} else if len(limitRanges.Items) == 0 {
+		out.WriteString("  (none)\n")
+	}
+	for _, lr := range limitRanges.Items {
+		for _, l := range lr.Spec.Limits {
+			fmt.Fprintf(&out, "  %s (%s): default limits %s, default requests %s, max %s\n",
+				lr.Metadata.Name, l.Type, formatQuantities(l.Default), formatQuantities(l.DefaultRequest), formatQuantities(l.Max))
+		}
+	}
+
+	var pods struct {
+		Items []kubePod `json:"items"`
+	}
+	query := url.Values{}
+	if selector != "" {
+		query.Set("labelSelector", selector)
+	}
+	if err := t.get("/api/v1/namespaces/"+t.namespace+"/pods", query, &pods); err != nil {
+		return "", err
+	}
+
+	// Usage from metrics-server is optional
+	usage := make(map[string]map[string]string) // pod/container -> resource -> quantity
+	var metrics struct {
+		Items []struct {
+			Metadata   kubeMeta `json:"metadata"`
+			Containers []struct {
+				Name  string            `json:"name"`
+				Usage map[string]string `json:"usage"`
+			} `json:"containers"`
+		} `json:"items"`
+	}
+	metricsErr := t.get("/apis/metrics.k8s.io/v1beta1/namespaces/"+t.namespace+"/pods", query, &metrics)
+	for _, m := range metrics.Items {
+		for _, c := range m.Containers {
+			usage[m.Metadata.Name+"/"+c.Name] = c.Usage
+		}
+	}
+
+	out.WriteString("\nContainers:\n")
+	for _, p := range pods.Items {
+		for _, c := range p.Spec.Containers {
+			fmt.Fprintf(&out, "  %s/%s: requests %s, limits %s", p.Metadata.Name, c.Name, formatQuantities(c.Resources.Requests), formatQuantities(c.Resources.Limits))
+			if u, ok := usage[p.Metadata.Name+"/"+c.Name]; ok {
+				fmt.Fprintf(&out, ", usage %s", formatQuantities(u))
+			}
+			out.WriteString("\n")
+		}
+	}
+	if metricsErr != nil {
+		out.WriteString("(current usage unavailable: metrics-server not reachable)\n")
+	}
+	return out.String(), nil
+}
+
+func formatContainerState(s kubeContainerState) string {
+	switch {
+	case s.Running != nil:
+		return fmt.Sprintf("Running since %s", s.Running.StartedAt.Format(time.RFC3339))
+	case s.Waiting != nil:
+		if s.Waiting.Message != "" {
+			return fmt.Sprintf("Waiting (%s: %s)", s.Waiting.Reason, s.Waiting.Message)
+		}
+		return fmt.Sprintf("Waiting (%s)", s.Waiting.Reason)
+	case s.Terminated != nil:
+		state := fmt.Sprintf("Terminated (%s, exit code %d) at %s", s.Terminated.Reason, s.Terminated.ExitCode, s.Terminated.FinishedAt.Format(time.RFC3339))
+		if s.Terminated.Message != "" {
+			state += ": " + s.Terminated.Message
+		}
+		return state
+	}
+	return "Unknown"
+}
+
+// formatQuantities renders a resource map as "cpu=100m memory=128Mi"
+func formatQuantities(q map[string]string) string {
+	if len(q) == 0 {
+		return "none"
+	}
+	var parts []string
+	for _, k := range sortedKeys(q) {
+		parts = append(parts, k+"="+q[k])
+	}
+	return strings.Join(parts, " ")
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatAge renders a duration like kubectl: 45s, 12m, 5h, 3d
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(d.Hours()/24))
+}
+
+// get sends a GET request to the API server and decodes the JSON response into out
+func (t *KubernetesTool) get(path string, query url.Values, out interface{}) error {
+	u := t.server + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	token := t.token
+	if t.tokenFile != "" {
+		data, err := os.ReadFile(t.tokenFile)
+		if err != nil {
+			return fmt.Errorf("failed to read token file: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	if t.exec != nil {
+		if token, err = t.execCredential(); err != nil {
+			return err
+		}
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else if t.username != "" {
+		req.SetBasicAuth(t.username, t.password)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var status struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(body, &status) == nil && status.Message != "" {
+			return fmt.Errorf("Kubernetes API returned status %d: %s", resp.StatusCode, status.Message)
+		}
+		return fmt.Errorf("Kubernetes API returned status %d: %s", resp.StatusCode, string(body))
+	}
+	return json.Unmarshal(body, out)
+}
+
+// execCredential runs the exec plugin, caching the token until it expires
+func (t *KubernetesTool) execCredential() (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.execToken != "" && (t.execExpires.IsZero() || time.Now().Add(time.Minute).Before(t.execExpires)) {
+		return t.execToken, nil
+	}
+
+	cmd := exec.Command(t.exec.Command, t.exec.Args...)
+	cmd.Env = os.Environ()
+	for _, e := range t.exec.Env {
+		cmd.Env = append(cmd.Env, e.Name+"="+e.Value)
+	}
+	apiVersion := t.exec.APIVersion
+	if apiVersion == "" {
+		apiVersion = "client.authentication.k8s.io/v1beta1"
+	}
+	cmd.Env = append(cmd.Env, fmt.Sprintf(`KUBERNETES_EXEC_INFO={"apiVersion":%q,"kind":"ExecCredential","spec":{"interactive":false}}`, apiVersion))
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("exec credential plugin %s failed: %w", t.exec.Command, err)
+	}
+
+	var cred struct {
+		Status struct {
+			Token               string    `json:"token"`
+			ExpirationTimestamp time.Time `json:"expirationTimestamp"`
+		} `json:"status"`
+	}
+	if err := json.Unmarshal(output, &cred); err != nil {
+		return "", fmt.Errorf("invalid exec credential output: %w", err)
+	}
+	if cred.Status.Token == "" {
+		return "", fmt.Errorf("exec credential plugin %s returned no token", t.exec.Command)
+	}
+	t.execToken, t.execExpires = cred.Status.Token, cred.Status.ExpirationTimestamp
+	return t.execToken, nil
+}
@@ -0,0 +1,100 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxCorrelatedEntries bounds the entries of a correlated timeline
+const maxCorrelatedEntries = 200
+
+// correlatedEntry is a log entry of one source in a timeline
+type correlatedEntry struct {
+	source string
+	line   string
+	time   time.Time // zero if the entry has no recognizable timestamp
+}
+
+// correlate searches every log source a user with roles may read for the
+// query (usually a request or trace ID) and merges the matching entries
+// into one time-ordered timeline, so the path of a request through the
+// app, access and worker logs can be followed
+func (t *LogQueryTool) correlate(query LogQuery, roles []string) string {
+	query.ContextLines = 0
+
+	var entries []correlatedEntry
+	var notes []string
+	sources := 0
+	for _, source := range t.sources {
+		if !t.allowed(source.Name(), roles) {
+			notes = append(notes, fmt.Sprintf("%s not searched: the user lacks the required role", source.Name()))
+			continue
+		}
+		matches, err := source.Search(query)
+		if err != nil {
+			notes = append(notes, fmt.Sprintf("Error reading %s: %v", source.Name(), err))
+			continue
+		}
+		found := false
+		for _, match := range filterEntries(matches, query) {
+			line := strings.TrimPrefix(strings.TrimRight(match, "\n"), "> ")
+			if strings.HasPrefix(line, "... (showing first") {
+				notes = append(notes, fmt.Sprintf("%s has more matches than the %d shown", source.Name(), query.Limit))
+				continue
+			}
+			ts, _ := lineTimestamp(line)
+			entries = append(entries, correlatedEntry{source: source.Name(), line: line, time: ts})
+			found = true
+		}
+		if found {
+			sources++
+		}
+	}
+
+	if len(entries) == 0 {
+		result := fmt.Sprintf("No log entries found for query: %s", query.Text)
+		if note := filterNote(query); note != "" {
+			notes = append(notes, note)
+		}
+		if len(notes) > 0 {
+			result += "\n" + strings.Join(notes, "\n")
+		}
+		return result
+	}
+
+	// Order by time; entries without a timestamp keep their source order
+	// after the timed ones
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].time.IsZero() || entries[j].time.IsZero() {
+			return !entries[i].time.IsZero() && entries[j].time.IsZero()
+		}
+		return entries[i].time.Before(entries[j].time)
+	})
+	if len(entries) > maxCorrelatedEntries {
+		notes = append(notes, fmt.Sprintf("Timeline truncated to the first %d of %d entries", maxCorrelatedEntries, len(entries)))
+		entries = entries[:maxCorrelatedEntries]
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Timeline of %d log entries for query %s across %d log source(s)\n%s\n", len(entries), query.Text, sources, strings.Repeat("-", 80))
+	var first time.Time
+	for _, e := range entries {
+		if e.time.IsZero() {
+			fmt.Fprintf(&b, "%-12s [%s] %s\n", "(no time)", e.source, e.line)
+			continue
+		}
+		if first.IsZero() {
+			first = e.time
+		}
+		fmt.Fprintf(&b, "%-12s [%s] %s\n", "+"+e.time.Sub(first).String(), e.source, e.line)
+	}
+	if note := filterNote(query); note != "" {
+		notes = append(notes, note)
+	}
+	if len(notes) > 0 {
+		b.WriteString("\n" + strings.Join(notes, "\n"))
+	}
+	return b.String()
+}
@@ -0,0 +1,157 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultLogNoisePatterns match entries that rarely help a diagnosis but can
+// make up most of a log: health checks, readiness and liveness probes, and
+// metrics scrapes
+var DefaultLogNoisePatterns = []string{
+	`(?i)\b(?:GET|HEAD) /(?:health|healthz|healthcheck|ready|readyz|readiness|live|livez|liveness|ping|metrics)\b`,
+	`(?i)kube-probe/`,
+	`(?i)ELB-HealthChecker`,
+	`(?i)GoogleHC/`,
+	`(?i)Prometheus/\d`,
+	`(?i)grpc\.health\.v1\.Health/Check`,
+}
+
+// LogFilterConfig controls which entries read_logs returns, so matches are
+// not drowned in irrelevant lines
+type LogFilterConfig struct {
+	// MinLevel is the lowest level returned unless read_logs asks for
+	// another: trace, debug, info, warn, error or fatal. Entries without a
+	// recognizable level are always returned.
+	// Default: "" (every level)
+	MinLevel string
+
+	// NoisePatterns are regular expressions of entries left out of results,
+	// unless the query itself matches one of them (e.g. "GET /healthz") or
+	// read_logs asks to include noise
+	// Default: DefaultLogNoisePatterns
+	NoisePatterns []string
+
+	// KeepNoise returns noisy entries too
+	KeepNoise bool
+}
+
+// logFilter is the parsed LogFilterConfig
+type logFilter struct {
+	minLevel string
+	noise    []*regexp.Regexp
+}
+
+// defaultLogFilter excludes DefaultLogNoisePatterns
+var defaultLogFilter, _ = newLogFilter(LogFilterConfig{})
+
+// newLogFilter parses the configured noise patterns
+func newLogFilter(config LogFilterConfig) (*logFilter, error) {
+	f := &logFilter{}
+	if config.MinLevel != "" {
+		if _, ok := logLevels[strings.ToLower(config.MinLevel)]; !ok {
+			return nil, fmt.Errorf("unknown log level %q", config.MinLevel)
+		}
+		f.minLevel = strings.ToLower(config.MinLevel)
+	}
+	if config.KeepNoise {
+		return f, nil
+	}
+	patterns := config.NoisePatterns
+	if patterns == nil {
+		patterns = DefaultLogNoisePatterns
+	}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid log noise pattern %q: %w", p, err)
+		}
+		f.noise = append(f.noise, re)
+	}
+	return f, nil
+}
+
+// logLevels ranks level names, including common aliases
+var logLevels = map[string]int{
+	"trace": 0, "debug": 1, "dbg": 1,
+	"info": 2, "notice": 2,
+	"warn": 3, "warning": 3,
+	"error": 4, "err": 4,
+	"fatal": 5, "critical": 5, "crit": 5, "panic": 5, "alert": 5, "emergency": 5,
+}
+
+// logLevelFields are the JSON fields holding an entry's level
+var logLevelFields = []string{"level", "severity", "lvl", "levelname", "log.level"}
+
+// logfmtLevelRegex matches level=... in logfmt lines
+var logfmtLevelRegex = regexp.MustCompile(`(?i)\b(?:level|lvl|severity)=["']?([a-z]+)`)
+
+// textLevelRegex matches an upper-case level word, as in "2024-01-02 ERROR ..."
+var textLevelRegex = regexp.MustCompile(`\b(TRACE|DEBUG|DBG|INFO|NOTICE|WARN|WARNING|ERROR|ERR|FATAL|CRITICAL|CRIT|PANIC)\b`)
+
+// lineLevel returns the rank of a log line's level (JSON field, logfmt or
+// text), or false if it has none
+func lineLevel(line string) (int, bool) {
+	var entry map[string]interface{}
+	if json.Unmarshal([]byte(line), &entry) == nil {
+		for _, key := range logLevelFields {
+			switch v := entry[key].(type) {
+			case string:
+				if rank, ok := logLevels[strings.ToLower(v)]; ok {
+					return rank, true
+				}
+			case float64:
+				// Numeric levels, as written by pino and bunyan (30 = info)
+				if v >= 10 && v <= 60 {
+					return int(v)/10 - 1, true
+				}
+			}
+		}
+		return 0, false
+	}
+	if m := logfmtLevelRegex.FindStringSubmatch(line); m != nil {
+		if rank, ok := logLevels[strings.ToLower(m[1])]; ok {
+			return rank, true
+		}
+	}
+	if m := textLevelRegex.FindString(line); m != "" {
+		return logLevels[strings.ToLower(m)], true
+	}
+	return 0, false
+}
+
+// atLeastLevel reports whether a log line is at minLevel or above. Lines
+// without a recognizable level are kept.
+func atLeastLevel(line, minLevel string) bool {
+	if minLevel == "" {
+		return true
+	}
+	rank, ok := lineLevel(line)
+	return !ok || rank >= logLevels[minLevel]
+}
+
+// isNoise reports whether a log line matches one of the noise patterns
+func isNoise(line string, noise []*regexp.Regexp) bool {
+	for _, re := range noise {
+		if re.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// keepEntry reports whether a search result passes the level and noise
+// filters of query, judged by its matching line (marked "> " in blocks
+// with context)
+func keepEntry(entry string, query LogQuery) bool {
+	line := entry
+	for _, l := range strings.Split(entry, "\n") {
+		if strings.HasPrefix(l, "> ") {
+			line = strings.TrimPrefix(l, "> ")
+			break
+		}
+	}
+	return atLeastLevel(line, query.MinLevel) && !isNoise(line, query.Exclude)
+}
@@ -0,0 +1,150 @@
+package tools
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"sync"
+)
+
+// defaultLogBufferLines is the capacity of a LogBuffer created with maxLines <= 0
+const defaultLogBufferLines = 10000
+
+// --- Reader log source ---
+
+// readerLogSource searches the lines of a reader opened for each search
+type readerLogSource struct {
+	name string
+	open func() (io.ReadCloser, error)
+}
+
+// NewReaderLogSource creates a LogSource for logs that are not in a file,
+// e.g. a stream kept by a custom logging framework. open is called for every
+// search and must return the log lines oldest first; the reader is closed
+// after the search.
+func NewReaderLogSource(name string, open func() (io.ReadCloser, error)) LogSource {
+	return &readerLogSource{name: name, open: open}
+}
+
+// Name returns the source name
+func (s *readerLogSource) Name() string {
+	return s.name
+}
+
+// Search scans the lines of a freshly opened reader
+func (s *readerLogSource) Search(query LogQuery) ([]string, error) {
+	r, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return searchLines(lines, query), nil
+}
+
+// --- Callback log source ---
+
+// funcLogSource delegates searches to a callback
+type funcLogSource struct {
+	name   string
+	search func(query LogQuery) ([]string, error)
+}
+
+// NewFuncLogSource creates a LogSource whose searches are answered by a
+// callback, e.g. a query of the host application's logging framework.
+// Each returned entry is shown as one match.
+func NewFuncLogSource(name string, search func(query LogQuery) ([]string, error)) LogSource {
+	return &funcLogSource{name: name, search: search}
+}
+
+// Name returns the source name
+func (s *funcLogSource) Name() string {
+	return s.name
+}
+
+// Search calls the callback
+func (s *funcLogSource) Search(query LogQuery) ([]string, error) {
+	return s.search(query)
+}
+
+// --- In-memory log buffer ---
+
+// LogBuffer is an io.Writer keeping the latest log lines in memory, and a
+// LogSource searching them, for applications that never write log files:
+//
+//	buf := tools.NewLogBuffer("app", 10000)
+//	log.SetOutput(io.MultiWriter(os.Stderr, buf))
+//	// register buf in Config.LogSources
+type LogBuffer struct {
+	name     string
+	maxLines int
+
+	mu      sync.Mutex
+	lines   []string // ring buffer of complete lines
+	next    int      // index of the oldest line once the buffer is full
+	full    bool
+	partial string // start of a line not yet terminated by a newline
+}
+
+// NewLogBuffer creates a buffer keeping the latest maxLines lines
+// (default 10000 when maxLines <= 0)
+func NewLogBuffer(name string, maxLines int) *LogBuffer {
+	if maxLines <= 0 {
+		maxLines = defaultLogBufferLines
+	}
+	return &LogBuffer{name: name, maxLines: maxLines}
+}
+
+// Write adds the complete lines of p; a trailing partial line is kept until
+// its newline is written
+func (b *LogBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	text := b.partial + string(p)
+	parts := strings.Split(text, "\n")
+	b.partial = parts[len(parts)-1]
+	for _, line := range parts[:len(parts)-1] {
+		b.add(strings.TrimSuffix(line, "\r"))
+	}
+	return len(p), nil
+}
+
+// add stores a line, replacing the oldest when full. Callers hold b.mu.
+func (b *LogBuffer) add(line string) {
+	if !b.full {
+		b.lines = append(b.lines, line)
+		b.full = len(b.lines) == b.maxLines
+		return
+	}
+	b.lines[b.next] = line
+	b.next = (b.next + 1) % b.maxLines
+}
+
+// Lines returns the buffered lines, oldest first
+func (b *LogBuffer) Lines() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	lines := make([]string, 0, len(b.lines))
+	lines = append(lines, b.lines[b.next:]...)
+	lines = append(lines, b.lines[:b.next]...)
+	return lines
+}
+
+// Name returns the buffer name
+func (b *LogBuffer) Name() string {
+	return b.name
+}
+
+// Search scans the buffered lines
+func (b *LogBuffer) Search(query LogQuery) ([]string, error) {
+	return searchLines(b.Lines(), query), nil
+}
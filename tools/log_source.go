@@ -0,0 +1,252 @@
+package tools
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LogSource is a backend that read_logs can search.
+// Implement it to make logs stored outside the local filesystem queryable.
+type LogSource interface {
+	// Name identifies the source in tool output (e.g., a file path or "loki")
+	Name() string
+
+	// Search returns matching log entries. Each entry is one formatted block
+	// (a line, or a line with surrounding context).
+	Search(query LogQuery) ([]string, error)
+}
+
+// LogQuery describes a read_logs search
+type LogQuery struct {
+	// Text is the text to search for (request ID, error message, ...)
+	Text string
+
+	// Filter is an optional backend-native filter expression
+	// (e.g., a LogQL stream selector for Loki)
+	Filter string
+
+	// ContextLines is the number of lines to show around each match, where supported
+	ContextLines int
+
+	// Start and End bound the search time range. Zero values mean unbounded.
+	Start time.Time
+	End   time.Time
+
+	// Limit is the maximum number of entries to return
+	Limit int
+
+	// MinLevel is the lowest level returned (trace, debug, info, warn, error
+	// or fatal); entries without a recognizable level are kept. Exclude
+	// matches noisy entries to leave out. Sources that do not apply them
+	// have their results filtered by read_logs.
+	MinLevel string
+	Exclude  []*regexp.Regexp
+}
+
+// defaultLogLimit is the maximum number of entries returned per source
+const defaultLogLimit = 50
+
+// parseTimeParam parses an absolute (RFC3339) or relative ("15m", "2h" ago) time
+func parseTimeParam(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: use RFC3339 (2024-01-02T15:04:05Z) or a relative duration (e.g., 15m, 2h)", value)
+}
+
+// ParseTimeParam parses a start_time/end_time tool argument the way the log
+// and metrics tools do. The zero time means the argument is unset.
+func ParseTimeParam(value string, now time.Time) (time.Time, error) {
+	return parseTimeParam(value, now)
+}
+
+// --- File log source ---
+
+// fileLogSource searches a local log file
+type fileLogSource struct {
+	path string
+}
+
+// NewFileLogSource creates a LogSource that searches a local log file
+func NewFileLogSource(path string) LogSource {
+	return &fileLogSource{path: path}
+}
+
+// Name returns the log file path
+func (s *fileLogSource) Name() string {
+	return s.path
+}
+
+// Search scans the log file for the query and returns matches with context
+func (s *fileLogSource) Search(query LogQuery) ([]string, error) {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+
+	// Read all lines into memory (for context)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return searchLines(lines, query), nil
+}
+
+// searchLines returns the lines matching the query with their context
+func searchLines(lines []string, query LogQuery) []string {
+	var matches []string
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultLogLimit
+	}
+
+	// Search for matches
+	for i, line := range lines {
+		if !matchesQuery(line, query.Text) || !inTimeRange(line, query.Start, query.End) ||
+			!atLeastLevel(line, query.MinLevel) || isNoise(line, query.Exclude) {
+			continue
+		}
+
+		// Add context
+		start := i - query.ContextLines
+		if start < 0 {
+			start = 0
+		}
+		end := i + query.ContextLines + 1
+		if end > len(lines) {
+			end = len(lines)
+		}
+
+		// Build context block
+		var contextBlock []string
+		for j := start; j < end; j++ {
+			prefix := "  "
+			if j == i {
+				prefix = "> " // Mark the matching line
+			}
+			contextBlock = append(contextBlock, fmt.Sprintf("%s%s", prefix, lines[j]))
+		}
+
+		matches = append(matches, strings.Join(contextBlock, "\n")+"\n")
+
+		// Limit results
+		if len(matches) >= limit {
+			matches = append(matches, fmt.Sprintf("... (showing first %d matches)", limit))
+			break
+		}
+	}
+
+	return matches
+}
+
+// matchesQuery checks if a log line matches the query
+func matchesQuery(line, query string) bool {
+	// Try simple text search first
+	if strings.Contains(strings.ToLower(line), strings.ToLower(query)) {
+		return true
+	}
+
+	// Try to parse as JSON and search in fields
+	var logEntry map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &logEntry); err == nil {
+		// Successfully parsed as JSON
+		// Search in common fields
+		for _, value := range logEntry {
+			if strValue, ok := value.(string); ok {
+				if strings.Contains(strings.ToLower(strValue), strings.ToLower(query)) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+var lineTimestampRegex = regexp.MustCompile(`\d{4}[-/]\d{2}[-/]\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2})?`)
+
+// lineTimestampLayouts are tried in order when parsing a timestamp found in a log line
+var lineTimestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z0700",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006/01/02 15:04:05.999999999",
+}
+
+// inTimeRange reports whether a log line's timestamp lies in [start, end].
+// Lines without a recognizable timestamp are kept.
+func inTimeRange(line string, start, end time.Time) bool {
+	if start.IsZero() && end.IsZero() {
+		return true
+	}
+	ts, ok := lineTimestamp(line)
+	if !ok {
+		return true
+	}
+	if !start.IsZero() && ts.Before(start) {
+		return false
+	}
+	if !end.IsZero() && ts.After(end) {
+		return false
+	}
+	return true
+}
+
+// lineTimestamp extracts the timestamp of a log line (JSON field or text prefix)
+func lineTimestamp(line string) (time.Time, bool) {
+	var entry map[string]interface{}
+	if json.Unmarshal([]byte(line), &entry) == nil {
+		for _, key := range []string{"time", "timestamp", "ts", "@timestamp"} {
+			switch v := entry[key].(type) {
+			case string:
+				if t, ok := parseLineTimestamp(v); ok {
+					return t, true
+				}
+			case float64:
+				// Unix seconds (possibly fractional), as written by zap
+				sec, frac := int64(v), v-float64(int64(v))
+				return time.Unix(sec, int64(frac*1e9)), true
+			}
+		}
+	}
+
+	if m := lineTimestampRegex.FindString(line); m != "" {
+		return parseLineTimestamp(m)
+	}
+	return time.Time{}, false
+}
+
+// parseLineTimestamp parses a timestamp string using the known layouts (local time if no zone)
+func parseLineTimestamp(s string) (time.Time, bool) {
+	for _, layout := range lineTimestampLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, true
+		}
+	}
+	if sec, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(sec, 0), true
+	}
+	return time.Time{}, false
+}
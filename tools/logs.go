@@ -1,20 +1,29 @@
 package tools
 
 import (
-	"bufio"
-	"encoding/json"
 	"fmt"
-	"os"
+	"io"
 	"strings"
+	"time"
 )
 
+// maxExportEntries bounds the entries an export collects per log source
+const maxExportEntries = 100000
+
 // LogQueryTool implements log querying functionality
 type LogQueryTool struct {
-	logFiles []string
+	sources []LogSource
+	access  map[string][]string // source name → roles allowed to query it; unlisted sources are open
+	filter  *logFilter          // nil applies defaultLogFilter
 }
 
-// Execute queries logs for a search pattern
+// Execute queries the unrestricted logs for a search pattern
 func (t *LogQueryTool) Execute(params map[string]interface{}) (string, error) {
+	return t.ExecuteAs(params, nil)
+}
+
+// ExecuteAs queries the logs a user with the given roles may read
+func (t *LogQueryTool) ExecuteAs(params map[string]interface{}, roles []string) (string, error) {
 	query, ok := params["query"].(string)
 	if !ok {
 		return "", fmt.Errorf("query parameter is required")
@@ -25,31 +34,90 @@ func (t *LogQueryTool) Execute(params map[string]interface{}) (string, error) {
 		contextLines = int(cl)
 	}
 
-	if len(t.logFiles) == 0 {
+	if len(t.sources) == 0 {
 		return "", fmt.Errorf("no log files configured")
 	}
 
+	now := time.Now()
+	startStr, _ := params["start_time"].(string)
+	start, err := parseTimeParam(startStr, now)
+	if err != nil {
+		return "", err
+	}
+	endStr, _ := params["end_time"].(string)
+	end, err := parseTimeParam(endStr, now)
+	if err != nil {
+		return "", err
+	}
+	filter, _ := params["filter"].(string)
+
+	logQuery := LogQuery{
+		Text:         query,
+		Filter:       filter,
+		ContextLines: contextLines,
+		Start:        start,
+		End:          end,
+		Limit:        defaultLogLimit,
+	}
+	logFilter := t.filter
+	if logFilter == nil {
+		logFilter = defaultLogFilter
+	}
+	logQuery.MinLevel = logFilter.minLevel
+	// Searching for noise, e.g. failing health checks, returns it
+	if includeNoise, _ := params["include_noise"].(bool); !includeNoise && !isNoise(query, logFilter.noise) {
+		logQuery.Exclude = logFilter.noise
+	}
+	if minLevel, _ := params["min_level"].(string); minLevel != "" {
+		if _, ok := logLevels[strings.ToLower(minLevel)]; !ok {
+			return "", fmt.Errorf("invalid min_level %q: use trace, debug, info, warn, error or fatal", minLevel)
+		}
+		logQuery.MinLevel = strings.ToLower(minLevel)
+	}
+
+	if correlate, _ := params["correlate"].(bool); correlate {
+		return t.correlate(logQuery, roles), nil
+	}
+
 	var allMatches []string
 	totalMatches := 0
+	denied := 0
 
-	// Search in each log file
-	for _, logFile := range t.logFiles {
-		matches, err := t.searchLogFile(logFile, query, contextLines)
+	// Search in each log source the user may read
+	for _, source := range t.sources {
+		if !t.allowed(source.Name(), roles) {
+			denied++
+			continue
+		}
+		matches, err := source.Search(logQuery)
 		if err != nil {
-			// Log error but continue with other files
-			allMatches = append(allMatches, fmt.Sprintf("Error reading %s: %v", logFile, err))
+			// Log error but continue with other sources
+			allMatches = append(allMatches, fmt.Sprintf("Error reading %s: %v", source.Name(), err))
 			continue
 		}
+		matches = filterEntries(matches, logQuery)
 
 		if len(matches) > 0 {
-			allMatches = append(allMatches, fmt.Sprintf("\n=== Log file: %s ===", logFile))
+			allMatches = append(allMatches, fmt.Sprintf("\n=== Log source: %s ===", source.Name()))
 			allMatches = append(allMatches, matches...)
 			totalMatches += len(matches)
 		}
 	}
 
+	if denied > 0 {
+		allMatches = append(allMatches, fmt.Sprintf("\n(%d log source(s) not searched: the user lacks the required role)", denied))
+	}
+	if note := filterNote(logQuery); note != "" {
+		allMatches = append(allMatches, "\n"+note)
+	}
+
 	if totalMatches == 0 {
-		return fmt.Sprintf("No log entries found for query: %s", query), nil
+		result := fmt.Sprintf("No log entries found for query: %s", query)
+		// Surface per-source errors so the AI knows a backend failed
+		if len(allMatches) > 0 {
+			result += "\n" + strings.Join(allMatches, "\n")
+		}
+		return result, nil
 	}
 
 	result := fmt.Sprintf("Found %d log entries for query: %s\n%s\n%s",
@@ -61,84 +129,89 @@ func (t *LogQueryTool) Execute(params map[string]interface{}) (string, error) {
 	return result, nil
 }
 
-// searchLogFile searches a single log file for the query
-func (t *LogQueryTool) searchLogFile(logFile, query string, contextLines int) ([]string, error) {
-	file, err := os.Open(logFile)
-	if err != nil {
-		return nil, err
+// filterEntries applies the level and noise filters of query to the results
+// of a source that does not filter them itself
+func filterEntries(matches []string, query LogQuery) []string {
+	if query.MinLevel == "" && len(query.Exclude) == 0 {
+		return matches
 	}
-	defer file.Close()
-
-	var matches []string
-	var lines []string
-	scanner := bufio.NewScanner(file)
-
-	// Read all lines into memory (for context)
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
+	var kept []string
+	for _, match := range matches {
+		if keepEntry(match, query) {
+			kept = append(kept, match)
+		}
 	}
+	return kept
+}
 
-	if err := scanner.Err(); err != nil {
-		return nil, err
+// filterNote says what the filters of query left out, so an empty result
+// is not mistaken for missing logs
+func filterNote(query LogQuery) string {
+	var filtered []string
+	if query.MinLevel != "" {
+		filtered = append(filtered, "entries below "+query.MinLevel+" are excluded")
 	}
-
-	// Search for matches
-	for i, line := range lines {
-		if t.matchesQuery(line, query) {
-			// Add context
-			start := i - contextLines
-			if start < 0 {
-				start = 0
-			}
-			end := i + contextLines + 1
-			if end > len(lines) {
-				end = len(lines)
-			}
-
-			// Build context block
-			var contextBlock []string
-			for j := start; j < end; j++ {
-				prefix := "  "
-				if j == i {
-					prefix = "> " // Mark the matching line
-				}
-				contextBlock = append(contextBlock, fmt.Sprintf("%s%s", prefix, lines[j]))
-			}
-
-			matches = append(matches, strings.Join(contextBlock, "\n"))
-			matches = append(matches, "") // Empty line between matches
-
-			// Limit results
-			if len(matches) >= 50 {
-				matches = append(matches, "... (showing first ~50 matches)")
-				break
-			}
-		}
+	if len(query.Exclude) > 0 {
+		filtered = append(filtered, "health checks and other noise are excluded, set include_noise to include them")
 	}
-
-	return matches, nil
+	if len(filtered) == 0 {
+		return ""
+	}
+	return "(" + strings.Join(filtered, "; ") + ")"
 }
 
-// matchesQuery checks if a log line matches the query
-func (t *LogQueryTool) matchesQuery(line, query string) bool {
-	// Try simple text search first
-	if strings.Contains(strings.ToLower(line), strings.ToLower(query)) {
+// allowed reports whether a user with roles may query the named log source
+func (t *LogQueryTool) allowed(name string, roles []string) bool {
+	required, restricted := t.access[name]
+	if !restricted {
 		return true
 	}
-
-	// Try to parse as JSON and search in fields
-	var logEntry map[string]interface{}
-	if err := json.Unmarshal([]byte(line), &logEntry); err == nil {
-		// Successfully parsed as JSON
-		// Search in common fields
-		for _, value := range logEntry {
-			if strValue, ok := value.(string); ok {
-				if strings.Contains(strings.ToLower(strValue), strings.ToLower(query)) {
-					return true
-				}
+	for _, want := range required {
+		for _, role := range roles {
+			if role == want {
+				return true
 			}
 		}
 	}
-
 	return false
 }
+
+// Export writes every entry matching query that a user with roles may read,
+// one per line without context, and stops once maxBytes are written.
+// Returns the number of entries written and whether the export was truncated.
+func (t *LogQueryTool) Export(w io.Writer, query LogQuery, roles []string, maxBytes int) (entries int, truncated bool, err error) {
+	query.ContextLines = 0
+	query.Limit = maxExportEntries
+	written := 0
+	for _, source := range t.sources {
+		if !t.allowed(source.Name(), roles) {
+			continue
+		}
+		matches, err := source.Search(query)
+		if err != nil {
+			fmt.Fprintf(w, "# Error reading %s: %v\n", source.Name(), err)
+			continue
+		}
+		if len(matches) == 0 {
+			continue
+		}
+		header := fmt.Sprintf("# === Log source: %s ===\n", source.Name())
+		io.WriteString(w, header)
+		written += len(header)
+		for _, match := range matches {
+			line := strings.TrimPrefix(strings.TrimRight(match, "\n"), "> ") + "\n"
+			if written+len(line) > maxBytes {
+				return entries, true, nil
+			}
+			if _, err := io.WriteString(w, line); err != nil {
+				return entries, false, err
+			}
+			written += len(line)
+			entries++
+		}
+		if len(matches) >= maxExportEntries {
+			truncated = true
+		}
+	}
+	return entries, truncated, nil
+}
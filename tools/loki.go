@@ -0,0 +1,192 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LokiConfig configures the Grafana Loki log source
+type LokiConfig struct {
+	// URL is the Loki base URL (e.g., "http://loki:3100").
+	// When empty, the Loki source is disabled.
+	URL string
+
+	// Selector is the default LogQL stream selector used when the AI does not
+	// pass one (e.g., `{app="checkout"}`). Required.
+	Selector string
+
+	// TenantID is sent as X-Scope-OrgID for multi-tenant Loki deployments
+	TenantID string
+
+	// Username and Password enable basic auth (e.g., Grafana Cloud)
+	Username string
+	Password string
+
+	// BearerToken enables bearer token auth
+	BearerToken string
+
+	// DefaultLookback is the time range searched when no start time is given.
+	// Default: 1 hour
+	DefaultLookback time.Duration
+}
+
+// LokiSource searches logs stored in Grafana Loki using LogQL
+type LokiSource struct {
+	config LokiConfig
+	client *http.Client
+}
+
+// NewLokiSource creates a LogSource backed by Grafana Loki
+func NewLokiSource(config LokiConfig) (*LokiSource, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("Loki URL is required")
+	}
+	if config.Selector == "" {
+		return nil, fmt.Errorf("Loki stream selector is required")
+	}
+	if config.DefaultLookback == 0 {
+		config.DefaultLookback = time.Hour
+	}
+	config.URL = strings.TrimRight(config.URL, "/")
+	return &LokiSource{
+		config: config,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// Name identifies the source in tool output
+func (s *LokiSource) Name() string {
+	return "loki " + s.config.Selector
+}
+
+// Search runs a LogQL range query.
+// Filter may be a stream selector ({app="api"}) or a complete LogQL query
+// (a selector followed by pipeline stages); the query text is added as a
+// case-insensitive line filter.
+func (s *LokiSource) Search(query LogQuery) ([]string, error) {
+	logQL := s.buildLogQL(query)
+
+	end := query.End
+	if end.IsZero() {
+		end = time.Now()
+	}
+	start := query.Start
+	if start.IsZero() {
+		start = end.Add(-s.config.DefaultLookback)
+	}
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultLogLimit
+	}
+
+	values := url.Values{}
+	values.Set("query", logQL)
+	values.Set("start", strconv.FormatInt(start.UnixNano(), 10))
+	values.Set("end", strconv.FormatInt(end.UnixNano(), 10))
+	values.Set("limit", strconv.Itoa(limit))
+	values.Set("direction", "backward")
+
+	req, err := http.NewRequest(http.MethodGet, s.config.URL+"/loki/api/v1/query_range?"+values.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if s.config.TenantID != "" {
+		req.Header.Set("X-Scope-OrgID", s.config.TenantID)
+	}
+	if s.config.Username != "" {
+		req.SetBasicAuth(s.config.Username, s.config.Password)
+	} else if s.config.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+s.config.BearerToken)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Loki query failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Loki returned status %d for %s: %s", resp.StatusCode, logQL, strings.TrimSpace(string(body)))
+	}
+
+	var result struct {
+		Data struct {
+			ResultType string `json:"resultType"`
+			Result     []struct {
+				Stream map[string]string `json:"stream"`
+				Values [][2]string       `json:"values"` // [nanosecond timestamp, line]
+			} `json:"result"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("invalid Loki response: %w", err)
+	}
+	if result.Data.ResultType != "streams" {
+		return nil, fmt.Errorf("LogQL query returned %s, expected log lines; use a log query rather than a metric query", result.Data.ResultType)
+	}
+
+	type entry struct {
+		ts     int64
+		labels string
+		line   string
+	}
+	var entries []entry
+	for _, stream := range result.Data.Result {
+		labels := formatLabels(stream.Stream)
+		for _, v := range stream.Values {
+			ts, _ := strconv.ParseInt(v[0], 10, 64)
+			entries = append(entries, entry{ts: ts, labels: labels, line: v[1]})
+		}
+	}
+
+	// Merge streams into chronological order
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ts < entries[j].ts })
+
+	matches := make([]string, 0, len(entries))
+	for _, e := range entries {
+		ts := time.Unix(0, e.ts).UTC().Format(time.RFC3339Nano)
+		matches = append(matches, fmt.Sprintf("%s %s %s", ts, e.labels, e.line))
+	}
+	if len(entries) >= limit {
+		matches = append(matches, fmt.Sprintf("... (showing latest %d entries; narrow the time range or filter for more)", limit))
+	}
+	return matches, nil
+}
+
+// buildLogQL combines the stream selector, optional pipeline and query text into a LogQL query
+func (s *LokiSource) buildLogQL(query LogQuery) string {
+	logQL := strings.TrimSpace(query.Filter)
+	if logQL == "" {
+		logQL = s.config.Selector
+	}
+	if query.Text != "" {
+		logQL += " |~ " + strconv.Quote("(?i)"+regexp.QuoteMeta(query.Text))
+	}
+	return logQL
+}
+
+// formatLabels renders stream labels in a stable {k="v", ...} form
+func formatLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%q", k, labels[k]))
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
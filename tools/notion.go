@@ -0,0 +1,261 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	notionAPIURL  = "https://api.notion.com/v1"
+	notionVersion = "2022-06-28"
+	// notionMaxText is the maximum length of a single rich text object
+	notionMaxText = 2000
+)
+
+// NotionKnowledgeConfig stores root-cause reports as pages in a Notion database
+type NotionKnowledgeConfig struct {
+	// Token is a Notion internal integration token. The database must be
+	// shared with the integration.
+	// When empty, the Notion store is disabled.
+	Token string
+
+	// DatabaseID is the database new report pages are created in
+	DatabaseID string
+
+	// TitleProperty is the name of the database's title property.
+	// Default: "Name"
+	TitleProperty string
+}
+
+// notionKnowledgeStore implements KnowledgeStore with the Notion API
+type notionKnowledgeStore struct {
+	config NotionKnowledgeConfig
+	client *http.Client
+}
+
+// newNotionKnowledgeStore creates a Notion store with defaults applied
+func newNotionKnowledgeStore(config NotionKnowledgeConfig) (*notionKnowledgeStore, error) {
+	if config.DatabaseID == "" {
+		return nil, fmt.Errorf("KnowledgeBase.Notion.DatabaseID is required when Notion.Token is set")
+	}
+	if config.TitleProperty == "" {
+		config.TitleProperty = "Name"
+	}
+	return &notionKnowledgeStore{
+		config: config,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+func (s *notionKnowledgeStore) Name() string {
+	return "notion"
+}
+
+// notionBlock is a page content block with rich text
+type notionBlock struct {
+	ID   string `json:"id,omitempty"`
+	Type string `json:"type"`
+	// Only the rich text of the block's type-named field is used
+	Heading2  *notionRichTextBlock `json:"heading_2,omitempty"`
+	Paragraph *notionRichTextBlock `json:"paragraph,omitempty"`
+	Bulleted  *notionRichTextBlock `json:"bulleted_list_item,omitempty"`
+}
+
+type notionRichTextBlock struct {
+	RichText []notionRichText `json:"rich_text"`
+}
+
+type notionRichText struct {
+	Type      string `json:"type,omitempty"`
+	PlainText string `json:"plain_text,omitempty"`
+	Text      *struct {
+		Content string `json:"content"`
+	} `json:"text,omitempty"`
+}
+
+// Save creates a page in the database, converting the Markdown report to blocks
+func (s *notionKnowledgeStore) Save(report RootCauseReport) (string, error) {
+	var children []notionBlock
+	for _, line := range strings.Split(report.Markdown(), "\n") {
+		switch {
+		case line == "" || strings.HasPrefix(line, "# "):
+			// The title is the page title
+		case strings.HasPrefix(line, "## "):
+			children = append(children, notionBlock{Type: "heading_2", Heading2: notionText(line[3:])})
+		case strings.HasPrefix(line, "- "):
+			children = append(children, notionBlock{Type: "bulleted_list_item", Bulleted: notionText(line[2:])})
+		default:
+			children = append(children, notionBlock{Type: "paragraph", Paragraph: notionText(line)})
+		}
+	}
+	if len(children) > 100 {
+		children = children[:100]
+	}
+
+	body := map[string]interface{}{
+		"parent": map[string]string{"database_id": s.config.DatabaseID},
+		"properties": map[string]interface{}{
+			s.config.TitleProperty: map[string]interface{}{"title": notionText(report.Title).RichText},
+		},
+		"children": children,
+	}
+	var page struct {
+		URL string `json:"url"`
+	}
+	if err := s.do(http.MethodPost, "/pages", body, &page); err != nil {
+		return "", err
+	}
+	return page.URL, nil
+}
+
+// Search runs a Notion search (which matches page titles) and loads the
+// content of the matching pages in the database
+func (s *notionKnowledgeStore) Search(query string, limit int) ([]RootCauseReport, error) {
+	var result struct {
+		Results []struct {
+			ID     string `json:"id"`
+			URL    string `json:"url"`
+			Parent struct {
+				DatabaseID string `json:"database_id"`
+			} `json:"parent"`
+			Properties map[string]struct {
+				Type  string           `json:"type"`
+				Title []notionRichText `json:"title"`
+			} `json:"properties"`
+		} `json:"results"`
+	}
+	body := map[string]interface{}{
+		"query":     query,
+		"filter":    map[string]string{"property": "object", "value": "page"},
+		"page_size": 50,
+	}
+	if err := s.do(http.MethodPost, "/search", body, &result); err != nil {
+		return nil, err
+	}
+
+	var reports []RootCauseReport
+	for _, page := range result.Results {
+		if len(reports) >= limit {
+			break
+		}
+		if normalizeNotionID(page.Parent.DatabaseID) != normalizeNotionID(s.config.DatabaseID) {
+			continue
+		}
+		var title string
+		for _, prop := range page.Properties {
+			if prop.Type == "title" {
+				title = plainText(prop.Title)
+			}
+		}
+
+		var blocks struct {
+			Results []notionBlock `json:"results"`
+		}
+		if err := s.do(http.MethodGet, "/blocks/"+page.ID+"/children?page_size=100", nil, &blocks); err != nil {
+			return nil, err
+		}
+		lines := []string{"# " + title}
+		for _, block := range blocks.Results {
+			switch block.Type {
+			case "heading_2":
+				lines = append(lines, "", "## "+block.Heading2.plain(), "")
+			case "bulleted_list_item":
+				lines = append(lines, "- "+block.Bulleted.plain())
+			case "paragraph":
+				lines = append(lines, block.Paragraph.plain())
+			}
+		}
+		report := parseMarkdownReport(strings.Join(lines, "\n"))
+		report.Location = page.URL
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// notionText splits text into rich text objects within the API's length limit
+func notionText(text string) *notionRichTextBlock {
+	block := &notionRichTextBlock{RichText: []notionRichText{}}
+	for len(text) > 0 {
+		n := len(text)
+		if n > notionMaxText {
+			n = notionMaxText
+			for n > 0 && !utf8.RuneStart(text[n]) {
+				n--
+			}
+		}
+		rt := notionRichText{Type: "text", Text: &struct {
+			Content string `json:"content"`
+		}{Content: text[:n]}}
+		block.RichText = append(block.RichText, rt)
+		text = text[n:]
+	}
+	return block
+}
+
+// plain returns the block's text, or "" for a missing block body
+func (b *notionRichTextBlock) plain() string {
+	if b == nil {
+		return ""
+	}
+	return plainText(b.RichText)
+}
+
+// plainText joins the plain text of rich text objects
+func plainText(rich []notionRichText) string {
+	var b strings.Builder
+	for _, rt := range rich {
+		b.WriteString(rt.PlainText)
+	}
+	return b.String()
+}
+
+// normalizeNotionID removes dashes so IDs from URLs and the API compare equal
+func normalizeNotionID(id string) string {
+	return strings.ReplaceAll(id, "-", "")
+}
+
+// do sends a request to the Notion API and decodes the JSON response into out
+func (s *notionKnowledgeStore) do(method, path string, body interface{}, out interface{}) error {
+	var bodyReader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		bodyReader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, notionAPIURL+path, bodyReader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.config.Token)
+	req.Header.Set("Notion-Version", notionVersion)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Notion API returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+	if out != nil {
+		return json.Unmarshal(respBody, out)
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// ownershipRefreshInterval is how long CODEOWNERS is reused before it
+	// is read again
+	ownershipRefreshInterval = 5 * time.Minute
+	// ownershipHistoryCommits is how many recent commits of a file are
+	// counted to find its committers
+	ownershipHistoryCommits = 30
+	// ownershipHistoryAuthors is how many committers are reported
+	ownershipHistoryAuthors = 3
+)
+
+// codeownersPaths are where CODEOWNERS is looked for, as GitHub and GitLab do
+var codeownersPaths = []string{"CODEOWNERS", ".github/CODEOWNERS", ".gitlab/CODEOWNERS", "docs/CODEOWNERS"}
+
+// OwnershipConfig annotates source files with their owners: the teams or
+// people a CODEOWNERS file assigns them to, or else their recent committers
+type OwnershipConfig struct {
+	// Enabled reads the owners
+	Enabled bool
+
+	// File is the path of the CODEOWNERS file in the source
+	// Default: the first of CODEOWNERS, .github/CODEOWNERS,
+	// .gitlab/CODEOWNERS and docs/CODEOWNERS
+	File string
+
+	// GitDir is a git checkout of the source. The recent committers of a
+	// file without a CODEOWNERS rule are reported as its likely owners.
+	// Default: "" (CODEOWNERS only)
+	GitDir string
+}
+
+// ownerRule is one CODEOWNERS line: the last matching rule wins
+type ownerRule struct {
+	pattern string
+	re      *regexp.Regexp
+	owners  []string
+}
+
+// Ownership is the owners of a file
+type Ownership struct {
+	Owners []string // e.g. "@payments-team"
+	Rule   string   // matching CODEOWNERS pattern; "" if the owners come from git history
+}
+
+// String describes the ownership, e.g. "@payments-team (CODEOWNERS: /payments/)"
+func (o Ownership) String() string {
+	if len(o.Owners) == 0 {
+		return "no owner found"
+	}
+	if o.Rule == "" {
+		return strings.Join(o.Owners, ", ") + " (recent committers)"
+	}
+	return strings.Join(o.Owners, " ") + " (CODEOWNERS: " + o.Rule + ")"
+}
+
+// OwnershipTool finds the owners of source files
+type OwnershipTool struct {
+	config OwnershipConfig
+	source fs.FS
+
+	mu       sync.Mutex
+	rules    []ownerRule
+	loadedAt time.Time
+}
+
+// newOwnershipTool creates the tool; CODEOWNERS is read on first use
+func newOwnershipTool(config OwnershipConfig, source fs.FS) *OwnershipTool {
+	return &OwnershipTool{config: config, source: source}
+}
+
+// forSource returns the tool for another source tree, without git history
+func (t *OwnershipTool) forSource(source fs.FS) *OwnershipTool {
+	if t == nil {
+		return nil
+	}
+	config := t.config
+	config.GitDir = ""
+	return newOwnershipTool(config, source)
+}
+
+// Owners returns the owners of a file: the last matching CODEOWNERS rule,
+// or the recent committers
+func (t *OwnershipTool) Owners(file string) Ownership {
+	file = strings.TrimPrefix(strings.TrimPrefix(file, "./"), "/")
+	rules := t.codeowners()
+	for i := len(rules) - 1; i >= 0; i-- {
+		if rules[i].re.MatchString(file) {
+			return Ownership{Owners: rules[i].owners, Rule: rules[i].pattern}
+		}
+	}
+	return Ownership{Owners: t.committers(file)}
+}
+
+// codeowners returns the parsed CODEOWNERS rules, re-read periodically
+func (t *OwnershipTool) codeowners() []ownerRule {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.loadedAt.IsZero() && time.Since(t.loadedAt) < ownershipRefreshInterval {
+		return t.rules
+	}
+	t.loadedAt = time.Now()
+
+	paths := codeownersPaths
+	if t.config.File != "" {
+		paths = []string{strings.TrimPrefix(t.config.File, "/")}
+	}
+	t.rules = nil
+	for _, p := range paths {
+		if data, err := fs.ReadFile(t.source, p); err == nil {
+			t.rules = parseCodeowners(data)
+			break
+		}
+	}
+	return t.rules
+}
+
+// parseCodeowners parses CODEOWNERS lines ("pattern @owner..."), skipping
+// comments, GitLab section headers and patterns without owners
+func parseCodeowners(data []byte) []ownerRule {
+	var rules []ownerRule
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], "[") || strings.HasPrefix(fields[0], "^[") {
+			continue
+		}
+		re, err := regexp.Compile(codeownersRegexp(fields[0]))
+		if err != nil {
+			continue
+		}
+		rules = append(rules, ownerRule{pattern: fields[0], re: re, owners: fields[1:]})
+	}
+	return rules
+}
+
+// codeownersRegexp converts a gitignore-style CODEOWNERS pattern to a regular
+// expression matching the paths it covers, including the files of a
+// matching directory
+func codeownersRegexp(pattern string) string {
+	anchored := strings.HasPrefix(pattern, "/") || strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("(?:/.*)?$")
+	return b.String()
+}
+
+// committers returns the most frequent authors of a file's recent commits
+func (t *OwnershipTool) committers(file string) []string {
+	if t.config.GitDir == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "git", "-C", t.config.GitDir, "log",
+		fmt.Sprintf("-n%d", ownershipHistoryCommits), "--format=%an <%ae>", "--", file).Output()
+	if err != nil {
+		return nil
+	}
+
+	counts := make(map[string]int)
+	var authors []string
+	for _, author := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if author == "" {
+			continue
+		}
+		if counts[author] == 0 {
+			authors = append(authors, author)
+		}
+		counts[author]++
+	}
+	sort.SliceStable(authors, func(i, j int) bool { return counts[authors[i]] > counts[authors[j]] })
+	if len(authors) > ownershipHistoryAuthors {
+		authors = authors[:ownershipHistoryAuthors]
+	}
+	return authors
+}
+
+// Execute lists the owners of the given files
+func (t *OwnershipTool) Execute(params map[string]interface{}) (string, error) {
+	var files []string
+	if list, ok := params["file_paths"].([]interface{}); ok {
+		for _, f := range list {
+			if s, ok := f.(string); ok && s != "" {
+				files = append(files, s)
+			}
+		}
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("file_paths parameter is required")
+	}
+
+	var b strings.Builder
+	for _, f := range files {
+		fmt.Fprintf(&b, "%s: %s\n", f, t.Owners(f))
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,254 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maxMetricSeries limits the number of series rendered per query
+	maxMetricSeries = 20
+	// maxMetricPoints limits the number of points rendered per range series
+	maxMetricPoints = 30
+)
+
+// PrometheusConfig configures the Prometheus metrics tool
+type PrometheusConfig struct {
+	// URL is the Prometheus (or compatible: Thanos, Mimir, VictoriaMetrics) base URL.
+	// When empty, the metrics tool is disabled.
+	URL string
+
+	// TenantID is sent as X-Scope-OrgID for multi-tenant Mimir/Cortex deployments
+	TenantID string
+
+	// Username and Password enable basic auth
+	Username string
+	Password string
+
+	// BearerToken enables bearer token auth
+	BearerToken string
+}
+
+// PrometheusTool runs PromQL instant and range queries
+type PrometheusTool struct {
+	config PrometheusConfig
+	client *http.Client
+}
+
+// newPrometheusTool creates a Prometheus tool
+func newPrometheusTool(config PrometheusConfig) *PrometheusTool {
+	config.URL = strings.TrimRight(config.URL, "/")
+	return &PrometheusTool{
+		config: config,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// promSeries is a series in a Prometheus query result
+type promSeries struct {
+	Metric map[string]string `json:"metric"`
+	Value  []interface{}     `json:"value"`  // instant: [unix seconds, "value"]
+	Values [][]interface{}   `json:"values"` // range: [[unix seconds, "value"], ...]
+}
+
+// Execute runs a PromQL query and renders the result
+func (t *PrometheusTool) Execute(params map[string]interface{}) (string, error) {
+	query, ok := params["query"].(string)
+	if !ok || query == "" {
+		return "", fmt.Errorf("query parameter is required")
+	}
+
+	now := time.Now()
+	values := url.Values{}
+	values.Set("query", query)
+
+	startStr, _ := params["start_time"].(string)
+	endStr, _ := params["end_time"].(string)
+	isRange := startStr != ""
+
+	var endpoint string
+	if isRange {
+		start, err := parseTimeParam(startStr, now)
+		if err != nil {
+			return "", err
+		}
+		end := now
+		if endStr != "" {
+			if end, err = parseTimeParam(endStr, now); err != nil {
+				return "", err
+			}
+		}
+		step := end.Sub(start) / 60 // ~60 points by default
+		if s, ok := params["step"].(string); ok && s != "" {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return "", fmt.Errorf("invalid step %q: %w", s, err)
+			}
+			step = d
+		}
+		if step < time.Second {
+			step = time.Second
+		}
+		values.Set("start", formatPromTime(start))
+		values.Set("end", formatPromTime(end))
+		values.Set("step", strconv.FormatFloat(step.Seconds(), 'f', -1, 64))
+		endpoint = "/api/v1/query_range"
+	} else {
+		if endStr != "" {
+			at, err := parseTimeParam(endStr, now)
+			if err != nil {
+				return "", err
+			}
+			values.Set("time", formatPromTime(at))
+		}
+		endpoint = "/api/v1/query"
+	}
+
+	req, err := http.NewRequest(http.MethodGet, t.config.URL+endpoint+"?"+values.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	if t.config.TenantID != "" {
+		req.Header.Set("X-Scope-OrgID", t.config.TenantID)
+	}
+	if t.config.Username != "" {
+		req.SetBasicAuth(t.config.Username, t.config.Password)
+	} else if t.config.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+t.config.BearerToken)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("Prometheus query failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		Status    string `json:"status"`
+		ErrorType string `json:"errorType"`
+		Error     string `json:"error"`
+		Data      struct {
+			ResultType string          `json:"resultType"`
+			Result     json.RawMessage `json:"result"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("invalid Prometheus response (status %d): %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if result.Status != "success" {
+		return "", fmt.Errorf("PromQL %s: %s", result.ErrorType, result.Error)
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "PromQL: %s\n", query)
+	if isRange {
+		fmt.Fprintf(&out, "Range: %s → %s, step %ss\n", values.Get("start"), values.Get("end"), values.Get("step"))
+	}
+	out.WriteString(strings.Repeat("-", 80) + "\n")
+
+	switch result.Data.ResultType {
+	case "scalar", "string":
+		var v []interface{}
+		json.Unmarshal(result.Data.Result, &v)
+		if len(v) == 2 {
+			fmt.Fprintf(&out, "%v\n", v[1])
+		}
+		return out.String(), nil
+	}
+
+	var series []promSeries
+	if err := json.Unmarshal(result.Data.Result, &series); err != nil {
+		return "", fmt.Errorf("invalid Prometheus result: %w", err)
+	}
+	if len(series) == 0 {
+		out.WriteString("No data (the query matched no series in this time range)\n")
+		return out.String(), nil
+	}
+
+	shown := series
+	if len(shown) > maxMetricSeries {
+		shown = shown[:maxMetricSeries]
+	}
+	for _, s := range shown {
+		if result.Data.ResultType == "matrix" {
+			writeRangeSeries(&out, s)
+		} else if len(s.Value) == 2 {
+			fmt.Fprintf(&out, "%s = %v\n", formatLabels(s.Metric), s.Value[1])
+		}
+	}
+	if len(series) > len(shown) {
+		fmt.Fprintf(&out, "... (showing %d of %d series; aggregate with sum/topk to narrow down)\n", len(shown), len(series))
+	}
+	return out.String(), nil
+}
+
+// writeRangeSeries renders summary statistics and downsampled points of a range series
+func writeRangeSeries(out *strings.Builder, s promSeries) {
+	type point struct {
+		ts time.Time
+		v  float64
+	}
+	var points []point
+	for _, pair := range s.Values {
+		if len(pair) != 2 {
+			continue
+		}
+		ts, _ := pair[0].(float64)
+		str, _ := pair[1].(string)
+		v, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			continue
+		}
+		points = append(points, point{ts: time.Unix(0, int64(ts*1e9)), v: v})
+	}
+	if len(points) == 0 {
+		return
+	}
+
+	min, max, sum := math.Inf(1), math.Inf(-1), 0.0
+	var maxAt time.Time
+	for _, p := range points {
+		if p.v < min {
+			min = p.v
+		}
+		if p.v > max {
+			max, maxAt = p.v, p.ts
+		}
+		sum += p.v
+	}
+
+	fmt.Fprintf(out, "%s\n", formatLabels(s.Metric))
+	fmt.Fprintf(out, "  min=%s max=%s (at %s) avg=%s last=%s\n",
+		formatMetric(min), formatMetric(max), maxAt.UTC().Format(time.RFC3339),
+		formatMetric(sum/float64(len(points))), formatMetric(points[len(points)-1].v))
+
+	// Downsample evenly so spikes and their timing remain visible
+	stride := (len(points) + maxMetricPoints - 1) / maxMetricPoints
+	var samples []string
+	for i := 0; i < len(points); i += stride {
+		samples = append(samples, fmt.Sprintf("%s=%s", points[i].ts.UTC().Format("15:04:05"), formatMetric(points[i].v)))
+	}
+	fmt.Fprintf(out, "  %s\n", strings.Join(samples, " "))
+}
+
+// formatMetric renders a sample value compactly
+func formatMetric(v float64) string {
+	return strconv.FormatFloat(v, 'g', 4, 64)
+}
+
+// formatPromTime renders a time as Prometheus API unix seconds
+func formatPromTime(t time.Time) string {
+	return strconv.FormatFloat(float64(t.UnixNano())/1e9, 'f', 3, 64)
+}
@@ -3,14 +3,13 @@ package tools
 import (
 	"bufio"
 	"fmt"
-	"os"
-	"path/filepath"
+	"io/fs"
 	"strings"
 )
 
 // ReadFileTool implements file reading functionality
 type ReadFileTool struct {
-	sourcePath string
+	source fs.FS
 }
 
 // Execute reads a file and returns its contents
@@ -20,11 +19,14 @@ func (t *ReadFileTool) Execute(params map[string]interface{}) (string, error) {
 		return "", fmt.Errorf("file_path parameter is required")
 	}
 
-	// Build full path
-	fullPath := filepath.Join(t.sourcePath, filePath)
+	// Resolve the path inside the source tree
+	name, err := sourceRelPath(filePath)
+	if err != nil {
+		return "", err
+	}
 
 	// Open file
-	file, err := os.Open(fullPath)
+	file, err := t.source.Open(name)
 	if err != nil {
 		return "", fmt.Errorf("failed to open file: %w", err)
 	}
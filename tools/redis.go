@@ -0,0 +1,381 @@
+package tools
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maxRedisValue limits the size of a GET result
+	maxRedisValue = 4096
+	// redisScanRounds bounds the number of SCAN round trips per call
+	redisScanRounds = 50
+)
+
+// RedisConfig configures the read-only Redis inspection tool
+type RedisConfig struct {
+	// Addr is the Redis address (e.g., "localhost:6379").
+	// When empty, the Redis tool is disabled.
+	Addr string
+
+	// Username and Password authenticate with AUTH (Username requires Redis 6 ACLs)
+	Username string
+	Password string
+
+	// DB is the database number selected after connecting
+	DB int
+
+	// TLS enables transport security
+	TLS bool
+
+	// GetPatterns are glob patterns (Redis MATCH syntax, e.g. "cache:user:*")
+	// of keys whose values may be read with GET. Values of other keys are
+	// never returned, only their type and TTL.
+	// Default: none (GET disabled)
+	GetPatterns []string
+
+	// MaxScanKeys caps the number of keys returned by a scan.
+	// Default: 100
+	MaxScanKeys int
+}
+
+// RedisTool runs read-only inspection commands against Redis
+type RedisTool struct {
+	config RedisConfig
+}
+
+// newRedisTool creates a Redis tool
+func newRedisTool(config RedisConfig) *RedisTool {
+	if config.MaxScanKeys <= 0 {
+		config.MaxScanKeys = 100
+	}
+	return &RedisTool{config: config}
+}
+
+// Execute runs one of: info, type, ttl, scan, get
+func (t *RedisTool) Execute(params map[string]interface{}) (string, error) {
+	command, _ := params["command"].(string)
+	key, _ := params["key"].(string)
+
+	if command != "info" && command != "scan" && key == "" {
+		return "", fmt.Errorf("key parameter is required for %s", command)
+	}
+
+	conn, err := t.dial()
+	if err != nil {
+		return "", err
+	}
+	defer conn.close()
+
+	switch command {
+	case "info":
+		section, _ := params["section"].(string)
+		args := []string{"INFO"}
+		if section != "" {
+			args = append(args, section)
+		}
+		reply, err := conn.do(args...)
+		if err != nil {
+			return "", err
+		}
+		return strings.ReplaceAll(fmt.Sprint(reply), "\r\n", "\n"), nil
+
+	case "type":
+		reply, err := conn.do("TYPE", key)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s: %v", key, reply), nil
+
+	case "ttl":
+		return t.ttl(conn, key)
+
+	case "scan":
+		pattern, _ := params["pattern"].(string)
+		if pattern == "" {
+			pattern = "*"
+		}
+		return t.scan(conn, pattern)
+
+	case "get":
+		if !t.getAllowed(key) {
+			return "", fmt.Errorf("reading %s is not allowed; readable key patterns: %v", key, t.config.GetPatterns)
+		}
+		typ, err := conn.do("TYPE", key)
+		if err != nil {
+			return "", err
+		}
+		if typ != "string" {
+			return fmt.Sprintf("%s is of type %v; only string values can be read", key, typ), nil
+		}
+		reply, err := conn.do("GET", key)
+		if err != nil {
+			return "", err
+		}
+		value, _ := reply.(string)
+		ttl, _ := t.ttl(conn, key)
+		if len(value) > maxRedisValue {
+			value = value[:maxRedisValue] + fmt.Sprintf("... (truncated, %d bytes total)", len(value))
+		}
+		return fmt.Sprintf("%s\n%s", ttl, value), nil
+	}
+	return "", fmt.Errorf("unknown command: %s (use info, type, ttl, scan or get)", command)
+}
+
+// ttl reports the remaining time to live of a key
+func (t *RedisTool) ttl(conn *redisConn, key string) (string, error) {
+	reply, err := conn.do("PTTL", key)
+	if err != nil {
+		return "", err
+	}
+	ms, _ := reply.(int64)
+	switch ms {
+	case -2:
+		return fmt.Sprintf("%s: key does not exist", key), nil
+	case -1:
+		return fmt.Sprintf("%s: no expiry", key), nil
+	}
+	return fmt.Sprintf("%s: expires in %s", key, time.Duration(ms)*time.Millisecond), nil
+}
+
+// scan iterates SCAN with MATCH until the key cap or round limit is reached
+func (t *RedisTool) scan(conn *redisConn, pattern string) (string, error) {
+	var keys []string
+	cursor := "0"
+	complete := false
+	for round := 0; round < redisScanRounds && len(keys) < t.config.MaxScanKeys; round++ {
+		reply, err := conn.do("SCAN", cursor, "MATCH", pattern, "COUNT", "500")
+		if err != nil {
+			return "", err
+		}
+		parts, ok := reply.([]interface{})
+		if !ok || len(parts) != 2 {
+			return "", fmt.Errorf("unexpected SCAN reply")
+		}
+		cursor, _ = parts[0].(string)
+		batch, _ := parts[1].([]interface{})
+		for _, k := range batch {
+			if s, ok := k.(string); ok {
+				keys = append(keys, s)
+			}
+		}
+		if cursor == "0" {
+			complete = true
+			break
+		}
+	}
+	if len(keys) > t.config.MaxScanKeys {
+		keys = keys[:t.config.MaxScanKeys]
+		complete = false
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "Keys matching %q: %d\n", pattern, len(keys))
+	for _, k := range keys {
+		out.WriteString("  " + k + "\n")
+	}
+	if !complete {
+		out.WriteString("... (scan stopped early; use a more specific pattern)\n")
+	}
+	return out.String(), nil
+}
+
+// getAllowed reports whether a key's value may be read
+func (t *RedisTool) getAllowed(key string) bool {
+	for _, pattern := range t.config.GetPatterns {
+		if redisMatch(pattern, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// redisMatch reports whether key matches a glob pattern the way Redis MATCH
+// and KEYS do: * matches any run of bytes (including "/" and ":"), ? one
+// byte, [abc], [^abc] and [a-z] a byte of a set, and \ escapes a character
+func redisMatch(pattern, key string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(key); i++ {
+				if redisMatch(pattern[1:], key[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(key) == 0 {
+				return false
+			}
+			key = key[1:]
+			pattern = pattern[1:]
+		case '[':
+			if len(key) == 0 {
+				return false
+			}
+			pattern = pattern[1:]
+			not := len(pattern) > 0 && pattern[0] == '^'
+			if not {
+				pattern = pattern[1:]
+			}
+			match := false
+			for len(pattern) > 0 && pattern[0] != ']' {
+				switch {
+				case pattern[0] == '\\' && len(pattern) >= 2:
+					match = match || pattern[1] == key[0]
+					pattern = pattern[2:]
+				case len(pattern) >= 3 && pattern[1] == '-':
+					lo, hi := pattern[0], pattern[2]
+					if lo > hi {
+						lo, hi = hi, lo
+					}
+					match = match || key[0] >= lo && key[0] <= hi
+					pattern = pattern[3:]
+				default:
+					match = match || pattern[0] == key[0]
+					pattern = pattern[1:]
+				}
+			}
+			if len(pattern) > 0 {
+				pattern = pattern[1:] // the closing ]
+			}
+			if match == not {
+				return false
+			}
+			key = key[1:]
+		default:
+			if pattern[0] == '\\' && len(pattern) >= 2 {
+				pattern = pattern[1:]
+			}
+			if len(key) == 0 || pattern[0] != key[0] {
+				return false
+			}
+			key = key[1:]
+			pattern = pattern[1:]
+		}
+	}
+	return len(key) == 0
+}
+
+// redisConn is a minimal RESP client connection
+type redisConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// dial connects, authenticates and selects the configured database
+func (t *RedisTool) dial() (*redisConn, error) {
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	var conn net.Conn
+	var err error
+	if t.config.TLS {
+		host, _, _ := net.SplitHostPort(t.config.Addr)
+		conn, err = tls.DialWithDialer(dialer, "tcp", t.config.Addr, &tls.Config{ServerName: host})
+	} else {
+		conn, err = dialer.Dial("tcp", t.config.Addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+	conn.SetDeadline(time.Now().Add(15 * time.Second))
+
+	c := &redisConn{conn: conn, r: bufio.NewReader(conn)}
+	if t.config.Password != "" {
+		args := []string{"AUTH", t.config.Password}
+		if t.config.Username != "" {
+			args = []string{"AUTH", t.config.Username, t.config.Password}
+		}
+		if _, err := c.do(args...); err != nil {
+			c.close()
+			return nil, fmt.Errorf("Redis authentication failed: %w", err)
+		}
+	}
+	if t.config.DB != 0 {
+		if _, err := c.do("SELECT", strconv.Itoa(t.config.DB)); err != nil {
+			c.close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (c *redisConn) close() {
+	c.conn.Close()
+}
+
+// do sends a command and reads its reply
+func (c *redisConn) do(args ...string) (interface{}, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := c.conn.Write([]byte(b.String())); err != nil {
+		return nil, fmt.Errorf("Redis write failed: %w", err)
+	}
+	return c.readReply()
+}
+
+// readReply parses one RESP2 reply: strings, integers, bulk strings and arrays
+func (c *redisConn) readReply() (interface{}, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("Redis read failed: %w", err)
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("empty Redis reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, fmt.Errorf("Redis error: %s", line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			return nil, fmt.Errorf("Redis read failed: %w", err)
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, 0, n)
+		for i := 0; i < n; i++ {
+			item, err := c.readReply()
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("unexpected Redis reply: %q", line)
+}
@@ -0,0 +1,274 @@
+package tools
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// runbookRefreshInterval is how long the runbook index is reused before
+	// the folder and URLs are read again
+	runbookRefreshInterval = 5 * time.Minute
+	// defaultRunbookResults is the number of sections returned by a search
+	defaultRunbookResults = 3
+	// maxRunbookSectionChars truncates each section returned by a search
+	maxRunbookSectionChars = 4000
+	// maxRunbookBytes limits the size of a single runbook
+	maxRunbookBytes = 1 << 20
+)
+
+// RunbooksConfig configures the documented procedures searched by search_runbooks.
+// Runbooks are Markdown or plain text; they are split into sections at headings
+// and indexed separately from the source code.
+type RunbooksConfig struct {
+	// Dir is a folder of runbooks (.md, .markdown, .txt), searched recursively
+	Dir string
+
+	// URLs are runbooks fetched over HTTP(S), e.g. raw Markdown files in a
+	// wiki or repository
+	URLs []string
+
+	// Headers are extra HTTP headers sent when fetching URLs (e.g. Authorization)
+	Headers map[string]string
+}
+
+// runbookSection is one heading-delimited part of a runbook
+type runbookSection struct {
+	source string // file path relative to Dir, or URL
+	title  string // "Runbook title > Section heading"
+	text   string
+	lower  string // lowercase title and text for matching
+}
+
+// RunbookTool searches runbooks for documented procedures
+type RunbookTool struct {
+	config RunbooksConfig
+	client *http.Client
+
+	mu       sync.Mutex
+	sections []runbookSection
+	problems []string // runbooks that failed to load
+	loadedAt time.Time
+}
+
+// newRunbookTool creates the tool; runbooks are loaded on first search
+func newRunbookTool(config RunbooksConfig) (*RunbookTool, error) {
+	if config.Dir != "" {
+		info, err := os.Stat(config.Dir)
+		if err != nil {
+			return nil, fmt.Errorf("runbooks folder: %w", err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("runbooks folder %s is not a directory", config.Dir)
+		}
+	}
+	if config.Dir == "" && len(config.URLs) == 0 {
+		return nil, fmt.Errorf("no runbooks configured")
+	}
+	return &RunbookTool{
+		config: config,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// Execute searches the runbook sections for the query
+func (t *RunbookTool) Execute(params map[string]interface{}) (string, error) {
+	query, _ := params["query"].(string)
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return "", fmt.Errorf("query parameter is required")
+	}
+	limit := defaultRunbookResults
+	if l, ok := params["limit"].(float64); ok && l > 0 && l <= 10 {
+		limit = int(l)
+	}
+
+	sections, problems := t.index()
+
+	type scored struct {
+		section  runbookSection
+		distinct int
+		total    int
+	}
+	var matches []scored
+	for _, s := range sections {
+		m := scored{section: s}
+		for _, term := range terms {
+			if n := strings.Count(s.lower, term); n > 0 {
+				m.distinct++
+				m.total += n
+				// Matches in headings say more about what the section covers
+				if strings.Contains(strings.ToLower(s.title), term) {
+					m.total += 5
+				}
+			}
+		}
+		if m.distinct > 0 {
+			matches = append(matches, m)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].distinct != matches[j].distinct {
+			return matches[i].distinct > matches[j].distinct
+		}
+		return matches[i].total > matches[j].total
+	})
+
+	var out strings.Builder
+	for _, p := range problems {
+		fmt.Fprintf(&out, "Warning: %s\n", p)
+	}
+	if len(matches) == 0 {
+		fmt.Fprintf(&out, "No runbook sections match %q (%d sections searched)", query, len(sections))
+		return out.String(), nil
+	}
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	fmt.Fprintf(&out, "Found %d matching runbook sections for %q:\n", len(matches), query)
+	for _, m := range matches {
+		text := m.section.text
+		if len(text) > maxRunbookSectionChars {
+			text = text[:maxRunbookSectionChars] + "\n... (truncated)"
+		}
+		fmt.Fprintf(&out, "\n=== %s\nSource: %s\n\n%s\n", m.section.title, m.section.source, text)
+	}
+	return out.String(), nil
+}
+
+// index returns the runbook sections, reloading them when the cache is stale
+func (t *RunbookTool) index() ([]runbookSection, []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.loadedAt.IsZero() && time.Since(t.loadedAt) < runbookRefreshInterval {
+		return t.sections, t.problems
+	}
+
+	var sections []runbookSection
+	var problems []string
+	if t.config.Dir != "" {
+		err := filepath.Walk(t.config.Dir, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() {
+				if strings.HasPrefix(info.Name(), ".") && p != t.config.Dir {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			switch strings.ToLower(filepath.Ext(p)) {
+			case ".md", ".markdown", ".txt":
+			default:
+				return nil
+			}
+			if info.Size() > maxRunbookBytes {
+				problems = append(problems, fmt.Sprintf("%s skipped: larger than 1MB", p))
+				return nil
+			}
+			data, err := os.ReadFile(p)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", p, err))
+				return nil
+			}
+			rel, _ := filepath.Rel(t.config.Dir, p)
+			sections = append(sections, splitRunbook(rel, strings.TrimSuffix(filepath.Base(p), filepath.Ext(p)), string(data))...)
+			return nil
+		})
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("runbooks folder: %v", err))
+		}
+	}
+	for _, u := range t.config.URLs {
+		text, err := t.fetch(u)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", u, err))
+			continue
+		}
+		name := strings.TrimSuffix(path.Base(u), path.Ext(u))
+		sections = append(sections, splitRunbook(u, name, text)...)
+	}
+
+	t.sections, t.problems, t.loadedAt = sections, problems, time.Now()
+	return sections, problems
+}
+
+// fetch downloads a runbook URL
+func (t *RunbookTool) fetch(u string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return "", err
+	}
+	for k, v := range t.config.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRunbookBytes))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// splitRunbook splits a runbook into sections at Markdown headings. The first
+// top-level heading (or the file name) titles the runbook.
+func splitRunbook(source, name, text string) []runbookSection {
+	title := name
+	var sections []runbookSection
+	heading := ""
+	var body []string
+	flush := func() {
+		content := strings.TrimSpace(strings.Join(body, "\n"))
+		body = nil
+		if content == "" {
+			return
+		}
+		full := title
+		if heading != "" && heading != title {
+			full += " > " + heading
+		}
+		sections = append(sections, runbookSection{
+			source: source,
+			title:  full,
+			text:   content,
+			lower:  strings.ToLower(full + "\n" + content),
+		})
+	}
+
+	inCode := false
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+		}
+		if !inCode && strings.HasPrefix(line, "#") {
+			level := len(line) - len(strings.TrimLeft(line, "#"))
+			h := strings.TrimSpace(line[level:])
+			if level <= 3 && h != "" {
+				flush()
+				if level == 1 && len(sections) == 0 && heading == "" {
+					title = h
+				}
+				heading = h
+				continue
+			}
+		}
+		body = append(body, line)
+	}
+	flush()
+	return sections
+}
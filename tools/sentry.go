@@ -0,0 +1,290 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SentryConfig configures the Sentry error context tool
+type SentryConfig struct {
+	// DSN is the project's Sentry DSN. It determines the Sentry host to query.
+	DSN string
+
+	// AuthToken is a Sentry API auth token with event:read and project:read scopes.
+	// When empty, the Sentry tool is disabled.
+	AuthToken string
+
+	// Organization is the organization slug, used to resolve short issue IDs
+	// and look up release details. Optional: both are skipped when empty.
+	Organization string
+}
+
+// SentryTool fetches issue, event, breadcrumb and release details from Sentry
+type SentryTool struct {
+	config  SentryConfig
+	apiBase string
+	client  *http.Client
+}
+
+// maxSentryBreadcrumbs limits how many of the latest breadcrumbs are shown
+const maxSentryBreadcrumbs = 20
+
+// newSentryTool creates a Sentry tool, deriving the API host from the DSN
+func newSentryTool(config SentryConfig) (*SentryTool, error) {
+	apiBase := "https://sentry.io"
+	if config.DSN != "" {
+		u, err := url.Parse(config.DSN)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid Sentry DSN")
+		}
+		host := u.Host
+		// SaaS DSNs point at the ingest host (o123.ingest.sentry.io); the API lives on sentry.io
+		if strings.HasSuffix(host, ".sentry.io") {
+			host = "sentry.io"
+		}
+		apiBase = u.Scheme + "://" + host
+	}
+	return &SentryTool{
+		config:  config,
+		apiBase: apiBase,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// sentryEvent is the subset of a Sentry event used by the tool
+type sentryEvent struct {
+	EventID     string `json:"eventID"`
+	DateCreated string `json:"dateCreated"`
+	Message     string `json:"message"`
+	Release     *struct {
+		Version string `json:"version"`
+	} `json:"release"`
+	Tags []struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+	} `json:"tags"`
+	Entries []struct {
+		Type string          `json:"type"`
+		Data json.RawMessage `json:"data"`
+	} `json:"entries"`
+}
+
+// Execute fetches the context for a Sentry issue
+func (t *SentryTool) Execute(params map[string]interface{}) (string, error) {
+	issueID, ok := params["issue_id"].(string)
+	if !ok || issueID == "" {
+		return "", fmt.Errorf("issue_id parameter is required")
+	}
+	issueID = strings.TrimPrefix(issueID, "#")
+
+	// Short IDs (e.g. "BACKEND-1A2") must be resolved to the numeric issue ID
+	if _, err := strconv.ParseInt(issueID, 10, 64); err != nil {
+		if t.config.Organization == "" {
+			return "", fmt.Errorf("short issue IDs require Sentry.Organization to be configured; use the numeric issue ID instead")
+		}
+		var resolved struct {
+			GroupID string `json:"groupId"`
+		}
+		path := fmt.Sprintf("/api/0/organizations/%s/shortids/%s/", url.PathEscape(t.config.Organization), url.PathEscape(issueID))
+		if err := t.get(path, &resolved); err != nil {
+			return "", fmt.Errorf("failed to resolve Sentry short ID %s: %w", issueID, err)
+		}
+		issueID = resolved.GroupID
+	}
+
+	var issue struct {
+		ID        string `json:"id"`
+		ShortID   string `json:"shortId"`
+		Title     string `json:"title"`
+		Culprit   string `json:"culprit"`
+		Level     string `json:"level"`
+		Status    string `json:"status"`
+		Count     string `json:"count"`
+		UserCount int    `json:"userCount"`
+		FirstSeen string `json:"firstSeen"`
+		LastSeen  string `json:"lastSeen"`
+		Permalink string `json:"permalink"`
+	}
+	if err := t.get("/api/0/issues/"+url.PathEscape(issueID)+"/", &issue); err != nil {
+		return "", fmt.Errorf("failed to fetch Sentry issue %s: %w", issueID, err)
+	}
+
+	var event sentryEvent
+	if err := t.get("/api/0/issues/"+url.PathEscape(issue.ID)+"/events/latest/", &event); err != nil {
+		return "", fmt.Errorf("failed to fetch latest event: %w", err)
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "Sentry issue %s: %s\n", issue.ShortID, issue.Title)
+	out.WriteString(strings.Repeat("-", 80) + "\n")
+	fmt.Fprintf(&out, "Culprit: %s\n", issue.Culprit)
+	fmt.Fprintf(&out, "Level: %s, Status: %s, Events: %s, Users: %d\n", issue.Level, issue.Status, issue.Count, issue.UserCount)
+	fmt.Fprintf(&out, "First seen: %s, Last seen: %s\n", issue.FirstSeen, issue.LastSeen)
+	fmt.Fprintf(&out, "Link: %s\n", issue.Permalink)
+
+	fmt.Fprintf(&out, "\n=== Latest event %s (%s) ===\n", event.EventID, event.DateCreated)
+	if event.Message != "" {
+		fmt.Fprintf(&out, "Message: %s\n", event.Message)
+	}
+	if len(event.Tags) > 0 {
+		var tags []string
+		for _, tag := range event.Tags {
+			tags = append(tags, tag.Key+"="+tag.Value)
+		}
+		fmt.Fprintf(&out, "Tags: %s\n", strings.Join(tags, ", "))
+	}
+
+	for _, entry := range event.Entries {
+		switch entry.Type {
+		case "exception":
+			writeSentryException(&out, entry.Data)
+		case "breadcrumbs":
+			writeSentryBreadcrumbs(&out, entry.Data)
+		case "request":
+			writeSentryRequest(&out, entry.Data)
+		}
+	}
+
+	if event.Release != nil && event.Release.Version != "" {
+		out.WriteString("\n=== Release ===\n")
+		fmt.Fprintf(&out, "Version: %s\n", event.Release.Version)
+		if t.config.Organization != "" {
+			t.writeRelease(&out, event.Release.Version)
+		}
+	}
+
+	out.WriteString("\n💡 Use read_file on the in-app frames above and read_logs around the event time to correlate.\n")
+	return out.String(), nil
+}
+
+// writeSentryException formats exception values and their in-app stack frames
+func writeSentryException(out *strings.Builder, data json.RawMessage) {
+	var exc struct {
+		Values []struct {
+			Type       string `json:"type"`
+			Value      string `json:"value"`
+			Stacktrace *struct {
+				Frames []struct {
+					Filename string `json:"filename"`
+					Function string `json:"function"`
+					LineNo   int    `json:"lineNo"`
+					InApp    bool   `json:"inApp"`
+				} `json:"frames"`
+			} `json:"stacktrace"`
+		} `json:"values"`
+	}
+	if json.Unmarshal(data, &exc) != nil {
+		return
+	}
+
+	out.WriteString("\nException:\n")
+	for _, v := range exc.Values {
+		fmt.Fprintf(out, "  %s: %s\n", v.Type, v.Value)
+		if v.Stacktrace == nil {
+			continue
+		}
+		// Sentry orders frames oldest first; show the most recent call first
+		frames := v.Stacktrace.Frames
+		for i := len(frames) - 1; i >= 0; i-- {
+			f := frames[i]
+			marker := "   "
+			if f.InApp {
+				marker = " ▶ "
+			}
+			fmt.Fprintf(out, "  %s%s:%d in %s\n", marker, f.Filename, f.LineNo, f.Function)
+		}
+	}
+}
+
+// writeSentryBreadcrumbs formats the latest breadcrumbs of an event
+func writeSentryBreadcrumbs(out *strings.Builder, data json.RawMessage) {
+	var crumbs struct {
+		Values []struct {
+			Timestamp string                 `json:"timestamp"`
+			Category  string                 `json:"category"`
+			Level     string                 `json:"level"`
+			Message   string                 `json:"message"`
+			Data      map[string]interface{} `json:"data"`
+		} `json:"values"`
+	}
+	if json.Unmarshal(data, &crumbs) != nil || len(crumbs.Values) == 0 {
+		return
+	}
+
+	values := crumbs.Values
+	if len(values) > maxSentryBreadcrumbs {
+		values = values[len(values)-maxSentryBreadcrumbs:]
+	}
+	fmt.Fprintf(out, "\nBreadcrumbs (last %d):\n", len(values))
+	for _, c := range values {
+		msg := c.Message
+		if msg == "" && len(c.Data) > 0 {
+			b, _ := json.Marshal(c.Data)
+			msg = string(b)
+		}
+		fmt.Fprintf(out, "  [%s] %s %s: %s\n", c.Timestamp, c.Level, c.Category, msg)
+	}
+}
+
+// writeSentryRequest formats the HTTP request attached to an event
+func writeSentryRequest(out *strings.Builder, data json.RawMessage) {
+	var req struct {
+		Method string `json:"method"`
+		URL    string `json:"url"`
+	}
+	if json.Unmarshal(data, &req) != nil || req.URL == "" {
+		return
+	}
+	fmt.Fprintf(out, "\nRequest: %s %s\n", req.Method, req.URL)
+}
+
+// writeRelease appends release details (creation date and last commit)
+func (t *SentryTool) writeRelease(out *strings.Builder, version string) {
+	var release struct {
+		DateCreated  string `json:"dateCreated"`
+		DateReleased string `json:"dateReleased"`
+		LastCommit   *struct {
+			ID      string `json:"id"`
+			Message string `json:"message"`
+		} `json:"lastCommit"`
+	}
+	path := fmt.Sprintf("/api/0/organizations/%s/releases/%s/", url.PathEscape(t.config.Organization), url.PathEscape(version))
+	if err := t.get(path, &release); err != nil {
+		fmt.Fprintf(out, "(release details unavailable: %v)\n", err)
+		return
+	}
+	fmt.Fprintf(out, "Created: %s, Released: %s\n", release.DateCreated, release.DateReleased)
+	if release.LastCommit != nil {
+		fmt.Fprintf(out, "Last commit: %s %s\n", release.LastCommit.ID, strings.SplitN(release.LastCommit.Message, "\n", 2)[0])
+	}
+}
+
+// get performs an authenticated GET against the Sentry API
+func (t *SentryTool) get(path string, out interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, t.apiBase+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+t.config.AuthToken)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Sentry API returned status %d: %s", resp.StatusCode, string(body))
+	}
+	return json.Unmarshal(body, out)
+}
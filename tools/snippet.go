@@ -0,0 +1,271 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultSnippetTimeout limits how long a snippet may run
+	defaultSnippetTimeout = 10 * time.Second
+	// snippetBuildTimeout limits how long compiling a snippet may take
+	snippetBuildTimeout = 60 * time.Second
+	// defaultSnippetMemoryMB limits a snippet's memory
+	defaultSnippetMemoryMB = 256
+	// defaultSnippetOutputBytes truncates a snippet's stdout and stderr
+	defaultSnippetOutputBytes = 16 << 10
+	// maxSnippetFileMB limits the size of files a snippet may write
+	maxSnippetFileMB = 10
+)
+
+// SnippetConfig configures the run_snippet tool, which compiles and runs
+// short Go programs so the assistant can check the behavior of a suspect
+// function with concrete inputs.
+//
+// Snippets run as a subprocess in a temporary directory, with only the
+// standard library, no environment variables besides those below, and CPU,
+// memory, file size and wall-clock limits (CPU, memory and file limits
+// need a Unix shell). This limits runaway code; it is not a security
+// boundary against malicious code, which could still reach the network
+// and the file system, so only enable the tool for trusted users.
+type SnippetConfig struct {
+	// Enabled registers the run_snippet tool
+	Enabled bool
+
+	// GoBinary is the go command used to compile snippets
+	// Default: "go" from PATH
+	GoBinary string
+
+	// Timeout limits the run time (wall clock and CPU) of a snippet
+	// Default: 10s
+	Timeout time.Duration
+
+	// MemoryLimitMB limits the memory of a snippet
+	// Default: 256
+	MemoryLimitMB int
+
+	// MaxOutputBytes truncates a snippet's stdout and stderr; output
+	// beyond it is discarded as it is written
+	// Default: 16 KB
+	MaxOutputBytes int
+}
+
+// packageClause detects snippets that are complete programs
+var packageClause = regexp.MustCompile(`(?m)^\s*package\s+\w+`)
+
+// SnippetTool compiles and runs Go snippets in a sandboxed subprocess
+type SnippetTool struct {
+	config SnippetConfig
+	goBin  string
+	shell  string // for resource limits; empty if unavailable
+}
+
+// newSnippetTool creates the tool, checking that the go command is available
+func newSnippetTool(config SnippetConfig) (*SnippetTool, error) {
+	if config.GoBinary == "" {
+		config.GoBinary = "go"
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultSnippetTimeout
+	}
+	if config.MemoryLimitMB <= 0 {
+		config.MemoryLimitMB = defaultSnippetMemoryMB
+	}
+	if config.MaxOutputBytes <= 0 {
+		config.MaxOutputBytes = defaultSnippetOutputBytes
+	}
+	goBin, err := exec.LookPath(config.GoBinary)
+	if err != nil {
+		return nil, fmt.Errorf("go command not found: %w", err)
+	}
+	t := &SnippetTool{config: config, goBin: goBin}
+	if runtime.GOOS != "windows" {
+		t.shell, _ = exec.LookPath("sh")
+	}
+	return t, nil
+}
+
+// Execute compiles and runs a snippet and reports its exit status and output
+func (t *SnippetTool) Execute(params map[string]interface{}) (string, error) {
+	code, ok := params["code"].(string)
+	if !ok || strings.TrimSpace(code) == "" {
+		return "", fmt.Errorf("code parameter is required")
+	}
+	var imports []string
+	if list, ok := params["imports"].([]interface{}); ok {
+		for _, v := range list {
+			if s, ok := v.(string); ok && s != "" {
+				imports = append(imports, s)
+			}
+		}
+	}
+
+	dir, err := os.MkdirTemp("", "willknow-snippet-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create snippet directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(snippetProgram(code, imports)), 0600); err != nil {
+		return "", fmt.Errorf("failed to write snippet: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module snippet\n\ngo 1.21\n"), 0600); err != nil {
+		return "", fmt.Errorf("failed to write snippet: %w", err)
+	}
+
+	// Compile with the caller's Go environment (and build cache), offline
+	ctx, cancel := context.WithTimeout(context.Background(), snippetBuildTimeout)
+	defer cancel()
+	build := exec.CommandContext(ctx, t.goBin, "build", "-o", "snippet", ".")
+	build.Dir = dir
+	build.Env = append(os.Environ(), "GOPROXY=off", "GOFLAGS=-mod=mod", "GOTOOLCHAIN=local", "CGO_ENABLED=0")
+	out := &LimitedBuffer{Limit: t.config.MaxOutputBytes}
+	build.Stdout, build.Stderr = out, out
+	if err := build.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("compiling the snippet timed out after %s", snippetBuildTimeout)
+		}
+		msg := strings.ReplaceAll(out.Truncated(), dir+string(filepath.Separator), "")
+		return "Compilation failed (only the standard library is available):\n" + msg, nil
+	}
+
+	return t.run(dir)
+}
+
+// run executes the compiled snippet with resource limits
+func (t *SnippetTool) run(dir string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), t.config.Timeout)
+	defer cancel()
+
+	cmd := LimitedCommand(ctx, t.shell, ResourceLimits{
+		CPU:      t.config.Timeout,
+		MemoryMB: t.config.MemoryLimitMB,
+		FileMB:   maxSnippetFileMB,
+	}, filepath.Join(dir, "snippet"))
+	cmd.Dir = dir
+	// The snippet must not see the assistant's credentials
+	cmd.Env = []string{
+		"HOME=" + dir,
+		"TMPDIR=" + dir,
+		"GOMAXPROCS=2",
+		fmt.Sprintf("GOMEMLIMIT=%dMiB", t.config.MemoryLimitMB*3/4),
+	}
+	cmd.WaitDelay = time.Second
+	// ulimit -f does not apply to pipes: keep at most MaxOutputBytes in memory
+	stdout := &LimitedBuffer{Limit: t.config.MaxOutputBytes}
+	stderr := &LimitedBuffer{Limit: t.config.MaxOutputBytes}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	start := time.Now()
+	err := cmd.Run()
+	elapsed := time.Since(start).Round(time.Millisecond)
+
+	var result strings.Builder
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() != nil:
+		fmt.Fprintf(&result, "Killed: the snippet exceeded the %s time limit\n", t.config.Timeout)
+	case errors.As(err, &exitErr):
+		fmt.Fprintf(&result, "Exit status %d after %s\n", exitErr.ExitCode(), elapsed)
+	case err != nil:
+		return "", fmt.Errorf("failed to run snippet: %w", err)
+	default:
+		fmt.Fprintf(&result, "Exit status 0 after %s\n", elapsed)
+	}
+	if stdout.Total() > 0 {
+		result.WriteString("\n--- stdout ---\n" + stdout.Truncated())
+	}
+	if stderr.Total() > 0 {
+		result.WriteString("\n--- stderr ---\n" + stderr.Truncated())
+	}
+	if stdout.Total() == 0 && stderr.Total() == 0 {
+		result.WriteString("(no output)\n")
+	}
+	return result.String(), nil
+}
+
+// ResourceLimits are the CPU, memory and file size limits of a subprocess
+type ResourceLimits struct {
+	CPU      time.Duration
+	MemoryMB int
+	FileMB   int
+}
+
+// LimitedCommand returns a command running name with the given limits,
+// applied with ulimit by shell (the path of sh). Without a shell, e.g. on
+// Windows, the command runs without limits.
+func LimitedCommand(ctx context.Context, shell string, limits ResourceLimits, name string, args ...string) *exec.Cmd {
+	if shell == "" {
+		return exec.CommandContext(ctx, name, args...)
+	}
+	script := fmt.Sprintf(`ulimit -t %d; ulimit -d %d; ulimit -f %d; exec "$@"`,
+		int(limits.CPU.Seconds())+1, limits.MemoryMB*1024, limits.FileMB*1024*2)
+	return exec.CommandContext(ctx, shell, append([]string{"-c", script, "sh", name}, args...)...)
+}
+
+// LimitedBuffer is a subprocess output writer that keeps the first Limit
+// bytes written and counts the rest, so a runaway process cannot fill the
+// assistant's memory. Writes never fail, so the process is not interrupted.
+type LimitedBuffer struct {
+	Limit int
+
+	buf   bytes.Buffer
+	total int64
+}
+
+// Write stores p up to the limit
+func (b *LimitedBuffer) Write(p []byte) (int, error) {
+	b.total += int64(len(p))
+	if room := b.Limit - b.buf.Len(); room > 0 {
+		if len(p) > room {
+			b.buf.Write(p[:room])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+// String returns the stored output
+func (b *LimitedBuffer) String() string {
+	return b.buf.String()
+}
+
+// Total returns the number of bytes written, stored or not
+func (b *LimitedBuffer) Total() int64 {
+	return b.total
+}
+
+// Truncated returns the stored output, noting the total size if the
+// output exceeded the limit
+func (b *LimitedBuffer) Truncated() string {
+	if b.total <= int64(b.buf.Len()) {
+		return b.buf.String()
+	}
+	return b.buf.String() + fmt.Sprintf("\n... (truncated, %d bytes total)\n", b.total)
+}
+
+// snippetProgram returns code as is if it is a complete program, or wraps
+// statements in a main function with the given imports
+func snippetProgram(code string, imports []string) string {
+	if packageClause.MatchString(code) {
+		return code
+	}
+	var b strings.Builder
+	b.WriteString("package main\n\n")
+	for _, imp := range imports {
+		fmt.Fprintf(&b, "import %q\n", strings.Trim(imp, `"`))
+	}
+	b.WriteString("\nfunc main() {\n" + code + "\n}\n")
+	return b.String()
+}
@@ -0,0 +1,133 @@
+package tools
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+)
+
+// OpenSource opens the source code at p, which is either a directory or a
+// .zip, .tar, .tar.gz or .tgz archive. Archives with a single top-level
+// directory (e.g. "repo-main/") are served from inside that directory.
+func OpenSource(p string) (fs.FS, error) {
+	lower := strings.ToLower(p)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		r, err := zip.OpenReader(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open source archive: %w", err)
+		}
+		return stripTopLevelDir(r), nil
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"), strings.HasSuffix(lower, ".tar"):
+		fsys, err := readTarSource(p, !strings.HasSuffix(lower, ".tar"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open source archive: %w", err)
+		}
+		return stripTopLevelDir(fsys), nil
+	}
+	return os.DirFS(p), nil
+}
+
+// readTarSource loads a tar archive into memory. The regular files are
+// repacked into an uncompressed zip, whose reader provides the fs.FS.
+func readTarSource(p string, gzipped bool) (fs.FS, error) {
+	file, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var in io.Reader = file
+	if gzipped {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		in = gz
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	tr := tar.NewReader(in)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		name := path.Clean(strings.TrimPrefix(hdr.Name, "/"))
+		if hdr.Typeflag != tar.TypeReg || !fs.ValidPath(name) {
+			continue // skip directories, links and unsafe paths
+		}
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store, Modified: hdr.ModTime})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.Copy(w, tr); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+}
+
+// stripTopLevelDir returns the single top-level directory of fsys if it holds everything
+func stripTopLevelDir(fsys fs.FS) fs.FS {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil || len(entries) != 1 || !entries[0].IsDir() {
+		return fsys
+	}
+	sub, err := fs.Sub(fsys, entries[0].Name())
+	if err != nil {
+		return fsys
+	}
+	return sub
+}
+
+// sourceRelPath converts a path given by the model to an fs.FS path
+func sourceRelPath(p string) (string, error) {
+	name := path.Clean("/" + strings.ReplaceAll(p, "\\", "/"))[1:]
+	if name == "" {
+		name = "."
+	}
+	if !fs.ValidPath(name) {
+		return "", fmt.Errorf("invalid path: %s", p)
+	}
+	return name, nil
+}
+
+// maxSourceFileSize limits files served by ReadSourceFile
+const maxSourceFileSize = 2 << 20
+
+// ReadSourceFile returns the cleaned path and the contents of a file in the
+// registry's source tree
+func (r *Registry) ReadSourceFile(p string) (string, []byte, error) {
+	name, err := sourceRelPath(p)
+	if err != nil {
+		return "", nil, err
+	}
+	info, err := fs.Stat(r.source, name)
+	if err != nil {
+		return "", nil, err
+	}
+	if info.IsDir() {
+		return "", nil, fmt.Errorf("%s is a directory", name)
+	}
+	if info.Size() > maxSourceFileSize {
+		return "", nil, fmt.Errorf("%s is too large (%d bytes, max %d)", name, info.Size(), maxSourceFileSize)
+	}
+	data, err := fs.ReadFile(r.source, name)
+	return name, data, err
+}
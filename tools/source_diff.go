@@ -0,0 +1,255 @@
+package tools
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// compareRef holds the fetched tip of the branch compared with
+	compareRef = "refs/willknow/compare"
+	// maxComparedCommits bounds the commits listed by compare_deployed_source
+	maxComparedCommits = 50
+	// maxSourceDiffChars bounds the diff of a path
+	maxSourceDiffChars = 20000
+)
+
+// SourceDiffTool compares the deployed revision of a git source with the
+// tip of its main branch, so the assistant can tell whether a bug is
+// already fixed on main but not yet deployed
+type SourceDiffTool struct {
+	source   *GitSource
+	deployed string                 // "" compares the checked-out revision
+	exclude  func(path string) bool // files never shown, e.g. denied by policy
+}
+
+// comparedCommit is a commit of the branch missing from the deployed revision
+type comparedCommit struct {
+	sha     string
+	author  string
+	date    string
+	subject string
+	files   []string
+}
+
+// Execute lists the commits of the branch that are not deployed, optionally
+// only those touching a path or matching a message, and the diff of a path
+func (t *SourceDiffTool) Execute(params map[string]interface{}) (string, error) {
+	path, _ := params["path"].(string)
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "./"), "/")
+	query, _ := params["query"].(string)
+	showDiff, _ := params["show_diff"].(bool)
+	branch, _ := params["branch"].(string)
+	if branch == "" {
+		branch = t.source.config.CompareBranch
+	}
+	if showDiff && path == "" {
+		return "", fmt.Errorf("show_diff requires a path")
+	}
+
+	deployed, note := t.deployed, ""
+	if deployed == "" {
+		deployed = t.source.Revision()
+		note = "The running build's revision is unknown (Config.BuildRevision), so the analyzed source was compared instead."
+	}
+
+	g := t.source
+	g.fetchMu.Lock()
+	defer g.fetchMu.Unlock()
+
+	tip, err := g.fetchCompared(deployed, branch)
+	if err != nil {
+		return "", err
+	}
+	branchName := branch
+	if branchName == "" {
+		branchName = "the default branch"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Deployed revision %s compared with %s at %s\n", shortRevision(deployed), branchName, shortRevision(tip))
+	if note != "" {
+		b.WriteString(note + "\n")
+	}
+	if tip == deployed {
+		b.WriteString("The deployed revision is the tip of " + branchName + ": nothing is waiting to be deployed.\n")
+		return b.String(), nil
+	}
+	if _, err := g.git("merge-base", "--is-ancestor", deployed, tip); err != nil {
+		b.WriteString("Warning: the deployed revision is not an ancestor of " + branchName + " (it may come from another branch), so the list below may include commits that are deployed under another SHA.\n")
+	}
+
+	total, err := g.git("rev-list", "--count", deployed+".."+tip)
+	if err != nil {
+		return "", err
+	}
+	commits, err := g.comparedCommits(deployed, tip, path, query)
+	if err != nil {
+		return "", err
+	}
+	hidden := 0
+	for i := range commits {
+		files := commits[i].files[:0]
+		for _, f := range commits[i].files {
+			if t.excluded(f) {
+				hidden++
+				continue
+			}
+			files = append(files, f)
+		}
+		commits[i].files = files
+	}
+
+	var filters []string
+	if path != "" {
+		filters = append(filters, "touching "+path)
+	}
+	if query != "" {
+		filters = append(filters, fmt.Sprintf("with a message matching %q", query))
+	}
+	scope := ""
+	if len(filters) > 0 {
+		scope = " " + strings.Join(filters, " and ")
+	}
+	fmt.Fprintf(&b, "%s commits on %s are not deployed.\n", strings.TrimSpace(total), branchName)
+	if len(commits) == 0 {
+		fmt.Fprintf(&b, "No commits%s.\n", scope)
+	} else {
+		fmt.Fprintf(&b, "Commits%s, newest first:\n\n", scope)
+		for _, c := range commits {
+			fmt.Fprintf(&b, "%s %s %s: %s\n", shortRevision(c.sha), c.date, c.author, c.subject)
+			for _, f := range c.files {
+				b.WriteString("    " + f + "\n")
+			}
+		}
+		if len(commits) == maxComparedCommits {
+			fmt.Fprintf(&b, "(showing the %d newest; narrow with path or query)\n", maxComparedCommits)
+		}
+	}
+
+	if showDiff {
+		diff, err := g.git("diff", deployed, tip, "--", path)
+		if err != nil {
+			return "", err
+		}
+		var diffHidden int
+		diff, diffHidden = t.filterDiff(diff)
+		hidden += diffHidden
+		switch {
+		case diff == "":
+			fmt.Fprintf(&b, "\n%s is identical in the deployed revision and on %s.\n", path, branchName)
+		case len(diff) > maxSourceDiffChars:
+			fmt.Fprintf(&b, "\nDiff of %s (deployed → %s, truncated):\n%s\n...", path, branchName, diff[:maxSourceDiffChars])
+		default:
+			fmt.Fprintf(&b, "\nDiff of %s (deployed → %s):\n%s", path, branchName, diff)
+		}
+	}
+	if hidden > 0 {
+		fmt.Fprintf(&b, "\n(%d changed files protected by policy were hidden)\n", hidden)
+	}
+	return b.String(), nil
+}
+
+// excluded reports whether a file must not be shown
+func (t *SourceDiffTool) excluded(file string) bool {
+	return t.exclude != nil && t.exclude(file)
+}
+
+// filterDiff removes the sections of excluded files from a git diff and
+// returns how many were removed
+func (t *SourceDiffTool) filterDiff(diff string) (string, int) {
+	if t.exclude == nil || diff == "" {
+		return diff, 0
+	}
+	var b strings.Builder
+	hidden, skip := 0, false
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		if strings.HasPrefix(line, "diff --git ") {
+			// diff --git a/<old> b/<new>
+			names := strings.TrimSuffix(strings.TrimPrefix(line, "diff --git "), "\n")
+			oldName, newName, _ := strings.Cut(names, " b/")
+			skip = t.excluded(strings.TrimPrefix(oldName, "a/")) || t.excluded(newName)
+			if skip {
+				hidden++
+			}
+		}
+		if !skip {
+			b.WriteString(line)
+		}
+	}
+	return b.String(), hidden
+}
+
+// fetchCompared fetches the history of branch since the deployed revision
+// and returns the SHA of its tip. The clone is shallow, so the deployed
+// commit is fetched too if it is not the one checked out.
+func (g *GitSource) fetchCompared(deployed, branch string) (string, error) {
+	if _, err := g.git("cat-file", "-e", deployed+"^{commit}"); err != nil {
+		if _, err := g.git("fetch", "--quiet", "--depth", "1", "--no-tags", "origin", deployed); err != nil {
+			return "", fmt.Errorf("failed to fetch the deployed revision %s: %w", shortRevision(deployed), err)
+		}
+	}
+	out, err := g.git("log", "-1", "--format=%ct", deployed)
+	if err != nil {
+		return "", err
+	}
+	committed, err := strconv.ParseInt(strings.TrimSpace(out), 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the date of %s: %w", shortRevision(deployed), err)
+	}
+
+	ref := branch
+	if ref == "" {
+		ref = "HEAD"
+	}
+	// A day of margin covers clock skew between committers
+	since := time.Unix(committed, 0).Add(-24 * time.Hour).Format(time.RFC3339)
+	refspec := "+" + ref + ":" + compareRef
+	if _, err := g.git("fetch", "--quiet", "--no-tags", "--shallow-since="+since, "origin", refspec); err != nil {
+		// The branch has no commit since then; its tip is enough
+		if _, err := g.git("fetch", "--quiet", "--no-tags", "--depth", "1", "origin", refspec); err != nil {
+			return "", err
+		}
+	}
+	out, err = g.git("rev-parse", compareRef)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// comparedCommits returns the newest commits in tip but not in deployed,
+// touching path and with a message matching query if they are set
+func (g *GitSource) comparedCommits(deployed, tip, path, query string) ([]comparedCommit, error) {
+	args := []string{"log", "--name-only", "--format=%x00%H%x09%an%x09%cs%x09%s", "--max-count", strconv.Itoa(maxComparedCommits)}
+	if query != "" {
+		args = append(args, "-i", "--grep="+query)
+	}
+	args = append(args, deployed+".."+tip)
+	if path != "" {
+		args = append(args, "--", path)
+	}
+	out, err := g.git(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []comparedCommit
+	for _, record := range strings.Split(out, "\x00") {
+		lines := strings.Split(strings.TrimSpace(record), "\n")
+		fields := strings.SplitN(lines[0], "\t", 4)
+		if len(fields) < 4 {
+			continue
+		}
+		c := comparedCommit{sha: fields[0], author: fields[1], date: fields[2], subject: fields[3]}
+		for _, f := range lines[1:] {
+			if f = strings.TrimSpace(f); f != "" {
+				c.files = append(c.files, f)
+			}
+		}
+		commits = append(commits, c)
+	}
+	return commits, nil
+}
@@ -2,6 +2,10 @@ package tools
 
 import (
 	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
 
 	"github.com/willknow-ai/willknow-go/indexer"
 	"github.com/willknow-ai/willknow-go/provider"
@@ -14,24 +18,144 @@ type ToolExecutor interface {
 
 // Registry manages all available tools
 type Registry struct {
-	sourcePath    string
+	source        fs.FS
 	tools         map[string]ToolExecutor
 	logTool       *LogQueryTool
 	codeIndexTool *CodeIndexTool
+	githubTool    *GitHubTool
+	gitlabTool    *GitLabTool
+	sentryTool    *SentryTool
+	traceTool     *TraceTool
+	metricsTool   *PrometheusTool
+	schemaTool    *DatabaseSchemaTool
+	redisTool     *RedisTool
+	kafkaTool     *KafkaTool
+	knowledgeTool *KnowledgeBaseTool
+	kubeTool      *KubernetesTool
+	runbookTool   *RunbookTool
+	snippetTool   *SnippetTool
+	ownershipTool *OwnershipTool
+	diffTool      *SourceDiffTool
+	codeRefs      *codeRefResolver
 }
 
-// NewRegistry creates a new tool registry
+// NewRegistry creates a new tool registry for the source directory sourcePath
 func NewRegistry(sourcePath string) *Registry {
+	return NewRegistryFS(os.DirFS(sourcePath))
+}
+
+// NewRegistryFS creates a new tool registry whose file tools read the source
+// code from source, e.g. an embed.FS or an archive opened with OpenSource
+func NewRegistryFS(source fs.FS) *Registry {
 	return &Registry{
-		sourcePath: sourcePath,
-		tools:      make(map[string]ToolExecutor),
+		source:   source,
+		tools:    make(map[string]ToolExecutor),
+		codeRefs: newCodeRefResolver(source),
 	}
 }
 
+// ForSource returns a registry for another source tree that shares every
+// integration tool of r but has no log or code index tools yet
+func (r *Registry) ForSource(source fs.FS) *Registry {
+	clone := *r
+	clone.source = source
+	clone.tools = make(map[string]ToolExecutor)
+	clone.logTool = nil
+	clone.codeIndexTool = nil
+	clone.diffTool = nil
+	clone.codeRefs = newCodeRefResolver(source)
+	clone.ownershipTool = r.ownershipTool.forSource(source)
+	return &clone
+}
+
 // RegisterLogTool registers the log query tool with log file paths
 func (r *Registry) RegisterLogTool(logFiles []string) {
+	var sources []LogSource
+	for _, f := range logFiles {
+		sources = append(sources, NewFileLogSource(f))
+	}
+	var access map[string][]string
+	var filter *logFilter
+	if r.logTool != nil {
+		sources = append(sources, r.logTool.sources...)
+		access = r.logTool.access
+		filter = r.logTool.filter
+	}
 	r.logTool = &LogQueryTool{
-		logFiles: logFiles,
+		sources: sources,
+		access:  access,
+		filter:  filter,
+	}
+}
+
+// SetLogFilter sets the level and noise filters of read_logs. Without it,
+// entries matching DefaultLogNoisePatterns are excluded.
+func (r *Registry) SetLogFilter(config LogFilterConfig) error {
+	filter, err := newLogFilter(config)
+	if err != nil {
+		return err
+	}
+	if r.logTool == nil {
+		r.logTool = &LogQueryTool{}
+	}
+	r.logTool.filter = filter
+	return nil
+}
+
+// RestrictLogSource limits read_logs access to a log file or log source
+// (by path or Name) to users with at least one of roles
+func (r *Registry) RestrictLogSource(name string, roles []string) {
+	if r.logTool == nil {
+		r.logTool = &LogQueryTool{}
+	}
+	if r.logTool.access == nil {
+		r.logTool.access = make(map[string][]string)
+	}
+	r.logTool.access[name] = roles
+}
+
+// RegisterLogSource adds a log backend (e.g., Loki) to the log query tool
+func (r *Registry) RegisterLogSource(source LogSource) {
+	if r.logTool == nil {
+		r.logTool = &LogQueryTool{}
+	}
+	r.logTool.sources = append(r.logTool.sources, source)
+}
+
+// HasLogs reports whether the log query tool is available
+func (r *Registry) HasLogs() bool {
+	return r.logTool != nil
+}
+
+// LogSourceNames returns the names of the log files and sources read_logs
+// searches (file paths, "loki <selector>", ...)
+func (r *Registry) LogSourceNames() []string {
+	if r.logTool == nil {
+		return nil
+	}
+	var names []string
+	for _, source := range r.logTool.sources {
+		names = append(names, source.Name())
+	}
+	return names
+}
+
+// ExportLogs writes every log entry matching query that a user with the
+// given roles may read, up to maxBytes. See LogQueryTool.Export.
+func (r *Registry) ExportLogs(w io.Writer, query LogQuery, roles []string, maxBytes int) (int, bool, error) {
+	if r.logTool == nil {
+		return 0, false, fmt.Errorf("log tool not configured")
+	}
+	return r.logTool.Export(w, query, roles, maxBytes)
+}
+
+// ExcludeFiles keeps the files for which exclude returns true, e.g. files
+// denied by policy, out of the source snippets added to read_logs results
+// and the file lists and diffs of compare_deployed_source
+func (r *Registry) ExcludeFiles(exclude func(path string) bool) {
+	r.codeRefs.exclude = exclude
+	if r.diffTool != nil {
+		r.diffTool.exclude = exclude
 	}
 }
 
@@ -42,28 +166,250 @@ func (r *Registry) RegisterCodeIndexTool(codeIndex *indexer.CodeIndex) {
 	}
 }
 
-// Execute executes a tool by name
+// RegisterGitHubTools registers the GitHub issue and pull request tools
+func (r *Registry) RegisterGitHubTools(config GitHubConfig) {
+	r.githubTool = newGitHubTool(config)
+}
+
+// RegisterGitLabTools registers the GitLab issue and merge request tools
+func (r *Registry) RegisterGitLabTools(config GitLabConfig) {
+	r.gitlabTool = newGitLabTool(config)
+}
+
+// RegisterSentryTool registers the Sentry error context tool
+func (r *Registry) RegisterSentryTool(config SentryConfig) error {
+	tool, err := newSentryTool(config)
+	if err != nil {
+		return err
+	}
+	r.sentryTool = tool
+	return nil
+}
+
+// RegisterTraceTool registers the distributed trace tool
+func (r *Registry) RegisterTraceTool(config TracingConfig) error {
+	tool, err := newTraceTool(config)
+	if err != nil {
+		return err
+	}
+	r.traceTool = tool
+	return nil
+}
+
+// RegisterPrometheusTool registers the PromQL metrics tool
+func (r *Registry) RegisterPrometheusTool(config PrometheusConfig) {
+	r.metricsTool = newPrometheusTool(config)
+}
+
+// RegisterDatabaseTool registers the database schema introspection tool
+func (r *Registry) RegisterDatabaseTool(config DatabaseConfig) error {
+	tool, err := newDatabaseSchemaTool(config)
+	if err != nil {
+		return err
+	}
+	r.schemaTool = tool
+	return nil
+}
+
+// RegisterRedisTool registers the read-only Redis inspection tool
+func (r *Registry) RegisterRedisTool(config RedisConfig) {
+	r.redisTool = newRedisTool(config)
+}
+
+// RegisterKafkaTool registers the Kafka topic and consumer group inspection tool
+func (r *Registry) RegisterKafkaTool(config KafkaConfig) error {
+	tool, err := newKafkaTool(config)
+	if err != nil {
+		return err
+	}
+	r.kafkaTool = tool
+	return nil
+}
+
+// RegisterKnowledgeBaseTools registers the root-cause knowledge base tools
+func (r *Registry) RegisterKnowledgeBaseTools(config KnowledgeBaseConfig) error {
+	tool, err := newKnowledgeBaseTool(config)
+	if err != nil {
+		return err
+	}
+	r.knowledgeTool = tool
+	return nil
+}
+
+// RegisterKubernetesTool registers the read-only Kubernetes inspection tool
+func (r *Registry) RegisterKubernetesTool(config KubernetesConfig) error {
+	tool, err := newKubernetesTool(config)
+	if err != nil {
+		return err
+	}
+	r.kubeTool = tool
+	return nil
+}
+
+// RegisterRunbookTool registers the runbook search tool
+func (r *Registry) RegisterRunbookTool(config RunbooksConfig) error {
+	tool, err := newRunbookTool(config)
+	if err != nil {
+		return err
+	}
+	r.runbookTool = tool
+	return nil
+}
+
+// RegisterSnippetTool registers the run_snippet Go execution tool
+func (r *Registry) RegisterSnippetTool(config SnippetConfig) error {
+	tool, err := newSnippetTool(config)
+	if err != nil {
+		return err
+	}
+	r.snippetTool = tool
+	return nil
+}
+
+// RegisterOwnershipTool registers the find_owners tool and annotates the
+// files read with read_file with their owners
+func (r *Registry) RegisterOwnershipTool(config OwnershipConfig) {
+	r.ownershipTool = newOwnershipTool(config, r.source)
+}
+
+// RegisterSourceDiffTool registers the compare_deployed_source tool, which
+// compares deployed, the revision of the running build, with the main
+// branch of the git source ("" compares the checked-out revision)
+func (r *Registry) RegisterSourceDiffTool(source *GitSource, deployed string) {
+	r.diffTool = &SourceDiffTool{source: source, deployed: deployed, exclude: r.codeRefs.exclude}
+}
+
+// Owners returns the owners of a source file; ok is false unless ownership
+// is enabled
+func (r *Registry) Owners(file string) (ownership Ownership, ok bool) {
+	if r.ownershipTool == nil {
+		return Ownership{}, false
+	}
+	return r.ownershipTool.Owners(file), true
+}
+
+// Execute executes a tool by name without any user roles
 func (r *Registry) Execute(name string, params map[string]interface{}) (string, error) {
+	return r.ExecuteAs(name, params, nil)
+}
+
+// ExecuteAs executes a tool by name on behalf of a user with the given roles,
+// which decide the log sources read_logs may search
+func (r *Registry) ExecuteAs(name string, params map[string]interface{}, roles []string) (string, error) {
 	switch name {
 	case "read_file":
-		tool := &ReadFileTool{sourcePath: r.sourcePath}
-		return tool.Execute(params)
+		tool := &ReadFileTool{source: r.source}
+		result, err := tool.Execute(params)
+		if err == nil && r.ownershipTool != nil {
+			file, _ := params["file_path"].(string)
+			result += "\nOwned by: " + r.ownershipTool.Owners(file).String() + "\n"
+		}
+		return result, err
 	case "grep":
-		tool := &GrepTool{sourcePath: r.sourcePath}
+		tool := &GrepTool{source: r.source}
 		return tool.Execute(params)
 	case "glob":
-		tool := &GlobTool{sourcePath: r.sourcePath}
+		tool := &GlobTool{source: r.source}
 		return tool.Execute(params)
 	case "read_logs":
 		if r.logTool == nil {
 			return "", fmt.Errorf("log tool not configured")
 		}
-		return r.logTool.Execute(params)
+		result, err := r.logTool.ExecuteAs(params, roles)
+		if annotate, ok := params["annotate_code"].(bool); err == nil && (annotate || !ok) {
+			result = r.codeRefs.annotate(result)
+		}
+		return result, err
 	case "search_code_index":
 		if r.codeIndexTool == nil {
 			return "", fmt.Errorf("code index not available")
 		}
 		return r.codeIndexTool.Execute(params)
+	case "create_github_issue":
+		if r.githubTool == nil {
+			return "", fmt.Errorf("GitHub integration not configured")
+		}
+		return r.githubTool.CreateIssue(params)
+	case "create_github_pull_request":
+		if r.githubTool == nil {
+			return "", fmt.Errorf("GitHub integration not configured")
+		}
+		return r.githubTool.CreateDraftPullRequest(params)
+	case "create_gitlab_issue":
+		if r.gitlabTool == nil {
+			return "", fmt.Errorf("GitLab integration not configured")
+		}
+		return r.gitlabTool.CreateIssue(params)
+	case "comment_gitlab_merge_request":
+		if r.gitlabTool == nil {
+			return "", fmt.Errorf("GitLab integration not configured")
+		}
+		return r.gitlabTool.CommentOnMergeRequest(params)
+	case "get_sentry_issue":
+		if r.sentryTool == nil {
+			return "", fmt.Errorf("Sentry integration not configured")
+		}
+		return r.sentryTool.Execute(params)
+	case "get_trace":
+		if r.traceTool == nil {
+			return "", fmt.Errorf("tracing backend not configured")
+		}
+		return r.traceTool.Execute(params)
+	case "query_metrics":
+		if r.metricsTool == nil {
+			return "", fmt.Errorf("Prometheus not configured")
+		}
+		return r.metricsTool.Execute(params)
+	case "describe_schema":
+		if r.schemaTool == nil {
+			return "", fmt.Errorf("database not configured")
+		}
+		return r.schemaTool.Execute(params)
+	case "inspect_redis":
+		if r.redisTool == nil {
+			return "", fmt.Errorf("Redis not configured")
+		}
+		return r.redisTool.Execute(params)
+	case "inspect_kafka":
+		if r.kafkaTool == nil {
+			return "", fmt.Errorf("Kafka not configured")
+		}
+		return r.kafkaTool.Execute(params)
+	case "record_root_cause":
+		if r.knowledgeTool == nil {
+			return "", fmt.Errorf("knowledge base not configured")
+		}
+		return r.knowledgeTool.Record(params)
+	case "search_knowledge_base":
+		if r.knowledgeTool == nil {
+			return "", fmt.Errorf("knowledge base not configured")
+		}
+		return r.knowledgeTool.Search(params)
+	case "inspect_kubernetes":
+		if r.kubeTool == nil {
+			return "", fmt.Errorf("Kubernetes not configured")
+		}
+		return r.kubeTool.Execute(params)
+	case "search_runbooks":
+		if r.runbookTool == nil {
+			return "", fmt.Errorf("runbooks not configured")
+		}
+		return r.runbookTool.Execute(params)
+	case "run_snippet":
+		if r.snippetTool == nil {
+			return "", fmt.Errorf("snippet execution not enabled")
+		}
+		return r.snippetTool.Execute(params)
+	case "find_owners":
+		if r.ownershipTool == nil {
+			return "", fmt.Errorf("ownership not configured")
+		}
+		return r.ownershipTool.Execute(params)
+	case "compare_deployed_source":
+		if r.diffTool == nil {
+			return "", fmt.Errorf("git source not configured")
+		}
+		return r.diffTool.Execute(params)
 	default:
 		return "", fmt.Errorf("unknown tool: %s", name)
 	}
@@ -148,6 +494,35 @@ func (r *Registry) GetToolDefinitions() []provider.Tool {
 						"type":        "integer",
 						"description": "Optional: Number of context lines to show before and after each match (default: 5)",
 					},
+					"start_time": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional: Only return entries at or after this time. RFC3339 (e.g., '2024-01-02T15:04:05Z') or relative duration ago (e.g., '30m', '2h')",
+					},
+					"end_time": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional: Only return entries at or before this time. RFC3339 or relative duration ago",
+					},
+					"min_level": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"trace", "debug", "info", "warn", "error", "fatal"},
+						"description": "Optional: Only return entries at this level or above (e.g., 'warn' to skip INFO and DEBUG lines). Entries without a level are always returned.",
+					},
+					"correlate": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Optional: Merge the matching entries of all log sources (e.g. app, access and worker logs) into one time-ordered timeline, with the time since the first entry. Use it with a request or trace ID to follow a request across services.",
+					},
+					"annotate_code": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Optional: Show the source lines around file:line references and stack frames below the entries containing them (default: true). Set to false when the code is not needed.",
+					},
+					"include_noise": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Optional: Also return health checks, readiness probes and other noisy entries, which are excluded by default",
+					},
+					"filter": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional: Backend-native filter for remote log sources. Loki: a LogQL stream selector, optionally with pipeline stages (e.g., '{app=\"api\", level=\"error\"}'). Elasticsearch: a Lucene query string (e.g., 'level:error AND service:checkout'). Cloud Logging: a filter expression (e.g., 'severity>=ERROR AND resource.labels.service_name=\"api\"')",
+					},
 				},
 				"required": []string{"query"},
 			},
@@ -176,5 +551,451 @@ func (r *Registry) GetToolDefinitions() []provider.Tool {
 		})
 	}
 
+	// Add GitHub tools if configured
+	if r.githubTool != nil {
+		tools = append(tools, provider.Tool{
+			Name:        "create_github_issue",
+			Description: "Open a GitHub issue from a diagnosis. Only use when the user asks to file an issue. The body should be a structured markdown report with sections: Summary, Root Cause, Evidence (log lines, request IDs), Affected Files (with line numbers) and Suggested Fix.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"title": map[string]interface{}{
+						"type":        "string",
+						"description": "Concise issue title describing the problem",
+					},
+					"body": map[string]interface{}{
+						"type":        "string",
+						"description": "Markdown diagnosis report",
+					},
+					"labels": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Optional: Labels to apply (e.g., ['bug'])",
+					},
+				},
+				"required": []string{"title", "body"},
+			},
+		})
+		tools = append(tools, provider.Tool{
+			Name:        "create_github_pull_request",
+			Description: "Commit suggested changes to a new branch and open a draft GitHub pull request. Only use when the user asks for a PR. Provide the complete new content of every changed file (read the current file first).",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"title": map[string]interface{}{
+						"type":        "string",
+						"description": "Pull request title",
+					},
+					"body": map[string]interface{}{
+						"type":        "string",
+						"description": "Markdown description: the diagnosis and what the change fixes",
+					},
+					"branch": map[string]interface{}{
+						"type":        "string",
+						"description": "Name of the new branch to create (e.g., 'willknow/fix-nil-user')",
+					},
+					"files": map[string]interface{}{
+						"type": "array",
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"path": map[string]interface{}{
+									"type":        "string",
+									"description": "File path relative to the repository root",
+								},
+								"content": map[string]interface{}{
+									"type":        "string",
+									"description": "Complete new file content",
+								},
+							},
+							"required": []string{"path", "content"},
+						},
+						"description": "Files to create or replace on the branch",
+					},
+				},
+				"required": []string{"title", "branch", "files"},
+			},
+		})
+	}
+
+	// Add GitLab tools if configured
+	if r.gitlabTool != nil {
+		tools = append(tools, provider.Tool{
+			Name:        "create_gitlab_issue",
+			Description: "Open a GitLab issue from a diagnosis. Only use when the user asks to file an issue. The description should be a structured markdown report with sections: Summary, Root Cause, Evidence (log lines, request IDs), Affected Files (with line numbers) and Suggested Fix.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"title": map[string]interface{}{
+						"type":        "string",
+						"description": "Concise issue title describing the problem",
+					},
+					"description": map[string]interface{}{
+						"type":        "string",
+						"description": "Markdown diagnosis report",
+					},
+					"labels": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Optional: Labels to apply (e.g., ['bug'])",
+					},
+				},
+				"required": []string{"title", "description"},
+			},
+		})
+		tools = append(tools, provider.Tool{
+			Name:        "comment_gitlab_merge_request",
+			Description: "Post the analysis as a comment on the GitLab merge request that introduced the problem. Identify the merge request by IID, or by the offending commit SHA (the merge request containing it is looked up automatically).",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"body": map[string]interface{}{
+						"type":        "string",
+						"description": "Markdown analysis to post",
+					},
+					"merge_request_iid": map[string]interface{}{
+						"type":        "integer",
+						"description": "Optional: The merge request IID (the !number)",
+					},
+					"commit_sha": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional: SHA of the offending commit, used when the merge request IID is unknown",
+					},
+				},
+				"required": []string{"body"},
+			},
+		})
+	}
+
+	// Add Sentry tool if configured
+	if r.sentryTool != nil {
+		tools = append(tools, provider.Tool{
+			Name:        "get_sentry_issue",
+			Description: "Fetch a Sentry issue by ID: its latest event with exception and stack trace, breadcrumbs, tags, request and release info. Use when the user references a Sentry issue, then correlate the in-app frames with read_file and the event time with read_logs.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"issue_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The Sentry issue ID (numeric, e.g. '4501234567') or short ID (e.g. 'BACKEND-1A2')",
+					},
+				},
+				"required": []string{"issue_id"},
+			},
+		})
+	}
+
+	// Add trace tool if configured
+	if r.traceTool != nil {
+		tools = append(tools, provider.Tool{
+			Name:        "get_trace",
+			Description: "Fetch a distributed trace by trace ID and show its span tree: services, operation names, timing offsets, durations and errors. Use it to follow a request across services when logs contain a trace ID.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"trace_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The trace ID (hex, e.g. '4bf92f3577b34da6a3ce929d0e0e4736')",
+					},
+				},
+				"required": []string{"trace_id"},
+			},
+		})
+	}
+
+	// Add metrics tool if configured
+	if r.metricsTool != nil {
+		tools = append(tools, provider.Tool{
+			Name:        "query_metrics",
+			Description: "Run a PromQL query against Prometheus. Without start_time it is an instant query returning current values; with start_time it is a range query summarizing each series (min/max/avg/last and sampled points). Use it to check error rates, latency, saturation and resource usage around the time of an incident.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query": map[string]interface{}{
+						"type":        "string",
+						"description": "PromQL expression (e.g., 'sum(rate(http_requests_total{status=~\"5..\"}[5m])) by (route)')",
+					},
+					"start_time": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional: range start, RFC3339 or relative duration (e.g., '1h' for one hour ago). Makes this a range query.",
+					},
+					"end_time": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional: range end (default now), or the evaluation time of an instant query. RFC3339 or relative duration.",
+					},
+					"step": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional: range query resolution as a Go duration (e.g., '30s', '5m'). Default: about 60 points over the range.",
+					},
+				},
+				"required": []string{"query"},
+			},
+		})
+	}
+
+	// Add database schema tool if configured
+	if r.schemaTool != nil {
+		tools = append(tools, provider.Tool{
+			Name:        "describe_schema",
+			Description: fmt.Sprintf("Describe the application's %s database schema. Without a table, lists all tables. With a table, shows its columns (types, nullability, defaults), indexes and foreign keys (both referencing and referenced). Use it when diagnosing slow or failing queries, constraint violations and migrations.", r.schemaTool.dialect),
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"table": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional: table name to describe (schema-qualified for Postgres if ambiguous, e.g. 'billing.invoices')",
+					},
+				},
+			},
+		})
+	}
+
+	// Add Redis tool if configured
+	if r.redisTool != nil {
+		getNote := "GET is disabled."
+		if len(r.redisTool.config.GetPatterns) > 0 {
+			getNote = fmt.Sprintf("GET is allowed only for keys matching: %s.", strings.Join(r.redisTool.config.GetPatterns, ", "))
+		}
+		tools = append(tools, provider.Tool{
+			Name:        "inspect_redis",
+			Description: "Inspect the application's Redis (read-only) to investigate cache bugs such as stale or missing values. Commands: info (server stats, memory, keyspace, evictions), type and ttl (of a key), scan (list keys matching a pattern, capped), get (string value of a key). " + getNote,
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"command": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"info", "type", "ttl", "scan", "get"},
+						"description": "The inspection command to run",
+					},
+					"key": map[string]interface{}{
+						"type":        "string",
+						"description": "The key (required for type, ttl and get)",
+					},
+					"pattern": map[string]interface{}{
+						"type":        "string",
+						"description": "Glob pattern for scan (e.g., 'session:*'). Default: '*'",
+					},
+					"section": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional: INFO section (e.g., 'memory', 'stats', 'keyspace')",
+					},
+				},
+				"required": []string{"command"},
+			},
+		})
+	}
+
+	// Add Kafka tool if configured
+	if r.kafkaTool != nil {
+		tools = append(tools, provider.Tool{
+			Name:        "inspect_kafka",
+			Description: "Inspect the application's Kafka cluster (read-only). Commands: topics (list topics), groups (list consumer groups and states), lag (committed vs. end offsets per partition for a consumer group, or total lag of all groups), messages (the latest messages of a topic, size-capped). Use it when events seem delayed, lost or malformed, e.g. to check whether a consumer is behind.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"command": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"topics", "groups", "lag", "messages"},
+						"description": "The inspection command to run",
+					},
+					"topic": map[string]interface{}{
+						"type":        "string",
+						"description": "Topic name (required for messages; optional filter for lag)",
+					},
+					"group": map[string]interface{}{
+						"type":        "string",
+						"description": "Consumer group for lag. Default: totals for all groups",
+					},
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": fmt.Sprintf("Number of latest messages to return (default: %d, max: %d)", defaultKafkaMessages, maxKafkaMessages),
+					},
+				},
+				"required": []string{"command"},
+			},
+		})
+	}
+
+	// Add knowledge base tools if configured
+	if r.knowledgeTool != nil {
+		tools = append(tools, provider.Tool{
+			Name:        "search_knowledge_base",
+			Description: "Search the team's knowledge base of past confirmed root-cause reports. Use it early in an investigation, with the error message, component or symptom, to check whether a similar incident was diagnosed before and how it was fixed.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query": map[string]interface{}{
+						"type":        "string",
+						"description": "Keywords: error message, exception type, endpoint, component or file name",
+					},
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": fmt.Sprintf("Optional: maximum number of reports per store (default: %d)", defaultKnowledgeResults),
+					},
+				},
+				"required": []string{"query"},
+			},
+		})
+		tools = append(tools, provider.Tool{
+			Name:        "record_root_cause",
+			Description: "Save a root-cause report to the team's knowledge base so future investigations can learn from it. Only call this after the user has explicitly confirmed that the diagnosis is correct; ask them first.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"title": map[string]interface{}{
+						"type":        "string",
+						"description": "Short description of the incident, including the key error (e.g., 'Checkout 500s: nil pointer in PriceCalculator when coupon expired')",
+					},
+					"summary": map[string]interface{}{
+						"type":        "string",
+						"description": "What happened: symptoms, affected requests or users, time range",
+					},
+					"root_cause": map[string]interface{}{
+						"type":        "string",
+						"description": "The confirmed root cause with evidence (log lines, request IDs)",
+					},
+					"fix": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional: the fix or workaround applied or suggested",
+					},
+					"files": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Optional: relevant files with line numbers (e.g., 'billing/price.go:42')",
+					},
+					"tags": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Optional: tags such as component or error category (e.g., ['checkout', 'nil-pointer'])",
+					},
+				},
+				"required": []string{"title", "root_cause"},
+			},
+		})
+	}
+
+	// Add Kubernetes tool if configured
+	if r.kubeTool != nil {
+		tools = append(tools, provider.Tool{
+			Name:        "inspect_kubernetes",
+			Description: fmt.Sprintf("Read-only Kubernetes inspection of the application's namespace (%s), like kubectl get/describe. Many apparent application errors are really OOM kills, crash loops, failed probes, evictions or scheduling problems: check pods and events when errors coincide with restarts, timeouts or missing logs. Commands: pods (status, restarts, last termination reason such as OOMKilled), describe_pod (containers, states, requests/limits, conditions, events), events (recent namespace events, newest last), resources (quotas, limit ranges, container requests/limits and current usage).", r.kubeTool.namespace),
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"command": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"pods", "describe_pod", "events", "resources"},
+						"description": "The inspection command to run",
+					},
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Pod name (required for describe_pod; optional object name filter for events)",
+					},
+					"label_selector": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional: label selector for pods and resources (e.g., 'app=checkout')",
+					},
+					"warnings_only": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Optional: only Warning events",
+					},
+				},
+				"required": []string{"command"},
+			},
+		})
+	}
+
+	// Add runbook search tool if configured
+	if r.runbookTool != nil {
+		tools = append(tools, provider.Tool{
+			Name:        "search_runbooks",
+			Description: "Search the team's runbooks: documented procedures for known failure modes, alerts and operational tasks. Use it as soon as the error or symptom is known; when a runbook covers the problem, follow its procedure and cite it instead of improvising.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query": map[string]interface{}{
+						"type":        "string",
+						"description": "Keywords: error message, alert name, symptom, component or dependency",
+					},
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": fmt.Sprintf("Optional: maximum number of sections to return (default: %d)", defaultRunbookResults),
+					},
+				},
+				"required": []string{"query"},
+			},
+		})
+	}
+
+	// Add snippet execution tool if enabled
+	if r.snippetTool != nil {
+		tools = append(tools, provider.Tool{
+			Name:        "run_snippet",
+			Description: fmt.Sprintf("Compile and run a short Go program to verify the behavior of a suspect function with concrete inputs (e.g., the parsing of a value from the logs). Copy the function from the source code into the snippet; only the standard library is available. Runs in a sandbox limited to %s and %d MB. Returns the exit status, stdout and stderr.", r.snippetTool.config.Timeout, r.snippetTool.config.MemoryLimitMB),
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"code": map[string]interface{}{
+						"type":        "string",
+						"description": "Go code: either a complete program (package main with imports and func main) or statements to run as the body of main",
+					},
+					"imports": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Optional: Standard library packages to import when code is only statements (e.g., [\"fmt\", \"strconv\"])",
+					},
+				},
+				"required": []string{"code"},
+			},
+		})
+	}
+
+	// Add ownership tool if enabled
+	if r.ownershipTool != nil {
+		tools = append(tools, provider.Tool{
+			Name:        "find_owners",
+			Description: "Find the owners of source files: the teams or people CODEOWNERS assigns them to, or else their recent committers. Use it for the files your diagnosis points to, so the answer can say who owns the faulty code (files read with read_file already show their owners).",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"file_paths": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Paths of the files, relative to the source directory",
+					},
+				},
+				"required": []string{"file_paths"},
+			},
+		})
+	}
+
+	// Add source diff tool if the source comes from git
+	if r.diffTool != nil {
+		tools = append(tools, provider.Tool{
+			Name:        "compare_deployed_source",
+			Description: "Compare the deployed revision of the source with the latest main branch: lists the commits on main that are not deployed yet, with the files they change, and optionally the diff of a file. Use it once you have located a bug to check whether it is already fixed on main but not yet deployed, and name the fixing commit in your answer.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional: Only list commits changing this file or directory, relative to the source directory",
+					},
+					"query": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional: Only list commits whose message matches this regex, case-insensitively (e.g., 'nil user|null pointer')",
+					},
+					"show_diff": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Optional: Also show the diff of path between the deployed revision and main",
+					},
+					"branch": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional: Compare with this branch instead of main (e.g., 'release/2.4')",
+					},
+				},
+			},
+		})
+	}
 	return tools
 }
@@ -0,0 +1,420 @@
+package tools
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxTraceSpans limits the number of spans rendered for a single trace
+const maxTraceSpans = 200
+
+// TracingConfig configures the distributed trace tool
+type TracingConfig struct {
+	// Backend is the trace store type: "jaeger" or "tempo".
+	// Default: "jaeger"
+	Backend string
+
+	// URL is the query API base URL (e.g., "http://jaeger-query:16686" or "http://tempo:3200").
+	// When empty, the trace tool is disabled.
+	URL string
+
+	// TenantID is sent as X-Scope-OrgID for multi-tenant Tempo deployments
+	TenantID string
+
+	// Username and Password enable basic auth
+	Username string
+	Password string
+
+	// BearerToken enables bearer token auth
+	BearerToken string
+}
+
+// TraceTool fetches a distributed trace by ID and renders its span tree
+type TraceTool struct {
+	config TracingConfig
+	client *http.Client
+}
+
+// traceSpan is a backend-independent span
+type traceSpan struct {
+	id       string
+	parentID string
+	service  string
+	name     string
+	start    time.Time
+	duration time.Duration
+	isError  bool
+	errMsg   string
+	attrs    map[string]string
+}
+
+// newTraceTool creates a trace tool with defaults applied
+func newTraceTool(config TracingConfig) (*TraceTool, error) {
+	if config.Backend == "" {
+		config.Backend = "jaeger"
+	}
+	if config.Backend != "jaeger" && config.Backend != "tempo" {
+		return nil, fmt.Errorf("unsupported tracing backend: %s", config.Backend)
+	}
+	config.URL = strings.TrimRight(config.URL, "/")
+	return &TraceTool{
+		config: config,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// Execute fetches and renders a trace
+func (t *TraceTool) Execute(params map[string]interface{}) (string, error) {
+	traceID, ok := params["trace_id"].(string)
+	if !ok || traceID == "" {
+		return "", fmt.Errorf("trace_id parameter is required")
+	}
+	traceID = strings.ToLower(strings.TrimSpace(traceID))
+
+	body, err := t.fetch("/api/traces/" + url.PathEscape(traceID))
+	if err != nil {
+		return "", err
+	}
+
+	var spans []traceSpan
+	if t.config.Backend == "tempo" {
+		spans, err = parseOTLPTrace(body)
+	} else {
+		spans, err = parseJaegerTrace(body)
+	}
+	if err != nil {
+		return "", err
+	}
+	if len(spans) == 0 {
+		return fmt.Sprintf("Trace %s not found or has no spans", traceID), nil
+	}
+
+	return renderTrace(traceID, spans), nil
+}
+
+// fetch performs an authenticated GET against the trace backend
+func (t *TraceTool) fetch(path string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, t.config.URL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if t.config.TenantID != "" {
+		req.Header.Set("X-Scope-OrgID", t.config.TenantID)
+	}
+	if t.config.Username != "" {
+		req.SetBasicAuth(t.config.Username, t.config.Password)
+	} else if t.config.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+t.config.BearerToken)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("trace query failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("trace not found")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned status %d: %s", t.config.Backend, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+// parseJaegerTrace converts a Jaeger query API response into spans
+func parseJaegerTrace(body []byte) ([]traceSpan, error) {
+	var resp struct {
+		Data []struct {
+			Spans []struct {
+				SpanID        string `json:"spanID"`
+				OperationName string `json:"operationName"`
+				References    []struct {
+					RefType string `json:"refType"`
+					SpanID  string `json:"spanID"`
+				} `json:"references"`
+				StartTime int64  `json:"startTime"` // microseconds
+				Duration  int64  `json:"duration"`  // microseconds
+				ProcessID string `json:"processID"`
+				Tags      []struct {
+					Key   string      `json:"key"`
+					Value interface{} `json:"value"`
+				} `json:"tags"`
+			} `json:"spans"`
+			Processes map[string]struct {
+				ServiceName string `json:"serviceName"`
+			} `json:"processes"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("invalid Jaeger response: %w", err)
+	}
+
+	var spans []traceSpan
+	for _, trace := range resp.Data {
+		for _, s := range trace.Spans {
+			span := traceSpan{
+				id:       s.SpanID,
+				service:  trace.Processes[s.ProcessID].ServiceName,
+				name:     s.OperationName,
+				start:    time.UnixMicro(s.StartTime),
+				duration: time.Duration(s.Duration) * time.Microsecond,
+				attrs:    make(map[string]string),
+			}
+			for _, ref := range s.References {
+				if ref.RefType == "CHILD_OF" || span.parentID == "" {
+					span.parentID = ref.SpanID
+				}
+			}
+			for _, tag := range s.Tags {
+				value := fmt.Sprintf("%v", tag.Value)
+				switch tag.Key {
+				case "error":
+					span.isError = value == "true"
+				case "otel.status_code":
+					span.isError = span.isError || value == "ERROR"
+				case "otel.status_description", "error.message":
+					span.errMsg = value
+				default:
+					span.attrs[tag.Key] = value
+				}
+			}
+			spans = append(spans, span)
+		}
+	}
+	return spans, nil
+}
+
+// otlpAttribute is an OTLP key/value attribute
+type otlpAttribute struct {
+	Key   string `json:"key"`
+	Value struct {
+		StringValue *string     `json:"stringValue"`
+		IntValue    interface{} `json:"intValue"`
+		BoolValue   *bool       `json:"boolValue"`
+		DoubleValue *float64    `json:"doubleValue"`
+	} `json:"value"`
+}
+
+// String renders an OTLP attribute value
+func (a otlpAttribute) String() string {
+	switch {
+	case a.Value.StringValue != nil:
+		return *a.Value.StringValue
+	case a.Value.IntValue != nil:
+		return fmt.Sprintf("%v", a.Value.IntValue)
+	case a.Value.BoolValue != nil:
+		return strconv.FormatBool(*a.Value.BoolValue)
+	case a.Value.DoubleValue != nil:
+		return strconv.FormatFloat(*a.Value.DoubleValue, 'f', -1, 64)
+	}
+	return ""
+}
+
+// otlpSpans is a list of OTLP spans sharing an instrumentation scope
+type otlpSpans struct {
+	Spans []struct {
+		SpanID            string          `json:"spanId"`
+		ParentSpanID      string          `json:"parentSpanId"`
+		Name              string          `json:"name"`
+		StartTimeUnixNano string          `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+		Attributes        []otlpAttribute `json:"attributes"`
+		Status            struct {
+			Code    interface{} `json:"code"` // 2 or "STATUS_CODE_ERROR"
+			Message string      `json:"message"`
+		} `json:"status"`
+	} `json:"spans"`
+}
+
+// parseOTLPTrace converts a Tempo (OTLP JSON) trace response into spans
+func parseOTLPTrace(body []byte) ([]traceSpan, error) {
+	type resourceSpans struct {
+		Resource struct {
+			Attributes []otlpAttribute `json:"attributes"`
+		} `json:"resource"`
+		ScopeSpans                  []otlpSpans `json:"scopeSpans"`
+		InstrumentationLibrarySpans []otlpSpans `json:"instrumentationLibrarySpans"`
+	}
+	var resp struct {
+		Batches       []resourceSpans `json:"batches"`
+		ResourceSpans []resourceSpans `json:"resourceSpans"`
+		Trace         *struct {
+			ResourceSpans []resourceSpans `json:"resourceSpans"`
+		} `json:"trace"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("invalid Tempo response: %w", err)
+	}
+
+	batches := append(resp.Batches, resp.ResourceSpans...)
+	if resp.Trace != nil {
+		batches = append(batches, resp.Trace.ResourceSpans...)
+	}
+
+	var spans []traceSpan
+	for _, batch := range batches {
+		service := ""
+		for _, attr := range batch.Resource.Attributes {
+			if attr.Key == "service.name" {
+				service = attr.String()
+			}
+		}
+		scopes := append(batch.ScopeSpans, batch.InstrumentationLibrarySpans...)
+		for _, scope := range scopes {
+			for _, s := range scope.Spans {
+				startNs, _ := strconv.ParseInt(s.StartTimeUnixNano, 10, 64)
+				endNs, _ := strconv.ParseInt(s.EndTimeUnixNano, 10, 64)
+				span := traceSpan{
+					id:       normalizeSpanID(s.SpanID),
+					parentID: normalizeSpanID(s.ParentSpanID),
+					service:  service,
+					name:     s.Name,
+					start:    time.Unix(0, startNs),
+					duration: time.Duration(endNs - startNs),
+					errMsg:   s.Status.Message,
+					attrs:    make(map[string]string),
+				}
+				code := fmt.Sprintf("%v", s.Status.Code)
+				span.isError = code == "2" || code == "STATUS_CODE_ERROR"
+				for _, attr := range s.Attributes {
+					span.attrs[attr.Key] = attr.String()
+				}
+				spans = append(spans, span)
+			}
+		}
+	}
+	return spans, nil
+}
+
+// normalizeSpanID converts base64 OTLP span IDs to hex (hex IDs are returned unchanged)
+func normalizeSpanID(id string) string {
+	if id == "" {
+		return ""
+	}
+	if _, err := hex.DecodeString(id); err == nil && len(id) == 16 {
+		return id
+	}
+	if raw, err := base64.StdEncoding.DecodeString(id); err == nil {
+		return hex.EncodeToString(raw)
+	}
+	return id
+}
+
+// traceAttrKeys are the span attributes worth showing to the AI
+var traceAttrKeys = []string{
+	"http.method", "http.route", "http.target", "http.url", "http.status_code",
+	"http.request.method", "http.response.status_code", "url.path",
+	"db.system", "db.statement", "rpc.method", "messaging.destination", "exception.message",
+}
+
+// renderTrace renders spans as an indented tree ordered by start time
+func renderTrace(traceID string, spans []traceSpan) string {
+	byID := make(map[string]bool)
+	children := make(map[string][]int)
+	traceStart := spans[0].start
+	traceEnd := spans[0].start.Add(spans[0].duration)
+	services := make(map[string]bool)
+	errorCount := 0
+
+	for _, s := range spans {
+		byID[s.id] = true
+		if s.start.Before(traceStart) {
+			traceStart = s.start
+		}
+		if end := s.start.Add(s.duration); end.After(traceEnd) {
+			traceEnd = end
+		}
+		services[s.service] = true
+		if s.isError {
+			errorCount++
+		}
+	}
+
+	var roots []int
+	for i, s := range spans {
+		if s.parentID == "" || !byID[s.parentID] {
+			roots = append(roots, i)
+		} else {
+			children[s.parentID] = append(children[s.parentID], i)
+		}
+	}
+	byStart := func(idx []int) {
+		sort.Slice(idx, func(a, b int) bool { return spans[idx[a]].start.Before(spans[idx[b]].start) })
+	}
+	byStart(roots)
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "Trace %s: %d spans across %d services, duration %s, %d error span(s)\n",
+		traceID, len(spans), len(services), traceEnd.Sub(traceStart), errorCount)
+	fmt.Fprintf(&out, "Start: %s\n", traceStart.UTC().Format(time.RFC3339Nano))
+	out.WriteString(strings.Repeat("-", 80) + "\n")
+	out.WriteString("offset     duration   span\n")
+
+	rendered := 0
+	var walk func(i, depth int)
+	walk = func(i, depth int) {
+		if rendered >= maxTraceSpans {
+			return
+		}
+		rendered++
+		s := spans[i]
+		marker := ""
+		if s.isError {
+			marker = " ❌ ERROR"
+			if s.errMsg != "" {
+				marker += ": " + s.errMsg
+			}
+		}
+		fmt.Fprintf(&out, "+%-9s %-10s %s%s: %s%s\n",
+			formatSpanDuration(s.start.Sub(traceStart)),
+			formatSpanDuration(s.duration),
+			strings.Repeat("  ", depth), s.service, s.name, marker)
+
+		var attrs []string
+		for _, key := range traceAttrKeys {
+			if v, ok := s.attrs[key]; ok && v != "" {
+				attrs = append(attrs, key+"="+v)
+			}
+		}
+		if len(attrs) > 0 {
+			fmt.Fprintf(&out, "%22s%s  %s\n", "", strings.Repeat("  ", depth), strings.Join(attrs, " "))
+		}
+
+		kids := children[s.id]
+		byStart(kids)
+		for _, k := range kids {
+			walk(k, depth+1)
+		}
+	}
+	for _, r := range roots {
+		walk(r, 0)
+	}
+	if rendered < len(spans) {
+		fmt.Fprintf(&out, "... (showing first %d of %d spans)\n", rendered, len(spans))
+	}
+
+	return out.String()
+}
+
+// formatSpanDuration renders a duration with millisecond precision
+func formatSpanDuration(d time.Duration) string {
+	if d < time.Millisecond {
+		return d.String()
+	}
+	return d.Round(100 * time.Microsecond).String()
+}
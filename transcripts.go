@@ -0,0 +1,164 @@
+package aiassistant
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+)
+
+// encryptedLinePrefix marks an encrypted session log line:
+// "enc1:<key id>:<base64 nonce+ciphertext>"
+const encryptedLinePrefix = "enc1:"
+
+// TranscriptConfig controls how session logs (./sessions/*.jsonl) are stored.
+// Logs contain user prompts, code excerpts and log data; encryption protects
+// them at rest. Each line is sealed separately with AES-256-GCM, so logs stay
+// appendable and readable by share links, pins and "willknow eval" given the key.
+type TranscriptConfig struct {
+	// EncryptionKey is a 32-byte AES-256 key. When set, new log lines and
+	// the pinned answers file are encrypted. Default: nil (plaintext)
+	EncryptionKey []byte
+
+	// KeyFunc returns the encryption key instead of EncryptionKey, e.g. by
+	// unwrapping a data key with a KMS. It is called once at startup.
+	KeyFunc func() ([]byte, error)
+
+	// PreviousKeys decrypt logs written before a key rotation
+	PreviousKeys [][]byte
+
+	// ExcludeEvents lists event types that are not persisted, e.g.
+	// "tool_result" to keep log data and code excerpts out of transcripts.
+	// Types: user_message, assistant_message, tool_use, tool_result, error,
+	// policy_violation, session_end. session_start is always kept, since it
+	// records who owns the session.
+	ExcludeEvents []string
+}
+
+// transcriptPolicy encrypts and filters session log lines
+type transcriptPolicy struct {
+	keyID   string                 // ID of the key new lines are sealed with; "" for plaintext
+	keys    map[string]cipher.AEAD // key ID → cipher, including previous keys
+	exclude map[string]bool
+}
+
+// newTranscriptPolicy returns nil if transcripts are stored unchanged
+func newTranscriptPolicy(config TranscriptConfig) (*transcriptPolicy, error) {
+	key := config.EncryptionKey
+	if config.KeyFunc != nil {
+		var err error
+		if key, err = config.KeyFunc(); err != nil {
+			return nil, fmt.Errorf("failed to get the transcript encryption key: %w", err)
+		}
+	}
+	if key == nil && len(config.PreviousKeys) == 0 && len(config.ExcludeEvents) == 0 {
+		return nil, nil
+	}
+
+	p := &transcriptPolicy{keys: make(map[string]cipher.AEAD), exclude: make(map[string]bool)}
+	for _, k := range config.PreviousKeys {
+		if _, err := p.addKey(k); err != nil {
+			return nil, fmt.Errorf("invalid transcript PreviousKeys entry: %w", err)
+		}
+	}
+	if key != nil {
+		id, err := p.addKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid transcript encryption key: %w", err)
+		}
+		p.keyID = id
+	}
+	for _, eventType := range config.ExcludeEvents {
+		if eventType != "session_start" {
+			p.exclude[eventType] = true
+		}
+	}
+	return p, nil
+}
+
+// addKey registers a key and returns its ID, derived from the key's hash
+func (p *transcriptPolicy) addKey(key []byte) (string, error) {
+	if len(key) != 32 {
+		return "", fmt.Errorf("key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(key)
+	id := hex.EncodeToString(sum[:4])
+	p.keys[id] = aead
+	return id, nil
+}
+
+// encrypted reports whether new log lines are encrypted
+func (p *transcriptPolicy) encrypted() bool {
+	return p != nil && p.keyID != ""
+}
+
+// seal encrypts a log line, or returns it unchanged without a key
+func (p *transcriptPolicy) seal(line []byte) []byte {
+	if !p.encrypted() {
+		return line
+	}
+	aead := p.keys[p.keyID]
+	nonce := make([]byte, aead.NonceSize())
+	rand.Read(nonce)
+	sealed := aead.Seal(nonce, nonce, line, nil)
+	return []byte(encryptedLinePrefix + p.keyID + ":" + base64.StdEncoding.EncodeToString(sealed))
+}
+
+// open returns the plaintext of a log line
+func (p *transcriptPolicy) open(line []byte) ([]byte, error) {
+	if !bytes.HasPrefix(line, []byte(encryptedLinePrefix)) {
+		return line, nil
+	}
+	id, data, ok := bytes.Cut(line[len(encryptedLinePrefix):], []byte(":"))
+	if !ok {
+		return nil, fmt.Errorf("malformed encrypted line")
+	}
+	var aead cipher.AEAD
+	if p != nil {
+		aead = p.keys[string(id)]
+	}
+	if aead == nil {
+		return nil, fmt.Errorf("session log is encrypted with key %s, which is not configured (Transcripts.EncryptionKey or PreviousKeys)", id)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(string(data))
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("malformed encrypted line")
+	}
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt session log: %w", err)
+	}
+	return plain, nil
+}
+
+// sessionLog is the JSONL log of a session
+type sessionLog struct {
+	file   *os.File
+	policy *transcriptPolicy // nil stores every event in plaintext
+}
+
+// write appends an event line unless its type is excluded
+func (l *sessionLog) write(eventType string, line []byte) {
+	if l.policy != nil && l.policy.exclude[eventType] {
+		return
+	}
+	l.file.Write(append(l.policy.seal(line), '\n'))
+}
+
+// Close closes the log file
+func (l *sessionLog) Close() error {
+	return l.file.Close()
+}
@@ -0,0 +1,224 @@
+package aiassistant
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/willknow-ai/willknow-go/provider"
+)
+
+// Webhook event types
+const (
+	// WebhookEventAnalysisCompleted fires when the assistant finishes answering a
+	// question that required at least one tool call.
+	WebhookEventAnalysisCompleted = "analysis_completed"
+
+	// WebhookEventIncidentDetected fires when an incident is reported via ReportIncident.
+	WebhookEventIncidentDetected = "incident_detected"
+
+	// WebhookEventScheduledAnalysis fires when a scheduled analysis (see SchedulerConfig) finishes.
+	WebhookEventScheduledAnalysis = "scheduled_analysis_completed"
+
+	// WebhookEventAnomalyDetected fires when the log anomaly detector (see
+	// AnomalyConfig) flags a window, with the assistant's explanation as summary.
+	WebhookEventAnomalyDetected = "anomaly_detected"
+
+	// WebhookEventOwnerNotified is sent to an owner's channel (see
+	// Config.OwnerChannels) when an answer cites files the owner owns.
+	WebhookEventOwnerNotified = "owner_notified"
+)
+
+// maxWebhookSummaryChars limits the size of the summary sent in webhook payloads
+const maxWebhookSummaryChars = 2000
+
+// WebhookConfig configures a single webhook receiver
+type WebhookConfig struct {
+	// URL receives a POST with a JSON WebhookPayload
+	URL string
+
+	// Events limits which events are delivered.
+	// Default: all events
+	Events []string
+
+	// Secret, if set, signs each payload with HMAC-SHA256.
+	// The hex signature is sent in the X-Willknow-Signature header as "sha256=<hex>".
+	Secret string
+
+	// Headers are extra HTTP headers added to each request (e.g. auth tokens)
+	Headers map[string]string
+}
+
+// WebhookPayload is the JSON body delivered to webhook receivers
+type WebhookPayload struct {
+	Event           string    `json:"event"`
+	Timestamp       time.Time `json:"timestamp"`
+	SessionID       string    `json:"session_id,omitempty"`
+	UserID          string    `json:"user_id,omitempty"`
+	Question        string    `json:"question,omitempty"`
+	Summary         string    `json:"summary"`
+	ReferencedFiles []string  `json:"referenced_files,omitempty"`
+	Owners          []string  `json:"owners,omitempty"` // owner_notified: the notified owner
+	Text            string    `json:"text,omitempty"`   // owner_notified: message for chat webhooks
+}
+
+// webhookNotifier delivers payloads to the configured webhooks
+type webhookNotifier struct {
+	hooks  []WebhookConfig
+	client *http.Client
+}
+
+// newWebhookNotifier creates a notifier, or nil if no webhooks are configured
+func newWebhookNotifier(hooks []WebhookConfig) *webhookNotifier {
+	if len(hooks) == 0 {
+		return nil
+	}
+	return &webhookNotifier{
+		hooks:  hooks,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// notify sends the payload to all subscribed webhooks in the background
+func (n *webhookNotifier) notify(payload WebhookPayload) {
+	if n == nil {
+		return
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("[Webhook] Failed to marshal payload: %v", err)
+		return
+	}
+
+	for _, hook := range n.hooks {
+		if !hook.subscribes(payload.Event) {
+			continue
+		}
+		go n.deliver(hook, body)
+	}
+}
+
+// deliver posts a payload to a single webhook
+func (n *webhookNotifier) deliver(hook WebhookConfig, body []byte) {
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		log.Printf("[Webhook] Invalid URL %s: %v", hook.URL, err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "willknow-webhook")
+	for k, v := range hook.Headers {
+		req.Header.Set(k, v)
+	}
+	if hook.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(hook.Secret))
+		mac.Write(body)
+		req.Header.Set("X-Willknow-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		log.Printf("[Webhook] Delivery to %s failed: %v", hook.URL, err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		log.Printf("[Webhook] Delivery to %s failed with status %d", hook.URL, resp.StatusCode)
+	}
+}
+
+// subscribes reports whether the webhook wants the given event
+func (hook WebhookConfig) subscribes(event string) bool {
+	if len(hook.Events) == 0 {
+		return true
+	}
+	for _, e := range hook.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// ReportIncident notifies webhooks subscribed to incident_detected.
+// Monitors (or the host application) call this when they detect a problem.
+// The incident is also included in the email digest, if configured.
+func (a *Assistant) ReportIncident(summary string, referencedFiles []string) {
+	a.digest.record(digestEntry{
+		Time:  time.Now(),
+		Kind:  "incident",
+		Title: summary,
+		Files: referencedFiles,
+	})
+	a.webhooks.notify(WebhookPayload{
+		Event:           WebhookEventIncidentDetected,
+		Timestamp:       time.Now(),
+		Summary:         truncate(summary, maxWebhookSummaryChars),
+		ReferencedFiles: referencedFiles,
+	})
+}
+
+// notifyAnalysisCompleted sends an analysis_completed event for the messages
+// added to the session since index start (the user's question), and records
+// it for the email digest.
+func (a *Assistant) notifyAnalysisCompleted(session *Session, start int, answer string) {
+	if a.webhooks == nil && a.digest == nil {
+		return
+	}
+
+	session.mu.Lock()
+	turn := make([]provider.Message, len(session.messages)-start)
+	copy(turn, session.messages[start:])
+	session.mu.Unlock()
+
+	toolCalls := 0
+	var question string
+	var files []string
+	seen := make(map[string]bool)
+	for i, msg := range turn {
+		for _, block := range msg.Content {
+			if i == 0 && block.Type == "text" {
+				question += block.Text
+			}
+			if block.Type != "tool_use" {
+				continue
+			}
+			toolCalls++
+			if path, ok := block.Input["file_path"].(string); ok && !seen[path] {
+				seen[path] = true
+				files = append(files, path)
+			}
+		}
+	}
+
+	// Plain conversation without any investigation is not an analysis
+	if toolCalls == 0 {
+		return
+	}
+
+	var userID string
+	if session.User != nil {
+		userID = session.User.ID
+	}
+	a.webhooks.notify(WebhookPayload{
+		Event:           WebhookEventAnalysisCompleted,
+		Timestamp:       time.Now(),
+		SessionID:       session.ID,
+		UserID:          userID,
+		Question:        question,
+		Summary:         truncate(answer, maxWebhookSummaryChars),
+		ReferencedFiles: files,
+	})
+	a.digest.record(digestEntry{
+		Time:    time.Now(),
+		Kind:    "analysis",
+		Title:   question,
+		Summary: answer,
+		Files:   files,
+	})
+}
@@ -0,0 +1,280 @@
+package aiassistant
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/willknow-ai/willknow-go/provider"
+)
+
+const (
+	// workspacePath is the URL prefix of workspace file downloads
+	workspacePath = "/api/workspace/"
+	// listWorkspaceToolName lists the files of the session's workspace
+	listWorkspaceToolName = "list_workspace"
+	// writeWorkspaceToolName saves a file to the session's workspace
+	writeWorkspaceToolName = "write_workspace_file"
+	// defaultWorkspaceRetention is how long an unused workspace is kept
+	defaultWorkspaceRetention = 24 * time.Hour
+	// defaultWorkspaceMaxBytes limits the size of a session's workspace
+	defaultWorkspaceMaxBytes = 50 << 20
+	// workspaceOwnerFile records the user a workspace belongs to
+	workspaceOwnerFile = ".owner"
+)
+
+// workspaceFileName matches the file names tools may use in a workspace
+var workspaceFileName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,127}$`)
+
+// WorkspaceConfig gives each session a scratch directory that tools write
+// artifacts to (log exports, generated patches, reports), listed with the
+// list_workspace tool and downloadable from the UI by the session's user.
+type WorkspaceConfig struct {
+	// Dir holds one subdirectory per session (e.g. "./sessions/workspaces").
+	// When empty, workspaces are disabled.
+	Dir string
+
+	// Retention is how long a workspace is kept after its last change;
+	// older workspaces are deleted hourly
+	// Default: 24h
+	Retention time.Duration
+
+	// MaxBytes limits the total size of a session's workspace
+	// Default: 50 MB
+	MaxBytes int64
+}
+
+// WorkspaceFile describes a file in a session's workspace
+type WorkspaceFile struct {
+	Name     string    `json:"name"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+	URL      string    `json:"url"`
+}
+
+// workspaceStore manages the per-session scratch directories
+type workspaceStore struct {
+	config WorkspaceConfig
+}
+
+// newWorkspaceStore creates the store, or returns nil if no Dir is configured
+func newWorkspaceStore(config WorkspaceConfig) (*workspaceStore, error) {
+	if config.Dir == "" {
+		return nil, nil
+	}
+	if config.Retention <= 0 {
+		config.Retention = defaultWorkspaceRetention
+	}
+	if config.MaxBytes <= 0 {
+		config.MaxBytes = defaultWorkspaceMaxBytes
+	}
+	if err := os.MkdirAll(config.Dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create workspace folder: %w", err)
+	}
+	return &workspaceStore{config: config}, nil
+}
+
+// dir returns the workspace directory of a session
+func (w *workspaceStore) dir(sessionID string) string {
+	return filepath.Join(w.config.Dir, sessionID)
+}
+
+// fileURL returns the download link of a workspace file
+func (w *workspaceStore) fileURL(sessionID, name string) string {
+	return workspacePath + sessionID + "/" + url.PathEscape(name)
+}
+
+// write saves a file to the session's workspace and returns its download link
+func (w *workspaceStore) write(session *Session, name string, data []byte) (string, error) {
+	if !workspaceFileName.MatchString(name) {
+		return "", fmt.Errorf("invalid file name %q: use letters, digits, '.', '_' and '-'", name)
+	}
+	files, err := w.list(session.ID)
+	if err != nil {
+		return "", err
+	}
+	used := int64(len(data))
+	for _, f := range files {
+		if f.Name != name {
+			used += f.Size
+		}
+	}
+	if used > w.config.MaxBytes {
+		return "", fmt.Errorf("the workspace is limited to %.1f MB; this file would take it to %.1f MB", float64(w.config.MaxBytes)/(1<<20), float64(used)/(1<<20))
+	}
+
+	dir := w.dir(session.ID)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create workspace: %w", err)
+	}
+	owner := ""
+	if session.User != nil {
+		owner = session.User.ID
+	}
+	if err := os.WriteFile(filepath.Join(dir, workspaceOwnerFile), []byte(owner), 0600); err != nil {
+		return "", fmt.Errorf("failed to create workspace: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return w.fileURL(session.ID, name), nil
+}
+
+// list returns the files of a session's workspace, by name
+func (w *workspaceStore) list(sessionID string) ([]WorkspaceFile, error) {
+	entries, err := os.ReadDir(w.dir(sessionID))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workspace: %w", err)
+	}
+	var files []WorkspaceFile
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() || entry.Name() == workspaceOwnerFile {
+			continue
+		}
+		files = append(files, WorkspaceFile{
+			Name:     entry.Name(),
+			Size:     info.Size(),
+			Modified: info.ModTime(),
+			URL:      w.fileURL(sessionID, entry.Name()),
+		})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files, nil
+}
+
+// execute runs the list_workspace and write_workspace_file tools
+func (w *workspaceStore) execute(session *Session, name string, params map[string]interface{}) (string, error) {
+	if name == writeWorkspaceToolName {
+		fileName, _ := params["name"].(string)
+		content, _ := params["content"].(string)
+		link, err := w.write(session, fileName, []byte(content))
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Saved %s (%d bytes). Download link for the user: [%s](%s)", fileName, len(content), fileName, link), nil
+	}
+
+	files, err := w.list(session.ID)
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return "The workspace of this session is empty.", nil
+	}
+	var b strings.Builder
+	b.WriteString("Files in this session's workspace (download links for the user):\n")
+	for _, f := range files {
+		fmt.Fprintf(&b, "- [%s](%s): %d bytes, %s\n", f.Name, f.URL, f.Size, f.Modified.UTC().Format(time.RFC3339))
+	}
+	return b.String(), nil
+}
+
+// handleDownload serves GET /api/workspace/<session>/<file> to the
+// session's user
+func (w *workspaceStore) handleDownload(rw http.ResponseWriter, r *http.Request) {
+	if w == nil {
+		http.NotFound(rw, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(rw, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	sessionID, name, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, workspacePath), "/")
+	if !shareSessionID.MatchString(sessionID) || !workspaceFileName.MatchString(name) {
+		http.NotFound(rw, r)
+		return
+	}
+	// Workspaces of other users are reported as missing
+	user, _ := r.Context().Value(userContextKey).(*User)
+	owner, err := os.ReadFile(filepath.Join(w.dir(sessionID), workspaceOwnerFile))
+	if err != nil || user == nil || string(owner) != user.ID {
+		http.NotFound(rw, r)
+		return
+	}
+
+	rw.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, name))
+	rw.Header().Set("Cache-Control", "no-store")
+	http.ServeFile(rw, r, filepath.Join(w.dir(sessionID), name))
+}
+
+// cleanup deletes the workspaces not changed within the retention period
+func (w *workspaceStore) cleanup() {
+	entries, err := os.ReadDir(w.config.Dir)
+	if err != nil {
+		log.Printf("[Workspace] Failed to read %s: %v", w.config.Dir, err)
+		return
+	}
+	cutoff := time.Now().Add(-w.config.Retention)
+	for _, entry := range entries {
+		if !entry.IsDir() || !shareSessionID.MatchString(entry.Name()) {
+			continue
+		}
+		dir := filepath.Join(w.config.Dir, entry.Name())
+		latest := time.Time{}
+		filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+			if err == nil && info.ModTime().After(latest) {
+				latest = info.ModTime()
+			}
+			return nil
+		})
+		if latest.Before(cutoff) {
+			if err := os.RemoveAll(dir); err != nil {
+				log.Printf("[Workspace] Failed to delete %s: %v", dir, err)
+			} else {
+				log.Printf("[Workspace] Deleted expired workspace of session %s", entry.Name())
+			}
+		}
+	}
+}
+
+// run deletes expired workspaces every hour
+func (w *workspaceStore) run() {
+	for {
+		w.cleanup()
+		time.Sleep(time.Hour)
+	}
+}
+
+// toolDefinitions describes the workspace tools to the model
+func (w *workspaceStore) toolDefinitions() []provider.Tool {
+	return []provider.Tool{
+		{
+			Name:        listWorkspaceToolName,
+			Description: "List the files in this session's workspace (log exports, saved patches and reports) with their download links.",
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+		{
+			Name:        writeWorkspaceToolName,
+			Description: fmt.Sprintf("Save a file to this session's workspace so the user can download it, e.g. a generated patch, a script or an incident report. Files are kept for %s after the last change. Returns a download link to give to the user.", w.config.Retention),
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "File name with extension (e.g., 'fix-timeout.patch', 'report.md'); letters, digits, '.', '_' and '-'",
+					},
+					"content": map[string]interface{}{
+						"type":        "string",
+						"description": "The file content",
+					},
+				},
+				"required": []string{"name", "content"},
+			},
+		},
+	}
+}